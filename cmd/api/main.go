@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,10 +11,21 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/afdhali/GolangBlogpostServer/config"
 	"github.com/afdhali/GolangBlogpostServer/internal/di"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/pkg/database"
 )
 
 func main() {
+	// Sub-commands, e.g. `go run cmd/api/main.go migrate`
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("Command %q failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	// Initialize application with dependency injection
 	app, err := di.InitializeApp()
 	if err != nil {
@@ -51,6 +63,9 @@ func main() {
 		}
 	}()
 
+	// Start background job workers alongside the server
+	app.StartWorkers()
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -62,11 +77,19 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Close SSE streams first; Shutdown waits for active requests to finish
+	app.CloseStreams()
+
 	// Attempt graceful shutdown
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("⚠️  Server forced to shutdown: %v", err)
 	}
 
+	// Drain job workers after the server, so in-flight requests can still enqueue
+	if err := app.DrainWorkers(ctx); err != nil {
+		logger.Error("⚠️  Job workers did not finish in time: %v", err)
+	}
+
 	logger.Info("✅ Server exited gracefully")
 
 	// PENTING: Beri waktu untuk logger menulis semua pesan sebelum close
@@ -80,4 +103,131 @@ func main() {
 	time.Sleep(500 * time.Millisecond)
 
 	logger.Info("👋 Application stopped")
-}
\ No newline at end of file
+}
+
+// runCommand runs a one-off maintenance command instead of the HTTP server
+func runCommand(name string, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewPostgresDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	switch name {
+	case "migrate":
+		return database.Migrate(db, entity.Models()...)
+	case "backfill-media-hashes":
+		return backfillMediaHashes(args)
+	case "storage-gc":
+		return collectStorageGarbage(cfg, args)
+	case "storage-check":
+		return checkStorage()
+	case "migrate-featured-media":
+		return migrateFeaturedMedia()
+	default:
+		return fmt.Errorf("unknown command: %s", name)
+	}
+}
+
+// backfillMediaHashes hashes media uploaded before deduplication; run once
+// after migrating. Merged duplicates get their variants rendered again by
+// the server's job workers.
+func backfillMediaHashes(args []string) error {
+	flags := flag.NewFlagSet("backfill-media-hashes", flag.ContinueOnError)
+	batchSize := flags.Int("batch", 100, "media rows per batch")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	maintenance, err := di.InitializeMediaMaintenance()
+	if err != nil {
+		return err
+	}
+
+	result, err := maintenance.BackfillHashes(context.Background(), *batchSize)
+	if result != nil {
+		log.Printf("Hashed %d media, merged %d duplicates (%d bytes freed), %d files missing",
+			result.Hashed, result.Merged, result.BytesFreed, result.Missing)
+	}
+	return err
+}
+
+// collectStorageGarbage lists stored files nothing refers to; with -delete
+// they are removed
+func collectStorageGarbage(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("storage-gc", flag.ContinueOnError)
+	grace := flags.Duration("grace", time.Duration(cfg.Storage.GCGraceHours)*time.Hour, "only collect files older than this")
+	remove := flags.Bool("delete", false, "delete orphaned files instead of only listing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	maintenance, err := di.InitializeMediaMaintenance()
+	if err != nil {
+		return err
+	}
+
+	report, err := maintenance.CollectGarbage(context.Background(), *grace, !*remove)
+	if err != nil {
+		return err
+	}
+	for _, orphan := range report.Orphans {
+		fmt.Printf("%s\t%d\t%s\n", orphan.Path, orphan.Size, orphan.ModTime.Format(time.RFC3339))
+	}
+	if report.DryRun {
+		log.Printf("Scanned %d files: %d orphaned (%d bytes); run with -delete to remove them",
+			report.Scanned, len(report.Orphans), report.OrphanBytes)
+	} else {
+		log.Printf("Scanned %d files: deleted %d of %d orphaned (%d bytes)",
+			report.Scanned, report.Deleted, len(report.Orphans), report.OrphanBytes)
+	}
+	return nil
+}
+
+// migrateFeaturedMedia links posts from before featured_media_id to their
+// featured media; run once after migrating
+func migrateFeaturedMedia() error {
+	maintenance, err := di.InitializeMediaMaintenance()
+	if err != nil {
+		return err
+	}
+
+	result, err := maintenance.MigrateFeaturedMedia(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("Linked %d posts by featured_image and %d by is_featured; %d keep an external image",
+		result.FromURLs, result.FromFlags, result.External)
+	return nil
+}
+
+// checkStorage lists media and avatars whose files are missing
+func checkStorage() error {
+	maintenance, err := di.InitializeMediaMaintenance()
+	if err != nil {
+		return err
+	}
+
+	report, err := maintenance.CheckConsistency(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, m := range report.Missing {
+		fmt.Printf("%s\t%s\t%s\n", m.Kind, m.ID, m.Path)
+	}
+	log.Printf("Checked %d media and %d avatars: %d files missing",
+		report.CheckedMedia, report.CheckedAvatars, len(report.Missing))
+	if len(report.Missing) > 0 {
+		return fmt.Errorf("%d files missing", len(report.Missing))
+	}
+	return nil
+}
@@ -17,6 +17,15 @@ type Config struct {
 	Security SecurityConfig
 	CORS     CORSConfig
     Storage  StorageConfig
+	Views    ViewConfig
+	Stream   StreamConfig
+	Webhook  WebhookConfig
+	Jobs     JobsConfig
+	Image    ImageConfig
+	Media    MediaConfig
+	Scanner  ScannerConfig
+	Upload   UploadConfig
+	Quota    QuotaConfig
 }
 
 type AppConfig struct {
@@ -43,9 +52,10 @@ type JWTConfig struct {
 }
 
 type SecurityConfig struct {
-	APIKey     string
-	BcryptCost int
-	RateLimit  int
+	APIKey       string
+	BcryptCost   int
+	RateLimit    int
+	CursorSecret string // Signs listing cursors; falls back to JWT_SECRET
 }
 
 type CORSConfig struct {
@@ -60,6 +70,106 @@ type StorageConfig struct {
     BasePath  string // "./uploads" for local
     BaseURL   string // "http://localhost:5000/uploads"
     MaxSizeMB int    // Max file size in MB
+    S3        S3Config
+
+    // Orphaned file collection; see MediaMaintenanceService
+    GCIntervalHours int  // How often the scheduled collection runs; 0 disables it
+    GCGraceHours    int  // Files younger than this are never collected
+    GCDelete        bool // Delete orphans on schedule; otherwise they are only reported
+
+    // How long signed links to media of unpublished posts stay valid
+    SignedURLTTLMin int
+}
+
+type S3Config struct {
+    Endpoint     string // Empty for AWS; e.g. "http://localhost:9000" for MinIO
+    Region       string
+    Bucket       string
+    AccessKey    string
+    SecretKey    string
+    SessionToken string
+    PathStyle    bool   // Required by most S3-compatible servers
+    Prefix       string // Key prefix inside the bucket
+    URLMode      string // "public" or "presigned"
+    PublicURL    string // Base URL for public mode (CDN); defaults to the bucket URL
+    URLExpiryMin int    // Lifetime of presigned URLs
+    PartSizeMB   int    // Multipart part size; smaller files use a single PUT
+}
+
+type ViewConfig struct {
+	FlushIntervalSec int // How often buffered view counts are written
+	DedupWindowMin   int // Same visitor + post is counted once per window
+}
+
+type StreamConfig struct {
+	HeartbeatSec     int // Comment line sent to keep idle SSE connections open
+	ReplayBufferSize int // Events kept per topic for Last-Event-ID resume
+	ClientBufferSize int // Events queued per client before it is dropped as too slow
+}
+
+type WebhookConfig struct {
+	TimeoutSec     int // HTTP timeout per attempt
+	MaxAttempts    int // Attempts before a delivery is marked failed
+	BackoffBaseSec int // First retry delay; doubles on every attempt
+	BackoffMaxSec  int // Upper bound for the retry delay
+}
+
+type JobsConfig struct {
+	Workers         int // Jobs run concurrently by this instance
+	PollIntervalSec int // How often idle workers look for due jobs
+	TimeoutSec      int // Deadline for a single run; also bounds the claim lease
+	MaxAttempts     int // Default attempts before a job is dead-lettered
+	BackoffBaseSec  int // First retry delay; doubles on every attempt
+	BackoffMaxSec   int // Upper bound for the retry delay
+	RetentionDays   int // Succeeded jobs older than this are purged
+}
+
+type ImageConfig struct {
+	MaxDimension       int    // Originals are scaled down to fit this box
+	Quality            int    // Encoder quality (1-100)
+	Variants           string // e.g. "thumb:150x150:crop,small:480"
+	ResizeSecret       string // Signs on-demand resize URLs; falls back to JWT_SECRET
+	ResizeMaxDimension int    // Largest width/height the resize endpoint will render
+	OutputFormat       string // original, jpeg, png or webp
+	PNGOptimize        bool   // Lossless PNG optimization (palette reduction, best compression)
+	MaxPixels          int    // Uploads with more width*height pixels are rejected
+	StripMetadata      bool   // Drop EXIF (GPS, serial numbers, ...) from stored images
+}
+
+// MediaConfig covers uploads other than images
+type MediaConfig struct {
+	VideoMaxMB          int
+	VideoTypes          []string
+	AudioMaxMB          int
+	AudioTypes          []string
+	DocumentMaxMB       int
+	DocumentTypes       []string
+	Thumbnailer         string // "none" or "exec"
+	FFmpegPath          string // Poster frames for video; empty disables them
+	PdftoppmPath        string // First-page thumbnails for PDFs; empty disables them
+	ThumbnailTimeoutSec int
+}
+
+type UploadConfig struct {
+	SlotTTLMin    int    // How long a direct-upload slot accepts the file and the complete call
+	SigningSecret string // Signs local upload and private file URLs; falls back to JWT_SECRET
+}
+
+// QuotaConfig limits how much each role can store; 0 means unlimited.
+// Admins can override the limits per user.
+type QuotaConfig struct {
+	UserMaxMB          int
+	UserMaxFiles       int
+	AdminMaxMB         int
+	AdminMaxFiles      int
+	SuperAdminMaxMB    int
+	SuperAdminMaxFiles int
+}
+
+type ScannerConfig struct {
+	Driver     string // "none" or "clamd"
+	ClamdAddr  string // "host:port" or "unix:/path/to/clamd.sock"
+	TimeoutSec int
 }
 
 func LoadConfig() (*Config, error) {
@@ -89,14 +199,15 @@ func LoadConfig() (*Config, error) {
             RefreshTokenExpiry: getEnvInt("JWT_REFRESH_TOKEN_EXPIRY", 604800),
         },
         Security: SecurityConfig{
-            APIKey:     getEnv("API_KEY", "your-api-key"),
-            BcryptCost: getEnvInt("BCRYPT_COST", 10),
-            RateLimit:  getEnvInt("RATE_LIMIT", 60),
+            APIKey:       getEnv("API_KEY", "your-api-key"),
+            BcryptCost:   getEnvInt("BCRYPT_COST", 10),
+            RateLimit:    getEnvInt("RATE_LIMIT", 60),
+            CursorSecret: getEnv("CURSOR_SECRET", ""),
         },
         CORS: CORSConfig{
             AllowedOrigins:   strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000"), ","),
             AllowedMethods:   strings.Split(getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS"), ","),
-            AllowedHeaders:   strings.Split(getEnv("CORS_ALLOWED_HEADERS", "Origin,Content-Type,Accept,Authorization,X-API-Key"), ","),
+            AllowedHeaders:   strings.Split(getEnv("CORS_ALLOWED_HEADERS", "Origin,Content-Type,Accept,Authorization,X-API-Key,If-Match"), ","),
             AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", true),
         },
         Storage: StorageConfig{
@@ -104,6 +215,88 @@ func LoadConfig() (*Config, error) {
             BasePath:  getEnv("STORAGE_BASE_PATH", "./uploads"),
             BaseURL:   getEnv("STORAGE_BASE_URL", "http://localhost:5000/uploads"),
             MaxSizeMB: getEnvInt("STORAGE_MAX_SIZE_MB", 2),
+            S3: S3Config{
+                Endpoint:     getEnv("STORAGE_S3_ENDPOINT", ""),
+                Region:       getEnv("STORAGE_S3_REGION", "us-east-1"),
+                Bucket:       getEnv("STORAGE_S3_BUCKET", ""),
+                AccessKey:    getEnv("STORAGE_S3_ACCESS_KEY", ""),
+                SecretKey:    getEnv("STORAGE_S3_SECRET_KEY", ""),
+                SessionToken: getEnv("STORAGE_S3_SESSION_TOKEN", ""),
+                PathStyle:    getEnvBool("STORAGE_S3_PATH_STYLE", false),
+                Prefix:       getEnv("STORAGE_S3_PREFIX", ""),
+                URLMode:      getEnv("STORAGE_S3_URL_MODE", "public"),
+                PublicURL:    getEnv("STORAGE_S3_PUBLIC_URL", ""),
+                URLExpiryMin: getEnvInt("STORAGE_S3_URL_EXPIRY_MIN", 60),
+                PartSizeMB:   getEnvInt("STORAGE_S3_PART_SIZE_MB", 8),
+            },
+            GCIntervalHours: getEnvInt("STORAGE_GC_INTERVAL_HOURS", 24),
+            GCGraceHours:    getEnvInt("STORAGE_GC_GRACE_HOURS", 24),
+            GCDelete:        getEnvBool("STORAGE_GC_DELETE", false),
+            SignedURLTTLMin: getEnvInt("STORAGE_SIGNED_URL_TTL_MIN", 60),
+        },
+        Views: ViewConfig{
+            FlushIntervalSec: getEnvInt("VIEWS_FLUSH_INTERVAL_SEC", 10),
+            DedupWindowMin:   getEnvInt("VIEWS_DEDUP_WINDOW_MIN", 30),
+        },
+        Stream: StreamConfig{
+            HeartbeatSec:     getEnvInt("STREAM_HEARTBEAT_SEC", 15),
+            ReplayBufferSize: getEnvInt("STREAM_REPLAY_BUFFER", 100),
+            ClientBufferSize: getEnvInt("STREAM_CLIENT_BUFFER", 32),
+        },
+        Webhook: WebhookConfig{
+            TimeoutSec:     getEnvInt("WEBHOOK_TIMEOUT_SEC", 10),
+            MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+            BackoffBaseSec: getEnvInt("WEBHOOK_BACKOFF_BASE_SEC", 30),
+            BackoffMaxSec:  getEnvInt("WEBHOOK_BACKOFF_MAX_SEC", 21600),
+        },
+        Jobs: JobsConfig{
+            Workers:         getEnvInt("JOBS_WORKERS", 4),
+            PollIntervalSec: getEnvInt("JOBS_POLL_INTERVAL_SEC", 2),
+            TimeoutSec:      getEnvInt("JOBS_TIMEOUT_SEC", 120),
+            MaxAttempts:     getEnvInt("JOBS_MAX_ATTEMPTS", 5),
+            BackoffBaseSec:  getEnvInt("JOBS_BACKOFF_BASE_SEC", 15),
+            BackoffMaxSec:   getEnvInt("JOBS_BACKOFF_MAX_SEC", 3600),
+            RetentionDays:   getEnvInt("JOBS_RETENTION_DAYS", 7),
+        },
+        Image: ImageConfig{
+            MaxDimension:       getEnvInt("IMAGE_MAX_DIMENSION", 2048),
+            Quality:            getEnvInt("IMAGE_QUALITY", 85),
+            Variants:           getEnv("IMAGE_VARIANTS", "thumb:150x150:crop,small:480,medium:960,large:1600,og-image:1200x630:crop"),
+            ResizeSecret:       getEnv("IMAGE_RESIZE_SECRET", ""),
+            ResizeMaxDimension: getEnvInt("IMAGE_RESIZE_MAX_DIMENSION", 2400),
+            OutputFormat:       getEnv("IMAGE_OUTPUT_FORMAT", "original"),
+            PNGOptimize:        getEnvBool("IMAGE_PNG_OPTIMIZE", true),
+            MaxPixels:          getEnvInt("IMAGE_MAX_PIXELS", 40000000),
+            StripMetadata:      getEnvBool("IMAGE_STRIP_METADATA", true),
+        },
+        Media: MediaConfig{
+            VideoMaxMB:          getEnvInt("MEDIA_VIDEO_MAX_MB", 200),
+            VideoTypes:          strings.Split(getEnv("MEDIA_VIDEO_TYPES", "video/mp4,video/quicktime,video/webm"), ","),
+            AudioMaxMB:          getEnvInt("MEDIA_AUDIO_MAX_MB", 50),
+            AudioTypes:          strings.Split(getEnv("MEDIA_AUDIO_TYPES", "audio/mpeg,audio/mp4,audio/wav,audio/ogg"), ","),
+            DocumentMaxMB:       getEnvInt("MEDIA_DOCUMENT_MAX_MB", 25),
+            DocumentTypes:       strings.Split(getEnv("MEDIA_DOCUMENT_TYPES", "application/pdf"), ","),
+            Thumbnailer:         getEnv("MEDIA_THUMBNAILER", "none"),
+            FFmpegPath:          getEnv("MEDIA_FFMPEG_PATH", "ffmpeg"),
+            PdftoppmPath:        getEnv("MEDIA_PDFTOPPM_PATH", "pdftoppm"),
+            ThumbnailTimeoutSec: getEnvInt("MEDIA_THUMBNAIL_TIMEOUT_SEC", 60),
+        },
+        Scanner: ScannerConfig{
+            Driver:     getEnv("SCANNER_DRIVER", "none"),
+            ClamdAddr:  getEnv("SCANNER_CLAMD_ADDR", "localhost:3310"),
+            TimeoutSec: getEnvInt("SCANNER_TIMEOUT_SEC", 30),
+        },
+        Upload: UploadConfig{
+            SlotTTLMin:    getEnvInt("UPLOAD_SLOT_TTL_MIN", 15),
+            SigningSecret: getEnv("UPLOAD_SIGNING_SECRET", ""),
+        },
+        Quota: QuotaConfig{
+            UserMaxMB:          getEnvInt("QUOTA_USER_MAX_MB", 500),
+            UserMaxFiles:       getEnvInt("QUOTA_USER_MAX_FILES", 1000),
+            AdminMaxMB:         getEnvInt("QUOTA_ADMIN_MAX_MB", 0),
+            AdminMaxFiles:      getEnvInt("QUOTA_ADMIN_MAX_FILES", 0),
+            SuperAdminMaxMB:    getEnvInt("QUOTA_SUPER_ADMIN_MAX_MB", 0),
+            SuperAdminMaxFiles: getEnvInt("QUOTA_SUPER_ADMIN_MAX_FILES", 0),
         },
     }
 
@@ -1,25 +1,39 @@
 package di
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/afdhali/GolangBlogpostServer/config"
 	"github.com/afdhali/GolangBlogpostServer/internal/handler"
 	"github.com/afdhali/GolangBlogpostServer/internal/repository"
 	"github.com/afdhali/GolangBlogpostServer/internal/router"
 	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/broker"
 	"github.com/afdhali/GolangBlogpostServer/pkg/database"
 	"github.com/afdhali/GolangBlogpostServer/pkg/image"
 	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/afdhali/GolangBlogpostServer/pkg/mediafile"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
+	"github.com/afdhali/GolangBlogpostServer/pkg/scanner"
 	"github.com/afdhali/GolangBlogpostServer/pkg/security"
 	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
 	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/afdhali/GolangBlogpostServer/pkg/viewcounter"
+	"github.com/afdhali/GolangBlogpostServer/pkg/webhook"
 	"gorm.io/gorm"
 )
 
 // AppContainer holds all dependencies and provides cleanup
 type AppContainer struct {
-	Router *router.Router
-	db     *gorm.DB
-	logger *logger.Logger
+	Router      *router.Router
+	db          *gorm.DB
+	logger      *logger.Logger
+	viewCounter *viewcounter.Counter
+	broker      broker.Broker
+	jobQueue    *service.JobQueue
+	maintenance service.MediaMaintenanceService
 }
 
 // GetLogger returns the logger instance
@@ -27,11 +41,62 @@ func (c *AppContainer) GetLogger() *logger.Logger {
 	return c.logger
 }
 
+// CloseStreams ends all open SSE streams. Call it before http.Server.Shutdown,
+// which otherwise waits for these long-lived requests until its timeout.
+func (c *AppContainer) CloseStreams() {
+	if c.broker != nil {
+		c.broker.Close()
+	}
+}
+
+// StartWorkers starts the background job workers and queues scheduled
+// jobs. Call it once the HTTP server is up.
+func (c *AppContainer) StartWorkers() {
+	if c.jobQueue != nil {
+		c.jobQueue.Start()
+	}
+	if c.maintenance != nil {
+		if err := c.maintenance.ScheduleGC(context.Background()); err != nil && c.logger != nil {
+			c.logger.Error("Failed to schedule storage collection: %v", err)
+		}
+	}
+}
+
+// DrainWorkers stops claiming jobs and waits for running ones until ctx
+// expires. Call it after http.Server.Shutdown so requests can still enqueue.
+func (c *AppContainer) DrainWorkers(ctx context.Context) error {
+	if c.jobQueue == nil {
+		return nil
+	}
+	return c.jobQueue.Stop(ctx)
+}
+
 // Cleanup performs graceful shutdown
 // PENTING: Urutan cleanup SANGAT PENTING!
 // 1. Logger HARUS di-close PALING AKHIR
 // 2. Database di-close sebelum logger
 func (c *AppContainer) Cleanup() {
+	// 0. Make sure no stream outlives the server
+	c.CloseStreams()
+
+	// Flush buffered view counts while the database is still open
+	if c.viewCounter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := c.viewCounter.Stop(ctx); err != nil && c.logger != nil {
+			c.logger.Error("Failed to flush view counts: %v", err)
+		}
+		cancel()
+	}
+
+	// No-op when main already drained the workers
+	if c.jobQueue != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := c.jobQueue.Stop(ctx); err != nil && c.logger != nil {
+			c.logger.Error("Failed to stop job workers: %v", err)
+		}
+		cancel()
+	}
+
 	// 1. Close database connection TERLEBIH DAHULU
 	if c.db != nil {
 		if sqlDB, err := c.db.DB(); err == nil {
@@ -82,19 +147,126 @@ func ProvideSanitizer() security.Sanitizer {
 	return security.NewSanitizer()
 }
 
-// ProvideStorage creates storage instance
-func ProvideStorage(cfg *config.Config) storage.Storage {
-	return storage.NewLocalStorage(cfg.Storage.BasePath, cfg.Storage.BaseURL)
+// ProvideStorage creates the storage driver selected by STORAGE_DRIVER
+func ProvideStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.Storage.Driver {
+	case "", "local":
+		return storage.NewLocalStorage(cfg.Storage.BasePath, cfg.Storage.BaseURL), nil
+	case "s3":
+		s3 := cfg.Storage.S3
+		return storage.NewS3Storage(storage.S3Config{
+			Endpoint:     s3.Endpoint,
+			Region:       s3.Region,
+			Bucket:       s3.Bucket,
+			AccessKey:    s3.AccessKey,
+			SecretKey:    s3.SecretKey,
+			SessionToken: s3.SessionToken,
+			PathStyle:    s3.PathStyle,
+			Prefix:       s3.Prefix,
+			URLMode:      s3.URLMode,
+			PublicURL:    s3.PublicURL,
+			URLExpiry:    time.Duration(s3.URLExpiryMin) * time.Minute,
+			PartSize:     int64(s3.PartSizeMB) * 1024 * 1024,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// ProvideScanner creates the virus scanner uploads are checked with
+func ProvideScanner(cfg *config.Config) (scanner.Scanner, error) {
+	switch cfg.Scanner.Driver {
+	case "", "none":
+		return scanner.NopScanner{}, nil
+	case "clamd":
+		return scanner.NewClamdScanner(cfg.Scanner.ClamdAddr, time.Duration(cfg.Scanner.TimeoutSec)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown scanner driver %q", cfg.Scanner.Driver)
+	}
 }
 
 // ProvideImageValidator creates image validator
-func ProvideImageValidator(cfg *config.Config) *image.Validator {
-	return image.NewValidator(cfg.Storage.MaxSizeMB, []string{"image/jpeg", "image/jpg", "image/png", "image/webp"})
+func ProvideImageValidator(cfg *config.Config, s scanner.Scanner) *image.Validator {
+	return image.NewValidator(cfg.Storage.MaxSizeMB, []string{"image/jpeg", "image/jpg", "image/png", "image/webp"}).
+		WithMaxPixels(cfg.Image.MaxPixels).
+		WithScanner(s)
+}
+
+// ProvideFileValidator creates the validator for video, audio and document uploads
+func ProvideFileValidator(cfg *config.Config, s scanner.Scanner) *mediafile.Validator {
+	return mediafile.NewValidator().
+		Allow(mediafile.KindVideo, cfg.Media.VideoMaxMB, cfg.Media.VideoTypes).
+		Allow(mediafile.KindAudio, cfg.Media.AudioMaxMB, cfg.Media.AudioTypes).
+		Allow(mediafile.KindDocument, cfg.Media.DocumentMaxMB, cfg.Media.DocumentTypes).
+		WithScanner(s)
+}
+
+// ProvideThumbnailer creates the renderer for video and document stills
+func ProvideThumbnailer(cfg *config.Config) (mediafile.Thumbnailer, error) {
+	switch cfg.Media.Thumbnailer {
+	case "", "none":
+		return mediafile.NopThumbnailer{}, nil
+	case "exec":
+		return mediafile.NewExecThumbnailer(cfg.Media.FFmpegPath, cfg.Media.PdftoppmPath, time.Duration(cfg.Media.ThumbnailTimeoutSec)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown thumbnailer %q", cfg.Media.Thumbnailer)
+	}
+}
+
+// ProvideImageProcessor creates the avatar image processor
+func ProvideImageProcessor(cfg *config.Config) (*image.Processor, error) {
+	processor, err := image.DefaultAvatarProcessor().WithOutputFormat(cfg.Image.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	return processor.
+		WithPNGOptimization(cfg.Image.PNGOptimize).
+		WithMetadataStripping(cfg.Image.StripMetadata), nil
+}
+
+// ProvideMediaProcessor creates the post media processor with its variants
+func ProvideMediaProcessor(cfg *config.Config) (*image.MediaProcessor, error) {
+	variants, err := image.ParseVariants(cfg.Image.Variants)
+	if err != nil {
+		return nil, err
+	}
+	processor, err := image.NewProcessor(cfg.Image.Quality, uint(cfg.Image.MaxDimension), uint(cfg.Image.MaxDimension)).
+		WithOutputFormat(cfg.Image.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	processor.
+		WithPNGOptimization(cfg.Image.PNGOptimize).
+		WithMetadataStripping(cfg.Image.StripMetadata)
+	return image.NewMediaProcessor(processor, variants), nil
+}
+
+// ProvideResizeSigner creates the signer for on-demand resize URLs
+func ProvideResizeSigner(cfg *config.Config) *image.ResizeSigner {
+	secret := cfg.Image.ResizeSecret
+	if secret == "" {
+		secret = cfg.JWT.Secret
+	}
+	return image.NewResizeSigner(secret)
+}
+
+// ProvideCursorSigner creates the signer for listing cursors
+func ProvideCursorSigner(cfg *config.Config) *pagination.Signer {
+	secret := cfg.Security.CursorSecret
+	if secret == "" {
+		secret = cfg.JWT.Secret
+	}
+	return pagination.NewSigner(secret)
 }
 
-// ProvideImageProcessor creates image processor
-func ProvideImageProcessor() *image.Processor {
-	return image.DefaultAvatarProcessor()
+// ProvideBroker creates the in-process pub/sub broker behind SSE streams
+func ProvideBroker(cfg *config.Config) broker.Broker {
+	return broker.NewMemoryBroker(cfg.Stream.ReplayBufferSize, cfg.Stream.ClientBufferSize)
+}
+
+// ProvideWebhookSender creates the HTTP client used for webhook deliveries
+func ProvideWebhookSender(cfg *config.Config) *webhook.Sender {
+	return webhook.NewSender(time.Duration(cfg.Webhook.TimeoutSec) * time.Second)
 }
 
 // ============================================================================
@@ -110,6 +282,11 @@ func ProvideCategoryRepository(db *gorm.DB) repository.CategoryRepository {
 	return repository.NewCategoryRepository(db)
 }
 
+// ProvideTxManager runs repository calls of a service in one transaction
+func ProvideTxManager(db *gorm.DB) repository.TxManager {
+	return repository.NewTxManager(db)
+}
+
 func ProvidePostRepository(db *gorm.DB) repository.PostRepository {
 	return repository.NewPostRepository(db)
 }
@@ -127,6 +304,50 @@ func ProvideMediaRepository(db *gorm.DB) repository.MediaRepository {
 	return repository.NewMediaRepository(db)
 }
 
+func ProvideMediaFolderRepository(db *gorm.DB) repository.MediaFolderRepository {
+	return repository.NewMediaFolderRepository(db)
+}
+
+func ProvideAnalyticsRepository(db *gorm.DB) repository.AnalyticsRepository {
+	return repository.NewAnalyticsRepository(db)
+}
+
+func ProvideBookmarkRepository(db *gorm.DB) repository.BookmarkRepository {
+	return repository.NewBookmarkRepository(db)
+}
+
+func ProvideReadingListRepository(db *gorm.DB) repository.ReadingListRepository {
+	return repository.NewReadingListRepository(db)
+}
+
+func ProvideFollowRepository(db *gorm.DB) repository.FollowRepository {
+	return repository.NewFollowRepository(db)
+}
+
+func ProvideNotificationRepository(db *gorm.DB) repository.NotificationRepository {
+	return repository.NewNotificationRepository(db)
+}
+
+func ProvideWebhookRepository(db *gorm.DB) repository.WebhookRepository {
+	return repository.NewWebhookRepository(db)
+}
+
+func ProvideJobRepository(db *gorm.DB) repository.JobRepository {
+	return repository.NewJobRepository(db)
+}
+
+func ProvideUploadSlotRepository(db *gorm.DB) repository.UploadSlotRepository {
+	return repository.NewUploadSlotRepository(db)
+}
+
+func ProvideMediaBlobRepository(db *gorm.DB) repository.MediaBlobRepository {
+	return repository.NewMediaBlobRepository(db)
+}
+
+func ProvideStorageQuotaRepository(db *gorm.DB) repository.StorageQuotaRepository {
+	return repository.NewStorageQuotaRepository(db)
+}
+
 // ============================================================================
 // SERVICES
 // ============================================================================
@@ -144,15 +365,30 @@ func ProvideAuthService(
 }
 
 func ProvideUserService(
+	txManager repository.TxManager,
 	userRepo repository.UserRepository,
+	tokenRepo repository.RefreshTokenRepository,
 	postRepo repository.PostRepository,
+	followRepo repository.FollowRepository,
+	quotaService service.StorageQuotaService,
 	passwordHasher security.PasswordHasher,
 	validator *validator.CustomValidator,
 	storage storage.Storage,
 	imageValidator *image.Validator,
 	imageProcessor *image.Processor,
+	cursors *pagination.Signer,
 ) service.UserService {
-	return service.NewUserService(userRepo, postRepo, passwordHasher, validator, storage, imageValidator, imageProcessor)
+	return service.NewUserService(txManager, userRepo, tokenRepo, postRepo, followRepo, quotaService, passwordHasher, validator, storage, imageValidator, imageProcessor, cursors)
+}
+
+// ProvideStorageQuotaService enforces storage limits for media and avatar uploads
+func ProvideStorageQuotaService(
+	quotaRepo repository.StorageQuotaRepository,
+	userRepo repository.UserRepository,
+	validator *validator.CustomValidator,
+	cfg *config.Config,
+) service.StorageQuotaService {
+	return service.NewStorageQuotaService(quotaRepo, userRepo, validator, cfg)
 }
 
 func ProvideCategoryService(
@@ -164,13 +400,21 @@ func ProvideCategoryService(
 }
 
 func ProvidePostService(
+	txManager repository.TxManager,
 	postRepo repository.PostRepository,
 	categoryRepo repository.CategoryRepository,
 	commentRepo repository.CommentRepository,
+	bookmarkRepo repository.BookmarkRepository,
+	mediaRepo repository.MediaRepository,
+	storage storage.Storage,
 	sanitizer security.Sanitizer,
 	validator *validator.CustomValidator,
+	viewCounter *viewcounter.Counter,
+	notificationService service.NotificationService,
+	webhookService service.WebhookService,
+	cursors *pagination.Signer,
 ) service.PostService {
-	return service.NewPostService(postRepo, categoryRepo, commentRepo, sanitizer, validator)
+	return service.NewPostService(txManager, postRepo, categoryRepo, commentRepo, bookmarkRepo, mediaRepo, storage, sanitizer, validator, viewCounter, notificationService, webhookService, cursors)
 }
 
 func ProvideCommentService(
@@ -178,20 +422,136 @@ func ProvideCommentService(
 	postRepo repository.PostRepository,
 	sanitizer security.Sanitizer,
 	validator *validator.CustomValidator,
+	notificationService service.NotificationService,
+	broker broker.Broker,
+	webhookService service.WebhookService,
+	cursors *pagination.Signer,
 ) service.CommentService {
-	return service.NewCommentService(commentRepo, postRepo, sanitizer, validator)
+	return service.NewCommentService(commentRepo, postRepo, sanitizer, validator, notificationService, broker, webhookService, cursors)
 }
 
 // 👇 ADD THIS - Media Service Provider
 func ProvideMediaService(
+	txManager repository.TxManager,
 	mediaRepo repository.MediaRepository,
+	slotRepo repository.UploadSlotRepository,
+	blobRepo repository.MediaBlobRepository,
 	postRepo repository.PostRepository,
+	folderRepo repository.MediaFolderRepository,
+	quotaService service.StorageQuotaService,
 	storage storage.Storage,
 	imageValidator *image.Validator,
-	imageProcessor *image.Processor,
+	fileValidator *mediafile.Validator,
+	mediaProcessor *image.MediaProcessor,
+	thumbnailer mediafile.Thumbnailer,
+	resizeSigner *image.ResizeSigner,
+	cursors *pagination.Signer,
 	validator *validator.CustomValidator,
+	jobQueue *service.JobQueue,
+	logger *logger.Logger,
+	cfg *config.Config,
 ) service.MediaService {
-	return service.NewMediaService(mediaRepo, postRepo, storage, imageValidator, imageProcessor, validator)
+	return service.NewMediaService(txManager, mediaRepo, slotRepo, blobRepo, postRepo, folderRepo, quotaService, storage, imageValidator, fileValidator, mediaProcessor, thumbnailer, resizeSigner, cursors, validator, jobQueue, logger, cfg)
+}
+
+// ProvideMediaMaintenanceService backs the maintenance commands and the
+// scheduled storage collection
+func ProvideMediaMaintenanceService(
+	mediaRepo repository.MediaRepository,
+	blobRepo repository.MediaBlobRepository,
+	userRepo repository.UserRepository,
+	postRepo repository.PostRepository,
+	storage storage.Storage,
+	jobQueue *service.JobQueue,
+	logger *logger.Logger,
+	cfg *config.Config,
+) service.MediaMaintenanceService {
+	return service.NewMediaMaintenanceService(mediaRepo, blobRepo, userRepo, postRepo, storage, jobQueue, logger, cfg)
+}
+
+func ProvideAnalyticsService(
+	txManager repository.TxManager,
+	analyticsRepo repository.AnalyticsRepository,
+	postRepo repository.PostRepository,
+	validator *validator.CustomValidator,
+) service.AnalyticsService {
+	return service.NewAnalyticsService(txManager, analyticsRepo, postRepo, validator)
+}
+
+func ProvideBookmarkService(
+	bookmarkRepo repository.BookmarkRepository,
+	postRepo repository.PostRepository,
+	commentRepo repository.CommentRepository,
+	validator *validator.CustomValidator,
+) service.BookmarkService {
+	return service.NewBookmarkService(bookmarkRepo, postRepo, commentRepo, validator)
+}
+
+func ProvideReadingListService(
+	readingListRepo repository.ReadingListRepository,
+	postRepo repository.PostRepository,
+	commentRepo repository.CommentRepository,
+	validator *validator.CustomValidator,
+) service.ReadingListService {
+	return service.NewReadingListService(readingListRepo, postRepo, commentRepo, validator)
+}
+
+func ProvideFollowService(
+	followRepo repository.FollowRepository,
+	userRepo repository.UserRepository,
+	categoryRepo repository.CategoryRepository,
+	validator *validator.CustomValidator,
+	notificationService service.NotificationService,
+) service.FollowService {
+	return service.NewFollowService(followRepo, userRepo, categoryRepo, validator, notificationService)
+}
+
+func ProvideNotificationService(
+	notificationRepo repository.NotificationRepository,
+	followRepo repository.FollowRepository,
+	validator *validator.CustomValidator,
+	logger *logger.Logger,
+	broker broker.Broker,
+) service.NotificationService {
+	return service.NewNotificationService(notificationRepo, followRepo, validator, logger, broker)
+}
+
+func ProvideStreamService(broker broker.Broker, postRepo repository.PostRepository) service.StreamService {
+	return service.NewStreamService(broker, postRepo)
+}
+
+func ProvideWebhookService(
+	txManager repository.TxManager,
+	webhookRepo repository.WebhookRepository,
+	jobQueue *service.JobQueue,
+	sender *webhook.Sender,
+	validator *validator.CustomValidator,
+	logger *logger.Logger,
+	cfg *config.Config,
+) service.WebhookService {
+	return service.NewWebhookService(txManager, webhookRepo, jobQueue, sender, validator, logger, cfg)
+}
+
+// ProvideJobQueue creates the background job queue. Services register their
+// own job handlers; the workers are started from main via StartWorkers.
+func ProvideJobQueue(jobRepo repository.JobRepository, logger *logger.Logger, cfg *config.Config) *service.JobQueue {
+	return service.NewJobQueue(jobRepo, logger, cfg)
+}
+
+func ProvideJobService(jobRepo repository.JobRepository, validator *validator.CustomValidator) service.JobService {
+	return service.NewJobService(jobRepo, validator)
+}
+
+// ProvideViewCounter creates and starts the buffered post view counter
+func ProvideViewCounter(cfg *config.Config, analyticsService service.AnalyticsService, logger *logger.Logger) *viewcounter.Counter {
+	counter := viewcounter.NewCounter(
+		analyticsService.RecordViews,
+		time.Duration(cfg.Views.FlushIntervalSec)*time.Second,
+		time.Duration(cfg.Views.DedupWindowMin)*time.Minute,
+		logger,
+	)
+	counter.Start()
+	return counter
 }
 
 // ============================================================================
@@ -223,6 +583,42 @@ func ProvideMediaHandler(mediaService service.MediaService) *handler.MediaHandle
 	return handler.NewMediaHandler(mediaService)
 }
 
+func ProvideAnalyticsHandler(analyticsService service.AnalyticsService) *handler.AnalyticsHandler {
+	return handler.NewAnalyticsHandler(analyticsService)
+}
+
+func ProvideBookmarkHandler(bookmarkService service.BookmarkService) *handler.BookmarkHandler {
+	return handler.NewBookmarkHandler(bookmarkService)
+}
+
+func ProvideReadingListHandler(readingListService service.ReadingListService) *handler.ReadingListHandler {
+	return handler.NewReadingListHandler(readingListService)
+}
+
+func ProvideFollowHandler(followService service.FollowService) *handler.FollowHandler {
+	return handler.NewFollowHandler(followService)
+}
+
+func ProvideNotificationHandler(notificationService service.NotificationService) *handler.NotificationHandler {
+	return handler.NewNotificationHandler(notificationService)
+}
+
+func ProvideStreamHandler(cfg *config.Config, streamService service.StreamService) *handler.StreamHandler {
+	return handler.NewStreamHandler(streamService, time.Duration(cfg.Stream.HeartbeatSec)*time.Second)
+}
+
+func ProvideWebhookHandler(webhookService service.WebhookService) *handler.WebhookHandler {
+	return handler.NewWebhookHandler(webhookService)
+}
+
+func ProvideJobHandler(jobService service.JobService) *handler.JobHandler {
+	return handler.NewJobHandler(jobService)
+}
+
+func ProvideStorageQuotaHandler(quotaService service.StorageQuotaService) *handler.StorageQuotaHandler {
+	return handler.NewStorageQuotaHandler(quotaService)
+}
+
 // ============================================================================
 // ROUTER
 // ============================================================================
@@ -239,6 +635,15 @@ func ProvideRouter(
 	postHandler *handler.PostHandler,
 	commentHandler *handler.CommentHandler,
 	mediaHandler *handler.MediaHandler,
+	analyticsHandler *handler.AnalyticsHandler,
+	bookmarkHandler *handler.BookmarkHandler,
+	readingListHandler *handler.ReadingListHandler,
+	followHandler *handler.FollowHandler,
+	notificationHandler *handler.NotificationHandler,
+	streamHandler *handler.StreamHandler,
+	webhookHandler *handler.WebhookHandler,
+	jobHandler *handler.JobHandler,
+	storageQuotaHandler *handler.StorageQuotaHandler,
 ) *router.Router {
 	return router.NewRouter(
 		cfg,
@@ -251,6 +656,15 @@ func ProvideRouter(
 		postHandler,
 		commentHandler,
 		mediaHandler,
+		analyticsHandler,
+		bookmarkHandler,
+		readingListHandler,
+		followHandler,
+		notificationHandler,
+		streamHandler,
+		webhookHandler,
+		jobHandler,
+		storageQuotaHandler,
 	)
 }
 
@@ -259,10 +673,18 @@ func ProvideAppContainer(
 	router *router.Router,
 	db *gorm.DB,
 	logger *logger.Logger,
+	viewCounter *viewcounter.Counter,
+	broker broker.Broker,
+	jobQueue *service.JobQueue,
+	maintenance service.MediaMaintenanceService,
 ) *AppContainer {
 	return &AppContainer{
-		Router: router,
-		db:     db,
-		logger: logger,
+		Router:      router,
+		db:          db,
+		logger:      logger,
+		viewCounter: viewCounter,
+		broker:      broker,
+		jobQueue:    jobQueue,
+		maintenance: maintenance,
 	}
 }
\ No newline at end of file
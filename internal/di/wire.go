@@ -4,6 +4,7 @@
 package di
 
 import (
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
 	"github.com/google/wire"
 )
 
@@ -25,28 +26,65 @@ func InitializeApp() (*AppContainer, error) {
 		ProvideJWTService,
 		ProvideSanitizer,
 		ProvideStorage,
+		ProvideScanner,
 		ProvideImageValidator,
+		ProvideFileValidator,
 		ProvideImageProcessor,
+		ProvideMediaProcessor,
+		ProvideThumbnailer,
+		ProvideResizeSigner,
+		ProvideCursorSigner,
+		ProvideBroker,
+		ProvideWebhookSender,
 
 		// ============================================================================
 		// LAYER 1: REPOSITORIES (depends on Database)
 		// ============================================================================
+		ProvideTxManager,
 		ProvideUserRepository,
 		ProvideCategoryRepository,
 		ProvidePostRepository,
 		ProvideCommentRepository,
 		ProvideRefreshTokenRepository,
 		ProvideMediaRepository, 
+		ProvideMediaFolderRepository,
+		ProvideAnalyticsRepository,
+		ProvideBookmarkRepository,
+		ProvideReadingListRepository,
+		ProvideFollowRepository,
+		ProvideNotificationRepository,
+		ProvideWebhookRepository,
+		ProvideJobRepository,
+		ProvideUploadSlotRepository,
+		ProvideMediaBlobRepository,
+		ProvideStorageQuotaRepository,
 
 		// ============================================================================
 		// LAYER 2: SERVICES (depends on Repositories + Security/Storage)
 		// ============================================================================
+		// Background job queue first: services register their job handlers on it
+		// (started and drained from main)
+		ProvideJobQueue,
+
 		ProvideAuthService,
+		ProvideStorageQuotaService,
 		ProvideUserService,
 		ProvideCategoryService,
 		ProvidePostService,
 		ProvideCommentService,
 		ProvideMediaService, 
+		ProvideAnalyticsService,
+		ProvideBookmarkService,
+		ProvideReadingListService,
+		ProvideFollowService,
+		ProvideNotificationService,
+		ProvideStreamService,
+		ProvideWebhookService,
+		ProvideJobService,
+		ProvideMediaMaintenanceService,
+
+		// Buffered view counter (flushes into AnalyticsService)
+		ProvideViewCounter,
 
 		// ============================================================================
 		// LAYER 3: HANDLERS (depends on Services)
@@ -57,6 +95,15 @@ func InitializeApp() (*AppContainer, error) {
 		ProvidePostHandler,
 		ProvideCommentHandler,
 		ProvideMediaHandler, 
+		ProvideAnalyticsHandler,
+		ProvideBookmarkHandler,
+		ProvideReadingListHandler,
+		ProvideFollowHandler,
+		ProvideNotificationHandler,
+		ProvideStreamHandler,
+		ProvideWebhookHandler,
+		ProvideJobHandler,
+		ProvideStorageQuotaHandler,
 
 		// ============================================================================
 		// ROUTER & CONTAINER (depends on Handlers)
@@ -68,6 +115,26 @@ func InitializeApp() (*AppContainer, error) {
 	return nil, nil
 }
 
+// InitializeMediaMaintenance builds the media maintenance service for the
+// commands in cmd/api, without the HTTP stack
+func InitializeMediaMaintenance() (service.MediaMaintenanceService, error) {
+	wire.Build(
+		ProvideConfig,
+		ProvideLogger,
+		ProvideDatabase,
+		ProvideStorage,
+		ProvideMediaRepository,
+		ProvideMediaBlobRepository,
+		ProvideUserRepository,
+		ProvidePostRepository,
+		ProvideJobRepository,
+		ProvideJobQueue,
+		ProvideMediaMaintenanceService,
+	)
+
+	return nil, nil
+}
+
 /*
 DEPENDENCY INJECTION ORDER:
 
@@ -81,25 +148,57 @@ DEPENDENCY INJECTION ORDER:
      ├─ PasswordHasher
      ├─ JWTService
      ├─ Sanitizer
-     ├─ Storage
-     ├─ ImageValidator
-     └─ ImageProcessor
+     ├─ Storage (local or S3, by STORAGE_DRIVER)
+     ├─ Scanner (virus scanning for uploads, none or clamd)
+     ├─ ImageValidator (content sniffing, pixel limit, scanner hook)
+     ├─ FileValidator (video, audio and document uploads)
+     ├─ ImageProcessor (avatars)
+     ├─ MediaProcessor (post media + variants)
+     ├─ Thumbnailer (video/document stills, none or exec)
+     ├─ ResizeSigner
+     ├─ CursorSigner (signs keyset cursors of large listings)
+     ├─ Broker (in-process pub/sub for SSE streams)
+     └─ WebhookSender
 
   3. REPOSITORIES (requires Database)
+     ├─ TxManager (units of work across repositories, carried in the context)
      ├─ UserRepository
      ├─ CategoryRepository
      ├─ PostRepository
      ├─ CommentRepository
      ├─ RefreshTokenRepository
-     └─ MediaRepository 
+     ├─ MediaRepository 
+     ├─ MediaFolderRepository (media library folders)
+     ├─ AnalyticsRepository
+     ├─ BookmarkRepository
+     ├─ ReadingListRepository
+     ├─ FollowRepository
+     ├─ NotificationRepository
+     ├─ WebhookRepository
+     ├─ JobRepository
+     ├─ UploadSlotRepository
+     ├─ MediaBlobRepository (content-addressed files shared between media)
+     └─ StorageQuotaRepository (per-user limit overrides, usage totals)
 
   4. SERVICES (requires Repositories + Security/Storage)
+     ├─ JobQueue (workers started/drained from main; services register handlers)
      ├─ AuthService
+     ├─ StorageQuotaService (per-role/per-user limits, checked by User/Media services)
      ├─ UserService
      ├─ CategoryService
      ├─ PostService
      ├─ CommentService
-     └─ MediaService 
+     ├─ MediaService (renders image variants and expires upload slots as background jobs)
+     ├─ AnalyticsService
+     ├─ BookmarkService
+     ├─ ReadingListService
+     ├─ FollowService
+     ├─ NotificationService (hooked into Post/Comment/Follow services)
+     ├─ StreamService
+     ├─ WebhookService (hooked into Post/Comment services; sends deliveries as background jobs)
+     ├─ JobService (admin view of the job queue)
+     ├─ MediaMaintenanceService (hash backfill, featured media migration, orphaned file collection on a schedule)
+     └─ ViewCounter (buffered views, flushed into AnalyticsService on Cleanup)
 
   5. HANDLERS (requires Services)
      ├─ AuthHandler
@@ -107,7 +206,16 @@ DEPENDENCY INJECTION ORDER:
      ├─ CategoryHandler
      ├─ PostHandler
      ├─ CommentHandler
-     └─ MediaHandler 
+     ├─ MediaHandler 
+     ├─ AnalyticsHandler
+     ├─ BookmarkHandler
+     ├─ ReadingListHandler
+     ├─ FollowHandler
+     ├─ NotificationHandler
+     ├─ StreamHandler
+     ├─ WebhookHandler
+     ├─ JobHandler
+     └─ StorageQuotaHandler
 
   6. ROUTER & CONTAINER (requires Handlers)
      ├─ Router
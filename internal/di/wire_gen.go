@@ -6,6 +6,10 @@
 
 package di
 
+import (
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+)
+
 // Injectors from wire.go:
 
 // InitializeApp initializes the entire application with all dependencies
@@ -29,25 +33,110 @@ func InitializeApp() (*AppContainer, error) {
 	customValidator := ProvideValidator()
 	authService := ProvideAuthService(userRepository, refreshTokenRepository, passwordHasher, jwtService, customValidator, config)
 	authHandler := ProvideAuthHandler(authService)
+	txManager := ProvideTxManager(db)
 	postRepository := ProvidePostRepository(db)
-	storage := ProvideStorage(config)
-	validator := ProvideImageValidator(config)
-	processor := ProvideImageProcessor()
-	userService := ProvideUserService(userRepository, postRepository, passwordHasher, customValidator, storage, validator, processor)
+	followRepository := ProvideFollowRepository(db)
+	storageQuotaRepository := ProvideStorageQuotaRepository(db)
+	storageQuotaService := ProvideStorageQuotaService(storageQuotaRepository, userRepository, customValidator, config)
+	storage, err := ProvideStorage(config)
+	if err != nil {
+		return nil, err
+	}
+	scanner, err := ProvideScanner(config)
+	if err != nil {
+		return nil, err
+	}
+	validator := ProvideImageValidator(config, scanner)
+	processor, err := ProvideImageProcessor(config)
+	if err != nil {
+		return nil, err
+	}
+	signer := ProvideCursorSigner(config)
+	userService := ProvideUserService(txManager, userRepository, refreshTokenRepository, postRepository, followRepository, storageQuotaService, passwordHasher, customValidator, storage, validator, processor, signer)
 	userHandler := ProvideUserHandler(userService)
 	categoryRepository := ProvideCategoryRepository(db)
 	categoryService := ProvideCategoryService(categoryRepository, postRepository, customValidator)
 	categoryHandler := ProvideCategoryHandler(categoryService)
 	commentRepository := ProvideCommentRepository(db)
+	bookmarkRepository := ProvideBookmarkRepository(db)
+	mediaRepository := ProvideMediaRepository(db)
 	sanitizer := ProvideSanitizer()
-	postService := ProvidePostService(postRepository, categoryRepository, commentRepository, sanitizer, customValidator)
+	analyticsRepository := ProvideAnalyticsRepository(db)
+	analyticsService := ProvideAnalyticsService(txManager, analyticsRepository, postRepository, customValidator)
+	counter := ProvideViewCounter(config, analyticsService, logger)
+	notificationRepository := ProvideNotificationRepository(db)
+	broker := ProvideBroker(config)
+	notificationService := ProvideNotificationService(notificationRepository, followRepository, customValidator, logger, broker)
+	webhookRepository := ProvideWebhookRepository(db)
+	jobRepository := ProvideJobRepository(db)
+	jobQueue := ProvideJobQueue(jobRepository, logger, config)
+	sender := ProvideWebhookSender(config)
+	webhookService := ProvideWebhookService(txManager, webhookRepository, jobQueue, sender, customValidator, logger, config)
+	postService := ProvidePostService(txManager, postRepository, categoryRepository, commentRepository, bookmarkRepository, mediaRepository, storage, sanitizer, customValidator, counter, notificationService, webhookService, signer)
 	postHandler := ProvidePostHandler(postService)
-	commentService := ProvideCommentService(commentRepository, postRepository, sanitizer, customValidator)
+	commentService := ProvideCommentService(commentRepository, postRepository, sanitizer, customValidator, notificationService, broker, webhookService, signer)
 	commentHandler := ProvideCommentHandler(commentService)
-	mediaRepository := ProvideMediaRepository(db)
-	mediaService := ProvideMediaService(mediaRepository, postRepository, storage, validator, processor, customValidator)
+	uploadSlotRepository := ProvideUploadSlotRepository(db)
+	mediaBlobRepository := ProvideMediaBlobRepository(db)
+	mediaFolderRepository := ProvideMediaFolderRepository(db)
+	mediafileValidator := ProvideFileValidator(config, scanner)
+	mediaProcessor, err := ProvideMediaProcessor(config)
+	if err != nil {
+		return nil, err
+	}
+	thumbnailer, err := ProvideThumbnailer(config)
+	if err != nil {
+		return nil, err
+	}
+	resizeSigner := ProvideResizeSigner(config)
+	mediaService := ProvideMediaService(txManager, mediaRepository, uploadSlotRepository, mediaBlobRepository, postRepository, mediaFolderRepository, storageQuotaService, storage, validator, mediafileValidator, mediaProcessor, thumbnailer, resizeSigner, signer, customValidator, jobQueue, logger, config)
 	mediaHandler := ProvideMediaHandler(mediaService)
-	router := ProvideRouter(config, logger, jwtService, userRepository, authHandler, userHandler, categoryHandler, postHandler, commentHandler, mediaHandler)
-	appContainer := ProvideAppContainer(router, db, logger)
+	analyticsHandler := ProvideAnalyticsHandler(analyticsService)
+	bookmarkService := ProvideBookmarkService(bookmarkRepository, postRepository, commentRepository, customValidator)
+	bookmarkHandler := ProvideBookmarkHandler(bookmarkService)
+	readingListRepository := ProvideReadingListRepository(db)
+	readingListService := ProvideReadingListService(readingListRepository, postRepository, commentRepository, customValidator)
+	readingListHandler := ProvideReadingListHandler(readingListService)
+	followService := ProvideFollowService(followRepository, userRepository, categoryRepository, customValidator, notificationService)
+	followHandler := ProvideFollowHandler(followService)
+	notificationHandler := ProvideNotificationHandler(notificationService)
+	streamService := ProvideStreamService(broker, postRepository)
+	streamHandler := ProvideStreamHandler(config, streamService)
+	webhookHandler := ProvideWebhookHandler(webhookService)
+	jobService := ProvideJobService(jobRepository, customValidator)
+	jobHandler := ProvideJobHandler(jobService)
+	storageQuotaHandler := ProvideStorageQuotaHandler(storageQuotaService)
+	router := ProvideRouter(config, logger, jwtService, userRepository, authHandler, userHandler, categoryHandler, postHandler, commentHandler, mediaHandler, analyticsHandler, bookmarkHandler, readingListHandler, followHandler, notificationHandler, streamHandler, webhookHandler, jobHandler, storageQuotaHandler)
+	mediaMaintenanceService := ProvideMediaMaintenanceService(mediaRepository, mediaBlobRepository, userRepository, postRepository, storage, jobQueue, logger, config)
+	appContainer := ProvideAppContainer(router, db, logger, counter, broker, jobQueue, mediaMaintenanceService)
 	return appContainer, nil
 }
+
+// InitializeMediaMaintenance builds the media maintenance service for the
+// commands in cmd/api, without the HTTP stack
+func InitializeMediaMaintenance() (service.MediaMaintenanceService, error) {
+	config, err := ProvideConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := ProvideDatabase(config)
+	if err != nil {
+		return nil, err
+	}
+	mediaRepository := ProvideMediaRepository(db)
+	mediaBlobRepository := ProvideMediaBlobRepository(db)
+	userRepository := ProvideUserRepository(db)
+	postRepository := ProvidePostRepository(db)
+	storage, err := ProvideStorage(config)
+	if err != nil {
+		return nil, err
+	}
+	jobRepository := ProvideJobRepository(db)
+	logger, err := ProvideLogger()
+	if err != nil {
+		return nil, err
+	}
+	jobQueue := ProvideJobQueue(jobRepository, logger, config)
+	mediaMaintenanceService := ProvideMediaMaintenanceService(mediaRepository, mediaBlobRepository, userRepository, postRepository, storage, jobQueue, logger, config)
+	return mediaMaintenanceService, nil
+}
@@ -0,0 +1,7 @@
+package dto
+
+type AnalyticsQueryParams struct {
+	From  string `form:"from" validate:"omitempty,datetime=2006-01-02"`
+	To    string `form:"to" validate:"omitempty,datetime=2006-01-02"`
+	Limit int    `form:"limit" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+type DailyViewsResponse struct {
+	Date               string `json:"date"`
+	Views              int64  `json:"views"`
+	AuthenticatedViews int64  `json:"authenticated_views"`
+}
+
+type PostDailyViewsResponse struct {
+	PostID     uuid.UUID             `json:"post_id"`
+	From       string                `json:"from"`
+	To         string                `json:"to"`
+	TotalViews int64                 `json:"total_views"`
+	Days       []*DailyViewsResponse `json:"days"`
+}
+
+type ReferrerViewsResponse struct {
+	ReferrerHost string `json:"referrer_host"` // "" means direct / unknown
+	Views        int64  `json:"views"`
+}
+
+type PostReferrersResponse struct {
+	PostID    uuid.UUID                `json:"post_id"`
+	From      string                   `json:"from"`
+	To        string                   `json:"to"`
+	Referrers []*ReferrerViewsResponse `json:"referrers"`
+}
+
+type TopPostResponse struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	Slug  string    `json:"slug"`
+	Views int64     `json:"views"`
+}
+
+type TopCategoryResponse struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Slug  string    `json:"slug"`
+	Views int64     `json:"views"`
+}
+
+type TopAuthorResponse struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	FullName string    `json:"full_name"`
+	Views    int64     `json:"views"`
+}
+
+type TopContentResponse struct {
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Items interface{} `json:"items"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import "github.com/google/uuid"
+
+type CreateBookmarkRequest struct {
+	PostID uuid.UUID `json:"post_id" validate:"required"`
+}
+
+type BookmarkQueryParams struct {
+	Page  int `form:"page" validate:"omitempty,min=1"`
+	Limit int `form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type CreateReadingListRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description" validate:"omitempty,max=500"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+type UpdateReadingListRequest struct {
+	Name        string `json:"name" validate:"omitempty,min=1,max=100"`
+	Description string `json:"description" validate:"omitempty,max=500"`
+	IsPublic    *bool  `json:"is_public" validate:"omitempty"`
+}
+
+type AddReadingListItemRequest struct {
+	PostID uuid.UUID `json:"post_id" validate:"required"`
+	Note   string    `json:"note" validate:"omitempty,max=500"`
+}
+
+type ReorderReadingListRequest struct {
+	PostIDs []uuid.UUID `json:"post_ids" validate:"required,min=1,max=500"`
+}
@@ -0,0 +1,94 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+)
+
+// BookmarkResponse wraps a bookmarked post. When the post was deleted or
+// unpublished, Available is false and Post is omitted.
+type BookmarkResponse struct {
+	PostID       uuid.UUID         `json:"post_id"`
+	Available    bool              `json:"available"`
+	Post         *PostListResponse `json:"post,omitempty"`
+	BookmarkedAt time.Time         `json:"bookmarked_at"`
+}
+
+type ReadingListResponse struct {
+	ID          uuid.UUID                  `json:"id"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	IsPublic    bool                       `json:"is_public"`
+	Owner       *UserAuthor                `json:"owner,omitempty"`
+	ItemCount   int64                      `json:"item_count"`
+	Items       []*ReadingListItemResponse `json:"items,omitempty"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+}
+
+// ReadingListItemResponse follows the same availability rules as bookmarks
+type ReadingListItemResponse struct {
+	PostID    uuid.UUID         `json:"post_id"`
+	Position  int               `json:"position"`
+	Note      string            `json:"note,omitempty"`
+	Available bool              `json:"available"`
+	Post      *PostListResponse `json:"post,omitempty"`
+	AddedAt   time.Time         `json:"added_at"`
+}
+
+// Converter functions
+func ToBookmarkResponse(bookmark *entity.Bookmark, viewer *entity.User, commentCount int64) *BookmarkResponse {
+	response := &BookmarkResponse{
+		PostID:       bookmark.PostID,
+		BookmarkedAt: bookmark.CreatedAt,
+	}
+
+	if bookmark.Post != nil && bookmark.Post.IsVisibleTo(viewer) {
+		response.Available = true
+		response.Post = ToPostListResponse(bookmark.Post, commentCount)
+	}
+
+	return response
+}
+
+func ToReadingListResponse(list *entity.ReadingList, itemCount int64) *ReadingListResponse {
+	response := &ReadingListResponse{
+		ID:          list.ID,
+		Name:        list.Name,
+		Description: list.Description,
+		IsPublic:    list.IsPublic,
+		ItemCount:   itemCount,
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+	}
+
+	if list.User != nil {
+		response.Owner = ToUserAuthor(list.User)
+	}
+
+	return response
+}
+
+// ToReadingListDetailResponse includes items, resolved for the viewer
+func ToReadingListDetailResponse(list *entity.ReadingList, viewer *entity.User, commentCounts map[uuid.UUID]int64) *ReadingListResponse {
+	response := ToReadingListResponse(list, int64(len(list.Items)))
+
+	response.Items = make([]*ReadingListItemResponse, len(list.Items))
+	for i, item := range list.Items {
+		itemResponse := &ReadingListItemResponse{
+			PostID:   item.PostID,
+			Position: item.Position,
+			Note:     item.Note,
+			AddedAt:  item.CreatedAt,
+		}
+		if item.Post != nil && item.Post.IsVisibleTo(viewer) {
+			itemResponse.Available = true
+			itemResponse.Post = ToPostListResponse(item.Post, commentCounts[item.PostID])
+		}
+		response.Items[i] = itemResponse
+	}
+
+	return response
+}
@@ -8,6 +8,7 @@ type CreateCategoryRequest struct {
 type UpdateCategoryRequest struct {
 	Name        string `json:"name" validate:"omitempty,min=2,max=100"`
 	Description string `json:"description" validate:"omitempty,max=500"`
+	Version     *int64 `json:"version" validate:"omitempty,min=1"` // the version being edited; If-Match takes precedence
 }
 
 type CategoryQueryParams struct {
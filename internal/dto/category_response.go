@@ -13,6 +13,7 @@ type CategoryResponse struct {
     Slug        string    `json:"slug"`
     Description string    `json:"description"`
     PostCount   int64     `json:"post_count"`
+    Version     int64     `json:"version"` // also sent as the ETag; send it back in If-Match to update or delete
     CreatedAt   time.Time `json:"created_at"`
     UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -39,6 +40,7 @@ func ToCategoryResponse(category *entity.Category, postCount int64) *CategoryRes
         Slug:        category.Slug,
         Description: category.Description,
         PostCount:   postCount,
+        Version:     category.Version,
         CreatedAt:   category.CreatedAt,
         UpdatedAt:   category.UpdatedAt,
     }
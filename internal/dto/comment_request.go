@@ -9,6 +9,7 @@ type CreateCommentRequest struct {
 
 type UpdateCommentRequest struct {
     Content string `json:"content" validate:"required,min=1,max=1000"`
+    Version *int64 `json:"version" validate:"omitempty,min=1"` // the version being edited; If-Match takes precedence
 }
 
 type CommentQueryParams struct {
@@ -16,4 +17,5 @@ type CommentQueryParams struct {
     Limit     int    `form:"limit" validate:"omitempty,min=1,max=100"`
     SortBy    string `form:"sort_by" validate:"omitempty,oneof=created_at updated_at"`
     SortOrder string `form:"sort_order" validate:"omitempty,oneof=asc desc"`
+    CursorParams
 }
\ No newline at end of file
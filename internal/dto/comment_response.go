@@ -15,6 +15,7 @@ type CommentResponse struct {
     Author    *CommentAuthor   `json:"author"`
     ParentID  *uuid.UUID       `json:"parent_id,omitempty"`
     Replies   []*CommentReply  `json:"replies,omitempty"`
+    Version   int64            `json:"version"` // send it back in If-Match to update or delete
     CreatedAt time.Time        `json:"created_at"`
     UpdatedAt time.Time        `json:"updated_at"`
 }
@@ -30,10 +31,18 @@ type CommentReply struct {
     ID        uuid.UUID      `json:"id"`
     Content   string         `json:"content"`
     Author    *CommentAuthor `json:"author"`
+    Version   int64          `json:"version"`
     CreatedAt time.Time      `json:"created_at"`
     UpdatedAt time.Time      `json:"updated_at"`
 }
 
+// CommentDeletedEvent is the stream payload for a deleted comment
+type CommentDeletedEvent struct {
+    ID       uuid.UUID  `json:"id"`
+    PostID   uuid.UUID  `json:"post_id"`
+    ParentID *uuid.UUID `json:"parent_id,omitempty"`
+}
+
 // Converter functions
 func ToCommentResponse(comment *entity.Comment) *CommentResponse {
     response := &CommentResponse{
@@ -43,6 +52,7 @@ func ToCommentResponse(comment *entity.Comment) *CommentResponse {
         UserID:    comment.UserID,
         ParentID:  comment.ParentID,
         CreatedAt: comment.CreatedAt,
+        Version:   comment.Version,
         UpdatedAt: comment.UpdatedAt,
     }
 
@@ -72,6 +82,7 @@ func ToCommentReply(comment *entity.Comment) *CommentReply {
         ID:        comment.ID,
         Content:   comment.Content,
         CreatedAt: comment.CreatedAt,
+        Version:   comment.Version,
         UpdatedAt: comment.UpdatedAt,
     }
 
@@ -8,6 +8,25 @@ type PaginationMeta struct {
     TotalPages int   `json:"total_pages"`
 }
 
+// CursorParams switches a listing from page numbers to keyset cursors,
+// which stay fast deep into large tables. UseCursor is set when the client
+// sent ?cursor= (empty for the first page); NoTotal when it sent
+// total=false to skip counting the rows.
+type CursorParams struct {
+    Cursor    string `form:"cursor" validate:"omitempty,max=1024"`
+    UseCursor bool   `form:"-"`
+    NoTotal   bool   `form:"-"`
+}
+
+// PageInfo describes the page a listing returned. Total is nil when the
+// client skipped it; the cursors are only set in cursor mode, and are empty
+// when there is nothing that way.
+type PageInfo struct {
+    Total      *int64
+    NextCursor string
+    PrevCursor string
+}
+
 // MessageResponse for simple message responses
 type MessageResponse struct {
     Message string `json:"message"`
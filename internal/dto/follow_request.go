@@ -0,0 +1,11 @@
+package dto
+
+type FollowQueryParams struct {
+	Page  int `form:"page" validate:"omitempty,min=1"`
+	Limit int `form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type FeedQueryParams struct {
+	Cursor string `form:"cursor" validate:"omitempty,max=512"`
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=100"`
+}
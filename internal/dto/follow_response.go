@@ -0,0 +1,9 @@
+package dto
+
+import "github.com/google/uuid"
+
+type FollowResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Following     bool      `json:"following"`
+	FollowerCount int64     `json:"follower_count,omitempty"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+type JobQueryParams struct {
+	Page   int    `form:"page" validate:"omitempty,min=1"`
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Status string `form:"status" validate:"omitempty,oneof=pending running succeeded dead"`
+	Type   string `form:"type" validate:"omitempty,max=100"`
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+)
+
+type JobResponse struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	UniqueKey   *string         `json:"unique_key,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedAt    *time.Time      `json:"locked_at,omitempty"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// JobStatsResponse counts jobs per status
+type JobStatsResponse struct {
+	Pending   int64 `json:"pending"`
+	Running   int64 `json:"running"`
+	Succeeded int64 `json:"succeeded"`
+	Dead      int64 `json:"dead"`
+}
+
+// Converter functions
+func ToJobResponse(job *entity.Job) *JobResponse {
+	return &JobResponse{
+		ID:          job.ID,
+		Type:        job.Type,
+		Status:      string(job.Status),
+		Payload:     json.RawMessage(job.Payload),
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		UniqueKey:   job.UniqueKey,
+		LastError:   job.LastError,
+		RunAt:       job.RunAt,
+		LockedAt:    job.LockedAt,
+		LockedBy:    job.LockedBy,
+		CompletedAt: job.CompletedAt,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+	}
+}
+
+func ToJobStatsResponse(counts map[entity.JobStatus]int64) *JobStatsResponse {
+	return &JobStatsResponse{
+		Pending:   counts[entity.JobStatusPending],
+		Running:   counts[entity.JobStatusRunning],
+		Succeeded: counts[entity.JobStatusSucceeded],
+		Dead:      counts[entity.JobStatusDead],
+	}
+}
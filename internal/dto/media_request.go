@@ -1,12 +1,30 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type UploadMediaRequest struct {
 	AltText     string     `form:"alt_text" validate:"omitempty,max=500"`
 	Description string     `form:"description" validate:"omitempty,max=2000"`
 	PostID      *uuid.UUID `form:"post_id" validate:"omitempty,uuid"`
 	IsFeatured  bool       `form:"is_featured"`
+	FolderID    *uuid.UUID `form:"folder_id" validate:"omitempty"`
+	Tags        []string   `form:"tags" validate:"omitempty,max=20,dive,max=50"` // comma separated in the form
+}
+
+// CreateUploadSlotRequest declares a file the client will upload directly
+// to storage, along with the fields of the media it becomes
+type CreateUploadSlotRequest struct {
+	Filename    string     `json:"filename" validate:"required,max=255"`
+	ContentType string     `json:"content_type" validate:"required,max=100"`
+	Size        int64      `json:"size" validate:"required,min=1"`
+	AltText     string     `json:"alt_text" validate:"omitempty,max=500"`
+	Description string     `json:"description" validate:"omitempty,max=2000"`
+	PostID      *uuid.UUID `json:"post_id" validate:"omitempty"`
+	IsFeatured  bool       `json:"is_featured"`
 }
 
 type UpdateMediaRequest struct {
@@ -14,6 +32,8 @@ type UpdateMediaRequest struct {
 	Description string     `json:"description" validate:"omitempty,max=2000"`
 	PostID      *uuid.UUID `json:"post_id" validate:"omitempty,uuid"`
 	IsFeatured  *bool      `json:"is_featured" validate:"omitempty"`
+	FolderID    *uuid.UUID `json:"folder_id" validate:"omitempty"`
+	Tags        []string   `json:"tags" validate:"omitempty,max=20,dive,max=50"` // replaces the tags; [] clears them
 }
 
 type MediaQueryParams struct {
@@ -25,4 +45,46 @@ type MediaQueryParams struct {
 	IsFeatured *bool  `form:"is_featured" validate:"omitempty"`
 	SortBy     string `form:"sort_by" validate:"omitempty,oneof=created_at updated_at size"`
 	SortOrder  string `form:"sort_order" validate:"omitempty,oneof=asc desc"`
+	CursorParams
+
+	// Media library
+	Search       string     `form:"q" validate:"omitempty,max=100"`
+	Tags         []string   `form:"tags" validate:"omitempty,max=20,dive,max=50"`
+	FolderID     *uuid.UUID `form:"folder_id" validate:"omitempty"`
+	Unfiled      bool       `form:"unfiled"`
+	MinWidth     *int       `form:"min_width" validate:"omitempty,min=1"`
+	MaxWidth     *int       `form:"max_width" validate:"omitempty,min=1"`
+	MinHeight    *int       `form:"min_height" validate:"omitempty,min=1"`
+	MaxHeight    *int       `form:"max_height" validate:"omitempty,min=1"`
+	UploadedFrom *time.Time `form:"uploaded_from"`
+	UploadedTo   *time.Time `form:"uploaded_to"` // exclusive
+}
+
+type CreateMediaFolderRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description" validate:"omitempty,max=500"`
+}
+
+type UpdateMediaFolderRequest struct {
+	Name        string `json:"name" validate:"omitempty,min=1,max=100"`
+	Description string `json:"description" validate:"omitempty,max=500"`
+}
+
+// Bulk media actions
+const (
+	MediaBulkMove   = "move"   // into FolderID, or out of any folder when it is null
+	MediaBulkTag    = "tag"    // add Tags
+	MediaBulkUntag  = "untag"  // remove Tags
+	MediaBulkAttach = "attach" // to PostID
+	MediaBulkDelete = "delete"
+)
+
+// BulkMediaRequest applies one action to several media. Each item is
+// checked separately; the ones the caller can't change are reported back.
+type BulkMediaRequest struct {
+	Action   string      `json:"action" validate:"required,oneof=move tag untag attach delete"`
+	MediaIDs []uuid.UUID `json:"media_ids" validate:"required,min=1,max=100"`
+	FolderID *uuid.UUID  `json:"folder_id" validate:"omitempty"`
+	PostID   *uuid.UUID  `json:"post_id" validate:"omitempty"`
+	Tags     []string    `json:"tags" validate:"omitempty,max=20,dive,max=50"`
 }
\ No newline at end of file
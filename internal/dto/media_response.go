@@ -1,6 +1,10 @@
 package dto
 
 import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
@@ -24,10 +28,27 @@ type MediaResponse struct {
 	UserID       uuid.UUID `json:"user_id"`
 	User         *MediaAuthor `json:"user,omitempty"`
 	IsFeatured   bool      `json:"is_featured"`
+	Variants     []*MediaVariantResponse `json:"variants,omitempty"` // generated shortly after upload
+	Srcset       string    `json:"srcset,omitempty"`
+	Poster       string    `json:"poster,omitempty"` // still for video and documents
+	Duration     *float64  `json:"duration,omitempty"` // seconds, video and audio
+	PageCount    *int      `json:"page_count,omitempty"`
+	FolderID     *uuid.UUID `json:"folder_id,omitempty"`
+	Tags         []string  `json:"tags"`
+	Metadata     *MediaMetadataResponse `json:"metadata,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// MediaMetadataResponse is what was read from the upload's EXIF data
+type MediaMetadataResponse struct {
+	CameraMake     string     `json:"camera_make,omitempty"`
+	CameraModel    string     `json:"camera_model,omitempty"`
+	CapturedAt     *time.Time `json:"captured_at,omitempty"`
+	OriginalWidth  *int       `json:"original_width,omitempty"`
+	OriginalHeight *int       `json:"original_height,omitempty"`
+}
+
 type MediaListResponse struct {
 	ID           uuid.UUID `json:"id"`
 	Filename     string    `json:"filename"`
@@ -41,9 +62,144 @@ type MediaListResponse struct {
 	PostID       *uuid.UUID `json:"post_id,omitempty"`
 	User         *MediaAuthor  `json:"user,omitempty"`
 	IsFeatured   bool      `json:"is_featured"`
+	Variants     []*MediaVariantResponse `json:"variants,omitempty"`
+	Srcset       string    `json:"srcset,omitempty"`
+	Poster       string    `json:"poster,omitempty"`
+	Duration     *float64  `json:"duration,omitempty"`
+	PageCount    *int      `json:"page_count,omitempty"`
+	FolderID     *uuid.UUID `json:"folder_id,omitempty"`
+	Tags         []string  `json:"tags"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+type MediaVariantResponse struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	MimeType string `json:"mime_type"`
+}
+
+type MediaFolderResponse struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	MediaCount  int64     `json:"media_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type MediaTagResponse struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// BulkMediaResponse lists which media a bulk action applied to
+type BulkMediaResponse struct {
+	Action    string             `json:"action"`
+	Succeeded []uuid.UUID        `json:"succeeded"`
+	Failed    []BulkMediaFailure `json:"failed"`
+}
+
+type BulkMediaFailure struct {
+	ID    uuid.UUID `json:"id"`
+	Error string    `json:"error"`
+}
+
+// UploadSlotResponse tells the client where to send the file; it then calls
+// the complete endpoint before ExpiresAt
+type UploadSlotResponse struct {
+	ID        uuid.UUID             `json:"id"`
+	Upload    *UploadTargetResponse `json:"upload"`
+	ExpiresAt time.Time             `json:"expires_at"`
+}
+
+type UploadTargetResponse struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ResizeURLResponse is a signed URL for the on-demand resize endpoint
+type ResizeURLResponse struct {
+	URL    string `json:"url"`
+	Width  uint   `json:"width"`
+	Height uint   `json:"height"`
+	Fit    string `json:"fit"`
+}
+
+// ResizedImage is a rendition produced by the resize endpoint
+type ResizedImage struct {
+	Data     []byte
+	MimeType string
+	ETag     string
+	ModTime  time.Time
+}
+
+// FileURLResponse is a signed, expiring link to a stored file
+type FileURLResponse struct {
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // unset when storage serves the file itself
+}
+
+// MediaFile is a stored file opened for delivery
+type MediaFile struct {
+	Content   io.ReadSeekCloser
+	MimeType  string
+	Filename  string // as uploaded, for Content-Disposition
+	ETag      string
+	ModTime   time.Time
+	Public    bool // visible to anyone, so shared caches may keep it
+	Immutable bool // content-addressed: the path never gets other content
+}
+
+// MediaBackfillResult summarises a content hash backfill
+type MediaBackfillResult struct {
+	Hashed     int   `json:"hashed"`
+	Merged     int   `json:"merged"`  // duplicates now sharing another file
+	Missing    int   `json:"missing"` // files that could not be read
+	BytesFreed int64 `json:"bytes_freed"`
+}
+
+// FeaturedMediaMigrationResult counts the posts linked to their featured
+// media
+type FeaturedMediaMigrationResult struct {
+	FromURLs  int64 `json:"from_urls"`  // matched by featured_image
+	FromFlags int64 `json:"from_flags"` // matched by an is_featured media
+	External  int64 `json:"external"`   // featured_image points elsewhere, left as is
+}
+
+// StorageObject is a stored file
+type StorageObject struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// StorageGCReport lists stored files nothing refers to
+type StorageGCReport struct {
+	Scanned     int             `json:"scanned"`
+	Orphans     []StorageObject `json:"orphans"`
+	OrphanBytes int64           `json:"orphan_bytes"`
+	Deleted     int             `json:"deleted"`
+	DryRun      bool            `json:"dry_run"`
+}
+
+// MissingFile is a row whose file is not in storage
+type MissingFile struct {
+	Kind string    `json:"kind"` // media, variant or avatar
+	ID   uuid.UUID `json:"id"`   // media or user ID
+	Path string    `json:"path"`
+}
+
+// StorageConsistencyReport lists rows whose files are missing
+type StorageConsistencyReport struct {
+	CheckedMedia   int           `json:"checked_media"`
+	CheckedAvatars int           `json:"checked_avatars"`
+	Missing        []MissingFile `json:"missing"`
+}
+
 type MediaAuthor struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
@@ -76,9 +232,16 @@ func ToMediaResponse(media *entity.Media) *MediaResponse {
 		PostID:       media.PostID,
 		UserID:       media.UserID,
 		IsFeatured:   media.IsFeatured,
+		Duration:     media.Duration,
+		PageCount:    media.PageCount,
+		FolderID:     media.FolderID,
+		Tags:         toMediaTags(media),
 		CreatedAt:    media.CreatedAt,
 		UpdatedAt:    media.UpdatedAt,
 	}
+	response.Variants, response.Srcset = toMediaVariants(media)
+	response.Poster = toMediaPoster(media)
+	response.Metadata = toMediaMetadata(media)
 
 	// Add user info if exists
 	if media.User != nil {
@@ -106,8 +269,14 @@ func ToMediaListResponse(media *entity.Media) *MediaListResponse {
 		MediaType:    string(media.MediaType),
 		PostID:       media.PostID,
 		IsFeatured:   media.IsFeatured,
+		Duration:     media.Duration,
+		PageCount:    media.PageCount,
+		FolderID:     media.FolderID,
+		Tags:         toMediaTags(media),
 		CreatedAt:    media.CreatedAt,
 	}
+	response.Variants, response.Srcset = toMediaVariants(media)
+	response.Poster = toMediaPoster(media)
 
 	// ✅ TAMBAHKAN INI - Include user info
 	if media.User != nil {
@@ -122,6 +291,71 @@ func ToMediaListResponse(media *entity.Media) *MediaListResponse {
 	return response
 }
 
+// toMediaTags never returns nil, so tags is always a JSON array
+func toMediaTags(media *entity.Media) []string {
+	if len(media.Tags) == 0 {
+		return []string{}
+	}
+	return media.Tags
+}
+
+// toMediaVariants lists the variants by width and builds a srcset from the
+// ones that keep the original aspect ratio, plus the original itself
+func toMediaVariants(media *entity.Media) ([]*MediaVariantResponse, string) {
+	if len(media.Variants) == 0 {
+		return nil, ""
+	}
+
+	variants := make([]*MediaVariantResponse, len(media.Variants))
+	for i, v := range media.Variants {
+		variants[i] = &MediaVariantResponse{
+			Name:     v.Name,
+			URL:      v.URL,
+			Width:    v.Width,
+			Height:   v.Height,
+			MimeType: v.MimeType,
+		}
+	}
+	sort.SliceStable(variants, func(i, j int) bool { return variants[i].Width < variants[j].Width })
+
+	var candidates []string
+	for _, v := range media.Variants {
+		if !v.Crop {
+			candidates = append(candidates, fmt.Sprintf("%s %dw", v.URL, v.Width))
+		}
+	}
+	if len(candidates) == 0 {
+		return variants, ""
+	}
+	// Only an image can stand in for its own variants
+	if media.Width != nil && media.IsImage() {
+		candidates = append(candidates, fmt.Sprintf("%s %dw", media.URL, *media.Width))
+	}
+
+	return variants, strings.Join(candidates, ", ")
+}
+
+// toMediaPoster returns the URL of the still rendered for non-image media
+func toMediaPoster(media *entity.Media) string {
+	if poster, ok := media.Variants.Variant(entity.MediaVariantPoster); ok {
+		return poster.URL
+	}
+	return ""
+}
+
+func toMediaMetadata(media *entity.Media) *MediaMetadataResponse {
+	if media.CameraMake == "" && media.CameraModel == "" && media.CapturedAt == nil && media.OriginalWidth == nil {
+		return nil
+	}
+	return &MediaMetadataResponse{
+		CameraMake:     media.CameraMake,
+		CameraModel:    media.CameraModel,
+		CapturedAt:     media.CapturedAt,
+		OriginalWidth:  media.OriginalWidth,
+		OriginalHeight: media.OriginalHeight,
+	}
+}
+
 func ToMediaBasic(media *entity.Media) *MediaBasic {
 	return &MediaBasic{
 		ID:       media.ID,
@@ -145,4 +379,24 @@ func ToMediaListResponses(medias []*entity.Media) []*MediaListResponse {
 		responses[i] = ToMediaListResponse(media)
 	}
 	return responses
-}
\ No newline at end of file
+}
+
+func ToMediaFolderResponse(folder *entity.MediaFolder) *MediaFolderResponse {
+	return &MediaFolderResponse{
+		ID:          folder.ID,
+		UserID:      folder.UserID,
+		Name:        folder.Name,
+		Description: folder.Description,
+		MediaCount:  folder.MediaCount,
+		CreatedAt:   folder.CreatedAt,
+		UpdatedAt:   folder.UpdatedAt,
+	}
+}
+
+func ToMediaFolderResponses(folders []*entity.MediaFolder) []*MediaFolderResponse {
+	responses := make([]*MediaFolderResponse, len(folders))
+	for i, folder := range folders {
+		responses[i] = ToMediaFolderResponse(folder)
+	}
+	return responses
+}
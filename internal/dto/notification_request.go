@@ -0,0 +1,16 @@
+package dto
+
+type NotificationQueryParams struct {
+	Page   int  `form:"page" validate:"omitempty,min=1"`
+	Limit  int  `form:"limit" validate:"omitempty,min=1,max=100"`
+	Unread bool `form:"unread"`
+}
+
+// UpdateNotificationPreferencesRequest only changes the fields that are sent
+type UpdateNotificationPreferencesRequest struct {
+	PostComment   *bool `json:"post_comment"`
+	CommentReply  *bool `json:"comment_reply"`
+	PostPublished *bool `json:"post_published"`
+	FollowedPost  *bool `json:"followed_post"`
+	NewFollower   *bool `json:"new_follower"`
+}
@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+)
+
+type NotificationResponse struct {
+	ID        uuid.UUID         `json:"id"`
+	Type      string            `json:"type"`
+	Message   string            `json:"message"`
+	Actor     *UserAuthor       `json:"actor,omitempty"`
+	Post      *NotificationPost `json:"post,omitempty"`
+	CommentID *uuid.UUID        `json:"comment_id,omitempty"`
+	IsRead    bool              `json:"is_read"`
+	ReadAt    *time.Time        `json:"read_at,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type NotificationPost struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+	Slug  string    `json:"slug"`
+}
+
+type UnreadCountResponse struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+type NotificationPreferencesResponse struct {
+	PostComment   bool `json:"post_comment"`
+	CommentReply  bool `json:"comment_reply"`
+	PostPublished bool `json:"post_published"`
+	FollowedPost  bool `json:"followed_post"`
+	NewFollower   bool `json:"new_follower"`
+}
+
+// Converter functions
+func ToNotificationResponse(notification *entity.Notification) *NotificationResponse {
+	resp := &NotificationResponse{
+		ID:        notification.ID,
+		Type:      string(notification.Type),
+		Message:   notification.Message,
+		CommentID: notification.CommentID,
+		IsRead:    notification.IsRead(),
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+
+	if notification.Actor != nil {
+		resp.Actor = ToUserAuthor(notification.Actor)
+	}
+
+	// Soft-deleted posts are not preloaded and simply drop out
+	if notification.Post != nil {
+		resp.Post = &NotificationPost{
+			ID:    notification.Post.ID,
+			Title: notification.Post.Title,
+			Slug:  notification.Post.Slug,
+		}
+	}
+
+	return resp
+}
+
+func ToNotificationPreferencesResponse(pref *entity.NotificationPreference) *NotificationPreferencesResponse {
+	return &NotificationPreferencesResponse{
+		PostComment:   pref.PostComment,
+		CommentReply:  pref.CommentReply,
+		PostPublished: pref.PostPublished,
+		FollowedPost:  pref.FollowedPost,
+		NewFollower:   pref.NewFollower,
+	}
+}
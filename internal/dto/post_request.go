@@ -8,7 +8,8 @@ type CreatePostRequest struct {
     Content       string     `json:"content" validate:"required,min=10"`
     Excerpt       string     `json:"excerpt" validate:"omitempty,max=500"`
     CategoryID    uuid.UUID  `json:"category_id" validate:"required,uuid"`
-    FeaturedImage string     `json:"featured_image" validate:"omitempty,url"`
+    FeaturedImage string     `json:"featured_image" validate:"omitempty,url,excluded_with=FeaturedMediaID"` // external image; prefer featured_media_id
+    FeaturedMediaID *uuid.UUID `json:"featured_media_id" validate:"omitempty"`
     Tags          []string   `json:"tags" validate:"omitempty,dive,min=2,max=50"`
     Status        string     `json:"status" validate:"omitempty,oneof=draft published archived"`
 }
@@ -19,9 +20,11 @@ type UpdatePostRequest struct {
     Content       string     `json:"content" validate:"omitempty,min=10"`
     Excerpt       string     `json:"excerpt" validate:"omitempty,max=500"`
     CategoryID    *uuid.UUID `json:"category_id" validate:"omitempty,uuid"`
-    FeaturedImage string     `json:"featured_image" validate:"omitempty,url"`
+    FeaturedImage string     `json:"featured_image" validate:"omitempty,url,excluded_with=FeaturedMediaID"` // external image; prefer featured_media_id
+    FeaturedMediaID *uuid.UUID `json:"featured_media_id" validate:"omitempty"`
     Tags          []string   `json:"tags" validate:"omitempty,dive,min=2,max=50"`
     Status        string     `json:"status" validate:"omitempty,oneof=draft published archived"`
+    Version       *int64     `json:"version" validate:"omitempty,min=1"` // the version being edited; If-Match takes precedence
 }
 
 type PostQueryParams struct {
@@ -34,4 +37,16 @@ type PostQueryParams struct {
     AuthorID   *uuid.UUID `form:"author_id" validate:"omitempty,uuid"`
     SortBy     string     `form:"sort_by" validate:"omitempty,oneof=created_at updated_at title views"`
     SortOrder  string     `form:"sort_order" validate:"omitempty,oneof=asc desc"`
-}
\ No newline at end of file
+    CursorParams
+}
+
+// PostViewRequest describes a single post view. Referrer may be sent by
+// SPA clients (document.referrer); the rest is filled in by the handler.
+type PostViewRequest struct {
+    Referrer      string `json:"referrer" validate:"omitempty,max=2000"`
+    ReferrerHost  string `json:"-"`
+    Country       string `json:"-"`
+    VisitorKey    string `json:"-"`
+    UserAgent     string `json:"-"`
+    Authenticated bool   `json:"-"`
+}
@@ -14,6 +14,7 @@ type PostResponse struct {
     Content       string         `json:"content"`
     Excerpt       string         `json:"excerpt"`
     FeaturedImage string         `json:"featured_image,omitempty"`
+    FeaturedMedia *PostFeaturedMedia `json:"featured_media,omitempty"`
     Status        string         `json:"status"`
     Views         int64          `json:"views"`
     AuthorID      uuid.UUID      `json:"author_id"`
@@ -22,7 +23,9 @@ type PostResponse struct {
     Category      *PostCategory  `json:"category"`
     Tags          []string       `json:"tags"`         // 👈 Changed to []string
     CommentCount  int64          `json:"comment_count"`
+    IsBookmarked  *bool          `json:"is_bookmarked,omitempty"` // Only set for logged in users
     PublishedAt   *time.Time     `json:"published_at,omitempty"`
+    Version       int64          `json:"version"` // also sent as the ETag; send it back in If-Match to update or delete
     CreatedAt     time.Time      `json:"created_at"`
     UpdatedAt     time.Time      `json:"updated_at"`
 }
@@ -33,17 +36,31 @@ type PostListResponse struct {
     Slug          string        `json:"slug"`
     Excerpt       string        `json:"excerpt"`
     FeaturedImage string        `json:"featured_image,omitempty"`
+    FeaturedMedia *PostFeaturedMedia `json:"featured_media,omitempty"`
     Status        string        `json:"status"`
     Views         int64         `json:"views"`
     Author        *PostAuthor   `json:"author"`
     Category      *PostCategory `json:"category"`
     Tags          []string      `json:"tags"`         // 👈 Changed to []string
     CommentCount  int64         `json:"comment_count"`
+    IsBookmarked  *bool         `json:"is_bookmarked,omitempty"` // Only set for logged in users
     PublishedAt   *time.Time    `json:"published_at,omitempty"`
     CreatedAt     time.Time     `json:"created_at"`
     UpdatedAt     time.Time     `json:"updated_at"`
 }
 
+// PostFeaturedMedia is a post's featured image with its variants
+type PostFeaturedMedia struct {
+    ID       uuid.UUID               `json:"id"`
+    URL      string                  `json:"url"`
+    MimeType string                  `json:"mime_type"`
+    Width    *int                    `json:"width,omitempty"`
+    Height   *int                    `json:"height,omitempty"`
+    AltText  string                  `json:"alt_text,omitempty"`
+    Variants []*MediaVariantResponse `json:"variants,omitempty"`
+    Srcset   string                  `json:"srcset,omitempty"`
+}
+
 type PostAuthor struct {
     ID       uuid.UUID `json:"id"`
     Username string    `json:"username"`
@@ -57,6 +74,12 @@ type PostCategory struct {
     Slug string    `json:"slug"`
 }
 
+// PostDeletedEvent is the webhook payload for a deleted post
+type PostDeletedEvent struct {
+    ID   uuid.UUID `json:"id"`
+    Slug string    `json:"slug"`
+}
+
 // 👇 ADD commentCount parameter
 func ToPostResponse(post *entity.Post, commentCount int64) *PostResponse {
     response := &PostResponse{
@@ -72,6 +95,7 @@ func ToPostResponse(post *entity.Post, commentCount int64) *PostResponse {
         CategoryID:    post.CategoryID,
         CommentCount:  commentCount,         // 👈 From parameter
         PublishedAt:   post.PublishedAt,
+        Version:       post.Version,
         CreatedAt:     post.CreatedAt,
         UpdatedAt:     post.UpdatedAt,
     }
@@ -95,6 +119,12 @@ func ToPostResponse(post *entity.Post, commentCount int64) *PostResponse {
         }
     }
 
+    // Featured media, whose URL also fills the legacy featured_image
+    if post.FeaturedMedia != nil {
+        response.FeaturedMedia = toPostFeaturedMedia(post.FeaturedMedia)
+        response.FeaturedImage = post.FeaturedMedia.URL
+    }
+
     // Add tags (already []string in entity)
     if len(post.Tags) > 0 {
         response.Tags = post.Tags  // 👈 Direct assignment
@@ -140,6 +170,12 @@ func ToPostListResponse(post *entity.Post, commentCount int64) *PostListResponse
         }
     }
 
+    // Featured media, whose URL also fills the legacy featured_image
+    if post.FeaturedMedia != nil {
+        response.FeaturedMedia = toPostFeaturedMedia(post.FeaturedMedia)
+        response.FeaturedImage = post.FeaturedMedia.URL
+    }
+
     // Add tags (already []string in entity)
     if len(post.Tags) > 0 {
         response.Tags = post.Tags  // 👈 Direct assignment
@@ -150,5 +186,18 @@ func ToPostListResponse(post *entity.Post, commentCount int64) *PostListResponse
     return response
 }
 
+func toPostFeaturedMedia(media *entity.Media) *PostFeaturedMedia {
+    featured := &PostFeaturedMedia{
+        ID:       media.ID,
+        URL:      media.URL,
+        MimeType: media.MimeType,
+        Width:    media.Width,
+        Height:   media.Height,
+        AltText:  media.AltText,
+    }
+    featured.Variants, featured.Srcset = toMediaVariants(media)
+    return featured
+}
+
 // ❌ REMOVE bulk converter
 // func ToPostListResponses(posts []*entity.Post) []*PostListResponse
\ No newline at end of file
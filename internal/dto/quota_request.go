@@ -0,0 +1,9 @@
+package dto
+
+// SetStorageQuotaRequest overrides a user's storage limits. Omitted limits
+// keep the role's default; 0 means unlimited.
+type SetStorageQuotaRequest struct {
+	MaxBytes *int64 `json:"max_bytes" validate:"omitempty,min=0"`
+	MaxFiles *int64 `json:"max_files" validate:"omitempty,min=0"`
+	Note     string `json:"note" validate:"omitempty,max=255"`
+}
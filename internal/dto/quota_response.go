@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// StorageUsageResponse is what a user stores against their limits. Limits
+// of 0 are unlimited, and the remaining fields are left out for them.
+type StorageUsageResponse struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Username       string    `json:"username"`
+	Email          string    `json:"email,omitempty"`
+	Role           string    `json:"role"`
+	UsedBytes      int64     `json:"used_bytes"`
+	UsedFiles      int64     `json:"used_files"`
+	MediaBytes     int64     `json:"media_bytes"`
+	MediaFiles     int64     `json:"media_files"`
+	AvatarBytes    int64     `json:"avatar_bytes"`
+	MaxBytes       int64     `json:"max_bytes"`
+	MaxFiles       int64     `json:"max_files"`
+	RemainingBytes *int64    `json:"remaining_bytes,omitempty"`
+	RemainingFiles *int64    `json:"remaining_files,omitempty"`
+	Overridden     bool      `json:"overridden"` // the limits were set for this user
+}
@@ -33,4 +33,5 @@ type UserQueryParams struct {
 	IsActive  *bool  `form:"is_active" validate:"omitempty"`
 	SortBy    string `form:"sort_by" validate:"omitempty,oneof=created_at updated_at username email"`
 	SortOrder string `form:"sort_order" validate:"omitempty,oneof=asc desc"`
+	CursorParams
 }
\ No newline at end of file
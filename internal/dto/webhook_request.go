@@ -0,0 +1,23 @@
+package dto
+
+type CreateWebhookRequest struct {
+	Name     string   `json:"name" validate:"required,min=1,max=100"`
+	URL      string   `json:"url" validate:"required,url,max=500"`
+	Secret   string   `json:"secret" validate:"omitempty,min=16,max=255"` // generated when empty
+	Events   []string `json:"events" validate:"omitempty,dive,oneof=post.published post.updated post.unpublished post.deleted comment.created"`
+	IsActive *bool    `json:"is_active"`
+}
+
+type UpdateWebhookRequest struct {
+	Name     string   `json:"name" validate:"omitempty,min=1,max=100"`
+	URL      string   `json:"url" validate:"omitempty,url,max=500"`
+	Secret   string   `json:"secret" validate:"omitempty,min=16,max=255"`
+	Events   []string `json:"events" validate:"omitempty,dive,oneof=post.published post.updated post.unpublished post.deleted comment.created"`
+	IsActive *bool    `json:"is_active"`
+}
+
+type WebhookDeliveryQueryParams struct {
+	Page   int    `form:"page" validate:"omitempty,min=1"`
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Status string `form:"status" validate:"omitempty,oneof=pending succeeded failed"`
+}
@@ -0,0 +1,100 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+)
+
+type WebhookResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	IsActive  bool      `json:"is_active"`
+	Secret    string    `json:"secret,omitempty"` // only returned when created or rotated
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID            uuid.UUID                         `json:"id"`
+	WebhookID     uuid.UUID                         `json:"webhook_id"`
+	Event         string                            `json:"event"`
+	Status        string                            `json:"status"`
+	Attempts      int                               `json:"attempts"`
+	ResponseCode  int                               `json:"response_code,omitempty"`
+	NextAttemptAt *time.Time                        `json:"next_attempt_at,omitempty"`
+	LastAttemptAt *time.Time                        `json:"last_attempt_at,omitempty"`
+	DeliveredAt   *time.Time                        `json:"delivered_at,omitempty"`
+	RedeliveryOf  *uuid.UUID                        `json:"redelivery_of,omitempty"`
+	Payload       json.RawMessage                   `json:"payload,omitempty"`
+	AttemptLog    []*WebhookDeliveryAttemptResponse `json:"attempt_log,omitempty"`
+	CreatedAt     time.Time                         `json:"created_at"`
+}
+
+type WebhookDeliveryAttemptResponse struct {
+	Attempt      int       `json:"attempt"`
+	ResponseCode int       `json:"response_code,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Converter functions
+func ToWebhookResponse(webhook *entity.Webhook) *WebhookResponse {
+	events := []string(webhook.Events)
+	if events == nil {
+		events = []string{}
+	}
+	return &WebhookResponse{
+		ID:        webhook.ID,
+		Name:      webhook.Name,
+		URL:       webhook.URL,
+		Events:    events,
+		IsActive:  webhook.IsActive,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
+
+// ToWebhookDeliveryResponse includes payload and attempt log when withDetail is set
+func ToWebhookDeliveryResponse(delivery *entity.WebhookDelivery, withDetail bool) *WebhookDeliveryResponse {
+	resp := &WebhookDeliveryResponse{
+		ID:            delivery.ID,
+		WebhookID:     delivery.WebhookID,
+		Event:         delivery.Event,
+		Status:        string(delivery.Status),
+		Attempts:      delivery.Attempts,
+		ResponseCode:  delivery.ResponseCode,
+		LastAttemptAt: delivery.LastAttemptAt,
+		DeliveredAt:   delivery.DeliveredAt,
+		RedeliveryOf:  delivery.RedeliveryOf,
+		CreatedAt:     delivery.CreatedAt,
+	}
+
+	// Only pending deliveries have a meaningful next attempt
+	if delivery.Status == entity.WebhookDeliveryPending {
+		next := delivery.NextAttemptAt
+		resp.NextAttemptAt = &next
+	}
+
+	if withDetail {
+		resp.Payload = json.RawMessage(delivery.Payload)
+		for _, a := range delivery.AttemptLog {
+			resp.AttemptLog = append(resp.AttemptLog, &WebhookDeliveryAttemptResponse{
+				Attempt:      a.Attempt,
+				ResponseCode: a.ResponseCode,
+				ResponseBody: a.ResponseBody,
+				Error:        a.Error,
+				DurationMs:   a.DurationMs,
+				CreatedAt:    a.CreatedAt,
+			})
+		}
+	}
+
+	return resp
+}
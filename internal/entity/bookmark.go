@@ -0,0 +1,15 @@
+package entity
+
+import "github.com/google/uuid"
+
+type Bookmark struct {
+	BaseEntity
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bookmarks_user_post" json:"user_id"`
+	User   *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	PostID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bookmarks_user_post;index" json:"post_id"`
+	Post   *Post     `gorm:"foreignKey:PostID" json:"post,omitempty"`
+}
+
+func (Bookmark) TableName() string {
+	return "bookmarks"
+}
@@ -5,6 +5,7 @@ type Category struct {
 	Name        string `gorm:"type:varchar(100);not null" json:"name"`
 	Slug        string `gorm:"type:varchar(100);uniqueIndex;not null" json:"slug"`
 	Description string `gorm:"type:text" json:"description"`
+	Version     int64  `gorm:"not null;default:1" json:"version"` // bumped by every update, see repository.ErrVersionConflict
 	Posts       []Post `gorm:"foreignKey:CategoryID" json:"posts,omitempty"`
 }
 
@@ -5,6 +5,7 @@ import "github.com/google/uuid"
 type Comment struct {
     BaseEntity
     Content  string     `gorm:"type:text;not null" json:"content"`
+    Version  int64      `gorm:"not null;default:1" json:"version"` // bumped by every update, see repository.ErrVersionConflict
     PostID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"post_id"`
     Post     *Post      `gorm:"foreignKey:PostID" json:"post,omitempty"`
     UserID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
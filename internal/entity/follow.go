@@ -0,0 +1,29 @@
+package entity
+
+import "github.com/google/uuid"
+
+// UserFollow means Follower subscribes to posts written by Following
+type UserFollow struct {
+	BaseEntity
+	FollowerID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_follows_pair" json:"follower_id"`
+	Follower    *User     `gorm:"foreignKey:FollowerID" json:"follower,omitempty"`
+	FollowingID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_follows_pair;index" json:"following_id"`
+	Following   *User     `gorm:"foreignKey:FollowingID" json:"following,omitempty"`
+}
+
+func (UserFollow) TableName() string {
+	return "user_follows"
+}
+
+// CategoryFollow means User subscribes to posts in Category
+type CategoryFollow struct {
+	BaseEntity
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_category_follows_pair" json:"user_id"`
+	User       *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	CategoryID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_category_follows_pair;index" json:"category_id"`
+	Category   *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+}
+
+func (CategoryFollow) TableName() string {
+	return "category_follows"
+}
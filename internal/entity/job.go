@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending" // waiting for run_at, including scheduled retries
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusDead      JobStatus = "dead" // gave up; kept for inspection and manual retry
+)
+
+// Job is one unit of background work. Jobs are never soft-deleted: the
+// unique key index only covers pending and running rows, so a finished job
+// doesn't block a new one with the same key.
+type Job struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Type        string     `gorm:"type:varchar(100);not null;index" json:"type"`
+	Payload     string     `gorm:"type:text;not null" json:"payload"`
+	Status      JobStatus  `gorm:"type:varchar(20);not null;index:idx_jobs_due" json:"status"`
+	RunAt       time.Time  `gorm:"not null;index:idx_jobs_due" json:"run_at"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"not null" json:"max_attempts"`
+	UniqueKey   *string    `gorm:"type:varchar(255);uniqueIndex:idx_jobs_unique_key,where:status = 'pending' OR status = 'running'" json:"unique_key,omitempty"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	LockedAt    *time.Time `json:"locked_at,omitempty"`
+	LockedBy    string     `gorm:"type:varchar(100)" json:"locked_by,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
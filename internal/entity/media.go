@@ -1,7 +1,13 @@
 package entity
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type MediaType string
@@ -19,6 +25,7 @@ type Media struct {
 	OriginalName string    `gorm:"type:varchar(255);not null" json:"original_name"`
 	MimeType    string     `gorm:"type:varchar(100);not null" json:"mime_type"`
 	Path        string     `gorm:"type:varchar(500);not null;index" json:"path"`
+	ContentHash string     `gorm:"type:varchar(64);not null;default:'';index" json:"content_hash,omitempty"` // MediaBlob of the file; empty until backfilled
 	URL         string     `gorm:"type:varchar(500);not null" json:"url"`
 	Size        int64      `gorm:"not null" json:"size"`
 	Width       *int       `gorm:"type:integer" json:"width,omitempty"`
@@ -31,6 +38,78 @@ type Media struct {
 	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
 	User        *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	IsFeatured  bool       `gorm:"default:false" json:"is_featured"`
+	Variants    MediaVariants `gorm:"type:jsonb;not null;default:'[]';index:,type:gin" json:"variants"`
+
+	// Media library organisation
+	FolderID *uuid.UUID     `gorm:"type:uuid;index" json:"folder_id,omitempty"`
+	Tags     pq.StringArray `gorm:"type:text[];index:,type:gin" json:"tags,omitempty"`
+
+	// Read from the upload's EXIF data; the metadata itself is stripped
+	CameraMake     string     `gorm:"type:varchar(100)" json:"camera_make,omitempty"`
+	CameraModel    string     `gorm:"type:varchar(100)" json:"camera_model,omitempty"`
+	CapturedAt     *time.Time `json:"captured_at,omitempty"`
+	OriginalWidth  *int       `gorm:"type:integer" json:"original_width,omitempty"`
+	OriginalHeight *int       `gorm:"type:integer" json:"original_height,omitempty"`
+
+	// Read from video, audio and document containers
+	Duration  *float64 `gorm:"type:double precision" json:"duration,omitempty"` // seconds
+	PageCount *int     `gorm:"type:integer" json:"page_count,omitempty"`
+}
+
+// MediaVariantPoster is the full-size still of a video or document; the
+// other variants of such media are rendered from it
+const MediaVariantPoster = "poster"
+
+// MediaVariant is a resized rendition stored next to the original file
+type MediaVariant struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	URL      string `json:"url"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+	Crop     bool   `json:"crop,omitempty"` // cropped to a fixed box, so not for srcset
+}
+
+// MediaVariants is stored as a JSON array
+type MediaVariants []MediaVariant
+
+func (v MediaVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (v *MediaVariants) Scan(value interface{}) error {
+	var data []byte
+	switch src := value.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return errors.New("unsupported type for media variants")
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Variant returns the variant with the given name
+func (v MediaVariants) Variant(name string) (*MediaVariant, bool) {
+	for i := range v {
+		if v[i].Name == name {
+			return &v[i], true
+		}
+	}
+	return nil, false
 }
 
 func (Media) TableName() string {
@@ -45,6 +124,14 @@ func (m *Media) IsVideo() bool {
 	return m.MediaType == MediaTypeVideo
 }
 
+func (m *Media) IsAudio() bool {
+	return m.MediaType == MediaTypeAudio
+}
+
+func (m *Media) IsDocument() bool {
+	return m.MediaType == MediaTypeDocument
+}
+
 func (m *Media) GetDimensions() (width, height int, ok bool) {
 	if m.Width != nil && m.Height != nil {
 		return *m.Width, *m.Height, true
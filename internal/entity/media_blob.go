@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// MediaBlob is a stored file, keyed by the SHA-256 of its content. Media
+// rows with the same content share one blob; the file is removed when the
+// last of them is deleted.
+type MediaBlob struct {
+	Hash      string    `gorm:"type:varchar(64);primaryKey" json:"hash"` // hex SHA-256
+	Path      string    `gorm:"type:varchar(500);not null;uniqueIndex" json:"path"`
+	Size      int64     `gorm:"not null" json:"size"`
+	MimeType  string    `gorm:"type:varchar(100);not null" json:"mime_type"`
+	RefCount  int       `gorm:"not null;default:0" json:"ref_count"` // media rows using the file
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (MediaBlob) TableName() string {
+	return "media_blobs"
+}
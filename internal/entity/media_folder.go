@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MediaFolder groups a user's media in the library. Deleting a folder
+// leaves its media unfiled.
+type MediaFolder struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_media_folders_user_name" json:"user_id"`
+	Name        string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_media_folders_user_name" json:"name"`
+	Description string    `gorm:"type:varchar(500)" json:"description,omitempty"`
+	MediaCount  int64     `gorm:"->;-:migration" json:"media_count"` // filled by FindByUserID
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (MediaFolder) TableName() string {
+	return "media_folders"
+}
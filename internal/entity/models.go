@@ -0,0 +1,29 @@
+package entity
+
+// Models returns every persisted entity, in dependency order, for migrations
+func Models() []interface{} {
+	return []interface{}{
+		&User{},
+		&RefreshToken{},
+		&Category{},
+		&Post{},
+		&Comment{},
+		&Media{},
+		&MediaBlob{},
+		&MediaFolder{},
+		&PostViewStat{},
+		&Bookmark{},
+		&ReadingList{},
+		&ReadingListItem{},
+		&UserFollow{},
+		&CategoryFollow{},
+		&Notification{},
+		&NotificationPreference{},
+		&Webhook{},
+		&WebhookDelivery{},
+		&WebhookDeliveryAttempt{},
+		&Job{},
+		&UploadSlot{},
+		&StorageQuota{},
+	}
+}
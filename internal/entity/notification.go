@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type NotificationType string
+
+const (
+	NotificationTypePostComment   NotificationType = "post_comment"
+	NotificationTypeCommentReply  NotificationType = "comment_reply"
+	NotificationTypePostPublished NotificationType = "post_published"
+	NotificationTypeFollowedPost  NotificationType = "followed_post"
+	NotificationTypeNewFollower   NotificationType = "new_follower"
+)
+
+type Notification struct {
+	BaseEntity
+	UserID    uuid.UUID        `gorm:"type:uuid;not null;index:idx_notifications_user_read" json:"user_id"`
+	ActorID   *uuid.UUID       `gorm:"type:uuid" json:"actor_id,omitempty"`
+	Actor     *User            `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Type      NotificationType `gorm:"type:varchar(30);not null" json:"type"`
+	PostID    *uuid.UUID       `gorm:"type:uuid;index" json:"post_id,omitempty"`
+	Post      *Post            `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	CommentID *uuid.UUID       `gorm:"type:uuid" json:"comment_id,omitempty"`
+	Message   string           `gorm:"type:varchar(500);not null" json:"message"`
+	ReadAt    *time.Time       `gorm:"index:idx_notifications_user_read" json:"read_at,omitempty"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+func (n *Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
+
+// NotificationPreference stores which event types a user wants delivered.
+// Users without a row get DefaultNotificationPreference.
+type NotificationPreference struct {
+	UserID        uuid.UUID `gorm:"type:uuid;primary_key" json:"user_id"`
+	PostComment   bool      `gorm:"not null" json:"post_comment"`
+	CommentReply  bool      `gorm:"not null" json:"comment_reply"`
+	PostPublished bool      `gorm:"not null" json:"post_published"`
+	FollowedPost  bool      `gorm:"not null" json:"followed_post"`
+	NewFollower   bool      `gorm:"not null" json:"new_follower"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// DefaultNotificationPreference enables every event type
+func DefaultNotificationPreference(userID uuid.UUID) *NotificationPreference {
+	return &NotificationPreference{
+		UserID:        userID,
+		PostComment:   true,
+		CommentReply:  true,
+		PostPublished: true,
+		FollowedPost:  true,
+		NewFollower:   true,
+	}
+}
+
+// Allows reports whether notifications of type t should be delivered
+func (p *NotificationPreference) Allows(t NotificationType) bool {
+	switch t {
+	case NotificationTypePostComment:
+		return p.PostComment
+	case NotificationTypeCommentReply:
+		return p.CommentReply
+	case NotificationTypePostPublished:
+		return p.PostPublished
+	case NotificationTypeFollowedPost:
+		return p.FollowedPost
+	case NotificationTypeNewFollower:
+		return p.NewFollower
+	default:
+		return true
+	}
+}
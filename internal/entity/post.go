@@ -21,7 +21,7 @@ type Post struct {
 	Slug          string         `gorm:"type:varchar(200);uniqueIndex;not null" json:"slug"`
 	Content       string         `gorm:"type:text;not null" json:"content"`
 	Excerpt       string         `gorm:"type:varchar(500)" json:"excerpt"`
-	FeaturedImage string         `gorm:"type:varchar(500)" json:"featured_image,omitempty"`
+	FeaturedImage string         `gorm:"type:varchar(500)" json:"featured_image,omitempty"` // mirrors FeaturedMedia's URL, or an external image
 	Tags          pq.StringArray `gorm:"type:text[]" json:"tags,omitempty"`
 	Status        PostStatus     `gorm:"type:varchar(20);not null;default:'draft'" json:"status"`
 	ViewCount     int64          `gorm:"default:0" json:"view_count"`
@@ -31,16 +31,37 @@ type Post struct {
 	Category      *Category      `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
 	Comments      []Comment      `gorm:"foreignKey:PostID" json:"comments,omitempty"`
 	PublishedAt   *time.Time     `gorm:"index" json:"published_at,omitempty"`
+	Version       int64          `gorm:"not null;default:1" json:"version"` // bumped by every update, see repository.ErrVersionConflict
+
+	// The post's one featured media item. Its constraint is created after
+	// both tables exist, see DeferredConstraints.
+	FeaturedMediaID *uuid.UUID `gorm:"type:uuid;index" json:"featured_media_id,omitempty"`
+	FeaturedMedia   *Media     `gorm:"foreignKey:FeaturedMediaID;constraint:OnDelete:SET NULL;-:migration" json:"featured_media,omitempty"`
 }
 
 func (Post) TableName() string {
 	return "posts"
 }
 
+// DeferredConstraints names the foreign keys created once every table
+// exists: posts and media refer to each other
+func (Post) DeferredConstraints() []string {
+	return []string{"FeaturedMedia"}
+}
+
 func (p *Post) IsPublished() bool {
 	return p.Status == PostStatusPublished
 }
 
+// IsVisibleTo reports whether the post can be shown to user (nil = anonymous):
+// published posts to everyone, unpublished posts to their author and admins
+func (p *Post) IsVisibleTo(user *User) bool {
+	if p.IsPublished() {
+		return true
+	}
+	return user != nil && (user.ID == p.AuthorID || user.IsAdmin())
+}
+
 func (p *Post) Publish() {
 	p.Status = PostStatusPublished
 	now := time.Now()
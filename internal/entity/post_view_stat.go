@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostViewStat is a daily rollup of post views per referrer, country and
+// login state. The composite primary key is the upsert conflict target.
+type PostViewStat struct {
+	PostID        uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"post_id"`
+	Post          *Post     `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	Day           time.Time `gorm:"type:date;primaryKey;index" json:"day"`
+	ReferrerHost  string    `gorm:"type:varchar(255);primaryKey;default:''" json:"referrer_host"`
+	Country       string    `gorm:"type:varchar(2);primaryKey;default:''" json:"country"`
+	Authenticated bool      `gorm:"primaryKey;default:false" json:"authenticated"`
+	Views         int64     `gorm:"not null;default:0" json:"views"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (PostViewStat) TableName() string {
+	return "post_view_stats"
+}
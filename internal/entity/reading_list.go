@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReadingList struct {
+	BaseEntity
+	UserID      uuid.UUID         `gorm:"type:uuid;not null;index" json:"user_id"`
+	User        *User             `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Name        string            `gorm:"type:varchar(100);not null" json:"name"`
+	Description string            `gorm:"type:varchar(500)" json:"description"`
+	IsPublic    bool              `gorm:"default:false" json:"is_public"`
+	Items       []ReadingListItem `gorm:"foreignKey:ReadingListID" json:"items,omitempty"`
+}
+
+func (ReadingList) TableName() string {
+	return "reading_lists"
+}
+
+func (l *ReadingList) IsOwnedBy(userID uuid.UUID) bool {
+	return l.UserID == userID
+}
+
+// ReadingListItem is a post in a reading list. Items are hard-deleted so
+// the (list, post) pair can be re-added later.
+type ReadingListItem struct {
+	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ReadingListID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_reading_list_items_list_post" json:"reading_list_id"`
+	PostID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_reading_list_items_list_post;index" json:"post_id"`
+	Post          *Post     `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	Position      int       `gorm:"not null;default:0" json:"position"`
+	Note          string    `gorm:"type:varchar(500)" json:"note,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ReadingListItem) TableName() string {
+	return "reading_list_items"
+}
+
+func (i *ReadingListItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StorageQuota overrides the role's storage limits for one user. A nil
+// limit falls back to the role's; zero means unlimited.
+type StorageQuota struct {
+	UserID    uuid.UUID  `gorm:"type:uuid;primary_key" json:"user_id"`
+	MaxBytes  *int64     `json:"max_bytes"`
+	MaxFiles  *int64     `json:"max_files"`
+	Note      string     `gorm:"type:varchar(255)" json:"note,omitempty"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (StorageQuota) TableName() string {
+	return "storage_quotas"
+}
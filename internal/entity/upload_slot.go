@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UploadSlotStatus string
+
+const (
+	UploadSlotPending   UploadSlotStatus = "pending" // waiting for the file and the complete call
+	UploadSlotCompleted UploadSlotStatus = "completed"
+	UploadSlotExpired   UploadSlotStatus = "expired" // never completed; the file was removed
+)
+
+// UploadSlot is a reserved location a client uploads a file to directly,
+// before asking the server to turn it into a Media row. The media fields
+// are declared up front so the slot can be validated before any bytes move.
+type UploadSlot struct {
+	ID          uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	Status      UploadSlotStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Path        string           `gorm:"type:varchar(500);not null" json:"path"` // storage path of the raw upload
+	Filename    string           `gorm:"type:varchar(255);not null" json:"filename"`
+	ContentType string           `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int64            `gorm:"not null" json:"size"`
+	AltText     string           `gorm:"type:varchar(500)" json:"alt_text,omitempty"`
+	Description string           `gorm:"type:text" json:"description,omitempty"`
+	PostID      *uuid.UUID       `gorm:"type:uuid" json:"post_id,omitempty"`
+	IsFeatured  bool             `gorm:"default:false" json:"is_featured"`
+	MediaID     *uuid.UUID       `gorm:"type:uuid" json:"media_id,omitempty"` // set once completed
+	ExpiresAt   time.Time        `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (UploadSlot) TableName() string {
+	return "upload_slots"
+}
+
+func (s *UploadSlot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsExpired reports whether the slot can no longer receive or complete
+func (s *UploadSlot) IsExpired(now time.Time) bool {
+	return s.Status == UploadSlotExpired || (s.Status == UploadSlotPending && now.After(s.ExpiresAt))
+}
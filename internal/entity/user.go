@@ -22,6 +22,7 @@ type User struct {
 	Role     UserRole `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
 	IsActive bool     `gorm:"default:true" json:"is_active"`
 	Avatar 	 string   `gorm:"type:varchar(255)" json:"avatar,omitempty"` 
+	AvatarSize int64  `gorm:"not null;default:0" json:"-"` // bytes, counted against the storage quota
 }
 
 func (User) TableName() string {
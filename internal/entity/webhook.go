@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// Webhook lifecycle events
+const (
+	WebhookEventPostPublished   = "post.published"
+	WebhookEventPostUpdated     = "post.updated"
+	WebhookEventPostUnpublished = "post.unpublished"
+	WebhookEventPostDeleted     = "post.deleted"
+	WebhookEventCommentCreated  = "comment.created"
+)
+
+// WebhookEvents lists every event a subscription can filter on
+var WebhookEvents = []string{
+	WebhookEventPostPublished,
+	WebhookEventPostUpdated,
+	WebhookEventPostUnpublished,
+	WebhookEventPostDeleted,
+	WebhookEventCommentCreated,
+}
+
+type Webhook struct {
+	BaseEntity
+	Name        string         `gorm:"type:varchar(100);not null" json:"name"`
+	URL         string         `gorm:"type:varchar(500);not null" json:"url"`
+	Secret      string         `gorm:"type:varchar(255);not null" json:"-"`
+	Events      pq.StringArray `gorm:"type:text[]" json:"events"` // empty = all events
+	IsActive    bool           `gorm:"not null" json:"is_active"`
+	CreatedByID uuid.UUID      `gorm:"type:uuid;not null" json:"created_by_id"`
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Subscribes reports whether the webhook wants the given event
+func (w *Webhook) Subscribes(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed" // gave up after max attempts
+)
+
+// WebhookDelivery is one event queued for one webhook
+type WebhookDelivery struct {
+	BaseEntity
+	WebhookID     uuid.UUID                `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	Webhook       *Webhook                 `gorm:"foreignKey:WebhookID" json:"webhook,omitempty"`
+	Event         string                   `gorm:"type:varchar(50);not null" json:"event"`
+	Payload       string                   `gorm:"type:text;not null" json:"payload"`
+	Status        WebhookDeliveryStatus    `gorm:"type:varchar(20);not null;index:idx_webhook_deliveries_due" json:"status"`
+	Attempts      int                      `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time                `gorm:"not null;index:idx_webhook_deliveries_due" json:"next_attempt_at"`
+	LastAttemptAt *time.Time               `json:"last_attempt_at,omitempty"`
+	ResponseCode  int                      `json:"response_code,omitempty"`
+	DeliveredAt   *time.Time               `json:"delivered_at,omitempty"`
+	RedeliveryOf  *uuid.UUID               `gorm:"type:uuid" json:"redelivery_of,omitempty"`
+	AttemptLog    []WebhookDeliveryAttempt `gorm:"foreignKey:DeliveryID" json:"attempt_log,omitempty"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeliveryAttempt records a single HTTP attempt of a delivery
+type WebhookDeliveryAttempt struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	DeliveryID   uuid.UUID `gorm:"type:uuid;not null;index" json:"delivery_id"`
+	Attempt      int       `gorm:"not null" json:"attempt"`
+	ResponseCode int       `json:"response_code,omitempty"`
+	ResponseBody string    `gorm:"type:text" json:"response_body,omitempty"`
+	Error        string    `gorm:"type:text" json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (WebhookDeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+func (a *WebhookDeliveryAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// GetPostDailyViews get a post's daily view time series - author or admin
+func (h *AnalyticsHandler) GetPostDailyViews(c *gin.Context) {
+	h.postReport(c, func(user *entity.User, postID uuid.UUID, params *dto.AnalyticsQueryParams) (interface{}, error) {
+		return h.analyticsService.GetPostDailyViews(c.Request.Context(), postID, params, user)
+	})
+}
+
+// GetPostReferrers get a post's top referrers - author or admin
+func (h *AnalyticsHandler) GetPostReferrers(c *gin.Context) {
+	h.postReport(c, func(user *entity.User, postID uuid.UUID, params *dto.AnalyticsQueryParams) (interface{}, error) {
+		return h.analyticsService.GetPostReferrers(c.Request.Context(), postID, params, user)
+	})
+}
+
+// GetTopPosts get the most viewed posts in a date range - admin only
+func (h *AnalyticsHandler) GetTopPosts(c *gin.Context) {
+	h.topReport(c, h.analyticsService.GetTopPosts)
+}
+
+// GetTopCategories get the most viewed categories in a date range - admin only
+func (h *AnalyticsHandler) GetTopCategories(c *gin.Context) {
+	h.topReport(c, h.analyticsService.GetTopCategories)
+}
+
+// GetTopAuthors get the most viewed authors in a date range - admin only
+func (h *AnalyticsHandler) GetTopAuthors(c *gin.Context) {
+	h.topReport(c, h.analyticsService.GetTopAuthors)
+}
+
+func (h *AnalyticsHandler) postReport(c *gin.Context, fetch func(user *entity.User, postID uuid.UUID, params *dto.AnalyticsQueryParams) (interface{}, error)) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid post ID", err.Error())
+		return
+	}
+
+	var params dto.AnalyticsQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err.Error())
+		return
+	}
+
+	result, err := fetch(user, postID, &params)
+	if err != nil {
+		if err.Error() == "post not found" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		if err.Error() == "you don't have permission to view analytics for this post" {
+			response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+			return
+		}
+		h.rangeError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *AnalyticsHandler) topReport(c *gin.Context, fetch func(ctx context.Context, params *dto.AnalyticsQueryParams) (*dto.TopContentResponse, error)) {
+	var params dto.AnalyticsQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err.Error())
+		return
+	}
+
+	result, err := fetch(c.Request.Context(), &params)
+	if err != nil {
+		h.rangeError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *AnalyticsHandler) rangeError(c *gin.Context, err error) {
+	if err.Error() == "invalid date range" || err.Error() == "date range too large" ||
+		strings.HasPrefix(err.Error(), "validation error") {
+		response.Error(c, http.StatusBadRequest, "Bad request", err.Error())
+		return
+	}
+	response.Error(c, http.StatusInternalServerError, "Failed to get analytics", err.Error())
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type BookmarkHandler struct {
+	bookmarkService service.BookmarkService
+}
+
+func NewBookmarkHandler(bookmarkService service.BookmarkService) *BookmarkHandler {
+	return &BookmarkHandler{bookmarkService: bookmarkService}
+}
+
+// GetAll get current user's bookmarks with pagination
+func (h *BookmarkHandler) GetAll(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	params := &dto.BookmarkQueryParams{
+		Page:  page,
+		Limit: limit,
+	}
+
+	bookmarks, total, err := h.bookmarkService.GetAll(c.Request.Context(), params, user)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get bookmarks", err.Error())
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, bookmarks)
+}
+
+// Create bookmark a post for the current user
+func (h *BookmarkHandler) Create(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	var req dto.CreateBookmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	bookmark, err := h.bookmarkService.Add(c.Request.Context(), &req, user)
+	if err != nil {
+		if err.Error() == "post not found" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "Failed to create bookmark", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, bookmark)
+}
+
+// Delete remove a post from the current user's bookmarks
+func (h *BookmarkHandler) Delete(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	postID, err := uuid.Parse(c.Param("postId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid post ID", err.Error())
+		return
+	}
+
+	if err := h.bookmarkService.Remove(c.Request.Context(), postID, user); err != nil {
+		if err.Error() == "bookmark not found" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to delete bookmark", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Bookmark removed successfully"})
+}
@@ -66,6 +66,7 @@ func (h *CategoryHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, category.Version)
 	response.Success(c, http.StatusOK, category)
 }
 
@@ -79,6 +80,7 @@ func (h *CategoryHandler) GetBySlug(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, category.Version)
 	response.Success(c, http.StatusOK, category)
 }
 
@@ -154,9 +156,15 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
 		return
 	}
+	if req.Version, ok = expectedVersion(c, req.Version); !ok {
+		return
+	}
 
 	category, err := h.categoryService.Update(c.Request.Context(), id, &req, user)
 	if err != nil {
+		if handleConflict(c, err, true) {
+			return
+		}
 		if err.Error() == "category not found" {
 			response.Error(c, http.StatusNotFound, "Not found", err.Error())
 			return
@@ -169,6 +177,7 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, category.Version)
 	response.Success(c, http.StatusOK, category)
 }
 
@@ -199,8 +208,16 @@ func (h *CategoryHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.categoryService.Delete(c.Request.Context(), id, user)
+	version, ok := expectedVersion(c, queryVersion(c))
+	if !ok {
+		return
+	}
+
+	err = h.categoryService.Delete(c.Request.Context(), id, version, user)
 	if err != nil {
+		if handleConflict(c, err, true) {
+			return
+		}
 		if err.Error() == "category not found" {
 			response.Error(c, http.StatusNotFound, "Not found", err.Error())
 			return
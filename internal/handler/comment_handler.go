@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/afdhali/GolangBlogpostServer/internal/dto"
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
 	"github.com/afdhali/GolangBlogpostServer/pkg/response"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -42,23 +44,29 @@ func (h *CommentHandler) GetByPostID(c *gin.Context) {
 	}
 
 	params := &dto.CommentQueryParams{
-		Page:      page,
-		Limit:     limit,
-		SortBy:    sortBy,
-		SortOrder: sortOrder,
+		Page:         page,
+		Limit:        limit,
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
+		CursorParams: cursorParams(c),
 	}
 
-	comments, total, err := h.commentService.GetByPostID(c.Request.Context(), postID, params)
+	// Authors may also read the comments of their own drafts
+	comments, pageInfo, err := h.commentService.GetByPostID(c.Request.Context(), postID, params, optionalUser(c))
 	if err != nil {
 		if err.Error() == "post not found" {
 			response.Error(c, http.StatusNotFound, "Not found", err.Error())
 			return
 		}
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			response.Error(c, http.StatusBadRequest, "Invalid cursor", err.Error())
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "Failed to get comments", err.Error())
 		return
 	}
 
-	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, comments)
+	respondPage(c, params.CursorParams, page, limit, pageInfo, comments)
 }
 
 // Create create a new comment on a post
@@ -89,7 +97,7 @@ func (h *CommentHandler) Create(c *gin.Context) {
 		return
 	}
 
-	comment, err := h.commentService.Create(c.Request.Context(), postID, &req, user.ID)
+	comment, err := h.commentService.Create(c.Request.Context(), postID, &req, user)
 	if err != nil {
 		if err.Error() == "post not found" {
 			response.Error(c, http.StatusNotFound, "Not found", err.Error())
@@ -132,9 +140,15 @@ func (h *CommentHandler) Update(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
 		return
 	}
+	if req.Version, ok = expectedVersion(c, req.Version); !ok {
+		return
+	}
 
 	comment, err := h.commentService.Update(c.Request.Context(), commentID, &req, user)
 	if err != nil {
+		if handleConflict(c, err, true) {
+			return
+		}
 		if err.Error() == "comment not found" {
 			response.Error(c, http.StatusNotFound, "Not found", err.Error())
 			return
@@ -147,6 +161,7 @@ func (h *CommentHandler) Update(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, comment.Version)
 	response.Success(c, http.StatusOK, comment)
 }
 
@@ -171,8 +186,16 @@ func (h *CommentHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.commentService.Delete(c.Request.Context(), commentID, user)
+	version, ok := expectedVersion(c, queryVersion(c))
+	if !ok {
+		return
+	}
+
+	err = h.commentService.Delete(c.Request.Context(), commentID, version, user)
 	if err != nil {
+		if handleConflict(c, err, true) {
+			return
+		}
 		if err.Error() == "comment not found" {
 			response.Error(c, http.StatusNotFound, "Not found", err.Error())
 			return
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type FollowHandler struct {
+	followService service.FollowService
+}
+
+func NewFollowHandler(followService service.FollowService) *FollowHandler {
+	return &FollowHandler{followService: followService}
+}
+
+// FollowUser follow an author
+func (h *FollowHandler) FollowUser(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	result, err := h.followService.FollowUser(c.Request.Context(), targetID, user)
+	if err != nil {
+		if err.Error() == "user not found" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "Failed to follow user", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// UnfollowUser stop following an author
+func (h *FollowHandler) UnfollowUser(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	result, err := h.followService.UnfollowUser(c.Request.Context(), targetID, user)
+	if err != nil {
+		if err.Error() == "you are not following this user" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to unfollow user", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// GetFollowers get users following a user
+func (h *FollowHandler) GetFollowers(c *gin.Context) {
+	h.listUsers(c, h.followService.GetFollowers, "Failed to get followers")
+}
+
+// GetFollowing get authors a user follows
+func (h *FollowHandler) GetFollowing(c *gin.Context) {
+	h.listUsers(c, h.followService.GetFollowing, "Failed to get following")
+}
+
+func (h *FollowHandler) listUsers(
+	c *gin.Context,
+	list func(ctx context.Context, userID uuid.UUID, params *dto.FollowQueryParams) ([]*dto.UserAuthor, int64, error),
+	message string,
+) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	params := &dto.FollowQueryParams{
+		Page:  page,
+		Limit: limit,
+	}
+
+	users, total, err := list(c.Request.Context(), userID, params)
+	if err != nil {
+		if err.Error() == "user not found" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, message, err.Error())
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, users)
+}
+
+// FollowCategory follow a category
+func (h *FollowHandler) FollowCategory(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err.Error())
+		return
+	}
+
+	result, err := h.followService.FollowCategory(c.Request.Context(), categoryID, user)
+	if err != nil {
+		if err.Error() == "category not found" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to follow category", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// UnfollowCategory stop following a category
+func (h *FollowHandler) UnfollowCategory(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid category ID", err.Error())
+		return
+	}
+
+	result, err := h.followService.UnfollowCategory(c.Request.Context(), categoryID, user)
+	if err != nil {
+		if err.Error() == "you are not following this category" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to unfollow category", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// GetFollowedCategories get categories the current user follows
+func (h *FollowHandler) GetFollowedCategories(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	categories, err := h.followService.GetFollowedCategories(c.Request.Context(), user)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get categories", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, categories)
+}
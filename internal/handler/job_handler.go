@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type JobHandler struct {
+	jobService service.JobService
+}
+
+func NewJobHandler(jobService service.JobService) *JobHandler {
+	return &JobHandler{jobService: jobService}
+}
+
+// GetAll get background jobs (?status=pending|running|succeeded|dead&type=...)
+func (h *JobHandler) GetAll(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	params := &dto.JobQueryParams{
+		Page:   page,
+		Limit:  limit,
+		Status: c.Query("status"),
+		Type:   c.Query("type"),
+	}
+
+	jobs, total, err := h.jobService.GetAll(c.Request.Context(), params)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to get jobs", err.Error())
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, jobs)
+}
+
+// GetStats get the number of jobs per status
+func (h *JobHandler) GetStats(c *gin.Context) {
+	stats, err := h.jobService.GetStats(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get job stats", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, stats)
+}
+
+// GetByID get a background job
+func (h *JobHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid job ID", err.Error())
+		return
+	}
+
+	job, err := h.jobService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get job")
+		return
+	}
+
+	response.Success(c, http.StatusOK, job)
+}
+
+// Retry requeue a dead job
+func (h *JobHandler) Retry(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid job ID", err.Error())
+		return
+	}
+
+	job, err := h.jobService.Retry(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to retry job")
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, job)
+}
+
+// handleError maps job service errors to HTTP status codes
+func (h *JobHandler) handleError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "job not found":
+		response.Error(c, http.StatusNotFound, "Not found", err.Error())
+	case "only dead jobs can be retried", "a job with the same unique key is already queued":
+		response.Error(c, http.StatusConflict, "Conflict", err.Error())
+	default:
+		response.Error(c, http.StatusBadRequest, message, err.Error())
+	}
+}
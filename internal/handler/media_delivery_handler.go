@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetFileURL get an expiring link to a media file (or ?variant=name) that
+// works without a session, e.g. in <img> tags for media of a draft post.
+// With download=true the file is served as an attachment.
+func (h *MediaHandler) GetFileURL(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid media ID", err.Error())
+		return
+	}
+
+	signed, err := h.mediaService.SignFileURL(c.Request.Context(), id, c.Query("variant"), c.Query("download") == "true", optionalUser(c))
+	if err != nil {
+		h.handleFileError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, signed)
+}
+
+// ServeFile serve an uploaded file with range and conditional request
+// support. Media of unpublished posts needs a session that can see the post
+// or a signed URL from GetFileURL; ?download=1 asks for an attachment.
+func (h *MediaHandler) ServeFile(c *gin.Context) {
+	expires, _ := strconv.ParseInt(c.Query("expires"), 10, 64)
+
+	file, err := h.mediaService.OpenFile(c.Request.Context(), c.Param("filepath"), optionalUser(c), expires, c.Query("sig"))
+	if err != nil {
+		h.handleFileError(c, err)
+		return
+	}
+	defer file.Content.Close()
+
+	switch {
+	case file.Public && file.Immutable:
+		// The path only ever holds this content
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	case file.Public:
+		c.Header("Cache-Control", "public, max-age=86400")
+	default:
+		// Depends on the session or signature, so shared caches must not keep it
+		c.Header("Cache-Control", "private, no-cache")
+	}
+	if file.ETag != "" {
+		c.Header("ETag", file.ETag)
+	}
+	if file.MimeType != "" {
+		c.Header("Content-Type", file.MimeType)
+	}
+
+	disposition := "inline"
+	if download := c.Query("download"); download == "1" || download == "true" {
+		disposition = "attachment"
+	}
+	// Non-ASCII names are sent as filename* (RFC 6266)
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": file.Filename}); header != "" {
+		c.Header("Content-Disposition", header)
+	} else {
+		c.Header("Content-Disposition", disposition)
+	}
+
+	// Handles Range, If-Range, If-None-Match and If-Modified-Since
+	http.ServeContent(c.Writer, c.Request, file.Filename, file.ModTime, file.Content)
+}
+
+// handleFileError maps file delivery errors to HTTP status codes
+func (h *MediaHandler) handleFileError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "file not found", "media not found", "variant not found":
+		response.Error(c, http.StatusNotFound, "Not found", err.Error())
+	case "invalid signature", "signed URL expired":
+		response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, "Failed to serve file", err.Error())
+	}
+}
+
+// optionalUser returns the signed-in user on routes with optional auth
+func optionalUser(c *gin.Context) *entity.User {
+	if userVal, exists := c.Get("user"); exists {
+		if u, ok := userVal.(*entity.User); ok {
+			return u
+		}
+	}
+	return nil
+}
@@ -1,13 +1,19 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/afdhali/GolangBlogpostServer/internal/dto"
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
 	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/afdhali/GolangBlogpostServer/pkg/scanner"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -57,15 +63,28 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 		}
 	}
 
+	if folderIDStr := c.PostForm("folder_id"); folderIDStr != "" {
+		folderID, err := uuid.Parse(folderIDStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid folder ID", err.Error())
+			return
+		}
+		req.FolderID = &folderID
+	}
+	req.Tags = splitTags(c.PostFormArray("tags"))
+
 	// Upload media
-	media, err := h.mediaService.Upload(c.Request.Context(), user.ID, file, header, req)
+	media, err := h.mediaService.Upload(c.Request.Context(), user, file, header, req)
 	if err != nil {
-		if err.Error() == "post not found" {
+		if err.Error() == "post not found" || err.Error() == "folder not found" {
 			response.Error(c, http.StatusNotFound, "Not found", err.Error())
 			return
 		}
-		if err.Error() == "invalid image" || err.Error() == "image size too large" {
-			response.Error(c, http.StatusBadRequest, "Bad request", err.Error())
+		if err.Error() == "you can only add media to your own posts" {
+			response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+			return
+		}
+		if handleUploadError(c, err) {
 			return
 		}
 		response.Error(c, http.StatusInternalServerError, "Failed to upload media", err.Error())
@@ -130,7 +149,7 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 // 		return
 // 	}
 
-// 	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, medias)
+// 	respondPage(c, params.CursorParams, page, limit, pageInfo, medias)
 // }
 
 // GetAll get all media with pagination and filters
@@ -170,14 +189,19 @@ func (h *MediaHandler) GetAll(c *gin.Context) {
 	}
 
 	params := &dto.MediaQueryParams{
-		Page:       page,
-		Limit:      limit,
-		MediaType:  mediaType,
-		PostID:     postID,
-		UserID:     userID,
-		IsFeatured: isFeatured,
-		SortBy:     sortBy,
-		SortOrder:  sortOrder,
+		Page:         page,
+		Limit:        limit,
+		MediaType:    mediaType,
+		PostID:       postID,
+		UserID:       userID,
+		IsFeatured:   isFeatured,
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
+		CursorParams: cursorParams(c),
+	}
+	if err := parseLibraryQuery(c, params); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query", err.Error())
+		return
 	}
 
 	// ✅ 2. Extract currentUser dari context (bisa nil untuk public call)
@@ -189,18 +213,26 @@ func (h *MediaHandler) GetAll(c *gin.Context) {
 	}
 
 	// 3. Panggil service dengan currentUser (bisa nil untuk public)
-	medias, total, err := h.mediaService.GetAll(c.Request.Context(), params, currentUser)
+	medias, pageInfo, err := h.mediaService.GetAll(c.Request.Context(), params, currentUser)
 	if err != nil {
 		// Permission error
 		if err.Error() == "you can only view your own media" {
 			response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
 			return
 		}
+		if strings.HasPrefix(err.Error(), "validation error") {
+			response.Error(c, http.StatusBadRequest, "Invalid query", err.Error())
+			return
+		}
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			response.Error(c, http.StatusBadRequest, "Invalid cursor", err.Error())
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "Failed to get medias", err.Error())
 		return
 	}
 
-	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, medias)
+	respondPage(c, params.CursorParams, page, limit, pageInfo, medias)
 }
 
 // GetByID get media by ID
@@ -229,7 +261,7 @@ func (h *MediaHandler) GetByPostID(c *gin.Context) {
 		return
 	}
 
-	medias, err := h.mediaService.GetByPostID(c.Request.Context(), postID)
+	medias, err := h.mediaService.GetByPostID(c.Request.Context(), postID, optionalUser(c))
 	if err != nil {
 		if err.Error() == "post not found" {
 			response.Error(c, http.StatusNotFound, "Not found", err.Error())
@@ -251,7 +283,7 @@ func (h *MediaHandler) GetFeaturedByPostID(c *gin.Context) {
 		return
 	}
 
-	media, err := h.mediaService.GetFeaturedByPostID(c.Request.Context(), postID)
+	media, err := h.mediaService.GetFeaturedByPostID(c.Request.Context(), postID, optionalUser(c))
 	if err != nil {
 		response.Error(c, http.StatusNotFound, "Featured media not found", err.Error())
 		return
@@ -293,11 +325,12 @@ func (h *MediaHandler) Update(c *gin.Context) {
 			response.Error(c, http.StatusNotFound, "Not found", err.Error())
 			return
 		}
-		if err.Error() == "you don't have permission to update this media" {
+		if err.Error() == "you don't have permission to update this media" || err.Error() == "you can only add media to your own posts" {
 			response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
 			return
 		}
-		if err.Error() == "post not found" {
+		switch err.Error() {
+		case "post not found", "folder not found", "media is not attached to a post", "only images can be featured":
 			response.Error(c, http.StatusBadRequest, "Bad request", err.Error())
 			return
 		}
@@ -344,4 +377,224 @@ func (h *MediaHandler) Delete(c *gin.Context) {
 	}
 
 	response.Success(c, http.StatusOK, gin.H{"message": "Media deleted successfully"})
-}
\ No newline at end of file
+}
+// GetResizeURL get a signed URL for an arbitrary size (?w=&h=&fit=contain|cover)
+func (h *MediaHandler) GetResizeURL(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid media ID", err.Error())
+		return
+	}
+
+	width, height, err := resizeDimensions(c)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid size", err.Error())
+		return
+	}
+
+	signed, err := h.mediaService.SignResizeURL(c.Request.Context(), id, width, height, c.Query("fit"), optionalUser(c))
+	if err != nil {
+		h.handleResizeError(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, signed)
+}
+
+// Resize serve a resized image. Public: the signature stands in for the API key,
+// so the URL can be used directly in <img> tags and cached by CDNs.
+func (h *MediaHandler) Resize(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid media ID", err.Error())
+		return
+	}
+
+	width, height, err := resizeDimensions(c)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid size", err.Error())
+		return
+	}
+
+	resized, err := h.mediaService.Resize(c.Request.Context(), id, width, height, c.Query("fit"), c.Query("sig"))
+	if err != nil {
+		h.handleResizeError(c, err)
+		return
+	}
+
+	// Signed URLs never change meaning, so caches may keep them forever
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", resized.ETag)
+	c.Header("Content-Type", resized.MimeType)
+	http.ServeContent(c.Writer, c.Request, "", resized.ModTime, bytes.NewReader(resized.Data))
+}
+
+func resizeDimensions(c *gin.Context) (uint, uint, error) {
+	var width, height uint64
+	var err error
+
+	if w := c.Query("w"); w != "" {
+		if width, err = strconv.ParseUint(w, 10, 32); err != nil {
+			return 0, 0, err
+		}
+	}
+	if hv := c.Query("h"); hv != "" {
+		if height, err = strconv.ParseUint(hv, 10, 32); err != nil {
+			return 0, 0, err
+		}
+	}
+	return uint(width), uint(height), nil
+}
+
+// handleResizeError maps resize errors to HTTP status codes
+func (h *MediaHandler) handleResizeError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "media not found":
+		response.Error(c, http.StatusNotFound, "Media not found", err.Error())
+	case "invalid signature":
+		response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+	case "media is not an image":
+		response.Error(c, http.StatusUnprocessableEntity, "Cannot resize media", err.Error())
+	default:
+		response.Error(c, http.StatusBadRequest, "Failed to resize media", err.Error())
+	}
+}
+
+// handleUploadError responds to a rejected upload with its structured
+// validation error, 503 when the virus scanner could not be reached, or 413
+// when it would go over the user's storage quota
+func handleUploadError(c *gin.Context, err error) bool {
+	var fieldErr *validator.FieldError
+	if errors.As(err, &fieldErr) {
+		response.Error(c, http.StatusBadRequest, "Validation failed", fieldErr.Details())
+		return true
+	}
+	if errors.Is(err, scanner.ErrUnavailable) {
+		response.Error(c, http.StatusServiceUnavailable, "Upload could not be scanned", err.Error())
+		return true
+	}
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		response.Error(c, http.StatusRequestEntityTooLarge, "Storage quota exceeded", err.Error())
+		return true
+	}
+	return false
+}
+
+// CreateUploadSlot reserve an upload slot; the client PUTs the file to the
+// returned URL and then calls CompleteUpload
+func (h *MediaHandler) CreateUploadSlot(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	var req dto.CreateUploadSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	slot, err := h.mediaService.CreateUploadSlot(c.Request.Context(), user, &req)
+	if err != nil {
+		if err.Error() == "post not found" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		if err.Error() == "you can only add media to your own posts" {
+			response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+			return
+		}
+		if handleUploadError(c, err) {
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "Failed to create upload slot", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, slot)
+}
+
+// ReceiveUpload store the body of a signed upload URL. Used when the
+// storage cannot take uploads directly; the signature stands in for auth.
+func (h *MediaHandler) ReceiveUpload(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid upload slot ID", err.Error())
+		return
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusForbidden, "Forbidden", "invalid signature")
+		return
+	}
+
+	err = h.mediaService.ReceiveSlotUpload(c.Request.Context(), id, expires, c.Query("sig"), c.Request.Body)
+	if err != nil {
+		h.handleSlotError(c, err, "Failed to upload file")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "File uploaded"})
+}
+
+// CompleteUpload turn an uploaded slot into media
+func (h *MediaHandler) CompleteUpload(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid upload slot ID", err.Error())
+		return
+	}
+
+	media, err := h.mediaService.CompleteUploadSlot(c.Request.Context(), id, user)
+	if err != nil {
+		if handleUploadError(c, err) {
+			return
+		}
+		h.handleSlotError(c, err, "Failed to complete upload")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, media)
+}
+
+// handleSlotError maps upload slot errors to HTTP status codes
+func (h *MediaHandler) handleSlotError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "upload slot not found", "post not found", "media not found":
+		response.Error(c, http.StatusNotFound, "Not found", err.Error())
+	case "invalid signature":
+		response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+	case "you don't have permission to complete this upload":
+		response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+	case "upload slot expired":
+		response.Error(c, http.StatusGone, "Upload slot expired", err.Error())
+	case "upload exceeds declared size":
+		response.Error(c, http.StatusRequestEntityTooLarge, message, err.Error())
+	case "file has not been uploaded", "upload does not match declared size":
+		response.Error(c, http.StatusConflict, message, err.Error())
+	default:
+		response.Error(c, http.StatusInternalServerError, message, err.Error())
+	}
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetFolders get the current user's media folders; admins can pass user_id
+func (h *MediaHandler) GetFolders(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var userID *uuid.UUID
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		id, err := uuid.Parse(userIDStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+			return
+		}
+		userID = &id
+	}
+
+	folders, err := h.mediaService.GetFolders(c.Request.Context(), userID, user)
+	if err != nil {
+		h.handleLibraryError(c, err, "Failed to get folders")
+		return
+	}
+
+	response.Success(c, http.StatusOK, folders)
+}
+
+// CreateFolder create a media folder
+func (h *MediaHandler) CreateFolder(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateMediaFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	folder, err := h.mediaService.CreateFolder(c.Request.Context(), &req, user)
+	if err != nil {
+		h.handleLibraryError(c, err, "Failed to create folder")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, folder)
+}
+
+// UpdateFolder rename a media folder or change its description
+func (h *MediaHandler) UpdateFolder(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid folder ID", err.Error())
+		return
+	}
+
+	var req dto.UpdateMediaFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	folder, err := h.mediaService.UpdateFolder(c.Request.Context(), id, &req, user)
+	if err != nil {
+		h.handleLibraryError(c, err, "Failed to update folder")
+		return
+	}
+
+	response.Success(c, http.StatusOK, folder)
+}
+
+// DeleteFolder delete a media folder; its media is kept, unfiled
+func (h *MediaHandler) DeleteFolder(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid folder ID", err.Error())
+		return
+	}
+
+	if err := h.mediaService.DeleteFolder(c.Request.Context(), id, user); err != nil {
+		h.handleLibraryError(c, err, "Failed to delete folder")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Folder deleted successfully"})
+}
+
+// GetTags get the media tags in use with their counts
+func (h *MediaHandler) GetTags(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	tags, err := h.mediaService.GetTags(c.Request.Context(), user)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get tags", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, tags)
+}
+
+// BulkUpdate move, tag, untag, attach or delete several media at once.
+// Responds 200 with the media that were and weren't changed.
+func (h *MediaHandler) BulkUpdate(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req dto.BulkMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	result, err := h.mediaService.BulkUpdate(c.Request.Context(), &req, user)
+	if err != nil {
+		h.handleLibraryError(c, err, "Failed to update media")
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *MediaHandler) currentUser(c *gin.Context) (*entity.User, bool) {
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return nil, false
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return nil, false
+	}
+	return user, true
+}
+
+// handleLibraryError maps folder and bulk action errors to HTTP status codes
+func (h *MediaHandler) handleLibraryError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "folder not found", "post not found":
+		response.Error(c, http.StatusNotFound, "Not found", err.Error())
+	case "you can only view your own media", "you can only add media to your own posts":
+		response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+	case "folder name already exists":
+		response.Error(c, http.StatusConflict, message, err.Error())
+	default:
+		if strings.HasPrefix(err.Error(), "failed to") {
+			response.Error(c, http.StatusInternalServerError, message, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, message, err.Error())
+	}
+}
+
+// parseLibraryQuery reads the search, tag, folder, dimension and upload
+// date filters of the media list. Dates are RFC 3339 timestamps or plain
+// days; a plain uploaded_to day is included.
+func parseLibraryQuery(c *gin.Context, params *dto.MediaQueryParams) error {
+	params.Search = c.Query("q")
+	params.Tags = splitTags(c.QueryArray("tags"))
+
+	if folderIDStr := c.Query("folder_id"); folderIDStr != "" {
+		folderID, err := uuid.Parse(folderIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid folder_id: %w", err)
+		}
+		params.FolderID = &folderID
+	}
+	params.Unfiled = c.Query("unfiled") == "true"
+
+	for name, target := range map[string]**int{
+		"min_width":  &params.MinWidth,
+		"max_width":  &params.MaxWidth,
+		"min_height": &params.MinHeight,
+		"max_height": &params.MaxHeight,
+	} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid %s: must be a positive integer", name)
+		}
+		*target = &n
+	}
+	if params.MinWidth != nil && params.MaxWidth != nil && *params.MinWidth > *params.MaxWidth {
+		return errors.New("min_width is greater than max_width")
+	}
+	if params.MinHeight != nil && params.MaxHeight != nil && *params.MinHeight > *params.MaxHeight {
+		return errors.New("min_height is greater than max_height")
+	}
+
+	if value := c.Query("uploaded_from"); value != "" {
+		from, _, err := parseQueryDate(value)
+		if err != nil {
+			return fmt.Errorf("invalid uploaded_from: %w", err)
+		}
+		params.UploadedFrom = &from
+	}
+	if value := c.Query("uploaded_to"); value != "" {
+		to, day, err := parseQueryDate(value)
+		if err != nil {
+			return fmt.Errorf("invalid uploaded_to: %w", err)
+		}
+		if day {
+			to = to.AddDate(0, 0, 1)
+		}
+		params.UploadedTo = &to
+	}
+	if params.UploadedFrom != nil && params.UploadedTo != nil && !params.UploadedFrom.Before(*params.UploadedTo) {
+		return errors.New("uploaded_from must be before uploaded_to")
+	}
+
+	return nil
+}
+
+// parseQueryDate accepts an RFC 3339 timestamp or a YYYY-MM-DD day (UTC)
+func parseQueryDate(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, false, errors.New("expected RFC 3339 or YYYY-MM-DD")
+	}
+	return t, true, nil
+}
+
+// splitTags accepts tags repeated and/or comma separated
+func splitTags(values []string) []string {
+	var tags []string
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
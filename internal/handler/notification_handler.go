@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type NotificationHandler struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationHandler(notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// GetAll get current user's notifications (?unread=true for unread only)
+func (h *NotificationHandler) GetAll(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	unread, _ := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	params := &dto.NotificationQueryParams{
+		Page:   page,
+		Limit:  limit,
+		Unread: unread,
+	}
+
+	notifications, total, err := h.notificationService.GetAll(c.Request.Context(), params, user)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get notifications", err.Error())
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, notifications)
+}
+
+// GetUnreadCount get the number of unread notifications
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	count, err := h.notificationService.GetUnreadCount(c.Request.Context(), user)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to count notifications", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, count)
+}
+
+// MarkRead mark a single notification as read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid notification ID", err.Error())
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), id, user); err != nil {
+		if err.Error() == "notification not found" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to mark notification as read", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllRead mark every unread notification as read
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	count, err := h.notificationService.MarkAllRead(c.Request.Context(), user)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to mark notifications as read", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "All notifications marked as read", "updated": count})
+}
+
+// GetPreferences get which notification types the user receives
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	prefs, err := h.notificationService.GetPreferences(c.Request.Context(), user)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get notification preferences", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, prefs)
+}
+
+// UpdatePreferences update which notification types the user receives
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	var req dto.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	prefs, err := h.notificationService.UpdatePreferences(c.Request.Context(), &req, user)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to update notification preferences", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, prefs)
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// cursorParams reads keyset paging from the query. Sending cursor, empty for
+// the first page, switches a listing from page numbers to the cursors of
+// its previous response; total=false skips counting the rows.
+func cursorParams(c *gin.Context) dto.CursorParams {
+	cursor, ok := c.GetQuery("cursor")
+	return dto.CursorParams{
+		Cursor:    cursor,
+		UseCursor: ok,
+		NoTotal:   c.Query("total") == "false",
+	}
+}
+
+// respondPage sends a listing in the paging mode the client asked for
+func respondPage(c *gin.Context, params dto.CursorParams, page, limit int, info *dto.PageInfo, data interface{}) {
+	if params.UseCursor {
+		response.SuccessWithCursors(c, http.StatusOK, limit, info.NextCursor, info.PrevCursor, info.Total, data)
+		return
+	}
+
+	var total int64
+	if info.Total != nil {
+		total = *info.Total
+	}
+	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, data)
+}
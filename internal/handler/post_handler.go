@@ -1,13 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/afdhali/GolangBlogpostServer/internal/dto"
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
 	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/afdhali/GolangBlogpostServer/pkg/viewcounter"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -109,24 +112,29 @@ func (h *PostHandler) GetAll(c *gin.Context) {
 	}
 
 	params := &dto.PostQueryParams{
-		Page:       page,
-		Limit:      limit,
-		Search:     search,
-		Status:     status,
-		CategoryID: categoryID,
-		AuthorID:   authorID,
-		Tag:        c.DefaultQuery("tag", ""),
-		SortBy:     sortBy,
-		SortOrder:  sortOrder,
-	}
-
-	posts, total, err := h.postService.GetAll(c.Request.Context(), params, currentUser)
+		Page:         page,
+		Limit:        limit,
+		Search:       search,
+		Status:       status,
+		CategoryID:   categoryID,
+		AuthorID:     authorID,
+		Tag:          c.DefaultQuery("tag", ""),
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
+		CursorParams: cursorParams(c),
+	}
+
+	posts, pageInfo, err := h.postService.GetAll(c.Request.Context(), params, currentUser)
 	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			response.Error(c, http.StatusBadRequest, "Invalid cursor", err.Error())
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "Failed to get posts", err.Error())
 		return
 	}
 
-	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, posts)
+	respondPage(c, params.CursorParams, page, limit, pageInfo, posts)
 }
 
 // GetByID get post by ID
@@ -137,12 +145,19 @@ func (h *PostHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	post, err := h.postService.GetByID(c.Request.Context(), id)
+	// Optional user for is_bookmarked
+	var currentUser *entity.User
+	if userValue, exists := c.Get("user"); exists {
+		currentUser, _ = userValue.(*entity.User)
+	}
+
+	post, err := h.postService.GetByID(c.Request.Context(), id, currentUser)
 	if err != nil {
 		response.Error(c, http.StatusNotFound, "Post not found", err.Error())
 		return
 	}
 
+	setVersionETag(c, post.Version)
 	response.Success(c, http.StatusOK, post)
 }
 
@@ -150,12 +165,19 @@ func (h *PostHandler) GetByID(c *gin.Context) {
 func (h *PostHandler) GetBySlug(c *gin.Context) {
 	slug := c.Param("slug")
 
-	post, err := h.postService.GetBySlug(c.Request.Context(), slug)
+	// Optional user for is_bookmarked
+	var currentUser *entity.User
+	if userValue, exists := c.Get("user"); exists {
+		currentUser, _ = userValue.(*entity.User)
+	}
+
+	post, err := h.postService.GetBySlug(c.Request.Context(), slug, currentUser)
 	if err != nil {
 		response.Error(c, http.StatusNotFound, "Post not found", err.Error())
 		return
 	}
 
+	setVersionETag(c, post.Version)
 	response.Success(c, http.StatusOK, post)
 }
 
@@ -183,7 +205,9 @@ func (h *PostHandler) Create(c *gin.Context) {
 	post, err := h.postService.Create(c.Request.Context(), &req, user.ID)
 	if err != nil {
 		// Check error type for appropriate status code
-		if err.Error() == "category not found" || err.Error() == "slug already exists" {
+		switch err.Error() {
+		case "category not found", "slug already exists",
+			"featured media not found", "featured media must be an image", "featured media belongs to another post":
 			response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
 			return
 		}
@@ -220,9 +244,15 @@ func (h *PostHandler) Update(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
 		return
 	}
+	if req.Version, ok = expectedVersion(c, req.Version); !ok {
+		return
+	}
 
 	post, err := h.postService.Update(c.Request.Context(), id, &req, user)
 	if err != nil {
+		if handleConflict(c, err, true) {
+			return
+		}
 		// Check permission errors
 		if err.Error() == "you don't have permission to update this post" {
 			response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
@@ -237,6 +267,7 @@ func (h *PostHandler) Update(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, post.Version)
 	response.Success(c, http.StatusOK, post)
 }
 
@@ -261,8 +292,16 @@ func (h *PostHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.postService.Delete(c.Request.Context(), id, user)
+	version, ok := expectedVersion(c, queryVersion(c))
+	if !ok {
+		return
+	}
+
+	err = h.postService.Delete(c.Request.Context(), id, version, user)
 	if err != nil {
+		if handleConflict(c, err, true) {
+			return
+		}
 		// Check permission errors
 		if err.Error() == "you don't have permission to delete this post" {
 			response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
@@ -303,6 +342,9 @@ func (h *PostHandler) Publish(c *gin.Context) {
 
 	post, err := h.postService.Publish(c.Request.Context(), id, user)
 	if err != nil {
+		if handleConflict(c, err, false) {
+			return
+		}
 		// Check permission errors
 		if err.Error() == "you don't have permission to publish this post" {
 			response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
@@ -343,6 +385,9 @@ func (h *PostHandler) Unpublish(c *gin.Context) {
 
 	post, err := h.postService.Unpublish(c.Request.Context(), id, user)
 	if err != nil {
+		if handleConflict(c, err, false) {
+			return
+		}
 		// Check permission errors
 		if err.Error() == "you don't have permission to unpublish this post" {
 			response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
@@ -360,7 +405,7 @@ func (h *PostHandler) Unpublish(c *gin.Context) {
 	response.Success(c, http.StatusOK, post)
 }
 
-// IncrementViews increment post view count
+// IncrementViews record a post view (deduplicated per visitor, bots ignored)
 func (h *PostHandler) IncrementViews(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -368,11 +413,82 @@ func (h *PostHandler) IncrementViews(c *gin.Context) {
 		return
 	}
 
-	err = h.postService.IncrementViews(c.Request.Context(), id)
+	// Referrer may be sent in the body by SPA clients, else use the header
+	var req dto.PostViewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+			return
+		}
+	}
+	if req.Referrer == "" {
+		req.Referrer = c.Request.Referer()
+	}
+
+	// Identify the visitor: logged in user, or IP + user agent
+	var userID string
+	if userValue, exists := c.Get("user"); exists {
+		if user, ok := userValue.(*entity.User); ok {
+			userID = user.ID.String()
+		}
+	}
+
+	req.UserAgent = c.Request.UserAgent()
+	req.VisitorKey = viewcounter.VisitorKey(userID, c.ClientIP(), req.UserAgent)
+	req.ReferrerHost = viewcounter.ReferrerHost(req.Referrer, c.Request.Host)
+	req.Authenticated = userID != ""
+	req.Country = viewcounter.CountryCode(c.GetHeader("CF-IPCountry"))
+	if req.Country == "" {
+		req.Country = viewcounter.CountryCode(c.GetHeader("X-Country-Code"))
+	}
+
+	counted, err := h.postService.IncrementViews(c.Request.Context(), id, &req)
+	if err != nil {
+		if err.Error() == "post not found" {
+			response.Error(c, http.StatusNotFound, "Post not found", err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "Bad request", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "View recorded", "counted": counted})
+}
+
+// GetFeed get published posts from followed authors and categories
+func (h *PostHandler) GetFeed(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	params := &dto.FeedQueryParams{
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}
+
+	posts, page, err := h.postService.GetFeed(c.Request.Context(), params, user)
 	if err != nil {
-		response.Error(c, http.StatusNotFound, "Post not found", err.Error())
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			response.Error(c, http.StatusBadRequest, "Invalid cursor", err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to get feed", err.Error())
 		return
 	}
 
-	response.Success(c, http.StatusOK, gin.H{"message": "View count incremented"})
-}
\ No newline at end of file
+	response.SuccessWithCursors(c, http.StatusOK, limit, page.NextCursor, page.PrevCursor, nil, posts)
+}
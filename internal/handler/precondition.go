@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// setVersionETag sends the version of a post, category or comment as its
+// ETag, for If-Match on the next update or delete
+func setVersionETag(c *gin.Context, version int64) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// expectedVersion returns the version an update or delete is made against:
+// the If-Match header, or else fallback (the version field). If-Match: *
+// matches any version and gives nil. Without either it answers 428 and
+// returns false.
+func expectedVersion(c *gin.Context, fallback *int64) (*int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	switch {
+	case header == "*":
+		return nil, true
+	case header != "":
+		// A tag this API didn't send (or a weak one) matches no version
+		var version int64
+		if unquoted, err := strconv.Unquote(header); err == nil {
+			version, _ = strconv.ParseInt(unquoted, 10, 64)
+		}
+		return &version, true
+	case fallback != nil:
+		return fallback, true
+	}
+
+	response.Error(c, http.StatusPreconditionRequired, "Precondition required", "send the version in If-Match or in the version field")
+	return nil, false
+}
+
+// queryVersion reads ?version= for deletes, which have no body
+func queryVersion(c *gin.Context) *int64 {
+	version, err := strconv.ParseInt(c.Query("version"), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &version
+}
+
+// handleConflict answers a version conflict with 412 and the resource as
+// it is now, and reports whether err was one. Writes the client made
+// without a precondition (publish, unpublish) get 409.
+func handleConflict(c *gin.Context, err error, conditional bool) bool {
+	var conflict *service.ConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+
+	code := http.StatusConflict
+	if conditional {
+		code = http.StatusPreconditionFailed
+	}
+	setVersionETag(c, conflict.Version)
+	response.Conflict(c, code, "The resource was changed by someone else", conflict.Current)
+	return true
+}
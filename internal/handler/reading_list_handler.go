@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ReadingListHandler struct {
+	readingListService service.ReadingListService
+}
+
+func NewReadingListHandler(readingListService service.ReadingListService) *ReadingListHandler {
+	return &ReadingListHandler{readingListService: readingListService}
+}
+
+// GetMine get all reading lists of the current user
+func (h *ReadingListHandler) GetMine(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	lists, err := h.readingListService.GetMine(c.Request.Context(), user)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get reading lists", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, lists)
+}
+
+// GetPublicByUserID get public reading lists of a user
+func (h *ReadingListHandler) GetPublicByUserID(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	lists, err := h.readingListService.GetPublicByUserID(c.Request.Context(), userID)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get reading lists", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, lists)
+}
+
+// GetByID get a reading list with its items (public lists or own lists)
+func (h *ReadingListHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid reading list ID", err.Error())
+		return
+	}
+
+	// ✅ Extract current user from context (optional)
+	var currentUser *entity.User
+	if userValue, exists := c.Get("user"); exists {
+		currentUser, _ = userValue.(*entity.User)
+	}
+
+	list, err := h.readingListService.GetByID(c.Request.Context(), id, currentUser)
+	if err != nil {
+		h.handleError(c, err, "Failed to get reading list")
+		return
+	}
+
+	response.Success(c, http.StatusOK, list)
+}
+
+// Create create a new reading list
+func (h *ReadingListHandler) Create(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	var req dto.CreateReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	list, err := h.readingListService.Create(c.Request.Context(), &req, user)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to create reading list", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, list)
+}
+
+// Update update a reading list
+func (h *ReadingListHandler) Update(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid reading list ID", err.Error())
+		return
+	}
+
+	var req dto.UpdateReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	list, err := h.readingListService.Update(c.Request.Context(), id, &req, user)
+	if err != nil {
+		h.handleError(c, err, "Failed to update reading list")
+		return
+	}
+
+	response.Success(c, http.StatusOK, list)
+}
+
+// Delete delete a reading list
+func (h *ReadingListHandler) Delete(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid reading list ID", err.Error())
+		return
+	}
+
+	if err := h.readingListService.Delete(c.Request.Context(), id, user); err != nil {
+		h.handleError(c, err, "Failed to delete reading list")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Reading list deleted successfully"})
+}
+
+// AddItem add a post to a reading list
+func (h *ReadingListHandler) AddItem(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid reading list ID", err.Error())
+		return
+	}
+
+	var req dto.AddReadingListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	list, err := h.readingListService.AddItem(c.Request.Context(), id, &req, user)
+	if err != nil {
+		h.handleError(c, err, "Failed to add item")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, list)
+}
+
+// RemoveItem remove a post from a reading list
+func (h *ReadingListHandler) RemoveItem(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid reading list ID", err.Error())
+		return
+	}
+
+	postID, err := uuid.Parse(c.Param("postId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid post ID", err.Error())
+		return
+	}
+
+	list, err := h.readingListService.RemoveItem(c.Request.Context(), id, postID, user)
+	if err != nil {
+		h.handleError(c, err, "Failed to remove item")
+		return
+	}
+
+	response.Success(c, http.StatusOK, list)
+}
+
+// Reorder set the order of posts in a reading list
+func (h *ReadingListHandler) Reorder(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid reading list ID", err.Error())
+		return
+	}
+
+	var req dto.ReorderReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	list, err := h.readingListService.Reorder(c.Request.Context(), id, &req, user)
+	if err != nil {
+		h.handleError(c, err, "Failed to reorder reading list")
+		return
+	}
+
+	response.Success(c, http.StatusOK, list)
+}
+
+// handleError maps reading list service errors to HTTP status codes
+func (h *ReadingListHandler) handleError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "reading list not found", "post not found", "post is not in this reading list":
+		response.Error(c, http.StatusNotFound, "Not found", err.Error())
+	case "you don't have permission to modify this reading list":
+		response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+	case "post is already in this reading list":
+		response.Error(c, http.StatusConflict, "Conflict", err.Error())
+	default:
+		response.Error(c, http.StatusBadRequest, message, err.Error())
+	}
+}
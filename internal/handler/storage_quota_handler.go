@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StorageQuotaHandler struct {
+	quotaService service.StorageQuotaService
+}
+
+func NewStorageQuotaHandler(quotaService service.StorageQuotaService) *StorageQuotaHandler {
+	return &StorageQuotaHandler{quotaService: quotaService}
+}
+
+// GetMine get the current user's storage usage and limits
+func (h *StorageQuotaHandler) GetMine(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	usage, err := h.quotaService.GetUsage(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		h.handleError(c, err, "Failed to get storage usage")
+		return
+	}
+
+	response.Success(c, http.StatusOK, usage)
+}
+
+// GetReport get storage usage per user, largest first
+func (h *StorageQuotaHandler) GetReport(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	usages, total, err := h.quotaService.GetUsageReport(c.Request.Context(), page, limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get storage usage", err.Error())
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, usages)
+}
+
+// GetByUserID get one user's storage usage and limits
+func (h *StorageQuotaHandler) GetByUserID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	usage, err := h.quotaService.GetUsage(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get storage usage")
+		return
+	}
+
+	response.Success(c, http.StatusOK, usage)
+}
+
+// SetQuota override a user's storage limits
+func (h *StorageQuotaHandler) SetQuota(c *gin.Context) {
+	currentUser, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req dto.SetStorageQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	usage, err := h.quotaService.SetQuota(c.Request.Context(), id, &req, currentUser)
+	if err != nil {
+		h.handleError(c, err, "Failed to set storage quota")
+		return
+	}
+
+	response.Success(c, http.StatusOK, usage)
+}
+
+// ResetQuota go back to the role's storage limits
+func (h *StorageQuotaHandler) ResetQuota(c *gin.Context) {
+	currentUser, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	usage, err := h.quotaService.ResetQuota(c.Request.Context(), id, currentUser)
+	if err != nil {
+		h.handleError(c, err, "Failed to reset storage quota")
+		return
+	}
+
+	response.Success(c, http.StatusOK, usage)
+}
+
+func (h *StorageQuotaHandler) currentUser(c *gin.Context) (*entity.User, bool) {
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return nil, false
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return nil, false
+	}
+	return user, true
+}
+
+// handleError maps storage quota service errors to HTTP status codes
+func (h *StorageQuotaHandler) handleError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "user not found":
+		response.Error(c, http.StatusNotFound, "Not found", err.Error())
+	case "you don't have permission to change this user's quota":
+		response.Error(c, http.StatusForbidden, "Forbidden", err.Error())
+	default:
+		response.Error(c, http.StatusBadRequest, message, err.Error())
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/broker"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StreamHandler struct {
+	streamService service.StreamService
+	heartbeat     time.Duration
+}
+
+func NewStreamHandler(streamService service.StreamService, heartbeat time.Duration) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &StreamHandler{streamService: streamService, heartbeat: heartbeat}
+}
+
+// PostComments stream comment created/updated/deleted events for a post
+func (h *StreamHandler) PostComments(c *gin.Context) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid post ID", err.Error())
+		return
+	}
+
+	// ✅ Extract current user from context (optional)
+	var currentUser *entity.User
+	if userValue, exists := c.Get("user"); exists {
+		currentUser, _ = userValue.(*entity.User)
+	}
+
+	sub, err := h.streamService.SubscribePostComments(c.Request.Context(), postID, currentUser, lastEventID(c))
+	if err != nil {
+		if err.Error() == "post not found" {
+			response.Error(c, http.StatusNotFound, "Not found", err.Error())
+			return
+		}
+		h.subscribeError(c, err)
+		return
+	}
+
+	h.stream(c, sub)
+}
+
+// Notifications stream new notifications for the current user
+func (h *StreamHandler) Notifications(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	sub, err := h.streamService.SubscribeNotifications(c.Request.Context(), user, lastEventID(c))
+	if err != nil {
+		h.subscribeError(c, err)
+		return
+	}
+
+	h.stream(c, sub)
+}
+
+// stream writes events as text/event-stream until the client disconnects or
+// the subscription ends (server shutdown or client too slow)
+func (h *StreamHandler) stream(c *gin.Context, sub broker.Subscription) {
+	defer sub.Close()
+
+	// The server-wide WriteTimeout would cut long-lived streams
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	// Reconnect delay hint for EventSource
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+			c.Writer.Flush()
+		case <-ticker.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+func (h *StreamHandler) subscribeError(c *gin.Context, err error) {
+	if errors.Is(err, broker.ErrClosed) {
+		response.Error(c, http.StatusServiceUnavailable, "Server is shutting down", err.Error())
+		return
+	}
+	response.Error(c, http.StatusInternalServerError, "Failed to open stream", err.Error())
+}
+
+// lastEventID reads the resume position sent by EventSource on reconnect,
+// or by polyfills that can only use the query string
+func lastEventID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/afdhali/GolangBlogpostServer/internal/dto"
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
 	"github.com/afdhali/GolangBlogpostServer/pkg/response"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -49,13 +51,25 @@ func (h *UserHandler) GetAll(c *gin.Context) {
 		limit = 10
 	}
 
-	users, total, err := h.userService.GetAll(c.Request.Context(), page, limit, search, role)
+	params := &dto.UserQueryParams{
+		Page:         page,
+		Limit:        limit,
+		Search:       search,
+		Role:         role,
+		CursorParams: cursorParams(c),
+	}
+
+	users, pageInfo, err := h.userService.GetAll(c.Request.Context(), params)
 	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			response.Error(c, http.StatusBadRequest, "Invalid cursor", err.Error())
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "Failed to get users", err.Error())
 		return
 	}
 
-	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, users)
+	respondPage(c, params.CursorParams, page, limit, pageInfo, users)
 }
 
 func (h *UserHandler) CreateUser(c *gin.Context) {
@@ -200,6 +214,9 @@ func (h *UserHandler) UploadAvatar(c *gin.Context) {
 
 	user, err := h.userService.UploadAvatar(c.Request.Context(), userID.(uuid.UUID), file, header)
 	if err != nil {
+		if handleUploadError(c, err) {
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "Upload failed", err.Error())
 		return
 	}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// GetAll get all webhook subscriptions
+func (h *WebhookHandler) GetAll(c *gin.Context) {
+	webhooks, err := h.webhookService.GetAll(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get webhooks", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, webhooks)
+}
+
+// GetByID get a webhook subscription
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	webhook, err := h.webhookService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get webhook")
+		return
+	}
+
+	response.Success(c, http.StatusOK, webhook)
+}
+
+// Create create a webhook subscription; the signing secret is only returned here
+func (h *WebhookHandler) Create(c *gin.Context) {
+	// Get user from context
+	userValue, exists := c.Get("user")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "User not found")
+		return
+	}
+
+	user, ok := userValue.(*entity.User)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized", "Invalid user")
+		return
+	}
+
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	webhook, err := h.webhookService.Create(c.Request.Context(), &req, user)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to create webhook", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusCreated, webhook)
+}
+
+// Update update a webhook subscription
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	var req dto.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	webhook, err := h.webhookService.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update webhook")
+		return
+	}
+
+	response.Success(c, http.StatusOK, webhook)
+}
+
+// Delete delete a webhook subscription
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	if err := h.webhookService.Delete(c.Request.Context(), id); err != nil {
+		h.handleError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries get the delivery log of a webhook (?status=pending|succeeded|failed)
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	params := &dto.WebhookDeliveryQueryParams{
+		Page:   page,
+		Limit:  limit,
+		Status: c.Query("status"),
+	}
+
+	deliveries, total, err := h.webhookService.GetDeliveries(c.Request.Context(), id, params)
+	if err != nil {
+		h.handleError(c, err, "Failed to get deliveries")
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, page, limit, total, deliveries)
+}
+
+// GetDelivery get a delivery with its payload and attempt log
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid delivery ID", err.Error())
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get delivery")
+		return
+	}
+
+	response.Success(c, http.StatusOK, delivery)
+}
+
+// Redeliver queue a past delivery again with the same payload
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid delivery ID", err.Error())
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		h.handleError(c, err, "Failed to redeliver")
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, delivery)
+}
+
+// handleError maps webhook service errors to HTTP status codes
+func (h *WebhookHandler) handleError(c *gin.Context, err error, message string) {
+	switch err.Error() {
+	case "webhook not found", "delivery not found":
+		response.Error(c, http.StatusNotFound, "Not found", err.Error())
+	case "webhook is disabled":
+		response.Error(c, http.StatusConflict, "Conflict", err.Error())
+	default:
+		response.Error(c, http.StatusBadRequest, message, err.Error())
+	}
+}
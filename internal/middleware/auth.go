@@ -137,4 +137,17 @@ func OptionalAuthMiddleware(jwtService security.JWTService, userRepo repository.
 
 		ctx.Next()
 	}
-}
\ No newline at end of file
+}
+
+// TokenFromQuery lets clients that can't set headers (EventSource) pass the
+// access token as ?access_token=. It must run before the auth middlewares.
+func TokenFromQuery() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			if token := ctx.Query("access_token"); token != "" {
+				ctx.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		ctx.Next()
+	}
+}
@@ -14,6 +14,7 @@ func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
         AllowMethods:     cfg.CORS.AllowedMethods,
         AllowHeaders:     cfg.CORS.AllowedHeaders,
         AllowCredentials: cfg.CORS.AllowCredentials,
+        ExposeHeaders:    []string{"ETag"}, // versions for If-Match
         MaxAge:           12 * time.Hour,
 	})
 }
\ No newline at end of file
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DailyViewCount is one day of a post's view time series
+type DailyViewCount struct {
+	Day                time.Time
+	Views              int64
+	AuthenticatedViews int64
+}
+
+// ReferrerViewCount is the number of views coming from one referrer host
+type ReferrerViewCount struct {
+	ReferrerHost string
+	Views        int64
+}
+
+// ViewTotal is the number of views for one post, category or author.
+// Name/Slug hold title/slug, name/slug or full name/username respectively.
+type ViewTotal struct {
+	ID    uuid.UUID
+	Name  string
+	Slug  string
+	Views int64
+}
+
+type AnalyticsRepository interface {
+	UpsertViewStats(ctx context.Context, stats []*entity.PostViewStat) error
+	DailyViews(ctx context.Context, postID uuid.UUID, from, to time.Time) ([]DailyViewCount, error)
+	ReferrerViews(ctx context.Context, postID uuid.UUID, from, to time.Time, limit int) ([]ReferrerViewCount, error)
+	TopPosts(ctx context.Context, from, to time.Time, limit int) ([]ViewTotal, error)
+	TopCategories(ctx context.Context, from, to time.Time, limit int) ([]ViewTotal, error)
+	TopAuthors(ctx context.Context, from, to time.Time, limit int) ([]ViewTotal, error)
+}
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// UpsertViewStats adds views to existing rollup rows or inserts new ones
+func (r *analyticsRepository) UpsertViewStats(ctx context.Context, stats []*entity.PostViewStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{
+				{Name: "post_id"}, {Name: "day"}, {Name: "referrer_host"}, {Name: "country"}, {Name: "authenticated"},
+			},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"views":      gorm.Expr("post_view_stats.views + EXCLUDED.views"),
+				"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+			}),
+		}).
+		Create(&stats).Error
+}
+
+func (r *analyticsRepository) DailyViews(ctx context.Context, postID uuid.UUID, from, to time.Time) ([]DailyViewCount, error) {
+	var results []DailyViewCount
+	err := conn(ctx, r.db).
+		Model(&entity.PostViewStat{}).
+		Select("day, SUM(views) as views, SUM(CASE WHEN authenticated THEN views ELSE 0 END) as authenticated_views").
+		Where("post_id = ? AND day BETWEEN ? AND ?", postID, from, to).
+		Group("day").
+		Order("day ASC").
+		Scan(&results).Error
+	return results, err
+}
+
+func (r *analyticsRepository) ReferrerViews(ctx context.Context, postID uuid.UUID, from, to time.Time, limit int) ([]ReferrerViewCount, error) {
+	var results []ReferrerViewCount
+	err := conn(ctx, r.db).
+		Model(&entity.PostViewStat{}).
+		Select("referrer_host, SUM(views) as views").
+		Where("post_id = ? AND day BETWEEN ? AND ?", postID, from, to).
+		Group("referrer_host").
+		Order("views DESC").
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
+
+func (r *analyticsRepository) TopPosts(ctx context.Context, from, to time.Time, limit int) ([]ViewTotal, error) {
+	return r.topBy(ctx, "posts.id", "posts.title", "posts.slug", "", from, to, limit)
+}
+
+func (r *analyticsRepository) TopCategories(ctx context.Context, from, to time.Time, limit int) ([]ViewTotal, error) {
+	return r.topBy(ctx, "categories.id", "categories.name", "categories.slug",
+		"JOIN categories ON categories.id = posts.category_id", from, to, limit)
+}
+
+func (r *analyticsRepository) TopAuthors(ctx context.Context, from, to time.Time, limit int) ([]ViewTotal, error) {
+	return r.topBy(ctx, "users.id", "users.full_name", "users.username",
+		"JOIN users ON users.id = posts.author_id", from, to, limit)
+}
+
+// topBy sums views grouped by the given columns, skipping soft-deleted posts
+func (r *analyticsRepository) topBy(ctx context.Context, idCol, nameCol, slugCol, join string, from, to time.Time, limit int) ([]ViewTotal, error) {
+	var results []ViewTotal
+
+	query := conn(ctx, r.db).
+		Model(&entity.PostViewStat{}).
+		Select(idCol + " as id, " + nameCol + " as name, " + slugCol + " as slug, SUM(post_view_stats.views) as views").
+		Joins("JOIN posts ON posts.id = post_view_stats.post_id AND posts.deleted_at IS NULL")
+	if join != "" {
+		query = query.Joins(join)
+	}
+
+	err := query.
+		Where("post_view_stats.day BETWEEN ? AND ?", from, to).
+		Group(idCol + ", " + nameCol + ", " + slugCol).
+		Order("views DESC").
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkRepository interface {
+	Create(ctx context.Context, bookmark *entity.Bookmark) error
+	Delete(ctx context.Context, userID, postID uuid.UUID) (bool, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID, page, limit int) ([]*entity.Bookmark, int64, error)
+
+	// Returns the subset of postIDs bookmarked by the user
+	FindBookmarkedPostIDs(ctx context.Context, userID uuid.UUID, postIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+type bookmarkRepository struct {
+	db *gorm.DB
+}
+
+func NewBookmarkRepository(db *gorm.DB) BookmarkRepository {
+	return &bookmarkRepository{db: db}
+}
+
+// Create is idempotent: bookmarking the same post twice keeps the first
+// bookmark, which is loaded into bookmark
+func (r *bookmarkRepository) Create(ctx context.Context, bookmark *entity.Bookmark) error {
+	result := conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(bookmark)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	var existing entity.Bookmark
+	err := conn(ctx, r.db).
+		Where("user_id = ? AND post_id = ?", bookmark.UserID, bookmark.PostID).
+		First(&existing).Error
+	if err != nil {
+		return err
+	}
+	bookmark.BaseEntity = existing.BaseEntity
+	return nil
+}
+
+// Delete hard-deletes so the unique (user, post) pair can be reused
+func (r *bookmarkRepository) Delete(ctx context.Context, userID, postID uuid.UUID) (bool, error) {
+	result := conn(ctx, r.db).
+		Unscoped().
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Delete(&entity.Bookmark{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindByUserID preloads posts; soft-deleted posts come back as a nil Post
+func (r *bookmarkRepository) FindByUserID(ctx context.Context, userID uuid.UUID, page, limit int) ([]*entity.Bookmark, int64, error) {
+	var bookmarks []*entity.Bookmark
+	var total int64
+
+	query := conn(ctx, r.db).Model(&entity.Bookmark{}).
+		Where("user_id = ?", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Post").
+		Preload("Post.Author").
+		Preload("Post.Category").
+		Offset(offset).Limit(limit).Order("created_at DESC").
+		Find(&bookmarks).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bookmarks, total, nil
+}
+
+func (r *bookmarkRepository) FindBookmarkedPostIDs(ctx context.Context, userID uuid.UUID, postIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	bookmarked := make(map[uuid.UUID]bool)
+	if len(postIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	var ids []uuid.UUID
+	err := conn(ctx, r.db).
+		Model(&entity.Bookmark{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		bookmarked[id] = true
+	}
+	return bookmarked, nil
+}
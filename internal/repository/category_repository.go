@@ -13,8 +13,8 @@ type CategoryRepository interface {
 	FindByID(ctx context.Context, id uuid.UUID) (*entity.Category, error)
 	FindBySlug(ctx context.Context, slug string) (*entity.Category, error)
 	FindAll(ctx context.Context, page, limit int, search, sortBy, sortOrder string) ([]*entity.Category, int64, error)
-	Update(ctx context.Context, category *entity.Category) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Update(ctx context.Context, category *entity.Category) error // ErrVersionConflict unless still at category.Version
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
 
 	// 👇 Counting Posts by Category
     CountByCategoryIDs(ctx context.Context, categoryIDs []uuid.UUID) (map[uuid.UUID]int64, error)
@@ -29,12 +29,12 @@ func NewCategoryRepository(db *gorm.DB) CategoryRepository {
 }
 
 func (r *categoryRepository) Create(ctx context.Context, category *entity.Category) error {
-	return r.db.WithContext(ctx).Create(category).Error
+	return conn(ctx, r.db).Create(category).Error
 }
 
 func (r *categoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Category, error) {
 	var category entity.Category
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&category).Error
+	err := conn(ctx, r.db).Where("id = ?", id).First(&category).Error
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +43,7 @@ func (r *categoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*entit
 
 func (r *categoryRepository) FindBySlug(ctx context.Context, slug string) (*entity.Category, error) {
     var category entity.Category
-    err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&category).Error
+    err := conn(ctx, r.db).Where("slug = ?", slug).First(&category).Error
     if err != nil {
         return nil, err
     }
@@ -54,7 +54,7 @@ func (r *categoryRepository) FindAll(ctx context.Context, page, limit int, searc
 	var categories []*entity.Category
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&entity.Category{})
+	query := conn(ctx, r.db).Model(&entity.Category{})
 
 	if search != "" {
 		query = query.Where("name LIKE ?", "%"+search+"%")
@@ -84,11 +84,11 @@ func (r *categoryRepository) FindAll(ctx context.Context, page, limit int, searc
 }
 
 func (r *categoryRepository) Update(ctx context.Context, category *entity.Category) error {
-    return r.db.WithContext(ctx).Save(category).Error
+    return updateVersioned(conn(ctx, r.db), category, &category.Version)
 }
 
-func (r *categoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
-    return r.db.WithContext(ctx).Delete(&entity.Category{}, id).Error
+func (r *categoryRepository) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+    return deleteVersioned(conn(ctx, r.db), &entity.Category{}, id, version)
 }
 
 // 👇 NEW METHOD: Count posts for multiple categories
@@ -103,7 +103,7 @@ func (r *categoryRepository) CountByCategoryIDs(ctx context.Context, categoryIDs
     }
 
     var results []Result
-    err := r.db.WithContext(ctx).
+    err := conn(ctx, r.db).
         Table("posts").
         Select("category_id, COUNT(*) as count").
         Where("category_id IN ?", categoryIDs).
@@ -11,9 +11,9 @@ import (
 type CommentRepository interface {
 	Create(ctx context.Context, comment *entity.Comment) error
 	FindByID(ctx context.Context, id uuid.UUID) (*entity.Comment, error)
-	FindByPostID(ctx context.Context, postID uuid.UUID, page, limit int) ([]*entity.Comment, int64, error)
-    Update(ctx context.Context, comment *entity.Comment) error
-    Delete(ctx context.Context, id uuid.UUID) error
+	FindByPostID(ctx context.Context, postID uuid.UUID, paging Paging) ([]*entity.Comment, int64, error)
+    Update(ctx context.Context, comment *entity.Comment) error // ErrVersionConflict unless still at comment.Version
+    Delete(ctx context.Context, id uuid.UUID, version int64) error
 
     // Counting by Post
     CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error)
@@ -29,12 +29,12 @@ func NewCommentRepository(db *gorm.DB) CommentRepository {
 }
 
 func (r *commentRepository) Create(ctx context.Context, comment *entity.Comment) error {
-    return r.db.WithContext(ctx).Create(comment).Error
+    return conn(ctx, r.db).Create(comment).Error
 }
 
 func (r *commentRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Comment, error) {
     var comment entity.Comment
-    err := r.db.WithContext(ctx).
+    err := conn(ctx, r.db).
         Preload("User").
         Preload("Post").
         Where("id = ?", id).
@@ -45,40 +45,39 @@ func (r *commentRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity
     return &comment, nil
 }
 
-func (r *commentRepository) FindByPostID(ctx context.Context, postID uuid.UUID, page, limit int) ([]*entity.Comment, int64, error) {
+func (r *commentRepository) FindByPostID(ctx context.Context, postID uuid.UUID, paging Paging) ([]*entity.Comment, int64, error) {
     var comments []*entity.Comment
     var total int64
 
-    query := r.db.WithContext(ctx).Model(&entity.Comment{}).
+    query := conn(ctx, r.db).Model(&entity.Comment{}).
         Preload("User").
         Preload("Replies.User").
         Where("post_id = ? AND parent_id IS NULL", postID)
 
-    if err := query.Count(&total).Error; err != nil {
+    if err := paging.count(query, &total); err != nil {
         return nil, 0, err
     }
 
-    offset := (page - 1) * limit
-    err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&comments).Error
+    err := paging.apply(query, "comments").Find(&comments).Error
     if err != nil {
         return nil, 0, err
     }
 
-    return comments, total, nil
+    return reorder(paging, comments), total, nil
 }
 
 func (r *commentRepository) Update(ctx context.Context, comment *entity.Comment) error {
-    return r.db.WithContext(ctx).Save(comment).Error
+    return updateVersioned(conn(ctx, r.db), comment, &comment.Version)
 }
 
-func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID) error {
-    return r.db.WithContext(ctx).Delete(&entity.Comment{}, id).Error
+func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+    return deleteVersioned(conn(ctx, r.db), &entity.Comment{}, id, version)
 }
 
 // 👇 NEW: Count comments by single post
 func (r *commentRepository) CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error) {
     var count int64
-    err := r.db.WithContext(ctx).
+    err := conn(ctx, r.db).
         Model(&entity.Comment{}).
         Where("post_id = ?", postID).
         Count(&count).Error
@@ -97,7 +96,7 @@ func (r *commentRepository) CountByPostIDs(ctx context.Context, postIDs []uuid.U
     }
 
     var results []Result
-    err := r.db.WithContext(ctx).
+    err := conn(ctx, r.db).
         Model(&entity.Comment{}).
         Select("post_id, COUNT(*) as count").
         Where("post_id IN ?", postIDs).
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FollowRepository interface {
+	// Users → authors
+	FollowUser(ctx context.Context, follow *entity.UserFollow) (bool, error)
+	UnfollowUser(ctx context.Context, followerID, followingID uuid.UUID) (bool, error)
+	IsFollowingUser(ctx context.Context, followerID, followingID uuid.UUID) (bool, error)
+	FindFollowers(ctx context.Context, userID uuid.UUID, page, limit int) ([]*entity.User, int64, error)
+	FindFollowing(ctx context.Context, userID uuid.UUID, page, limit int) ([]*entity.User, int64, error)
+	CountFollowers(ctx context.Context, userID uuid.UUID) (int64, error)
+	CountFollowing(ctx context.Context, userID uuid.UUID) (int64, error)
+	FindFollowerIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+
+	// Users → categories
+	FollowCategory(ctx context.Context, follow *entity.CategoryFollow) error
+	UnfollowCategory(ctx context.Context, userID, categoryID uuid.UUID) (bool, error)
+	FindFollowedCategories(ctx context.Context, userID uuid.UUID) ([]*entity.Category, error)
+}
+
+type followRepository struct {
+	db *gorm.DB
+}
+
+func NewFollowRepository(db *gorm.DB) FollowRepository {
+	return &followRepository{db: db}
+}
+
+// FollowUser is idempotent: following the same author twice is a no-op.
+// The bool reports whether a new follow was created.
+func (r *followRepository) FollowUser(ctx context.Context, follow *entity.UserFollow) (bool, error) {
+	result := conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(follow)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UnfollowUser hard-deletes so the unique pair can be reused
+func (r *followRepository) UnfollowUser(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	result := conn(ctx, r.db).
+		Unscoped().
+		Where("follower_id = ? AND following_id = ?", followerID, followingID).
+		Delete(&entity.UserFollow{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *followRepository) IsFollowingUser(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&entity.UserFollow{}).
+		Where("follower_id = ? AND following_id = ?", followerID, followingID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *followRepository) FindFollowers(ctx context.Context, userID uuid.UUID, page, limit int) ([]*entity.User, int64, error) {
+	return r.findUsers(ctx, "user_follows.follower_id", "user_follows.following_id = ?", userID, page, limit)
+}
+
+func (r *followRepository) FindFollowing(ctx context.Context, userID uuid.UUID, page, limit int) ([]*entity.User, int64, error) {
+	return r.findUsers(ctx, "user_follows.following_id", "user_follows.follower_id = ?", userID, page, limit)
+}
+
+// findUsers lists the users on one side of user_follows, newest follow first
+func (r *followRepository) findUsers(ctx context.Context, joinCol, where string, userID uuid.UUID, page, limit int) ([]*entity.User, int64, error) {
+	var users []*entity.User
+	var total int64
+
+	query := conn(ctx, r.db).Model(&entity.User{}).
+		Joins("JOIN user_follows ON users.id = "+joinCol).
+		Where(where, userID).
+		Where("user_follows.deleted_at IS NULL")
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Offset(offset).Limit(limit).
+		Order("user_follows.created_at DESC").
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (r *followRepository) CountFollowers(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&entity.UserFollow{}).
+		Where("following_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *followRepository) CountFollowing(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&entity.UserFollow{}).
+		Where("follower_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *followRepository) FindFollowerIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := conn(ctx, r.db).
+		Model(&entity.UserFollow{}).
+		Where("following_id = ?", userID).
+		Pluck("follower_id", &ids).Error
+	return ids, err
+}
+
+// FollowCategory is idempotent: following the same category twice is a no-op
+func (r *followRepository) FollowCategory(ctx context.Context, follow *entity.CategoryFollow) error {
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(follow).Error
+}
+
+// UnfollowCategory hard-deletes so the unique pair can be reused
+func (r *followRepository) UnfollowCategory(ctx context.Context, userID, categoryID uuid.UUID) (bool, error) {
+	result := conn(ctx, r.db).
+		Unscoped().
+		Where("user_id = ? AND category_id = ?", userID, categoryID).
+		Delete(&entity.CategoryFollow{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *followRepository) FindFollowedCategories(ctx context.Context, userID uuid.UUID) ([]*entity.Category, error) {
+	var categories []*entity.Category
+	err := conn(ctx, r.db).
+		Joins("JOIN category_follows ON categories.id = category_follows.category_id").
+		Where("category_follows.user_id = ? AND category_follows.deleted_at IS NULL", userID).
+		Order("categories.name ASC").
+		Find(&categories).Error
+	return categories, err
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository interface {
+	// Create inserts a job. It returns false without error when the job has
+	// a unique key and a pending or running job with that key already exists.
+	Create(ctx context.Context, job *entity.Job) (bool, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.Job, error)
+	FindActiveByUniqueKey(ctx context.Context, key string) (*entity.Job, error)
+
+	// Worker side
+	Claim(ctx context.Context, types []string, workerID string, now time.Time, lease time.Duration) (*entity.Job, error)
+	Finish(ctx context.Context, job *entity.Job, workerID string) error
+	DeleteSucceededBefore(ctx context.Context, before time.Time) (int64, error)
+
+	// Admin
+	FindAll(ctx context.Context, status, jobType string, page, limit int) ([]*entity.Job, int64, error)
+	CountByStatus(ctx context.Context) (map[entity.JobStatus]int64, error)
+	Retry(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) Create(ctx context.Context, job *entity.Job) (bool, error) {
+	// DO NOTHING without a target also covers the partial unique key index
+	result := conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(job)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *jobRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Job, error) {
+	var job entity.Job
+	err := conn(ctx, r.db).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) FindActiveByUniqueKey(ctx context.Context, key string) (*entity.Job, error) {
+	var job entity.Job
+	err := conn(ctx, r.db).
+		Where("unique_key = ? AND status IN ?", key, []entity.JobStatus{entity.JobStatusPending, entity.JobStatusRunning}).
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Claim locks the oldest due job of the given types with SKIP LOCKED and
+// marks it running, so concurrent workers (or other instances) never run the
+// same job twice. A running job whose lease expired - its worker died - is
+// due again. Returns nil when nothing is due.
+func (r *jobRepository) Claim(ctx context.Context, types []string, workerID string, now time.Time, lease time.Duration) (*entity.Job, error) {
+	var claimed *entity.Job
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var jobs []*entity.Job
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("type IN ?", types).
+			Where("((status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?))",
+				entity.JobStatusPending, now, entity.JobStatusRunning, now.Add(-lease)).
+			Order("run_at ASC").
+			Limit(1).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		job := jobs[0]
+		job.Status = entity.JobStatusRunning
+		job.Attempts++
+		job.LockedAt = &now
+		job.LockedBy = workerID
+
+		err = tx.Model(job).
+			Select("status", "attempts", "locked_at", "locked_by").
+			Updates(job).Error
+		if err != nil {
+			return err
+		}
+
+		claimed = job
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// Finish saves the outcome of a run. It only applies while workerID still
+// holds the job; if the lease expired and another worker took over, the
+// stale result is dropped.
+func (r *jobRepository) Finish(ctx context.Context, job *entity.Job, workerID string) error {
+	return conn(ctx, r.db).Model(job).
+		Where("status = ? AND locked_by = ?", entity.JobStatusRunning, workerID).
+		Select("status", "run_at", "last_error", "locked_at", "locked_by", "completed_at").
+		Updates(job).Error
+}
+
+func (r *jobRepository) DeleteSucceededBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("status = ? AND completed_at < ?", entity.JobStatusSucceeded, before).
+		Delete(&entity.Job{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *jobRepository) FindAll(ctx context.Context, status, jobType string, page, limit int) ([]*entity.Job, int64, error) {
+	var jobs []*entity.Job
+	var total int64
+
+	query := conn(ctx, r.db).Model(&entity.Job{})
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&jobs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, total, nil
+}
+
+func (r *jobRepository) CountByStatus(ctx context.Context) (map[entity.JobStatus]int64, error) {
+	var rows []struct {
+		Status entity.JobStatus
+		Count  int64
+	}
+
+	err := conn(ctx, r.db).Model(&entity.Job{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[entity.JobStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// Retry moves a dead job back to pending with a fresh attempt budget
+func (r *jobRepository) Retry(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&entity.Job{}).
+		Where("id = ? AND status = ?", id, entity.JobStatusDead).
+		Updates(map[string]interface{}{
+			"status":   entity.JobStatusPending,
+			"attempts": 0,
+			"run_at":   now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MediaBlobRepository interface {
+	FindByHash(ctx context.Context, hash string) (*entity.MediaBlob, error)
+
+	// Acquire takes a reference on the blob with blob.Hash, creating it from
+	// blob if there is none. The stored blob is returned; its path differs
+	// from blob.Path when another upload of the same content got there first.
+	Acquire(ctx context.Context, blob *entity.MediaBlob) (*entity.MediaBlob, error)
+
+	// Reference takes a reference on the blob with hash only if it still
+	// exists, and reports whether it did
+	Reference(ctx context.Context, hash string) (bool, error)
+
+	// Release drops a reference and reports whether it was the last one, in
+	// which case the blob row is gone and its file can be deleted. Hashes
+	// without a blob count as the last reference.
+	Release(ctx context.Context, hash string) (bool, error)
+}
+
+type mediaBlobRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaBlobRepository(db *gorm.DB) MediaBlobRepository {
+	return &mediaBlobRepository{db: db}
+}
+
+func (r *mediaBlobRepository) FindByHash(ctx context.Context, hash string) (*entity.MediaBlob, error) {
+	var blob entity.MediaBlob
+	err := conn(ctx, r.db).Where("hash = ?", hash).First(&blob).Error
+	if err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+func (r *mediaBlobRepository) Acquire(ctx context.Context, blob *entity.MediaBlob) (*entity.MediaBlob, error) {
+	stored := *blob
+	stored.RefCount = 1
+
+	err := conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"ref_count":  gorm.Expr("media_blobs.ref_count + 1"),
+				"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+			}),
+		}, clause.Returning{}).
+		Create(&stored).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *mediaBlobRepository) Reference(ctx context.Context, hash string) (bool, error) {
+	result := conn(ctx, r.db).Model(&entity.MediaBlob{}).
+		Where("hash = ?", hash).
+		Updates(map[string]interface{}{
+			"ref_count":  gorm.Expr("ref_count + 1"),
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *mediaBlobRepository) Release(ctx context.Context, hash string) (bool, error) {
+	var updated []entity.MediaBlob
+	err := conn(ctx, r.db).Model(&updated).
+		Clauses(clause.Returning{}).
+		Where("hash = ?", hash).
+		Updates(map[string]interface{}{
+			"ref_count":  gorm.Expr("ref_count - 1"),
+			"updated_at": time.Now(),
+		}).Error
+	if err != nil {
+		return false, err
+	}
+	if len(updated) == 0 {
+		return true, nil
+	}
+	if updated[0].RefCount > 0 {
+		return false, nil
+	}
+
+	// Only delete if nobody acquired it again in the meantime
+	result := conn(ctx, r.db).
+		Where("hash = ? AND ref_count <= 0", hash).
+		Delete(&entity.MediaBlob{})
+	return result.RowsAffected == 1, result.Error
+}
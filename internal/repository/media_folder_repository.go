@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MediaFolderRepository interface {
+	Create(ctx context.Context, folder *entity.MediaFolder) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.MediaFolder, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.MediaFolder, error)
+	ExistsByName(ctx context.Context, userID uuid.UUID, name string, excludeID *uuid.UUID) (bool, error)
+	Update(ctx context.Context, folder *entity.MediaFolder) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type mediaFolderRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaFolderRepository(db *gorm.DB) MediaFolderRepository {
+	return &mediaFolderRepository{db: db}
+}
+
+func (r *mediaFolderRepository) Create(ctx context.Context, folder *entity.MediaFolder) error {
+	return conn(ctx, r.db).Create(folder).Error
+}
+
+func (r *mediaFolderRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.MediaFolder, error) {
+	var folder entity.MediaFolder
+	err := r.withMediaCount(ctx).
+		Where("media_folders.id = ?", id).
+		First(&folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// FindByUserID lists a user's folders by name, with how much media each holds
+func (r *mediaFolderRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.MediaFolder, error) {
+	var folders []*entity.MediaFolder
+	err := r.withMediaCount(ctx).
+		Where("media_folders.user_id = ?", userID).
+		Order("media_folders.name ASC").
+		Find(&folders).Error
+	return folders, err
+}
+
+func (r *mediaFolderRepository) ExistsByName(ctx context.Context, userID uuid.UUID, name string, excludeID *uuid.UUID) (bool, error) {
+	var count int64
+	query := conn(ctx, r.db).Model(&entity.MediaFolder{}).
+		Where("user_id = ? AND LOWER(name) = LOWER(?)", userID, name)
+	if excludeID != nil {
+		query = query.Where("id <> ?", *excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *mediaFolderRepository) Update(ctx context.Context, folder *entity.MediaFolder) error {
+	return conn(ctx, r.db).Save(folder).Error
+}
+
+// Delete removes a folder, leaving its media unfiled
+func (r *mediaFolderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Media{}).
+			Where("folder_id = ?", id).
+			Update("folder_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.MediaFolder{}, id).Error
+	})
+}
+
+func (r *mediaFolderRepository) withMediaCount(ctx context.Context) *gorm.DB {
+	return conn(ctx, r.db).Model(&entity.MediaFolder{}).
+		Select("media_folders.*, (SELECT COUNT(*) FROM media WHERE media.folder_id = media_folders.id AND media.deleted_at IS NULL) AS media_count")
+}
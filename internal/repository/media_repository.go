@@ -2,21 +2,62 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
+// MediaFilter narrows FindAll; zero fields are ignored
+type MediaFilter struct {
+	MediaType  string
+	PostID     *uuid.UUID
+	UserID     *uuid.UUID
+	FolderID   *uuid.UUID
+	Unfiled    bool // only media outside any folder
+	IsFeatured *bool
+	Search     string   // matched against the original name, alt text and description
+	Tags       []string // media must have all of them
+	MinWidth   *int
+	MaxWidth   *int
+	MinHeight  *int
+	MaxHeight  *int
+	From       *time.Time // uploaded at or after
+	To         *time.Time // uploaded before
+}
+
+// MediaTag is a tag with the number of media carrying it
+type MediaTag struct {
+	Tag   string
+	Count int64
+}
+
 type MediaRepository interface {
 	Create(ctx context.Context, media *entity.Media) error
 	FindByID(ctx context.Context, id uuid.UUID) (*entity.Media, error)
-	FindAll(ctx context.Context, page, limit int, mediaType string, postID, userID *uuid.UUID, isFeatured *bool, sortBy, sortOrder string) ([]*entity.Media, int64, error)
+	FindAll(ctx context.Context, paging Paging, filter *MediaFilter) ([]*entity.Media, int64, error)
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*entity.Media, error)
 	FindByPostID(ctx context.Context, postID uuid.UUID) ([]*entity.Media, error)
 	FindFeaturedByPostID(ctx context.Context, postID uuid.UUID) (*entity.Media, error)
+	FindByFilePath(ctx context.Context, path string) ([]*entity.Media, error)
 	Update(ctx context.Context, media *entity.Media) error
+	UpdateVariants(ctx context.Context, id uuid.UUID, variants entity.MediaVariants) error
 	Delete(ctx context.Context, id uuid.UUID) error
 
+	// Maintenance
+	FindBatch(ctx context.Context, afterID uuid.UUID, limit int) ([]*entity.Media, error)
+	FindUnhashed(ctx context.Context, afterID uuid.UUID, limit int) ([]*entity.Media, error)
+	SetContent(ctx context.Context, id uuid.UUID, hash, path, url string) error
+
+	// Library
+	FindTags(ctx context.Context, userID *uuid.UUID) ([]MediaTag, error)
+	SetTags(ctx context.Context, id uuid.UUID, tags []string) error
+	MoveToFolder(ctx context.Context, ids []uuid.UUID, folderID *uuid.UUID) error
+	AttachToPost(ctx context.Context, ids []uuid.UUID, postID uuid.UUID) error
+
 	// Bulk operations
 	DeleteByPostID(ctx context.Context, postID uuid.UUID) error
 	CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error)
@@ -32,12 +73,12 @@ func NewMediaRepository(db *gorm.DB) MediaRepository {
 }
 
 func (r *mediaRepository) Create(ctx context.Context, media *entity.Media) error {
-	return r.db.WithContext(ctx).Create(media).Error
+	return conn(ctx, r.db).Create(media).Error
 }
 
 func (r *mediaRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Media, error) {
 	var media entity.Media
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("User").
 		Preload("Post").
 		Where("id = ?", id).
@@ -48,56 +89,97 @@ func (r *mediaRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.M
 	return &media, nil
 }
 
-func (r *mediaRepository) FindAll(ctx context.Context, page, limit int, mediaType string, postID, userID *uuid.UUID, isFeatured *bool, sortBy, sortOrder string) ([]*entity.Media, int64, error) {
+func (r *mediaRepository) FindAll(ctx context.Context, paging Paging, filter *MediaFilter) ([]*entity.Media, int64, error) {
 	var medias []*entity.Media
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&entity.Media{}).
+	query := conn(ctx, r.db).Model(&entity.Media{}).
 		Preload("User")
 
 	// Apply filters
-	if mediaType != "" {
-		query = query.Where("media_type = ?", mediaType)
+	if filter != nil {
+		query = applyMediaFilter(query, filter)
 	}
 
-	if postID != nil {
-		query = query.Where("post_id = ?", *postID)
+	if err := paging.count(query, &total); err != nil {
+		return nil, 0, err
 	}
 
-	if userID != nil {
-		query = query.Where("user_id = ?", *userID)
+	err := paging.apply(query, "media").Find(&medias).Error
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if isFeatured != nil {
-		query = query.Where("is_featured = ?", *isFeatured)
+	return reorder(paging, medias), total, nil
+}
+
+func applyMediaFilter(query *gorm.DB, filter *MediaFilter) *gorm.DB {
+	if filter.MediaType != "" {
+		query = query.Where("media_type = ?", filter.MediaType)
 	}
 
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if filter.PostID != nil {
+		query = query.Where("post_id = ?", *filter.PostID)
 	}
 
-	offset := (page - 1) * limit
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
 
-	// Default sorting
-	order := "created_at DESC"
-	if sortBy != "" {
-		if sortOrder == "" {
-			sortOrder = "DESC"
-		}
-		order = sortBy + " " + sortOrder
+	if filter.FolderID != nil {
+		query = query.Where("folder_id = ?", *filter.FolderID)
+	} else if filter.Unfiled {
+		query = query.Where("folder_id IS NULL")
 	}
 
-	err := query.Offset(offset).Limit(limit).Order(order).Find(&medias).Error
-	if err != nil {
-		return nil, 0, err
+	if filter.IsFeatured != nil {
+		query = query.Where("is_featured = ?", *filter.IsFeatured)
+	}
+
+	if filter.Search != "" {
+		search := "%" + filter.Search + "%"
+		query = query.Where("original_name ILIKE ? OR alt_text ILIKE ? OR description ILIKE ?", search, search, search)
+	}
+
+	if len(filter.Tags) > 0 {
+		query = query.Where("tags @> ?", pq.StringArray(filter.Tags))
+	}
+
+	// Media without dimensions never match a dimension filter
+	if filter.MinWidth != nil {
+		query = query.Where("width >= ?", *filter.MinWidth)
+	}
+	if filter.MaxWidth != nil {
+		query = query.Where("width <= ?", *filter.MaxWidth)
+	}
+	if filter.MinHeight != nil {
+		query = query.Where("height >= ?", *filter.MinHeight)
+	}
+	if filter.MaxHeight != nil {
+		query = query.Where("height <= ?", *filter.MaxHeight)
+	}
+
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
 	}
 
-	return medias, total, nil
+	return query
+}
+
+func (r *mediaRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*entity.Media, error) {
+	var medias []*entity.Media
+	err := conn(ctx, r.db).
+		Where("id IN ?", ids).
+		Find(&medias).Error
+	return medias, err
 }
 
 func (r *mediaRepository) FindByPostID(ctx context.Context, postID uuid.UUID) ([]*entity.Media, error) {
 	var medias []*entity.Media
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("User").
 		Where("post_id = ?", postID).
 		Order("created_at DESC").
@@ -105,11 +187,12 @@ func (r *mediaRepository) FindByPostID(ctx context.Context, postID uuid.UUID) ([
 	return medias, err
 }
 
+// FindFeaturedByPostID returns the media the post's featured_media_id points at
 func (r *mediaRepository) FindFeaturedByPostID(ctx context.Context, postID uuid.UUID) (*entity.Media, error) {
 	var media entity.Media
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("User").
-		Where("post_id = ? AND is_featured = ?", postID, true).
+		Where("id = (?)", r.db.Model(&entity.Post{}).Select("featured_media_id").Where("id = ?", postID)).
 		First(&media).Error
 	if err != nil {
 		return nil, err
@@ -117,21 +200,110 @@ func (r *mediaRepository) FindFeaturedByPostID(ctx context.Context, postID uuid.
 	return &media, nil
 }
 
+// FindByFilePath returns the media whose original or one of its variants
+// is stored at path, with their posts. Deduplicated uploads share files,
+// so there can be several.
+func (r *mediaRepository) FindByFilePath(ctx context.Context, path string) ([]*entity.Media, error) {
+	variant, err := json.Marshal([]map[string]string{{"path": path}})
+	if err != nil {
+		return nil, err
+	}
+
+	var medias []*entity.Media
+	err = conn(ctx, r.db).
+		Preload("Post").
+		Where("path = ? OR variants @> ?", path, string(variant)).
+		Find(&medias).Error
+	return medias, err
+}
+
 func (r *mediaRepository) Update(ctx context.Context, media *entity.Media) error {
-	return r.db.WithContext(ctx).Save(media).Error
+	return conn(ctx, r.db).Save(media).Error
+}
+
+// UpdateVariants only touches the variants column, so a concurrent metadata
+// edit isn't overwritten by the background variant job
+func (r *mediaRepository) UpdateVariants(ctx context.Context, id uuid.UUID, variants entity.MediaVariants) error {
+	return conn(ctx, r.db).Model(&entity.Media{}).
+		Where("id = ?", id).
+		UpdateColumn("variants", variants).Error
 }
 
 func (r *mediaRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&entity.Media{}, id).Error
+	return conn(ctx, r.db).Delete(&entity.Media{}, id).Error
+}
+
+// FindBatch pages through every media row by ID
+func (r *mediaRepository) FindBatch(ctx context.Context, afterID uuid.UUID, limit int) ([]*entity.Media, error) {
+	var medias []*entity.Media
+	err := conn(ctx, r.db).
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&medias).Error
+	return medias, err
+}
+
+// FindUnhashed pages through media stored before content hashing, by ID
+func (r *mediaRepository) FindUnhashed(ctx context.Context, afterID uuid.UUID, limit int) ([]*entity.Media, error) {
+	var medias []*entity.Media
+	err := conn(ctx, r.db).
+		Where("content_hash = '' AND id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&medias).Error
+	return medias, err
+}
+
+// SetContent points a media row at a blob
+func (r *mediaRepository) SetContent(ctx context.Context, id uuid.UUID, hash, path, url string) error {
+	return conn(ctx, r.db).Model(&entity.Media{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"content_hash": hash,
+			"path":         path,
+			"url":          url,
+		}).Error
+}
+
+// FindTags lists the tags in use, most used first
+func (r *mediaRepository) FindTags(ctx context.Context, userID *uuid.UUID) ([]MediaTag, error) {
+	var tags []MediaTag
+	query := conn(ctx, r.db).Model(&entity.Media{}).
+		Select("unnest(tags) AS tag, COUNT(*) AS count")
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	err := query.Group("tag").Order("count DESC, tag").Scan(&tags).Error
+	return tags, err
+}
+
+func (r *mediaRepository) SetTags(ctx context.Context, id uuid.UUID, tags []string) error {
+	return conn(ctx, r.db).Model(&entity.Media{}).
+		Where("id = ?", id).
+		Update("tags", pq.StringArray(tags)).Error
+}
+
+// MoveToFolder files media into a folder, or out of any with a nil folderID
+func (r *mediaRepository) MoveToFolder(ctx context.Context, ids []uuid.UUID, folderID *uuid.UUID) error {
+	return conn(ctx, r.db).Model(&entity.Media{}).
+		Where("id IN ?", ids).
+		Update("folder_id", folderID).Error
+}
+
+func (r *mediaRepository) AttachToPost(ctx context.Context, ids []uuid.UUID, postID uuid.UUID) error {
+	return conn(ctx, r.db).Model(&entity.Media{}).
+		Where("id IN ?", ids).
+		Update("post_id", postID).Error
 }
 
 func (r *mediaRepository) DeleteByPostID(ctx context.Context, postID uuid.UUID) error {
-	return r.db.WithContext(ctx).Where("post_id = ?", postID).Delete(&entity.Media{}).Error
+	return conn(ctx, r.db).Where("post_id = ?", postID).Delete(&entity.Media{}).Error
 }
 
 func (r *mediaRepository) CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&entity.Media{}).
 		Where("post_id = ?", postID).
 		Count(&count).Error
@@ -140,7 +312,7 @@ func (r *mediaRepository) CountByPostID(ctx context.Context, postID uuid.UUID) (
 
 func (r *mediaRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&entity.Media{}).
 		Where("user_id = ?", userID).
 		Count(&count).Error
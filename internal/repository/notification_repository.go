@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	CreateBatch(ctx context.Context, notifications []*entity.Notification) error
+	FindByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool, page, limit int) ([]*entity.Notification, int64, error)
+	CountUnread(ctx context.Context, userID uuid.UUID) (int64, error)
+	MarkRead(ctx context.Context, id, userID uuid.UUID) (bool, error)
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// Preferences; users without a stored row get the defaults
+	FindPreferences(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*entity.NotificationPreference, error)
+	SavePreference(ctx context.Context, pref *entity.NotificationPreference) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) CreateBatch(ctx context.Context, notifications []*entity.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).CreateInBatches(notifications, 100).Error
+}
+
+func (r *notificationRepository) FindByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool, page, limit int) ([]*entity.Notification, int64, error) {
+	var notifications []*entity.Notification
+	var total int64
+
+	query := conn(ctx, r.db).Model(&entity.Notification{}).
+		Where("user_id = ?", userID)
+
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.
+		Preload("Actor").
+		Preload("Post").
+		Offset(offset).Limit(limit).Order("created_at DESC").
+		Find(&notifications).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead keeps the original read_at of already-read notifications
+func (r *notificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		UpdateColumn("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result := conn(ctx, r.db).
+		Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		UpdateColumn("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) FindPreferences(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*entity.NotificationPreference, error) {
+	prefs := make(map[uuid.UUID]*entity.NotificationPreference, len(userIDs))
+	if len(userIDs) == 0 {
+		return prefs, nil
+	}
+
+	var stored []*entity.NotificationPreference
+	err := conn(ctx, r.db).
+		Where("user_id IN ?", userIDs).
+		Find(&stored).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pref := range stored {
+		prefs[pref.UserID] = pref
+	}
+	for _, id := range userIDs {
+		if _, ok := prefs[id]; !ok {
+			prefs[id] = entity.DefaultNotificationPreference(id)
+		}
+	}
+
+	return prefs, nil
+}
+
+func (r *notificationRepository) SavePreference(ctx context.Context, pref *entity.NotificationPreference) error {
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(pref).Error
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
+	"gorm.io/gorm"
+)
+
+// Paging selects the rows a listing returns, ordered by (SortBy, id). By
+// default it skips (Page-1)*Limit rows; with Keyset it returns Limit+1
+// rows after Cursor, or before it for a prev cursor, from the top when
+// Cursor is nil. The extra row tells pagination.Trim whether there is
+// more. Rows come in display order either way.
+type Paging struct {
+	Page      int
+	Limit     int
+	SortBy    string // column, created_at by default; callers check it against the sort options
+	SortOrder string // "asc" or "desc" (the default)
+	Keyset    bool
+	Cursor    *pagination.Keyset
+	SkipTotal bool // keyset listings only: don't count the matching rows
+}
+
+// backward reports whether rows are fetched in reverse, for a prev cursor
+func (p Paging) backward() bool {
+	return p.Keyset && p.Cursor != nil && p.Cursor.Prev
+}
+
+// count counts the rows query matches, unless the listing opted out
+func (p Paging) count(query *gorm.DB, total *int64) error {
+	if p.Keyset && p.SkipTotal {
+		return nil
+	}
+	return query.Count(total).Error
+}
+
+// apply orders query and selects the page; table qualifies the columns.
+// Results of a backward query must go through reorder.
+func (p Paging) apply(query *gorm.DB, table string) *gorm.DB {
+	sortBy := p.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	return p.applyOn(query, table+"."+sortBy, table+".id")
+}
+
+// applyOn is apply for listings sorted by an expression rather than a
+// column of their table
+func (p Paging) applyOn(query *gorm.DB, column, id string) *gorm.DB {
+	desc := !strings.EqualFold(p.SortOrder, "asc")
+	if p.backward() {
+		desc = !desc
+	}
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	query = query.Order(column + " " + dir).Order(id + " " + dir)
+	if !p.Keyset {
+		return query.Offset((p.Page - 1) * p.Limit).Limit(p.Limit)
+	}
+	query = query.Limit(p.Limit + 1)
+	if p.Cursor != nil {
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", column, id, cmp), p.Cursor.Value, p.Cursor.ID)
+	}
+	return query
+}
+
+// reorder puts rows fetched backwards into display order
+func reorder[T any](p Paging, rows []T) []T {
+	if p.backward() {
+		slices.Reverse(rows)
+	}
+	return rows
+}
@@ -6,15 +6,30 @@ import (
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostRepository interface {
 	Create(ctx context.Context, post *entity.Post) error
 	FindByID(ctx context.Context, id uuid.UUID) (*entity.Post, error)
 	FindBySlug(ctx context.Context, slug string) (*entity.Post, error)
-	FindAll(ctx context.Context, page, limit int, search, status string, categoryID *uuid.UUID, tag string, authorID *uuid.UUID, sortBy, sortOrder string) ([]*entity.Post, int64, error)
-	Update(ctx context.Context, post *entity.Post) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	// FindVisibleByID and FindVisibleBySlug only find posts viewer may see
+	FindVisibleByID(ctx context.Context, id uuid.UUID, viewer PostViewer) (*entity.Post, error)
+	FindVisibleBySlug(ctx context.Context, slug string, viewer PostViewer) (*entity.Post, error)
+	FindAll(ctx context.Context, viewer PostViewer, paging Paging, search, status string, categoryID *uuid.UUID, tag string, authorID *uuid.UUID) ([]*entity.Post, int64, error)
+	Update(ctx context.Context, post *entity.Post) error // ErrVersionConflict unless still at post.Version
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
+
+    // Published posts from followed authors/categories, keyset-paginated
+    FindFeed(ctx context.Context, userID uuid.UUID, paging Paging) ([]*entity.Post, error)
+
+    // Atomically adds buffered views without touching updated_at
+    IncrementViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error
+
+    // Featured media
+    SetFeaturedMedia(ctx context.Context, postID uuid.UUID, media *entity.Media) error
+    UnsetFeaturedMedia(ctx context.Context, mediaIDs []uuid.UUID) error
+    MigrateFeaturedMedia(ctx context.Context) (*FeaturedMediaMigration, error)
 
     // 👇 For Dynamic Counting Posts
     CountByAuthorID(ctx context.Context, authorID uuid.UUID) (int64, error)
@@ -23,6 +38,13 @@ type PostRepository interface {
     CountByCategoryIDs(ctx context.Context, categoryIDs []uuid.UUID) (map[uuid.UUID]int64, error)
 }
 
+// FeaturedMediaMigration counts the posts MigrateFeaturedMedia linked
+type FeaturedMediaMigration struct {
+	FromURLs  int64 // by the media URL in featured_image
+	FromFlags int64 // by media flagged is_featured
+	External  int64 // featured_image matches no media; kept as an external URL
+}
+
 type postRepository struct {
     db *gorm.DB
 }
@@ -32,28 +54,31 @@ func NewPostRepository(db *gorm.DB) PostRepository {
 }
 
 func (r *postRepository) Create(ctx context.Context, post *entity.Post) error {
-    return r.db.WithContext(ctx).Create(post).Error
+    return conn(ctx, r.db).Create(post).Error
 }
 
 func (r *postRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Post, error) {
-    var post entity.Post
-    err := r.db.WithContext(ctx).
-        Preload("Author").
-        Preload("Category").
-        Where("id = ?", id).
-        First(&post).Error
-    if err != nil {
-        return nil, err
-    }
-    return &post, nil
+    return r.findOne(conn(ctx, r.db).Where("id = ?", id))
 }
 
 func (r *postRepository) FindBySlug(ctx context.Context, slug string) (*entity.Post, error) {
+    return r.findOne(conn(ctx, r.db).Where("slug = ?", slug))
+}
+
+func (r *postRepository) FindVisibleByID(ctx context.Context, id uuid.UUID, viewer PostViewer) (*entity.Post, error) {
+    return r.findOne(viewer.scope(conn(ctx, r.db).Where("posts.id = ?", id)))
+}
+
+func (r *postRepository) FindVisibleBySlug(ctx context.Context, slug string, viewer PostViewer) (*entity.Post, error) {
+    return r.findOne(viewer.scope(conn(ctx, r.db).Where("posts.slug = ?", slug)))
+}
+
+func (r *postRepository) findOne(query *gorm.DB) (*entity.Post, error) {
     var post entity.Post
-    err := r.db.WithContext(ctx).
+    err := query.
         Preload("Author").
         Preload("Category").
-        Where("slug = ?", slug).
+        Preload("FeaturedMedia").
         First(&post).Error
     if err != nil {
         return nil, err
@@ -61,13 +86,14 @@ func (r *postRepository) FindBySlug(ctx context.Context, slug string) (*entity.P
     return &post, nil
 }
 
-func (r *postRepository) FindAll(ctx context.Context, page, limit int, search, status string, categoryID *uuid.UUID, tag string, authorID *uuid.UUID, sortBy, sortOrder string) ([]*entity.Post, int64, error) {
+func (r *postRepository) FindAll(ctx context.Context, viewer PostViewer, paging Paging, search, status string, categoryID *uuid.UUID, tag string, authorID *uuid.UUID) ([]*entity.Post, int64, error) {
 	var posts []*entity.Post
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&entity.Post{}).
+	query := viewer.scope(conn(ctx, r.db).Model(&entity.Post{})).
 		Preload("Author").
-		Preload("Category")
+		Preload("Category").
+		Preload("FeaturedMedia")
 
 	// Apply filters
 	if search != "" {
@@ -94,41 +120,204 @@ func (r *postRepository) FindAll(ctx context.Context, page, limit int, search, s
 			Where("tags.name ILIKE ?", "%"+tag+"%")
 	}
 
-	if err := query.Count(&total).Error; err != nil {
+	if err := paging.count(query, &total); err != nil {
 		return nil, 0, err
 	}
 
-	offset := (page - 1) * limit
-
-	// Sorting
-	order := "created_at DESC"
-	if sortBy != "" {
-		if sortOrder == "" {
-			sortOrder = "DESC"
-		}
-		order = sortBy + " " + sortOrder
+	err := paging.apply(query, "posts").Find(&posts).Error
+	if err != nil {
+		return nil, 0, err
 	}
 
-	err := query.Offset(offset).Limit(limit).Order(order).Find(&posts).Error
+	return reorder(paging, posts), total, nil
+}
+
+// feedSortKey is the feed ordering column; legacy published rows may lack published_at
+const feedSortKey = "COALESCE(posts.published_at, posts.created_at)"
+
+// FindFeed returns a keyset page of published posts, newest first. The
+// feed has no total, so paging.SkipTotal is implied.
+func (r *postRepository) FindFeed(ctx context.Context, userID uuid.UUID, paging Paging) ([]*entity.Post, error) {
+	var posts []*entity.Post
+
+	query := conn(ctx, r.db).Model(&entity.Post{}).
+		Preload("Author").
+		Preload("Category").
+		Preload("FeaturedMedia").
+		Where("posts.status = ?", entity.PostStatusPublished).
+		Where(
+			r.db.Where("posts.author_id IN (?)",
+				r.db.Model(&entity.UserFollow{}).Select("following_id").Where("follower_id = ?", userID),
+			).Or("posts.category_id IN (?)",
+				r.db.Model(&entity.CategoryFollow{}).Select("category_id").Where("user_id = ?", userID),
+			),
+		)
+
+	err := paging.applyOn(query, feedSortKey, "posts.id").Find(&posts).Error
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	return posts, total, nil
+	return reorder(paging, posts), nil
 }
 
 func (r *postRepository) Update(ctx context.Context, post *entity.Post) error {
-    return r.db.WithContext(ctx).Save(post).Error
+    return updateVersioned(conn(ctx, r.db), post, &post.Version)
 }
 
-func (r *postRepository) Delete(ctx context.Context, id uuid.UUID) error {
-    return r.db.WithContext(ctx).Delete(&entity.Post{}, id).Error
+func (r *postRepository) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+    return deleteVersioned(conn(ctx, r.db), &entity.Post{}, id, version)
+}
+
+func (r *postRepository) IncrementViewCounts(ctx context.Context, counts map[uuid.UUID]int64) error {
+    if len(counts) == 0 {
+        return nil
+    }
+
+    return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+        for postID, n := range counts {
+            // UpdateColumn skips hooks and the updated_at timestamp
+            err := tx.Model(&entity.Post{}).
+                Where("id = ?", postID).
+                UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error
+            if err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+// SetFeaturedMedia makes media the post's only featured item, attaching it
+// if needed, and mirrors its URL into featured_image. A nil media clears
+// the featured item.
+func (r *postRepository) SetFeaturedMedia(ctx context.Context, postID uuid.UUID, media *entity.Media) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Lock the post so concurrent switches apply one after the other
+		var post entity.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", postID).
+			First(&post).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entity.Media{}).
+			Where("post_id = ? AND is_featured = ?", postID, true).
+			Update("is_featured", false).Error; err != nil {
+			return err
+		}
+
+		var mediaID *uuid.UUID
+		featuredImage := ""
+		if media != nil {
+			if err := tx.Model(&entity.Media{}).
+				Where("id = ?", media.ID).
+				Updates(map[string]interface{}{"post_id": postID, "is_featured": true}).Error; err != nil {
+				return err
+			}
+			mediaID, featuredImage = &media.ID, media.URL
+		}
+
+		return tx.Model(&entity.Post{}).
+			Where("id = ?", postID).
+			Updates(map[string]interface{}{
+				"featured_media_id": mediaID,
+				"featured_image":    featuredImage,
+				"version":           gorm.Expr("version + 1"),
+			}).Error
+	})
+}
+
+// UnsetFeaturedMedia stops media from being featured, for when it is
+// deleted or moved to another post
+func (r *postRepository) UnsetFeaturedMedia(ctx context.Context, mediaIDs []uuid.UUID) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Post{}).
+			Where("featured_media_id IN ?", mediaIDs).
+			Updates(map[string]interface{}{
+				"featured_media_id": nil,
+				"featured_image":    "",
+				"version":           gorm.Expr("version + 1"),
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&entity.Media{}).
+			Where("id IN ? AND is_featured = ?", mediaIDs, true).
+			Update("is_featured", false).Error
+	})
+}
+
+// MigrateFeaturedMedia links posts from before featured_media_id to their
+// media: first by the URL in featured_image, then, for posts without one,
+// by the most recently updated media flagged is_featured. Afterwards only
+// linked media keeps the flag. Safe to run again.
+func (r *postRepository) MigrateFeaturedMedia(ctx context.Context) (*FeaturedMediaMigration, error) {
+	var result FeaturedMediaMigration
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// The post's own media wins over unattached uploads of the same file
+		byURL := tx.Exec(`UPDATE posts SET featured_media_id = (
+				SELECT m.id FROM media m
+				WHERE m.url = posts.featured_image AND m.deleted_at IS NULL
+					AND (m.post_id = posts.id OR m.post_id IS NULL)
+				ORDER BY m.post_id IS NULL, m.created_at
+				LIMIT 1)
+			WHERE featured_media_id IS NULL AND featured_image <> '' AND deleted_at IS NULL
+				AND EXISTS (
+					SELECT 1 FROM media m
+					WHERE m.url = posts.featured_image AND m.deleted_at IS NULL
+						AND (m.post_id = posts.id OR m.post_id IS NULL))`)
+		if byURL.Error != nil {
+			return byURL.Error
+		}
+		result.FromURLs = byURL.RowsAffected
+
+		if err := tx.Exec(`UPDATE media SET post_id = posts.id
+			FROM posts
+			WHERE posts.featured_media_id = media.id AND media.post_id IS NULL`).Error; err != nil {
+			return err
+		}
+
+		byFlag := tx.Exec(`UPDATE posts SET featured_media_id = m.id, featured_image = m.url
+			FROM (
+				SELECT DISTINCT ON (post_id) id, post_id, url FROM media
+				WHERE is_featured AND post_id IS NOT NULL AND deleted_at IS NULL
+				ORDER BY post_id, updated_at DESC
+			) AS m
+			WHERE posts.id = m.post_id AND posts.featured_media_id IS NULL
+				AND posts.featured_image = '' AND posts.deleted_at IS NULL`)
+		if byFlag.Error != nil {
+			return byFlag.Error
+		}
+		result.FromFlags = byFlag.RowsAffected
+
+		if err := tx.Exec(`UPDATE media SET is_featured = NOT is_featured
+			WHERE deleted_at IS NULL AND is_featured <> EXISTS (
+				SELECT 1 FROM posts
+				WHERE posts.featured_media_id = media.id AND posts.id = media.post_id)`).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&entity.Post{}).
+			Where("featured_media_id IS NULL AND featured_image <> ''").
+			Count(&result.External).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
 // 👇 NEW METHOD: Count posts by single author
 func (r *postRepository) CountByAuthorID(ctx context.Context, authorID uuid.UUID) (int64, error) {
     var count int64
-    err := r.db.WithContext(ctx).
+    err := conn(ctx, r.db).
         Model(&entity.Post{}).
         Where("author_id = ?", authorID).
         Count(&count).Error
@@ -147,7 +336,7 @@ func (r *postRepository) CountByAuthorIDs(ctx context.Context, authorIDs []uuid.
     }
 
     var results []Result
-    err := r.db.WithContext(ctx).
+    err := conn(ctx, r.db).
         Model(&entity.Post{}).
         Select("author_id, COUNT(*) as count").
         Where("author_id IN ?", authorIDs).
@@ -186,7 +375,7 @@ func (r *postRepository) CountByCategoryIDs(ctx context.Context, categoryIDs []u
 	}
 
 	var results []Result
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Model(&entity.Post{}).
 		Select("category_id, COUNT(*) as count").
 		Where("category_id IN ?", categoryIDs).
@@ -216,7 +405,7 @@ func (r *postRepository) CountByCategoryIDs(ctx context.Context, categoryIDs []u
 // 👇 NEW METHOD: Count posts by category
 func (r *postRepository) CountByCategoryID(ctx context.Context, categoryID uuid.UUID) (int64, error) {
     var count int64
-    err := r.db.WithContext(ctx).
+    err := conn(ctx, r.db).
         Model(&entity.Post{}).
         Where("category_id = ?", categoryID).
         Count(&count).Error
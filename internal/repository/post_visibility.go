@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostViewer is who posts are read for; it decides which of them the
+// query may return. Anonymous readers see published posts, authors also
+// their own drafts and archived posts, and admins see everything.
+type PostViewer struct {
+	UserID *uuid.UUID // nil for anonymous readers
+	Admin  bool
+}
+
+// PostViewerFor returns the viewer of user, who may be nil
+func PostViewerFor(user *entity.User) PostViewer {
+	if user == nil {
+		return PostViewer{}
+	}
+	id := user.ID
+	return PostViewer{UserID: &id, Admin: user.IsAdmin()}
+}
+
+// scope restricts query to the posts v may see
+func (v PostViewer) scope(query *gorm.DB) *gorm.DB {
+	switch {
+	case v.Admin:
+		return query
+	case v.UserID != nil:
+		return query.Where("posts.status = ? OR posts.author_id = ?", entity.PostStatusPublished, *v.UserID)
+	default:
+		return query.Where("posts.status = ?", entity.PostStatusPublished)
+	}
+}
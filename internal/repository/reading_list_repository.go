@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReadingListRepository interface {
+	Create(ctx context.Context, list *entity.ReadingList) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.ReadingList, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID, publicOnly bool) ([]*entity.ReadingList, error)
+	Update(ctx context.Context, list *entity.ReadingList) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Items
+	AddItem(ctx context.Context, item *entity.ReadingListItem) error
+	RemoveItem(ctx context.Context, listID, postID uuid.UUID) (bool, error)
+	FindItem(ctx context.Context, listID, postID uuid.UUID) (*entity.ReadingListItem, error)
+	MaxPosition(ctx context.Context, listID uuid.UUID) (int, error)
+	ReorderItems(ctx context.Context, listID uuid.UUID, postIDs []uuid.UUID) error
+	CountItemsByListIDs(ctx context.Context, listIDs []uuid.UUID) (map[uuid.UUID]int64, error)
+}
+
+type readingListRepository struct {
+	db *gorm.DB
+}
+
+func NewReadingListRepository(db *gorm.DB) ReadingListRepository {
+	return &readingListRepository{db: db}
+}
+
+func (r *readingListRepository) Create(ctx context.Context, list *entity.ReadingList) error {
+	return conn(ctx, r.db).Create(list).Error
+}
+
+// FindByID preloads items in order; soft-deleted posts come back as a nil Post
+func (r *readingListRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.ReadingList, error) {
+	var list entity.ReadingList
+	err := conn(ctx, r.db).
+		Preload("User").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC, created_at ASC")
+		}).
+		Preload("Items.Post").
+		Preload("Items.Post.Author").
+		Preload("Items.Post.Category").
+		Where("id = ?", id).
+		First(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *readingListRepository) FindByUserID(ctx context.Context, userID uuid.UUID, publicOnly bool) ([]*entity.ReadingList, error) {
+	var lists []*entity.ReadingList
+
+	query := conn(ctx, r.db).Where("user_id = ?", userID)
+	if publicOnly {
+		query = query.Where("is_public = ?", true)
+	}
+
+	err := query.Order("created_at DESC").Find(&lists).Error
+	return lists, err
+}
+
+func (r *readingListRepository) Update(ctx context.Context, list *entity.ReadingList) error {
+	return conn(ctx, r.db).Omit("Items", "User").Save(list).Error
+}
+
+func (r *readingListRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("reading_list_id = ?", id).Delete(&entity.ReadingListItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&entity.ReadingList{}, id).Error
+	})
+}
+
+func (r *readingListRepository) AddItem(ctx context.Context, item *entity.ReadingListItem) error {
+	return conn(ctx, r.db).Create(item).Error
+}
+
+func (r *readingListRepository) RemoveItem(ctx context.Context, listID, postID uuid.UUID) (bool, error) {
+	result := conn(ctx, r.db).
+		Where("reading_list_id = ? AND post_id = ?", listID, postID).
+		Delete(&entity.ReadingListItem{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *readingListRepository) FindItem(ctx context.Context, listID, postID uuid.UUID) (*entity.ReadingListItem, error) {
+	var item entity.ReadingListItem
+	err := conn(ctx, r.db).
+		Where("reading_list_id = ? AND post_id = ?", listID, postID).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *readingListRepository) MaxPosition(ctx context.Context, listID uuid.UUID) (int, error) {
+	var max *int
+	err := conn(ctx, r.db).
+		Model(&entity.ReadingListItem{}).
+		Select("MAX(position)").
+		Where("reading_list_id = ?", listID).
+		Scan(&max).Error
+	if err != nil || max == nil {
+		return -1, err
+	}
+	return *max, nil
+}
+
+// ReorderItems sets positions to match the order of postIDs
+func (r *readingListRepository) ReorderItems(ctx context.Context, listID uuid.UUID, postIDs []uuid.UUID) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for position, postID := range postIDs {
+			err := tx.Model(&entity.ReadingListItem{}).
+				Where("reading_list_id = ? AND post_id = ?", listID, postID).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *readingListRepository) CountItemsByListIDs(ctx context.Context, listIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	if len(listIDs) == 0 {
+		return make(map[uuid.UUID]int64), nil
+	}
+
+	type Result struct {
+		ReadingListID uuid.UUID
+		Count         int64
+	}
+
+	var results []Result
+	err := conn(ctx, r.db).
+		Model(&entity.ReadingListItem{}).
+		Select("reading_list_id, COUNT(*) as count").
+		Where("reading_list_id IN ?", listIDs).
+		Group("reading_list_id").
+		Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	countMap := make(map[uuid.UUID]int64)
+	for _, result := range results {
+		countMap[result.ReadingListID] = result.Count
+	}
+	for _, listID := range listIDs {
+		if _, exists := countMap[listID]; !exists {
+			countMap[listID] = 0
+		}
+	}
+
+	return countMap, nil
+}
@@ -27,12 +27,12 @@ func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
 }
 
 func (r *refreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
-    return r.db.WithContext(ctx).Create(token).Error
+    return conn(ctx, r.db).Create(token).Error
 }
 
 func (r *refreshTokenRepository) FindByToken(ctx context.Context, token string) (*entity.RefreshToken, error) {
     var refreshToken entity.RefreshToken
-    err := r.db.WithContext(ctx).
+    err := conn(ctx, r.db).
         Preload("User").
         Where("token = ?", token).
         First(&refreshToken).Error
@@ -44,28 +44,28 @@ func (r *refreshTokenRepository) FindByToken(ctx context.Context, token string)
 
 func (r *refreshTokenRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.RefreshToken, error) {
     var tokens []*entity.RefreshToken
-    err := r.db.WithContext(ctx).
+    err := conn(ctx, r.db).
         Where("user_id = ? AND is_revoked = ?", userID, false).
         Find(&tokens).Error
     return tokens, err
 }
 
 func (r *refreshTokenRepository) Revoke(ctx context.Context, token string) error {
-    return r.db.WithContext(ctx).
+    return conn(ctx, r.db).
         Model(&entity.RefreshToken{}).
         Where("token = ?", token).
         Update("is_revoked", true).Error
 }
 
 func (r *refreshTokenRepository) RevokeAllByUserID(ctx context.Context, userID uuid.UUID) error {
-    return r.db.WithContext(ctx).
+    return conn(ctx, r.db).
         Model(&entity.RefreshToken{}).
         Where("user_id = ?", userID).
         Update("is_revoked", true).Error
 }
 
 func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) error {
-    return r.db.WithContext(ctx).
+    return conn(ctx, r.db).
         Where("expires_at < ?", time.Now()).
         Delete(&entity.RefreshToken{}).Error
 }
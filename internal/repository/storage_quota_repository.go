@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StorageUsage is what one user stores, with their quota override if any.
+// Media is counted at its own size even when its file is shared with
+// another upload.
+type StorageUsage struct {
+	UserID      uuid.UUID
+	Username    string
+	Email       string
+	Role        entity.UserRole
+	MediaBytes  int64
+	MediaFiles  int64
+	AvatarBytes int64
+	MaxBytes    *int64
+	MaxFiles    *int64
+}
+
+type StorageQuotaRepository interface {
+	FindByUserID(ctx context.Context, userID uuid.UUID) (*entity.StorageQuota, error)
+	Upsert(ctx context.Context, quota *entity.StorageQuota) error
+	Delete(ctx context.Context, userID uuid.UUID) error
+
+	// LockUser holds the user's row until the surrounding transaction ends,
+	// so quota checks of concurrent uploads run one after the other
+	LockUser(ctx context.Context, userID uuid.UUID) error
+
+	// Usage
+	UsageByUserID(ctx context.Context, userID uuid.UUID) (*StorageUsage, error)
+	FindUsage(ctx context.Context, page, limit int) ([]StorageUsage, int64, error)
+}
+
+type storageQuotaRepository struct {
+	db *gorm.DB
+}
+
+func NewStorageQuotaRepository(db *gorm.DB) StorageQuotaRepository {
+	return &storageQuotaRepository{db: db}
+}
+
+func (r *storageQuotaRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*entity.StorageQuota, error) {
+	var quota entity.StorageQuota
+	err := conn(ctx, r.db).Where("user_id = ?", userID).First(&quota).Error
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func (r *storageQuotaRepository) Upsert(ctx context.Context, quota *entity.StorageQuota) error {
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"max_bytes", "max_files", "note", "updated_by", "updated_at"}),
+		}).
+		Create(quota).Error
+}
+
+func (r *storageQuotaRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	return conn(ctx, r.db).Where("user_id = ?", userID).Delete(&entity.StorageQuota{}).Error
+}
+
+func (r *storageQuotaRepository) LockUser(ctx context.Context, userID uuid.UUID) error {
+	var user entity.User
+	return conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id = ?", userID).
+		Take(&user).Error
+}
+
+func (r *storageQuotaRepository) UsageByUserID(ctx context.Context, userID uuid.UUID) (*StorageUsage, error) {
+	var usage StorageUsage
+	err := r.usageQuery(ctx, &userID).
+		Where("users.id = ?", userID).
+		Take(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// FindUsage lists users by what they store, largest first
+func (r *storageQuotaRepository) FindUsage(ctx context.Context, page, limit int) ([]StorageUsage, int64, error) {
+	var results []StorageUsage
+	var total int64
+
+	if err := conn(ctx, r.db).Model(&entity.User{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := r.usageQuery(ctx, nil).
+		Order("COALESCE(m.bytes, 0) + users.avatar_size DESC, users.id").
+		Offset(offset).
+		Limit(limit).
+		Scan(&results).Error
+	return results, total, err
+}
+
+// usageQuery joins each user's media totals and quota override, skipping
+// soft-deleted users and media
+func (r *storageQuotaRepository) usageQuery(ctx context.Context, userID *uuid.UUID) *gorm.DB {
+	media := r.db.Model(&entity.Media{}).
+		Select("user_id, SUM(size) AS bytes, COUNT(*) AS files").
+		Group("user_id")
+	if userID != nil {
+		media = media.Where("user_id = ?", *userID)
+	}
+
+	return conn(ctx, r.db).
+		Table("users").
+		Select("users.id AS user_id, users.username, users.email, users.role, users.avatar_size AS avatar_bytes, "+
+			"COALESCE(m.bytes, 0) AS media_bytes, COALESCE(m.files, 0) AS media_files, "+
+			"storage_quotas.max_bytes, storage_quotas.max_files").
+		Joins("LEFT JOIN (?) AS m ON m.user_id = users.id", media).
+		Joins("LEFT JOIN storage_quotas ON storage_quotas.user_id = users.id").
+		Where("users.deleted_at IS NULL")
+}
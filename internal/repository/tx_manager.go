@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// TxManager runs several repository calls as one unit of work. The
+// transaction travels in the context: every repository method given that
+// context runs in it.
+type TxManager interface {
+	// WithinTx runs fn in a transaction that is committed if fn returns nil
+	// and rolled back otherwise. Calls nested in fn join the outer
+	// transaction, so services can use it without knowing their caller.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txKey is the context key of the current unit of work
+type txKey struct{}
+
+type unitOfWork struct {
+	tx            *gorm.DB
+	mu            sync.Mutex
+	afterCommit   []func(ctx context.Context)
+	afterRollback []func(ctx context.Context)
+}
+
+type txManager struct {
+	db *gorm.DB
+}
+
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db}
+}
+
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*unitOfWork); ok {
+		return fn(ctx)
+	}
+
+	uow := &unitOfWork{}
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		uow.tx = tx
+		return fn(context.WithValue(ctx, txKey{}, uow))
+	})
+
+	hooks := uow.afterCommit
+	if err != nil {
+		hooks = uow.afterRollback
+	}
+	// Hooks get the caller's context, outside the finished transaction
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+	return err
+}
+
+// AfterCommit defers a side effect, such as deleting a file, until the
+// transaction in ctx commits; it is dropped on rollback. Outside a
+// transaction fn runs right away.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	uow, ok := ctx.Value(txKey{}).(*unitOfWork)
+	if !ok {
+		fn(ctx)
+		return
+	}
+	uow.mu.Lock()
+	defer uow.mu.Unlock()
+	uow.afterCommit = append(uow.afterCommit, fn)
+}
+
+// AfterRollback registers a compensating action, such as deleting a file
+// stored for the transaction, to run if the transaction in ctx rolls back.
+// Outside a transaction it is ignored.
+func AfterRollback(ctx context.Context, fn func(ctx context.Context)) {
+	uow, ok := ctx.Value(txKey{}).(*unitOfWork)
+	if !ok {
+		return
+	}
+	uow.mu.Lock()
+	defer uow.mu.Unlock()
+	uow.afterRollback = append(uow.afterRollback, fn)
+}
+
+// conn returns the transaction carried by ctx, or db outside of one
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if uow, ok := ctx.Value(txKey{}).(*unitOfWork); ok {
+		return uow.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
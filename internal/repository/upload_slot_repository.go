@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type UploadSlotRepository interface {
+	Create(ctx context.Context, slot *entity.UploadSlot) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.UploadSlot, error)
+	FindExpired(ctx context.Context, now time.Time, limit int) ([]*entity.UploadSlot, error)
+
+	// State changes only apply to pending slots; they report whether the
+	// slot was still pending, so completion and expiry cannot both win
+	MarkCompleted(ctx context.Context, id, mediaID uuid.UUID) (bool, error)
+	MarkExpired(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+type uploadSlotRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadSlotRepository(db *gorm.DB) UploadSlotRepository {
+	return &uploadSlotRepository{db: db}
+}
+
+func (r *uploadSlotRepository) Create(ctx context.Context, slot *entity.UploadSlot) error {
+	return conn(ctx, r.db).Create(slot).Error
+}
+
+func (r *uploadSlotRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.UploadSlot, error) {
+	var slot entity.UploadSlot
+	err := conn(ctx, r.db).Where("id = ?", id).First(&slot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+func (r *uploadSlotRepository) FindExpired(ctx context.Context, now time.Time, limit int) ([]*entity.UploadSlot, error) {
+	var slots []*entity.UploadSlot
+	err := conn(ctx, r.db).
+		Where("status = ? AND expires_at < ?", entity.UploadSlotPending, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&slots).Error
+	return slots, err
+}
+
+func (r *uploadSlotRepository) MarkCompleted(ctx context.Context, id, mediaID uuid.UUID) (bool, error) {
+	return r.transition(ctx, id, map[string]interface{}{
+		"status":   entity.UploadSlotCompleted,
+		"media_id": mediaID,
+	})
+}
+
+func (r *uploadSlotRepository) MarkExpired(ctx context.Context, id uuid.UUID) (bool, error) {
+	return r.transition(ctx, id, map[string]interface{}{
+		"status": entity.UploadSlotExpired,
+	})
+}
+
+func (r *uploadSlotRepository) transition(ctx context.Context, id uuid.UUID, updates map[string]interface{}) (bool, error) {
+	result := conn(ctx, r.db).Model(&entity.UploadSlot{}).
+		Where("id = ? AND status = ?", id, entity.UploadSlotPending).
+		Updates(updates)
+	return result.RowsAffected == 1, result.Error
+}
@@ -13,9 +13,10 @@ type UserRepository interface {
 	FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
 	FindByEmail(ctx context.Context, email string) (*entity.User, error)
 	FindByUsername(ctx context.Context, username string) (*entity.User, error)
-	FindAll(ctx context.Context, page, limit int, search, role string, isActive *bool, sortBy, sortOrder string) ([]*entity.User, int64, error)
+	FindAll(ctx context.Context, paging Paging, search, role string, isActive *bool) ([]*entity.User, int64, error)
 	Update(ctx context.Context, user *entity.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	FindWithAvatar(ctx context.Context) ([]*entity.User, error)
 }
 
 type userRepository struct{
@@ -29,12 +30,12 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return conn(ctx, r.db).Create(user).Error
 }
 
 func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
     var user entity.User
-    err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+    err := conn(ctx, r.db).Where("id = ?", id).First(&user).Error
     if err != nil {
         return nil, err
     }
@@ -43,7 +44,7 @@ func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Us
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
     var user entity.User
-    err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+    err := conn(ctx, r.db).Where("email = ?", email).First(&user).Error
     if err != nil {
         return nil, err
     }
@@ -52,18 +53,18 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity
 
 func (r *userRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
     var user entity.User
-    err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
+    err := conn(ctx, r.db).Where("username = ?", username).First(&user).Error
     if err != nil {
         return nil, err
     }
     return &user, nil
 }
 
-func (r *userRepository) FindAll(ctx context.Context, page, limit int, search, role string, isActive *bool, sortBy, sortOrder string) ([]*entity.User, int64, error) {
+func (r *userRepository) FindAll(ctx context.Context, paging Paging, search, role string, isActive *bool) ([]*entity.User, int64, error) {
 	var users []*entity.User
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&entity.User{})
+	query := conn(ctx, r.db).Model(&entity.User{})
 
 	// Apply filters
 	if search != "" {
@@ -79,34 +80,32 @@ func (r *userRepository) FindAll(ctx context.Context, page, limit int, search, r
 	}
 
 	// Get total count
-	if err := query.Count(&total).Error; err != nil {
+	if err := paging.count(query, &total); err != nil {
 		return nil, 0, err
 	}
 
-	// Sorting (with defaults and basic validation)
-	if sortBy == "" {
-		sortBy = "created_at"
-	}
-	if sortOrder == "" {
-		sortOrder = "DESC"
-	}
-	// To prevent SQL injection, you could add a whitelist of allowed sortBy fields here (e.g., "created_at", "username", "email").
-	order := sortBy + " " + sortOrder
-
-	// Pagination
-	offset := (page - 1) * limit
-	err := query.Offset(offset).Limit(limit).Order(order).Find(&users).Error
+	err := paging.apply(query, "users").Find(&users).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
-	return users, total, nil
+	return reorder(paging, users), total, nil
 }
 
 func (r *userRepository) Update(ctx context.Context, user *entity.User) error {
-    return r.db.WithContext(ctx).Save(user).Error
+    return conn(ctx, r.db).Save(user).Error
 }
 
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
-    return r.db.WithContext(ctx).Delete(&entity.User{}, id).Error
-}
\ No newline at end of file
+    return conn(ctx, r.db).Delete(&entity.User{}, id).Error
+}
+
+// FindWithAvatar loads the ID and avatar of every user that has one
+func (r *userRepository) FindWithAvatar(ctx context.Context) ([]*entity.User, error) {
+	var users []*entity.User
+	err := conn(ctx, r.db).
+		Select("id", "avatar").
+		Where("avatar <> ''").
+		Find(&users).Error
+	return users, err
+}
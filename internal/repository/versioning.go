@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionConflict is returned when a versioned row (posts, categories,
+// comments) was changed or deleted since the caller loaded it
+var ErrVersionConflict = errors.New("version conflict")
+
+// updateVersioned saves every column of model if its row is still at
+// *version, and bumps the version. Associations are not saved: a loaded
+// Category or Author must not overwrite the foreign keys.
+func updateVersioned(db *gorm.DB, model interface{}, version *int64) error {
+	expected := *version
+	*version = expected + 1
+
+	result := db.Model(model).
+		Select("*").
+		Omit(clause.Associations).
+		Where("version = ?", expected).
+		Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		*version = expected
+	}
+	return result.Error
+}
+
+// deleteVersioned soft deletes the row id of model if it is still at version
+func deleteVersioned(db *gorm.DB, model interface{}, id uuid.UUID, version int64) error {
+	result := db.Where("version = ?", version).Delete(model, id)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return result.Error
+}
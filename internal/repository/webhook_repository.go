@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *entity.Webhook) error
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.Webhook, error)
+	FindAll(ctx context.Context) ([]*entity.Webhook, error)
+	FindActive(ctx context.Context) ([]*entity.Webhook, error)
+	Update(ctx context.Context, webhook *entity.Webhook) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Delivery queue
+	CreateDeliveries(ctx context.Context, deliveries []*entity.WebhookDelivery) error
+	FindDeliveryToSend(ctx context.Context, id uuid.UUID) (*entity.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, delivery *entity.WebhookDelivery, attempt *entity.WebhookDeliveryAttempt) error
+
+	// Delivery log
+	FindDeliveries(ctx context.Context, webhookID uuid.UUID, status string, page, limit int) ([]*entity.WebhookDelivery, int64, error)
+	FindDeliveryByID(ctx context.Context, id uuid.UUID) (*entity.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *entity.Webhook) error {
+	return conn(ctx, r.db).Create(webhook).Error
+}
+
+func (r *webhookRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Webhook, error) {
+	var webhook entity.Webhook
+	err := conn(ctx, r.db).Where("id = ?", id).First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) FindAll(ctx context.Context) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+	err := conn(ctx, r.db).Order("created_at DESC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) FindActive(ctx context.Context) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+	err := conn(ctx, r.db).Where("is_active = ?", true).Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) Update(ctx context.Context, webhook *entity.Webhook) error {
+	return conn(ctx, r.db).Save(webhook).Error
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return conn(ctx, r.db).Delete(&entity.Webhook{}, id).Error
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []*entity.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(deliveries).Error
+}
+
+// FindDeliveryToSend loads a delivery with its subscription. A soft-deleted
+// subscription comes back nil.
+func (r *webhookRepository) FindDeliveryToSend(ctx context.Context, id uuid.UUID) (*entity.WebhookDelivery, error) {
+	var delivery entity.WebhookDelivery
+	err := conn(ctx, r.db).
+		Preload("Webhook").
+		Where("id = ?", id).
+		First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// RecordAttempt saves the delivery's new state and appends to its attempt log
+func (r *webhookRepository) RecordAttempt(ctx context.Context, delivery *entity.WebhookDelivery, attempt *entity.WebhookDeliveryAttempt) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(delivery).
+			Select("status", "attempts", "next_attempt_at", "last_attempt_at", "response_code", "delivered_at").
+			Updates(delivery).Error
+	})
+}
+
+func (r *webhookRepository) FindDeliveries(ctx context.Context, webhookID uuid.UUID, status string, page, limit int) ([]*entity.WebhookDelivery, int64, error) {
+	var deliveries []*entity.WebhookDelivery
+	var total int64
+
+	query := conn(ctx, r.db).Model(&entity.WebhookDelivery{}).
+		Where("webhook_id = ?", webhookID)
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *webhookRepository) FindDeliveryByID(ctx context.Context, id uuid.UUID) (*entity.WebhookDelivery, error) {
+	var delivery entity.WebhookDelivery
+	err := conn(ctx, r.db).
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempt ASC")
+		}).
+		Where("id = ?", id).
+		First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
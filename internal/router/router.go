@@ -11,16 +11,25 @@ import (
 )
 
 type Router struct {
-	cfg             *config.Config
-	logger          *logger.Logger
-	jwtService      security.JWTService
-	userRepo        repository.UserRepository
-	authHandler     *handler.AuthHandler
-	userHandler     *handler.UserHandler
-	categoryHandler *handler.CategoryHandler
-	postHandler     *handler.PostHandler
-	commentHandler  *handler.CommentHandler
-	mediaHandler    *handler.MediaHandler 
+	cfg                 *config.Config
+	logger              *logger.Logger
+	jwtService          security.JWTService
+	userRepo            repository.UserRepository
+	authHandler         *handler.AuthHandler
+	userHandler         *handler.UserHandler
+	categoryHandler     *handler.CategoryHandler
+	postHandler         *handler.PostHandler
+	commentHandler      *handler.CommentHandler
+	mediaHandler        *handler.MediaHandler
+	analyticsHandler    *handler.AnalyticsHandler
+	bookmarkHandler     *handler.BookmarkHandler
+	readingListHandler  *handler.ReadingListHandler
+	followHandler       *handler.FollowHandler
+	notificationHandler *handler.NotificationHandler
+	streamHandler       *handler.StreamHandler
+	webhookHandler      *handler.WebhookHandler
+	jobHandler          *handler.JobHandler
+	storageQuotaHandler *handler.StorageQuotaHandler
 }
 
 func NewRouter(
@@ -33,19 +42,37 @@ func NewRouter(
 	categoryHandler *handler.CategoryHandler,
 	postHandler *handler.PostHandler,
 	commentHandler *handler.CommentHandler,
-	mediaHandler *handler.MediaHandler, 
+	mediaHandler *handler.MediaHandler,
+	analyticsHandler *handler.AnalyticsHandler,
+	bookmarkHandler *handler.BookmarkHandler,
+	readingListHandler *handler.ReadingListHandler,
+	followHandler *handler.FollowHandler,
+	notificationHandler *handler.NotificationHandler,
+	streamHandler *handler.StreamHandler,
+	webhookHandler *handler.WebhookHandler,
+	jobHandler *handler.JobHandler,
+	storageQuotaHandler *handler.StorageQuotaHandler,
 ) *Router {
 	return &Router{
-		cfg:             cfg,
-		logger:          logger,
-		jwtService:      jwtService,
-		userRepo:        userRepo,
-		authHandler:     authHandler,
-		userHandler:     userHandler,
-		categoryHandler: categoryHandler,
-		postHandler:     postHandler,
-		commentHandler:  commentHandler,
-		mediaHandler:    mediaHandler, 
+		cfg:                 cfg,
+		logger:              logger,
+		jwtService:          jwtService,
+		userRepo:            userRepo,
+		authHandler:         authHandler,
+		userHandler:         userHandler,
+		categoryHandler:     categoryHandler,
+		postHandler:         postHandler,
+		commentHandler:      commentHandler,
+		mediaHandler:        mediaHandler,
+		analyticsHandler:    analyticsHandler,
+		bookmarkHandler:     bookmarkHandler,
+		readingListHandler:  readingListHandler,
+		followHandler:       followHandler,
+		notificationHandler: notificationHandler,
+		streamHandler:       streamHandler,
+		webhookHandler:      webhookHandler,
+		jobHandler:          jobHandler,
+		storageQuotaHandler: storageQuotaHandler,
 	}
 }
 
@@ -59,8 +86,8 @@ func (r *Router) Setup() *gin.Engine {
 
 	// Global middlewares
 	router.Use(gin.Recovery())
-	router.Use(middleware.LoggerMiddleware(r.logger))      // Log semua request
-	router.Use(middleware.ErrorHandler(r.logger))          // Log semua error dengan detail
+	router.Use(middleware.LoggerMiddleware(r.logger)) // Log semua request
+	router.Use(middleware.ErrorHandler(r.logger))     // Log semua error dengan detail
 	router.Use(middleware.CORSMiddleware(r.cfg))
 	router.Use(middleware.SecurityHeadersMiddleware())
 
@@ -72,8 +99,22 @@ func (r *Router) Setup() *gin.Engine {
 		})
 	})
 
-	// Serve static files (uploads)
-	router.Static("/uploads", r.cfg.Storage.BasePath)
+	// Serve uploaded files; S3 serves its own. Media of unpublished posts
+	// needs a session or a signed URL.
+	if r.cfg.Storage.Driver != "s3" {
+		uploads := router.Group("/uploads")
+		uploads.Use(middleware.OptionalAuthMiddleware(r.jwtService, r.userRepo))
+		{
+			uploads.GET("/*filepath", r.mediaHandler.ServeFile)
+			uploads.HEAD("/*filepath", r.mediaHandler.ServeFile)
+		}
+	}
+
+	// Signed on-demand image resizing; outside /api/v1 so <img> tags can use it
+	router.GET("/media/:id/resize", r.mediaHandler.Resize)
+
+	// Signed upload URLs for storage that cannot take direct uploads
+	router.PUT("/upload-slots/:id", r.mediaHandler.ReceiveUpload)
 
 	// API routes
 	api := router.Group("/api/v1")
@@ -130,6 +171,33 @@ func (r *Router) Setup() *gin.Engine {
 			profile.PUT("/password", r.userHandler.ChangePassword)
 			profile.POST("/avatar", r.userHandler.UploadAvatar)
 			profile.DELETE("/avatar", r.userHandler.DeleteAvatar)
+			profile.GET("/storage", r.storageQuotaHandler.GetMine)
+
+			// Bookmarks
+			profile.GET("/bookmarks", r.bookmarkHandler.GetAll)
+			profile.POST("/bookmarks", r.bookmarkHandler.Create)
+			profile.DELETE("/bookmarks/:postId", r.bookmarkHandler.Delete)
+
+			// Reading lists
+			profile.GET("/lists", r.readingListHandler.GetMine)
+			profile.POST("/lists", r.readingListHandler.Create)
+			profile.GET("/lists/:id", r.readingListHandler.GetByID)
+			profile.PUT("/lists/:id", r.readingListHandler.Update)
+			profile.DELETE("/lists/:id", r.readingListHandler.Delete)
+			profile.POST("/lists/:id/items", r.readingListHandler.AddItem)
+			profile.PUT("/lists/:id/items/order", r.readingListHandler.Reorder)
+			profile.DELETE("/lists/:id/items/:postId", r.readingListHandler.RemoveItem)
+
+			// Followed categories
+			profile.GET("/following/categories", r.followHandler.GetFollowedCategories)
+		}
+
+		// Public reading lists with optional auth
+		lists := api.Group("/lists")
+		lists.Use(optionalAuthMiddleware)
+		{
+			lists.GET("/:id", r.readingListHandler.GetByID)
+			lists.GET("/user/:userId", r.readingListHandler.GetPublicByUserID)
 		}
 
 		// User management routes (Admin only)
@@ -141,6 +209,40 @@ func (r *Router) Setup() *gin.Engine {
 			users.POST("", middleware.RequireSuperAdmin(), r.userHandler.CreateUser)
 			users.PUT("/:id", middleware.RequireAdmin(), r.userHandler.UpdateUser)
 			users.DELETE("/:id", middleware.RequireSuperAdmin(), r.userHandler.DeleteUser)
+
+			// Follow authors
+			users.POST("/:id/follow", r.followHandler.FollowUser)
+			users.DELETE("/:id/follow", r.followHandler.UnfollowUser)
+			users.GET("/:id/followers", r.followHandler.GetFollowers)
+			users.GET("/:id/following", r.followHandler.GetFollowing)
+		}
+
+		// Notification center
+		notifications := api.Group("/notifications")
+		notifications.Use(authMiddleware)
+		{
+			notifications.GET("", r.notificationHandler.GetAll)
+			notifications.GET("/unread-count", r.notificationHandler.GetUnreadCount)
+			notifications.POST("/read-all", r.notificationHandler.MarkAllRead)
+			notifications.POST("/:id/read", r.notificationHandler.MarkRead)
+			notifications.GET("/preferences", r.notificationHandler.GetPreferences)
+			notifications.PUT("/preferences", r.notificationHandler.UpdatePreferences)
+		}
+
+		// Server-Sent Event streams; EventSource can't send headers, so the
+		// token may also come as ?access_token=
+		streams := api.Group("/streams")
+		streams.Use(middleware.TokenFromQuery())
+		{
+			streams.GET("/posts/:id/comments", optionalAuthMiddleware, r.streamHandler.PostComments)
+			streams.GET("/notifications", authMiddleware, r.streamHandler.Notifications)
+		}
+
+		// Personalized feed
+		feed := api.Group("/feed")
+		feed.Use(authMiddleware)
+		{
+			feed.GET("", r.postHandler.GetFeed)
 		}
 
 		// Category management routes (Admin only)
@@ -152,6 +254,14 @@ func (r *Router) Setup() *gin.Engine {
 			categoryManagement.DELETE("/:id", r.categoryHandler.Delete)
 		}
 
+		// Follow categories (any logged-in user)
+		categoryFollow := api.Group("/categories")
+		categoryFollow.Use(authMiddleware)
+		{
+			categoryFollow.POST("/:id/follow", r.followHandler.FollowCategory)
+			categoryFollow.DELETE("/:id/follow", r.followHandler.UnfollowCategory)
+		}
+
 		// Post management routes
 		postManagement := api.Group("/posts")
 		postManagement.Use(authMiddleware)
@@ -161,6 +271,10 @@ func (r *Router) Setup() *gin.Engine {
 			postManagement.DELETE("/:id", r.postHandler.Delete)
 			postManagement.POST("/:id/publish", middleware.RequireAdmin(), r.postHandler.Publish)
 			postManagement.POST("/:id/unpublish", middleware.RequireAdmin(), r.postHandler.Unpublish)
+
+			// Post analytics (author or admin)
+			postManagement.GET("/:id/analytics/daily", r.analyticsHandler.GetPostDailyViews)
+			postManagement.GET("/:id/analytics/referrers", r.analyticsHandler.GetPostReferrers)
 		}
 
 		// Comment management routes
@@ -180,10 +294,12 @@ func (r *Router) Setup() *gin.Engine {
 		// ðŸ'‡ ADD THESE MEDIA ROUTES (PROTECTED & PUBLIC)
 		// Media routes - Public (list & detail)
 		mediaRead := api.Group("/media")
-		mediaRead.Use(optionalAuthMiddleware)  // ✅ Optional auth!
+		mediaRead.Use(optionalAuthMiddleware) // ✅ Optional auth!
 		{
 			mediaRead.GET("", r.mediaHandler.GetAll)
 			mediaRead.GET("/:id", r.mediaHandler.GetByID)
+			mediaRead.GET("/:id/resize-url", r.mediaHandler.GetResizeURL)
+			mediaRead.GET("/:id/file-url", r.mediaHandler.GetFileURL)
 		}
 
 		// Media routes - Protected (upload, update, delete)
@@ -191,10 +307,52 @@ func (r *Router) Setup() *gin.Engine {
 		mediaProtected.Use(authMiddleware)
 		{
 			mediaProtected.POST("", r.mediaHandler.Upload)
+			mediaProtected.POST("/uploads", r.mediaHandler.CreateUploadSlot)
+			mediaProtected.POST("/uploads/:id/complete", r.mediaHandler.CompleteUpload)
 			mediaProtected.PUT("/:id", r.mediaHandler.Update)
 			mediaProtected.DELETE("/:id", r.mediaHandler.Delete)
+
+			// Media library
+			mediaProtected.GET("/folders", r.mediaHandler.GetFolders)
+			mediaProtected.POST("/folders", r.mediaHandler.CreateFolder)
+			mediaProtected.PUT("/folders/:id", r.mediaHandler.UpdateFolder)
+			mediaProtected.DELETE("/folders/:id", r.mediaHandler.DeleteFolder)
+			mediaProtected.GET("/tags", r.mediaHandler.GetTags)
+			mediaProtected.POST("/bulk", r.mediaHandler.BulkUpdate)
+		}
+
+		// Admin routes
+		admin := api.Group("/admin")
+		admin.Use(authMiddleware, middleware.RequireAdmin())
+		{
+			// Site-wide analytics reports
+			admin.GET("/analytics/top-posts", r.analyticsHandler.GetTopPosts)
+			admin.GET("/analytics/top-categories", r.analyticsHandler.GetTopCategories)
+			admin.GET("/analytics/top-authors", r.analyticsHandler.GetTopAuthors)
+
+			// Outgoing webhooks
+			admin.GET("/webhooks", r.webhookHandler.GetAll)
+			admin.POST("/webhooks", r.webhookHandler.Create)
+			admin.GET("/webhooks/:id", r.webhookHandler.GetByID)
+			admin.PUT("/webhooks/:id", r.webhookHandler.Update)
+			admin.DELETE("/webhooks/:id", r.webhookHandler.Delete)
+			admin.GET("/webhooks/:id/deliveries", r.webhookHandler.GetDeliveries)
+			admin.GET("/webhooks/:id/deliveries/:deliveryId", r.webhookHandler.GetDelivery)
+			admin.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", r.webhookHandler.Redeliver)
+
+			// Background jobs
+			admin.GET("/jobs", r.jobHandler.GetAll)
+			admin.GET("/jobs/stats", r.jobHandler.GetStats)
+			admin.GET("/jobs/:id", r.jobHandler.GetByID)
+			admin.POST("/jobs/:id/retry", r.jobHandler.Retry)
+
+			// Storage usage and per-user quotas
+			admin.GET("/storage/usage", r.storageQuotaHandler.GetReport)
+			admin.GET("/storage/users/:id", r.storageQuotaHandler.GetByUserID)
+			admin.PUT("/storage/users/:id/quota", r.storageQuotaHandler.SetQuota)
+			admin.DELETE("/storage/users/:id/quota", r.storageQuotaHandler.ResetQuota)
 		}
 	}
 
 	return router
-}
\ No newline at end of file
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/afdhali/GolangBlogpostServer/pkg/viewcounter"
+	"github.com/google/uuid"
+)
+
+const (
+	analyticsDateLayout   = "2006-01-02"
+	analyticsDefaultDays  = 30
+	analyticsMaxRangeDays = 366
+)
+
+type AnalyticsService interface {
+	// RecordViews persists a batch of buffered views (view counter flush target)
+	RecordViews(ctx context.Context, views map[viewcounter.ViewEvent]int64) error
+
+	GetPostDailyViews(ctx context.Context, postID uuid.UUID, params *dto.AnalyticsQueryParams, user *entity.User) (*dto.PostDailyViewsResponse, error)
+	GetPostReferrers(ctx context.Context, postID uuid.UUID, params *dto.AnalyticsQueryParams, user *entity.User) (*dto.PostReferrersResponse, error)
+	GetTopPosts(ctx context.Context, params *dto.AnalyticsQueryParams) (*dto.TopContentResponse, error)
+	GetTopCategories(ctx context.Context, params *dto.AnalyticsQueryParams) (*dto.TopContentResponse, error)
+	GetTopAuthors(ctx context.Context, params *dto.AnalyticsQueryParams) (*dto.TopContentResponse, error)
+}
+
+type analyticsService struct {
+	txManager     repository.TxManager
+	analyticsRepo repository.AnalyticsRepository
+	postRepo      repository.PostRepository
+	validator     *validator.CustomValidator
+}
+
+func NewAnalyticsService(
+	txManager repository.TxManager,
+	analyticsRepo repository.AnalyticsRepository,
+	postRepo repository.PostRepository,
+	validator *validator.CustomValidator,
+) AnalyticsService {
+	return &analyticsService{
+		txManager:     txManager,
+		analyticsRepo: analyticsRepo,
+		postRepo:      postRepo,
+		validator:     validator,
+	}
+}
+
+func (s *analyticsService) RecordViews(ctx context.Context, views map[viewcounter.ViewEvent]int64) error {
+	if len(views) == 0 {
+		return nil
+	}
+
+	stats := make([]*entity.PostViewStat, 0, len(views))
+	for event, n := range views {
+		stats = append(stats, &entity.PostViewStat{
+			PostID:        event.PostID,
+			Day:           event.Day,
+			ReferrerHost:  event.ReferrerHost,
+			Country:       event.Country,
+			Authenticated: event.Authenticated,
+			Views:         n,
+		})
+	}
+
+	// A failed batch is retried whole by the view counter, so the counts
+	// and the rollups are written together or not at all
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Keep the denormalized posts.view_count in sync
+		if err := s.postRepo.IncrementViewCounts(ctx, viewcounter.CountsByPost(views)); err != nil {
+			return fmt.Errorf("failed to increment view counts: %w", err)
+		}
+
+		if err := s.analyticsRepo.UpsertViewStats(ctx, stats); err != nil {
+			return fmt.Errorf("failed to record view stats: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *analyticsService) GetPostDailyViews(ctx context.Context, postID uuid.UUID, params *dto.AnalyticsQueryParams, user *entity.User) (*dto.PostDailyViewsResponse, error) {
+	from, to, err := s.resolveRange(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPostAccess(ctx, postID, user); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.analyticsRepo.DailyViews(ctx, postID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily views: %w", err)
+	}
+
+	byDay := make(map[string]repository.DailyViewCount, len(rows))
+	for _, row := range rows {
+		byDay[row.Day.Format(analyticsDateLayout)] = row
+	}
+
+	// Fill in days without views so the series is continuous
+	result := &dto.PostDailyViewsResponse{
+		PostID: postID,
+		From:   from.Format(analyticsDateLayout),
+		To:     to.Format(analyticsDateLayout),
+		Days:   []*dto.DailyViewsResponse{},
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(analyticsDateLayout)
+		row := byDay[key]
+		result.Days = append(result.Days, &dto.DailyViewsResponse{
+			Date:               key,
+			Views:              row.Views,
+			AuthenticatedViews: row.AuthenticatedViews,
+		})
+		result.TotalViews += row.Views
+	}
+
+	return result, nil
+}
+
+func (s *analyticsService) GetPostReferrers(ctx context.Context, postID uuid.UUID, params *dto.AnalyticsQueryParams, user *entity.User) (*dto.PostReferrersResponse, error) {
+	from, to, err := s.resolveRange(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPostAccess(ctx, postID, user); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.analyticsRepo.ReferrerViews(ctx, postID, from, to, s.limit(params))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referrers: %w", err)
+	}
+
+	referrers := make([]*dto.ReferrerViewsResponse, len(rows))
+	for i, row := range rows {
+		referrers[i] = &dto.ReferrerViewsResponse{
+			ReferrerHost: row.ReferrerHost,
+			Views:        row.Views,
+		}
+	}
+
+	return &dto.PostReferrersResponse{
+		PostID:    postID,
+		From:      from.Format(analyticsDateLayout),
+		To:        to.Format(analyticsDateLayout),
+		Referrers: referrers,
+	}, nil
+}
+
+func (s *analyticsService) GetTopPosts(ctx context.Context, params *dto.AnalyticsQueryParams) (*dto.TopContentResponse, error) {
+	from, to, err := s.resolveRange(params)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.analyticsRepo.TopPosts(ctx, from, to, s.limit(params))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top posts: %w", err)
+	}
+
+	items := make([]*dto.TopPostResponse, len(rows))
+	for i, row := range rows {
+		items[i] = &dto.TopPostResponse{ID: row.ID, Title: row.Name, Slug: row.Slug, Views: row.Views}
+	}
+
+	return s.topResponse(from, to, items), nil
+}
+
+func (s *analyticsService) GetTopCategories(ctx context.Context, params *dto.AnalyticsQueryParams) (*dto.TopContentResponse, error) {
+	from, to, err := s.resolveRange(params)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.analyticsRepo.TopCategories(ctx, from, to, s.limit(params))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top categories: %w", err)
+	}
+
+	items := make([]*dto.TopCategoryResponse, len(rows))
+	for i, row := range rows {
+		items[i] = &dto.TopCategoryResponse{ID: row.ID, Name: row.Name, Slug: row.Slug, Views: row.Views}
+	}
+
+	return s.topResponse(from, to, items), nil
+}
+
+func (s *analyticsService) GetTopAuthors(ctx context.Context, params *dto.AnalyticsQueryParams) (*dto.TopContentResponse, error) {
+	from, to, err := s.resolveRange(params)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.analyticsRepo.TopAuthors(ctx, from, to, s.limit(params))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top authors: %w", err)
+	}
+
+	items := make([]*dto.TopAuthorResponse, len(rows))
+	for i, row := range rows {
+		items[i] = &dto.TopAuthorResponse{ID: row.ID, Username: row.Slug, FullName: row.Name, Views: row.Views}
+	}
+
+	return s.topResponse(from, to, items), nil
+}
+
+// checkPostAccess allows the post author and admins
+func (s *analyticsService) checkPostAccess(ctx context.Context, postID uuid.UUID, user *entity.User) error {
+	post, err := s.postRepo.FindByID(ctx, postID)
+	if err != nil {
+		return errors.New("post not found")
+	}
+
+	if post.AuthorID != user.ID && !user.IsAdmin() {
+		return errors.New("you don't have permission to view analytics for this post")
+	}
+
+	return nil
+}
+
+// resolveRange validates params and returns an inclusive [from, to] date
+// range, defaulting to the last 30 days
+func (s *analyticsService) resolveRange(params *dto.AnalyticsQueryParams) (time.Time, time.Time, error) {
+	if err := s.validator.Validate(params); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("validation error: %w", err)
+	}
+
+	to := viewcounter.Day(time.Now())
+	if params.To != "" {
+		to, _ = time.Parse(analyticsDateLayout, params.To)
+	}
+
+	from := to.AddDate(0, 0, -(analyticsDefaultDays - 1))
+	if params.From != "" {
+		from, _ = time.Parse(analyticsDateLayout, params.From)
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("invalid date range")
+	}
+	if to.Sub(from) > analyticsMaxRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, errors.New("date range too large")
+	}
+
+	return from, to, nil
+}
+
+func (s *analyticsService) limit(params *dto.AnalyticsQueryParams) int {
+	if params.Limit < 1 {
+		return 10
+	}
+	return params.Limit
+}
+
+func (s *analyticsService) topResponse(from, to time.Time, items interface{}) *dto.TopContentResponse {
+	return &dto.TopContentResponse{
+		From:  from.Format(analyticsDateLayout),
+		To:    to.Format(analyticsDateLayout),
+		Items: items,
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+)
+
+type BookmarkService interface {
+	Add(ctx context.Context, req *dto.CreateBookmarkRequest, user *entity.User) (*dto.BookmarkResponse, error)
+	Remove(ctx context.Context, postID uuid.UUID, user *entity.User) error
+	GetAll(ctx context.Context, params *dto.BookmarkQueryParams, user *entity.User) ([]*dto.BookmarkResponse, int64, error)
+}
+
+type bookmarkService struct {
+	bookmarkRepo repository.BookmarkRepository
+	postRepo     repository.PostRepository
+	commentRepo  repository.CommentRepository
+	validator    *validator.CustomValidator
+}
+
+func NewBookmarkService(
+	bookmarkRepo repository.BookmarkRepository,
+	postRepo repository.PostRepository,
+	commentRepo repository.CommentRepository,
+	validator *validator.CustomValidator,
+) BookmarkService {
+	return &bookmarkService{
+		bookmarkRepo: bookmarkRepo,
+		postRepo:     postRepo,
+		commentRepo:  commentRepo,
+		validator:    validator,
+	}
+}
+
+func (s *bookmarkService) Add(ctx context.Context, req *dto.CreateBookmarkRequest, user *entity.User) (*dto.BookmarkResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	// Only posts the user can see may be bookmarked
+	post, err := s.postRepo.FindByID(ctx, req.PostID)
+	if err != nil || !post.IsVisibleTo(user) {
+		return nil, errors.New("post not found")
+	}
+
+	bookmark := &entity.Bookmark{
+		UserID: user.ID,
+		PostID: post.ID,
+	}
+
+	// Re-bookmarking returns the existing bookmark
+	if err := s.bookmarkRepo.Create(ctx, bookmark); err != nil {
+		return nil, fmt.Errorf("failed to create bookmark: %w", err)
+	}
+	bookmark.Post = post
+
+	commentCount, _ := s.commentRepo.CountByPostID(ctx, post.ID)
+
+	return dto.ToBookmarkResponse(bookmark, user, commentCount), nil
+}
+
+func (s *bookmarkService) Remove(ctx context.Context, postID uuid.UUID, user *entity.User) error {
+	deleted, err := s.bookmarkRepo.Delete(ctx, user.ID, postID)
+	if err != nil {
+		return fmt.Errorf("failed to delete bookmark: %w", err)
+	}
+	if !deleted {
+		return errors.New("bookmark not found")
+	}
+	return nil
+}
+
+func (s *bookmarkService) GetAll(ctx context.Context, params *dto.BookmarkQueryParams, user *entity.User) ([]*dto.BookmarkResponse, int64, error) {
+	// Validate params
+	if err := s.validator.Validate(params); err != nil {
+		return nil, 0, fmt.Errorf("validation error: %w", err)
+	}
+
+	// Default pagination
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 10
+	}
+
+	bookmarks, total, err := s.bookmarkRepo.FindByUserID(ctx, user.ID, params.Page, params.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get bookmarks: %w", err)
+	}
+
+	// Bulk count comments for all posts
+	postIDs := make([]uuid.UUID, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		postIDs[i] = bookmark.PostID
+	}
+
+	commentCounts, err := s.commentRepo.CountByPostIDs(ctx, postIDs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	responses := make([]*dto.BookmarkResponse, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		responses[i] = dto.ToBookmarkResponse(bookmark, user, commentCounts[bookmark.PostID])
+	}
+
+	return responses, total, nil
+}
@@ -20,7 +20,7 @@ type CategoryService interface {
 	GetBySlug(ctx context.Context, slug string) (*dto.CategoryResponse, error)
 	Create(ctx context.Context, req *dto.CreateCategoryRequest, user *entity.User) (*dto.CategoryResponse, error)
 	Update(ctx context.Context, id uuid.UUID, req *dto.UpdateCategoryRequest, user *entity.User) (*dto.CategoryResponse, error)
-	Delete(ctx context.Context, id uuid.UUID, user *entity.User) error
+	Delete(ctx context.Context, id uuid.UUID, version *int64, user *entity.User) error
 }
 
 type categoryService struct {
@@ -214,6 +214,10 @@ func (s *categoryService) Update(ctx context.Context, id uuid.UUID, req *dto.Upd
 		return nil, errors.New("you don't have permission to update category")
 	}
 
+	if !checkVersion(req.Version, category.Version) {
+		return nil, s.categoryConflict(ctx, category.ID)
+	}
+
 	// Update fields
 	if req.Name != "" {
 		category.Name = req.Name
@@ -232,6 +236,9 @@ func (s *categoryService) Update(ctx context.Context, id uuid.UUID, req *dto.Upd
 	}
 
 	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		if isVersionConflict(err) {
+			return nil, s.categoryConflict(ctx, category.ID)
+		}
 		return nil, fmt.Errorf("failed to update category: %w", err)
 	}
 
@@ -241,7 +248,7 @@ func (s *categoryService) Update(ctx context.Context, id uuid.UUID, req *dto.Upd
 	return dto.ToCategoryResponse(category, postCount), nil
 }
 
-func (s *categoryService) Delete(ctx context.Context, id uuid.UUID, user *entity.User) error {
+func (s *categoryService) Delete(ctx context.Context, id uuid.UUID, version *int64, user *entity.User) error {
 	// Get category
 	category, err := s.categoryRepo.FindByID(ctx, id)
 	if err != nil {
@@ -253,6 +260,10 @@ func (s *categoryService) Delete(ctx context.Context, id uuid.UUID, user *entity
 		return errors.New("you don't have permission to delete category")
 	}
 
+	if !checkVersion(version, category.Version) {
+		return s.categoryConflict(ctx, category.ID)
+	}
+
 	// Check if category has posts
 	postCount, err := s.postRepo.CountByCategoryID(ctx, category.ID)
 	if err != nil {
@@ -263,5 +274,21 @@ func (s *categoryService) Delete(ctx context.Context, id uuid.UUID, user *entity
 		return errors.New("cannot delete category with posts")
 	}
 
-	return s.categoryRepo.Delete(ctx, id)
+	if err := s.categoryRepo.Delete(ctx, id, category.Version); err != nil {
+		if isVersionConflict(err) {
+			return s.categoryConflict(ctx, category.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// categoryConflict reports a version conflict along with the category as it is now
+func (s *categoryService) categoryConflict(ctx context.Context, id uuid.UUID) error {
+	category, err := s.categoryRepo.FindByID(ctx, id)
+	if err != nil {
+		return errors.New("category not found")
+	}
+	postCount, _ := s.postRepo.CountByCategoryID(ctx, category.ID)
+	return &ConflictError{Version: category.Version, Current: dto.ToCategoryResponse(category, postCount)}
 }
\ No newline at end of file
@@ -8,16 +8,18 @@ import (
 	"github.com/afdhali/GolangBlogpostServer/internal/dto"
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/broker"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
 	"github.com/afdhali/GolangBlogpostServer/pkg/security"
 	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
 	"github.com/google/uuid"
 )
 
 type CommentService interface {
-	Create(ctx context.Context, postID uuid.UUID, req *dto.CreateCommentRequest, userID uuid.UUID) (*dto.CommentResponse, error)
-	GetByPostID(ctx context.Context, postID uuid.UUID, params *dto.CommentQueryParams) ([]*dto.CommentResponse, int64, error)
+	Create(ctx context.Context, postID uuid.UUID, req *dto.CreateCommentRequest, user *entity.User) (*dto.CommentResponse, error)
+	GetByPostID(ctx context.Context, postID uuid.UUID, params *dto.CommentQueryParams, currentUser *entity.User) ([]*dto.CommentResponse, *dto.PageInfo, error)
 	Update(ctx context.Context, id uuid.UUID, req *dto.UpdateCommentRequest, user *entity.User) (*dto.CommentResponse, error)
-	Delete(ctx context.Context, id uuid.UUID, user *entity.User) error
+	Delete(ctx context.Context, id uuid.UUID, version *int64, user *entity.User) error
 }
 
 type commentService struct {
@@ -25,6 +27,10 @@ type commentService struct {
 	postRepo    repository.PostRepository
 	sanitizer   security.Sanitizer
 	validator   *validator.CustomValidator
+	notifier    NotificationService
+	broker      broker.Broker
+	webhooks    WebhookService
+	cursors     *pagination.Signer
 }
 
 func NewCommentService(
@@ -32,30 +38,39 @@ func NewCommentService(
 	postRepo repository.PostRepository,
 	sanitizer security.Sanitizer,
 	validator *validator.CustomValidator,
+	notifier NotificationService,
+	broker broker.Broker,
+	webhooks WebhookService,
+	cursors *pagination.Signer,
 ) CommentService {
 	return &commentService{
 		commentRepo: commentRepo,
 		postRepo:    postRepo,
 		sanitizer:   sanitizer,
 		validator:   validator,
+		notifier:    notifier,
+		broker:      broker,
+		webhooks:    webhooks,
+		cursors:     cursors,
 	}
 }
 
-func (s *commentService) Create(ctx context.Context, postID uuid.UUID, req *dto.CreateCommentRequest, userID uuid.UUID) (*dto.CommentResponse, error) {
+func (s *commentService) Create(ctx context.Context, postID uuid.UUID, req *dto.CreateCommentRequest, user *entity.User) (*dto.CommentResponse, error) {
 	// Validate request
 	if err := s.validator.Validate(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
-	// Check if post exists
-	_, err := s.postRepo.FindByID(ctx, postID)
+	// Only posts the user may read can be commented on
+	_, err := s.postRepo.FindVisibleByID(ctx, postID, repository.PostViewerFor(user))
 	if err != nil {
 		return nil, errors.New("post not found")
 	}
 
 	// If has parent, check parent exists and belongs to same post
+	var parentComment *entity.Comment
 	if req.ParentID != nil {
-		parentComment, err := s.commentRepo.FindByID(ctx, *req.ParentID)
+		parentComment, err = s.commentRepo.FindByID(ctx, *req.ParentID)
 		if err != nil {
 			return nil, errors.New("parent comment not found")
 		}
@@ -71,7 +86,7 @@ func (s *commentService) Create(ctx context.Context, postID uuid.UUID, req *dto.
 	comment := &entity.Comment{
 		Content:  sanitizedContent,
 		PostID:   postID,
-		UserID:   userID,
+		UserID:   user.ID,
 		ParentID: req.ParentID,
 	}
 
@@ -82,19 +97,25 @@ func (s *commentService) Create(ctx context.Context, postID uuid.UUID, req *dto.
 	// Load relations (User, Replies)
 	comment, _ = s.commentRepo.FindByID(ctx, comment.ID)
 
-	return dto.ToCommentResponse(comment), nil
+	s.notifier.NotifyComment(ctx, comment, parentComment)
+
+	resp := dto.ToCommentResponse(comment)
+	s.publish(ctx, comment.PostID, EventCommentCreated, resp)
+	s.webhooks.Enqueue(ctx, entity.WebhookEventCommentCreated, resp)
+
+	return resp, nil
 }
 
-func (s *commentService) GetByPostID(ctx context.Context, postID uuid.UUID, params *dto.CommentQueryParams) ([]*dto.CommentResponse, int64, error) {
+func (s *commentService) GetByPostID(ctx context.Context, postID uuid.UUID, params *dto.CommentQueryParams, currentUser *entity.User) ([]*dto.CommentResponse, *dto.PageInfo, error) {
 	// Validate params
 	if err := s.validator.Validate(params); err != nil {
-		return nil, 0, fmt.Errorf("validation error: %w", err)
+		return nil, nil, fmt.Errorf("validation error: %w", err)
 	}
 
-	// Check if post exists
-	_, err := s.postRepo.FindByID(ctx, postID)
+	// Comments of a post the viewer may not read stay hidden with it
+	_, err := s.postRepo.FindVisibleByID(ctx, postID, repository.PostViewerFor(currentUser))
 	if err != nil {
-		return nil, 0, errors.New("post not found")
+		return nil, nil, errors.New("post not found")
 	}
 
 	// Default pagination
@@ -105,11 +126,22 @@ func (s *commentService) GetByPostID(ctx context.Context, postID uuid.UUID, para
 		params.Limit = 10
 	}
 
+	paging, err := listPaging(s.cursors, params.CursorParams, params.Page, params.Limit, params.SortBy, params.SortOrder)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Get comments
-	comments, total, err := s.commentRepo.FindByPostID(ctx, postID, params.Page, params.Limit)
+	comments, total, err := s.commentRepo.FindByPostID(ctx, postID, paging)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get comments: %w", err)
+		return nil, nil, fmt.Errorf("failed to get comments: %w", err)
 	}
+	comments, page := listPage(s.cursors, paging, comments, total, func(comment *entity.Comment) (interface{}, uuid.UUID) {
+		if paging.SortBy == "updated_at" {
+			return comment.UpdatedAt, comment.ID
+		}
+		return comment.CreatedAt, comment.ID
+	})
 
 	// Convert to response
 	responses := make([]*dto.CommentResponse, len(comments))
@@ -117,7 +149,7 @@ func (s *commentService) GetByPostID(ctx context.Context, postID uuid.UUID, para
 		responses[i] = dto.ToCommentResponse(comment)
 	}
 
-	return responses, total, nil
+	return responses, page, nil
 }
 
 func (s *commentService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateCommentRequest, user *entity.User) (*dto.CommentResponse, error) {
@@ -140,20 +172,30 @@ func (s *commentService) Update(ctx context.Context, id uuid.UUID, req *dto.Upda
 		return nil, errors.New("you don't have permission to update this comment")
 	}
 
+	if !checkVersion(req.Version, comment.Version) {
+		return nil, s.commentConflict(ctx, comment.ID)
+	}
+
 	// Sanitize content
 	comment.Content = s.sanitizer.StrictSanitize(req.Content)
 
 	if err := s.commentRepo.Update(ctx, comment); err != nil {
+		if isVersionConflict(err) {
+			return nil, s.commentConflict(ctx, comment.ID)
+		}
 		return nil, fmt.Errorf("failed to update comment: %w", err)
 	}
 
 	// Reload with relations
 	comment, _ = s.commentRepo.FindByID(ctx, comment.ID)
 
-	return dto.ToCommentResponse(comment), nil
+	resp := dto.ToCommentResponse(comment)
+	s.publish(ctx, comment.PostID, EventCommentUpdated, resp)
+
+	return resp, nil
 }
 
-func (s *commentService) Delete(ctx context.Context, id uuid.UUID, user *entity.User) error {
+func (s *commentService) Delete(ctx context.Context, id uuid.UUID, version *int64, user *entity.User) error {
 	comment, err := s.commentRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("comment not found")
@@ -167,5 +209,37 @@ func (s *commentService) Delete(ctx context.Context, id uuid.UUID, user *entity.
 		return errors.New("you don't have permission to delete this comment")
 	}
 
-	return s.commentRepo.Delete(ctx, id)
+	if !checkVersion(version, comment.Version) {
+		return s.commentConflict(ctx, comment.ID)
+	}
+
+	if err := s.commentRepo.Delete(ctx, id, comment.Version); err != nil {
+		if isVersionConflict(err) {
+			return s.commentConflict(ctx, comment.ID)
+		}
+		return err
+	}
+
+	s.publish(ctx, comment.PostID, EventCommentDeleted, &dto.CommentDeletedEvent{
+		ID:       comment.ID,
+		PostID:   comment.PostID,
+		ParentID: comment.ParentID,
+	})
+
+	return nil
+}
+
+// commentConflict reports a version conflict along with the comment as it is now
+func (s *commentService) commentConflict(ctx context.Context, id uuid.UUID) error {
+	comment, err := s.commentRepo.FindByID(ctx, id)
+	if err != nil {
+		return errors.New("comment not found")
+	}
+	return &ConflictError{Version: comment.Version, Current: dto.ToCommentResponse(comment)}
+}
+
+// publish pushes a live update to the post's comment stream. Streaming is
+// best-effort; the comment itself is already saved.
+func (s *commentService) publish(ctx context.Context, postID uuid.UUID, eventType string, payload interface{}) {
+	_ = s.broker.Publish(ctx, PostCommentsTopic(postID), eventType, payload)
 }
\ No newline at end of file
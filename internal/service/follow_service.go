@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+)
+
+type FollowService interface {
+	FollowUser(ctx context.Context, targetID uuid.UUID, user *entity.User) (*dto.FollowResponse, error)
+	UnfollowUser(ctx context.Context, targetID uuid.UUID, user *entity.User) (*dto.FollowResponse, error)
+	GetFollowers(ctx context.Context, userID uuid.UUID, params *dto.FollowQueryParams) ([]*dto.UserAuthor, int64, error)
+	GetFollowing(ctx context.Context, userID uuid.UUID, params *dto.FollowQueryParams) ([]*dto.UserAuthor, int64, error)
+
+	FollowCategory(ctx context.Context, categoryID uuid.UUID, user *entity.User) (*dto.FollowResponse, error)
+	UnfollowCategory(ctx context.Context, categoryID uuid.UUID, user *entity.User) (*dto.FollowResponse, error)
+	GetFollowedCategories(ctx context.Context, user *entity.User) ([]*dto.CategoryBasic, error)
+}
+
+type followService struct {
+	followRepo   repository.FollowRepository
+	userRepo     repository.UserRepository
+	categoryRepo repository.CategoryRepository
+	validator    *validator.CustomValidator
+	notifier     NotificationService
+}
+
+func NewFollowService(
+	followRepo repository.FollowRepository,
+	userRepo repository.UserRepository,
+	categoryRepo repository.CategoryRepository,
+	validator *validator.CustomValidator,
+	notifier NotificationService,
+) FollowService {
+	return &followService{
+		followRepo:   followRepo,
+		userRepo:     userRepo,
+		categoryRepo: categoryRepo,
+		validator:    validator,
+		notifier:     notifier,
+	}
+}
+
+func (s *followService) FollowUser(ctx context.Context, targetID uuid.UUID, user *entity.User) (*dto.FollowResponse, error) {
+	if targetID == user.ID {
+		return nil, errors.New("you cannot follow yourself")
+	}
+
+	target, err := s.userRepo.FindByID(ctx, targetID)
+	if err != nil || !target.IsActive {
+		return nil, errors.New("user not found")
+	}
+
+	follow := &entity.UserFollow{
+		FollowerID:  user.ID,
+		FollowingID: target.ID,
+	}
+
+	created, err := s.followRepo.FollowUser(ctx, follow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to follow user: %w", err)
+	}
+
+	// Only a new follow is news; re-following is a no-op
+	if created {
+		s.notifier.NotifyNewFollower(ctx, user, target.ID)
+	}
+
+	followerCount, _ := s.followRepo.CountFollowers(ctx, target.ID)
+
+	return &dto.FollowResponse{ID: target.ID, Following: true, FollowerCount: followerCount}, nil
+}
+
+func (s *followService) UnfollowUser(ctx context.Context, targetID uuid.UUID, user *entity.User) (*dto.FollowResponse, error) {
+	deleted, err := s.followRepo.UnfollowUser(ctx, user.ID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unfollow user: %w", err)
+	}
+	if !deleted {
+		return nil, errors.New("you are not following this user")
+	}
+
+	followerCount, _ := s.followRepo.CountFollowers(ctx, targetID)
+
+	return &dto.FollowResponse{ID: targetID, Following: false, FollowerCount: followerCount}, nil
+}
+
+func (s *followService) GetFollowers(ctx context.Context, userID uuid.UUID, params *dto.FollowQueryParams) ([]*dto.UserAuthor, int64, error) {
+	return s.listUsers(ctx, userID, params, s.followRepo.FindFollowers)
+}
+
+func (s *followService) GetFollowing(ctx context.Context, userID uuid.UUID, params *dto.FollowQueryParams) ([]*dto.UserAuthor, int64, error) {
+	return s.listUsers(ctx, userID, params, s.followRepo.FindFollowing)
+}
+
+func (s *followService) listUsers(
+	ctx context.Context,
+	userID uuid.UUID,
+	params *dto.FollowQueryParams,
+	find func(ctx context.Context, userID uuid.UUID, page, limit int) ([]*entity.User, int64, error),
+) ([]*dto.UserAuthor, int64, error) {
+	// Validate params
+	if err := s.validator.Validate(params); err != nil {
+		return nil, 0, fmt.Errorf("validation error: %w", err)
+	}
+
+	// Default pagination
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 10
+	}
+
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, 0, errors.New("user not found")
+	}
+
+	users, total, err := find(ctx, userID, params.Page, params.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	responses := make([]*dto.UserAuthor, len(users))
+	for i, u := range users {
+		responses[i] = dto.ToUserAuthor(u)
+	}
+
+	return responses, total, nil
+}
+
+func (s *followService) FollowCategory(ctx context.Context, categoryID uuid.UUID, user *entity.User) (*dto.FollowResponse, error) {
+	category, err := s.categoryRepo.FindByID(ctx, categoryID)
+	if err != nil {
+		return nil, errors.New("category not found")
+	}
+
+	follow := &entity.CategoryFollow{
+		UserID:     user.ID,
+		CategoryID: category.ID,
+	}
+
+	if err := s.followRepo.FollowCategory(ctx, follow); err != nil {
+		return nil, fmt.Errorf("failed to follow category: %w", err)
+	}
+
+	return &dto.FollowResponse{ID: category.ID, Following: true}, nil
+}
+
+func (s *followService) UnfollowCategory(ctx context.Context, categoryID uuid.UUID, user *entity.User) (*dto.FollowResponse, error) {
+	deleted, err := s.followRepo.UnfollowCategory(ctx, user.ID, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unfollow category: %w", err)
+	}
+	if !deleted {
+		return nil, errors.New("you are not following this category")
+	}
+
+	return &dto.FollowResponse{ID: categoryID, Following: false}, nil
+}
+
+func (s *followService) GetFollowedCategories(ctx context.Context, user *entity.User) ([]*dto.CategoryBasic, error) {
+	categories, err := s.followRepo.FindFollowedCategories(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	responses := make([]*dto.CategoryBasic, len(categories))
+	for i, category := range categories {
+		responses[i] = dto.ToCategoryBasic(category)
+	}
+
+	return responses, nil
+}
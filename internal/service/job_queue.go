@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/config"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/backoff"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// JobHandler runs one job. Returning an error schedules a retry with
+// backoff; wrap it with PermanentJobError to dead-letter the job right away.
+type JobHandler func(ctx context.Context, job *entity.Job) error
+
+// JobEnqueuer is what services depend on to hand work to the queue
+type JobEnqueuer interface {
+	Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...JobOption) (*entity.Job, error)
+}
+
+// JobOption customizes a job at enqueue time
+type JobOption func(*entity.Job)
+
+// JobRunAt schedules the job for t instead of now
+func JobRunAt(t time.Time) JobOption {
+	return func(j *entity.Job) { j.RunAt = t }
+}
+
+// JobDelay schedules the job d from now
+func JobDelay(d time.Duration) JobOption {
+	return func(j *entity.Job) { j.RunAt = time.Now().Add(d) }
+}
+
+// JobUniqueKey makes Enqueue a no-op while a pending or running job with the
+// same key exists; the existing job is returned instead
+func JobUniqueKey(key string) JobOption {
+	return func(j *entity.Job) { j.UniqueKey = &key }
+}
+
+// JobMaxAttempts overrides the configured attempt budget
+func JobMaxAttempts(n int) JobOption {
+	return func(j *entity.Job) { j.MaxAttempts = n }
+}
+
+type permanentJobError struct {
+	err error
+}
+
+func (e *permanentJobError) Error() string { return e.err.Error() }
+func (e *permanentJobError) Unwrap() error { return e.err }
+
+// PermanentJobError marks a handler error as not worth retrying
+func PermanentJobError(err error) error {
+	return &permanentJobError{err: err}
+}
+
+type retryJobError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryJobError) Error() string { return e.err.Error() }
+func (e *retryJobError) Unwrap() error { return e.err }
+
+// RetryJobAfter retries a failed job after d instead of the queue's backoff,
+// for handlers that keep their own retry schedule
+func RetryJobAfter(err error, d time.Duration) error {
+	return &retryJobError{err: err, after: d}
+}
+
+// JobQueue is a PostgreSQL-backed background job queue. Handlers are
+// registered by job type before Start; each worker claims one due job at a
+// time with SKIP LOCKED, so any number of instances can share the table.
+type JobQueue struct {
+	jobRepo repository.JobRepository
+	logger  *logger.Logger
+
+	workers     int
+	interval    time.Duration
+	timeout     time.Duration
+	lease       time.Duration
+	maxAttempts int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	retention   time.Duration
+	workerID    string
+
+	mu       sync.RWMutex
+	handlers map[string]JobHandler
+
+	wake     chan struct{}
+	stopCh   chan struct{}
+	runCtx   context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	started  bool
+	stopOnce sync.Once
+}
+
+func NewJobQueue(jobRepo repository.JobRepository, logger *logger.Logger, cfg *config.Config) *JobQueue {
+	host, _ := os.Hostname()
+	timeout := time.Duration(cfg.Jobs.TimeoutSec) * time.Second
+	runCtx, cancel := context.WithCancel(context.Background())
+
+	workers := cfg.Jobs.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &JobQueue{
+		jobRepo:     jobRepo,
+		logger:      logger,
+		workers:     workers,
+		interval:    time.Duration(cfg.Jobs.PollIntervalSec) * time.Second,
+		timeout:     timeout,
+		lease:       timeout + time.Minute,
+		maxAttempts: cfg.Jobs.MaxAttempts,
+		backoffBase: time.Duration(cfg.Jobs.BackoffBaseSec) * time.Second,
+		backoffMax:  time.Duration(cfg.Jobs.BackoffMaxSec) * time.Second,
+		retention:   time.Duration(cfg.Jobs.RetentionDays) * 24 * time.Hour,
+		workerID:    fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8]),
+		handlers:    make(map[string]JobHandler),
+		wake:        make(chan struct{}, workers),
+		stopCh:      make(chan struct{}),
+		runCtx:      runCtx,
+		cancel:      cancel,
+	}
+}
+
+// Register sets the handler for a job type. Only registered types are
+// claimed, so an instance never dead-letters jobs it doesn't know about.
+func (q *JobQueue) Register(jobType string, handler JobHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// RegisterJob registers a handler that receives the decoded payload. A
+// payload that doesn't decode is dead-lettered without retrying.
+func RegisterJob[T any](q *JobQueue, jobType string, handler func(ctx context.Context, payload T) error) {
+	q.Register(jobType, func(ctx context.Context, job *entity.Job) error {
+		var payload T
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return PermanentJobError(fmt.Errorf("invalid payload: %w", err))
+		}
+		return handler(ctx, payload)
+	})
+}
+
+// Enqueue stores a job. payload is encoded as JSON.
+func (q *JobQueue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...JobOption) (*entity.Job, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	job := &entity.Job{
+		Type:        jobType,
+		Payload:     string(body),
+		Status:      entity.JobStatusPending,
+		RunAt:       time.Now(),
+		MaxAttempts: q.maxAttempts,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	created, err := q.jobRepo.Create(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	if !created {
+		// Unique key already queued; hand back the job that holds it
+		existing, err := q.jobRepo.FindActiveByUniqueKey(ctx, *job.UniqueKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load existing job: %w", err)
+		}
+		return existing, nil
+	}
+
+	if !job.RunAt.After(time.Now()) {
+		// Nudge an idle worker instead of waiting for the next poll
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return job, nil
+}
+
+// Start launches the workers and the janitor that purges old succeeded jobs
+func (q *JobQueue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		return
+	}
+	q.started = true
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work(fmt.Sprintf("%s/%d", q.workerID, i))
+	}
+
+	if q.retention > 0 {
+		q.wg.Add(1)
+		go q.janitor()
+	}
+}
+
+// Stop stops claiming new jobs and waits for running ones to finish. If ctx
+// expires first, running handlers are cancelled; their jobs are retried
+// later like any other failure.
+func (q *JobQueue) Stop(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stopCh) })
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		return ctx.Err()
+	}
+}
+
+// RunNext claims and runs a single due job. It reports whether a job ran.
+func (q *JobQueue) RunNext(ctx context.Context) (bool, error) {
+	return q.runNext(ctx, q.workerID)
+}
+
+func (q *JobQueue) work(workerID string) {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stopCh:
+			return
+		default:
+		}
+
+		ran, err := q.runNext(q.runCtx, workerID)
+		if err != nil {
+			q.logger.Error("Job worker %s: %v", workerID, err)
+		}
+		if ran {
+			continue
+		}
+
+		select {
+		case <-q.stopCh:
+			return
+		case <-q.wake:
+		case <-time.After(q.interval):
+		}
+	}
+}
+
+func (q *JobQueue) janitor() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stopCh:
+			return
+		case <-ticker.C:
+			deleted, err := q.jobRepo.DeleteSucceededBefore(q.runCtx, time.Now().Add(-q.retention))
+			if err != nil {
+				q.logger.Error("Failed to purge finished jobs: %v", err)
+			} else if deleted > 0 {
+				q.logger.Info("Purged %d finished jobs", deleted)
+			}
+		}
+	}
+}
+
+func (q *JobQueue) registeredTypes() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	types := make([]string, 0, len(q.handlers))
+	for t := range q.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (q *JobQueue) handler(jobType string) JobHandler {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.handlers[jobType]
+}
+
+func (q *JobQueue) runNext(ctx context.Context, workerID string) (bool, error) {
+	types := q.registeredTypes()
+	if len(types) == 0 {
+		return false, nil
+	}
+
+	job, err := q.jobRepo.Claim(ctx, types, workerID, time.Now(), q.lease)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	runErr := q.run(ctx, job)
+
+	now := time.Now()
+	job.LockedAt = nil
+	job.LockedBy = ""
+
+	var permanent *permanentJobError
+	switch {
+	case runErr == nil:
+		job.Status = entity.JobStatusSucceeded
+		job.CompletedAt = &now
+		job.LastError = ""
+	case errors.As(runErr, &permanent) || job.Attempts >= job.MaxAttempts:
+		job.Status = entity.JobStatusDead
+		job.LastError = runErr.Error()
+		q.logger.Error("Job %s (%s) dead after %d attempts: %v", job.ID, job.Type, job.Attempts, runErr)
+	default:
+		delay := backoff.Exponential(job.Attempts, q.backoffBase, q.backoffMax)
+		var retry *retryJobError
+		if errors.As(runErr, &retry) {
+			delay = retry.after
+		}
+		job.Status = entity.JobStatusPending
+		job.RunAt = now.Add(delay)
+		job.LastError = runErr.Error()
+	}
+
+	// Record the outcome even when shutdown cancelled the run
+	if err := q.jobRepo.Finish(context.Background(), job, workerID); err != nil {
+		return true, fmt.Errorf("failed to record job %s: %w", job.ID, err)
+	}
+
+	return true, nil
+}
+
+// run calls the handler with the job timeout, turning panics into errors
+func (q *JobQueue) run(ctx context.Context, job *entity.Job) (err error) {
+	handler := q.handler(job.Type)
+	if handler == nil {
+		return PermanentJobError(fmt.Errorf("no handler registered for job type %q", job.Type))
+	}
+
+	if q.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
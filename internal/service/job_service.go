@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+)
+
+// JobService is the admin view of the background job queue
+type JobService interface {
+	GetAll(ctx context.Context, params *dto.JobQueryParams) ([]*dto.JobResponse, int64, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*dto.JobResponse, error)
+	GetStats(ctx context.Context) (*dto.JobStatsResponse, error)
+	Retry(ctx context.Context, id uuid.UUID) (*dto.JobResponse, error)
+}
+
+type jobService struct {
+	jobRepo   repository.JobRepository
+	validator *validator.CustomValidator
+}
+
+func NewJobService(jobRepo repository.JobRepository, validator *validator.CustomValidator) JobService {
+	return &jobService{
+		jobRepo:   jobRepo,
+		validator: validator,
+	}
+}
+
+func (s *jobService) GetAll(ctx context.Context, params *dto.JobQueryParams) ([]*dto.JobResponse, int64, error) {
+	// Validate params
+	if err := s.validator.Validate(params); err != nil {
+		return nil, 0, fmt.Errorf("validation error: %w", err)
+	}
+
+	// Default pagination
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 20
+	}
+
+	jobs, total, err := s.jobRepo.FindAll(ctx, params.Status, params.Type, params.Page, params.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get jobs: %w", err)
+	}
+
+	responses := make([]*dto.JobResponse, len(jobs))
+	for i, job := range jobs {
+		responses[i] = dto.ToJobResponse(job)
+	}
+	return responses, total, nil
+}
+
+func (s *jobService) GetByID(ctx context.Context, id uuid.UUID) (*dto.JobResponse, error) {
+	job, err := s.jobRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("job not found")
+	}
+	return dto.ToJobResponse(job), nil
+}
+
+func (s *jobService) GetStats(ctx context.Context) (*dto.JobStatsResponse, error) {
+	counts, err := s.jobRepo.CountByStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job stats: %w", err)
+	}
+	return dto.ToJobStatsResponse(counts), nil
+}
+
+// Retry requeues a dead job with a fresh attempt budget
+func (s *jobService) Retry(ctx context.Context, id uuid.UUID) (*dto.JobResponse, error) {
+	job, err := s.jobRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("job not found")
+	}
+
+	if job.Status != entity.JobStatusDead {
+		return nil, errors.New("only dead jobs can be retried")
+	}
+
+	// The unique key index would reject the update anyway
+	if job.UniqueKey != nil {
+		if _, err := s.jobRepo.FindActiveByUniqueKey(ctx, *job.UniqueKey); err == nil {
+			return nil, errors.New("a job with the same unique key is already queued")
+		}
+	}
+
+	retried, err := s.jobRepo.Retry(ctx, id, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry job: %w", err)
+	}
+	if !retried {
+		return nil, errors.New("only dead jobs can be retried")
+	}
+
+	job, err = s.jobRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("job not found")
+	}
+	return dto.ToJobResponse(job), nil
+}
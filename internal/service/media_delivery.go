@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+)
+
+// SignFileURL hands out an expiring link to a media file, or one of its
+// variants, that works without a session. Media of unpublished posts is
+// only signed for users who can see the post.
+func (s *mediaService) SignFileURL(ctx context.Context, id uuid.UUID, variant string, download bool, user *entity.User) (*dto.FileURLResponse, error) {
+	media, err := s.mediaRepo.FindByID(ctx, id)
+	if err != nil || !s.canViewMedia(user, media) {
+		return nil, errors.New("media not found")
+	}
+
+	filePath := media.Path
+	if variant != "" {
+		v, ok := media.Variants.Variant(variant)
+		if !ok {
+			return nil, errors.New("variant not found")
+		}
+		filePath = v.Path
+	}
+
+	// S3 URLs are public or presigned by the storage itself
+	if !s.signFileURLs {
+		return &dto.FileURLResponse{URL: s.storage.GetURL(filePath)}, nil
+	}
+
+	expiresAt := time.Now().Add(s.fileURLTTL).Truncate(time.Second)
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("sig", s.signFile(filePath, expiresAt.Unix()))
+	if download {
+		query.Set("download", "1")
+	}
+
+	return &dto.FileURLResponse{
+		URL:       s.storage.GetURL(filePath) + "?" + query.Encode(),
+		ExpiresAt: &expiresAt,
+	}, nil
+}
+
+func (s *mediaService) signFile(filePath string, expires int64) string {
+	mac := hmac.New(sha256.New, s.uploadSecret)
+	fmt.Fprintf(mac, "file\n%s\n%d", filePath, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// OpenFile opens a stored file for delivery. Files of media that is
+// unattached or on a published post are public; the others need a session
+// that can see the post, or a URL from SignFileURL. Avatars are public.
+// Files no media refers to are not served.
+func (s *mediaService) OpenFile(ctx context.Context, filePath string, user *entity.User, expires int64, signature string) (*dto.MediaFile, error) {
+	filePath = cleanStoragePath(strings.TrimPrefix(filePath, "/"))
+	if filePath == "." || filePath == ".." || strings.HasPrefix(filePath, "../") {
+		return nil, errors.New("file not found")
+	}
+
+	if strings.HasPrefix(filePath, "avatars/") {
+		return s.openAvatar(ctx, filePath)
+	}
+
+	medias, err := s.mediaRepo.FindByFilePath(ctx, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find media: %w", err)
+	}
+	if len(medias) == 0 {
+		return nil, errors.New("file not found")
+	}
+
+	// Deduplicated uploads share files; any public one makes the file public
+	var media, visible *entity.Media
+	for _, m := range medias {
+		if isPublicMedia(m) {
+			media = m
+			break
+		}
+		if visible == nil && s.canViewMedia(user, m) {
+			visible = m
+		}
+	}
+	public := media != nil
+	if !public {
+		media = visible
+	}
+	if media == nil {
+		if signature == "" {
+			// Unpublished media is not disclosed
+			return nil, errors.New("file not found")
+		}
+		if !hmac.Equal([]byte(signature), []byte(s.signFile(filePath, expires))) {
+			return nil, errors.New("invalid signature")
+		}
+		if time.Now().Unix() > expires {
+			return nil, errors.New("signed URL expired")
+		}
+		media = medias[0]
+	}
+
+	content, err := s.openContent(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &dto.MediaFile{
+		Content:  content,
+		MimeType: media.MimeType,
+		Filename: media.OriginalName,
+		ModTime:  media.CreatedAt,
+		Public:   public,
+	}
+
+	// Originals never change once stored; with a known hash the ETag is
+	// their content. Variants are rendered again when the sizes change.
+	tag := media.ContentHash
+	if tag == "" {
+		tag = media.ID.String()
+	}
+	if filePath == media.Path {
+		file.Immutable = media.ContentHash != ""
+		file.ETag = fmt.Sprintf(`"%s-%d"`, tag, media.Size)
+		if file.Immutable {
+			file.ETag = fmt.Sprintf(`"%s"`, tag)
+		}
+		return file, nil
+	}
+
+	for _, v := range media.Variants {
+		if v.Path == filePath {
+			file.MimeType = v.MimeType
+			file.Filename = variantFilename(media.OriginalName, v)
+			file.ETag = fmt.Sprintf(`"%s-%s-%dx%d-%d"`, tag, v.Name, v.Width, v.Height, v.Size)
+			break
+		}
+	}
+	return file, nil
+}
+
+// openAvatar serves an avatar; each upload gets a new name, so the file's
+// size and time identify it
+func (s *mediaService) openAvatar(ctx context.Context, filePath string) (*dto.MediaFile, error) {
+	content, err := s.openContent(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &dto.MediaFile{
+		Content:  content,
+		MimeType: mime.TypeByExtension(path.Ext(filePath)),
+		Filename: path.Base(filePath),
+		Public:   true,
+	}
+	if stat, ok := content.(interface{ Stat() (os.FileInfo, error) }); ok {
+		if info, err := stat.Stat(); err == nil {
+			file.ModTime = info.ModTime()
+			file.ETag = fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return file, nil
+}
+
+// openContent opens a stored file for seeking, which range requests need;
+// storage that can't seek is read into memory
+func (s *mediaService) openContent(ctx context.Context, filePath string) (io.ReadSeekCloser, error) {
+	rc, err := s.storage.Open(ctx, filePath)
+	if err != nil {
+		return nil, errors.New("file not found")
+	}
+	if content, ok := rc.(io.ReadSeekCloser); ok {
+		return content, nil
+	}
+
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return &bytesFileMedia{Reader: bytes.NewReader(data), size: int64(len(data))}, nil
+}
+
+// canViewMedia reports whether user (nil = anonymous) may see media: public
+// media to everyone, the rest to its owner, admins and whoever can see the post
+func (s *mediaService) canViewMedia(user *entity.User, media *entity.Media) bool {
+	if isPublicMedia(media) {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	return s.canManageMedia(user, media) || (media.Post != nil && media.Post.IsVisibleTo(user))
+}
+
+// isPublicMedia reports whether media is unattached or on a published post.
+// Media whose post was deleted is not public.
+func isPublicMedia(media *entity.Media) bool {
+	if media.PostID == nil {
+		return true
+	}
+	return media.Post != nil && media.Post.IsPublished()
+}
+
+// variantFilename names a variant download after the original upload,
+// e.g. "beach-thumb.webp"
+func variantFilename(original string, v entity.MediaVariant) string {
+	ext := path.Ext(v.Path)
+	return strings.TrimSuffix(original, path.Ext(original)) + "-" + v.Name + ext
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/google/uuid"
+)
+
+// maxMediaTags caps the tags on one media, including ones added in bulk
+const maxMediaTags = 20
+
+// GetFolders lists a user's folders. Users only see their own; admins can
+// look at anyone's.
+func (s *mediaService) GetFolders(ctx context.Context, userID *uuid.UUID, currentUser *entity.User) ([]*dto.MediaFolderResponse, error) {
+	ownerID := currentUser.ID
+	if userID != nil && *userID != currentUser.ID {
+		if !currentUser.IsAdmin() {
+			return nil, errors.New("you can only view your own media")
+		}
+		ownerID = *userID
+	}
+
+	folders, err := s.folderRepo.FindByUserID(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folders: %w", err)
+	}
+
+	return dto.ToMediaFolderResponses(folders), nil
+}
+
+func (s *mediaService) CreateFolder(ctx context.Context, req *dto.CreateMediaFolderRequest, user *entity.User) (*dto.MediaFolderResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkFolderName(ctx, user.ID, name, nil); err != nil {
+		return nil, err
+	}
+
+	folder := &entity.MediaFolder{
+		UserID:      user.ID,
+		Name:        name,
+		Description: req.Description,
+	}
+	if err := s.folderRepo.Create(ctx, folder); err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+
+	return dto.ToMediaFolderResponse(folder), nil
+}
+
+func (s *mediaService) UpdateFolder(ctx context.Context, id uuid.UUID, req *dto.UpdateMediaFolderRequest, user *entity.User) (*dto.MediaFolderResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	folder, err := s.findFolder(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(req.Name); name != "" && name != folder.Name {
+		if err := s.checkFolderName(ctx, folder.UserID, name, &folder.ID); err != nil {
+			return nil, err
+		}
+		folder.Name = name
+	}
+
+	if req.Description != "" {
+		folder.Description = req.Description
+	}
+
+	if err := s.folderRepo.Update(ctx, folder); err != nil {
+		return nil, fmt.Errorf("failed to update folder: %w", err)
+	}
+
+	return dto.ToMediaFolderResponse(folder), nil
+}
+
+// DeleteFolder removes a folder; its media stays in the library, unfiled
+func (s *mediaService) DeleteFolder(ctx context.Context, id uuid.UUID, user *entity.User) error {
+	if _, err := s.findFolder(ctx, id, user); err != nil {
+		return err
+	}
+
+	if err := s.folderRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+	return nil
+}
+
+// GetTags lists the tags in use with their media counts: the caller's own
+// media for users, the whole library for admins
+func (s *mediaService) GetTags(ctx context.Context, currentUser *entity.User) ([]*dto.MediaTagResponse, error) {
+	var userID *uuid.UUID
+	if !currentUser.IsAdmin() {
+		userID = &currentUser.ID
+	}
+
+	tags, err := s.mediaRepo.FindTags(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	responses := make([]*dto.MediaTagResponse, len(tags))
+	for i, tag := range tags {
+		responses[i] = &dto.MediaTagResponse{Tag: tag.Tag, Count: tag.Count}
+	}
+	return responses, nil
+}
+
+// BulkUpdate applies one action to up to 100 media. Media the caller can't
+// manage is skipped and reported, the rest is still changed.
+func (s *mediaService) BulkUpdate(ctx context.Context, req *dto.BulkMediaRequest, user *entity.User) (*dto.BulkMediaResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	tags := normalizeTags(req.Tags)
+	var folder *entity.MediaFolder
+	switch req.Action {
+	case dto.MediaBulkTag, dto.MediaBulkUntag:
+		if len(tags) == 0 {
+			return nil, errors.New("tags are required")
+		}
+	case dto.MediaBulkAttach:
+		if req.PostID == nil {
+			return nil, errors.New("post_id is required")
+		}
+		if err := s.checkPostAccess(ctx, *req.PostID, user); err != nil {
+			return nil, err
+		}
+	case dto.MediaBulkMove:
+		// A null folder_id takes the media out of its folder
+		if req.FolderID != nil {
+			var err error
+			if folder, err = s.findFolder(ctx, *req.FolderID, user); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ids := uniqueIDs(req.MediaIDs)
+	medias, err := s.mediaRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get medias: %w", err)
+	}
+	byID := make(map[uuid.UUID]*entity.Media, len(medias))
+	for _, media := range medias {
+		byID[media.ID] = media
+	}
+
+	result := &dto.BulkMediaResponse{
+		Action:    req.Action,
+		Succeeded: []uuid.UUID{},
+		Failed:    []dto.BulkMediaFailure{},
+	}
+	fail := func(id uuid.UUID, err error) {
+		result.Failed = append(result.Failed, dto.BulkMediaFailure{ID: id, Error: err.Error()})
+	}
+
+	// Move and attach are done in one update once every item is checked
+	var batch, unfeature []uuid.UUID
+	for _, id := range ids {
+		media, ok := byID[id]
+		if !ok {
+			fail(id, errors.New("media not found"))
+			continue
+		}
+		if !s.canManageMedia(user, media) {
+			fail(id, errors.New("you don't have permission to change this media"))
+			continue
+		}
+
+		switch req.Action {
+		case dto.MediaBulkMove:
+			// Folders hold their owner's media only
+			if folder != nil && folder.UserID != media.UserID {
+				fail(id, errors.New("folder belongs to another user"))
+				continue
+			}
+			batch = append(batch, id)
+		case dto.MediaBulkAttach:
+			batch = append(batch, id)
+			if media.IsFeatured && (media.PostID == nil || *media.PostID != *req.PostID) {
+				unfeature = append(unfeature, id)
+			}
+		case dto.MediaBulkTag, dto.MediaBulkUntag:
+			updated := removeTags(media.Tags, tags)
+			if req.Action == dto.MediaBulkTag {
+				updated = normalizeTags(append(slices.Clone(media.Tags), tags...))
+			}
+			if len(updated) > maxMediaTags {
+				fail(id, fmt.Errorf("media can't have more than %d tags", maxMediaTags))
+				continue
+			}
+			if err := s.mediaRepo.SetTags(ctx, id, updated); err != nil {
+				fail(id, fmt.Errorf("failed to update media: %w", err))
+				continue
+			}
+			result.Succeeded = append(result.Succeeded, id)
+		case dto.MediaBulkDelete:
+			if err := s.deleteMedia(ctx, media); err != nil {
+				fail(id, fmt.Errorf("failed to delete media: %w", err))
+				continue
+			}
+			result.Succeeded = append(result.Succeeded, id)
+		}
+	}
+
+	if len(batch) > 0 {
+		var err error
+		if req.Action == dto.MediaBulkMove {
+			var folderID *uuid.UUID
+			if folder != nil {
+				folderID = &folder.ID
+			}
+			err = s.mediaRepo.MoveToFolder(ctx, batch, folderID)
+		} else if err = s.postRepo.UnsetFeaturedMedia(ctx, unfeature); err == nil {
+			err = s.mediaRepo.AttachToPost(ctx, batch, *req.PostID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update media: %w", err)
+		}
+		result.Succeeded = append(result.Succeeded, batch...)
+	}
+
+	return result, nil
+}
+
+// findFolder loads a folder the user may manage: their own, or any for admins
+func (s *mediaService) findFolder(ctx context.Context, id uuid.UUID, user *entity.User) (*entity.MediaFolder, error) {
+	folder, err := s.folderRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("folder not found")
+	}
+	if folder.UserID != user.ID && !user.IsAdmin() {
+		// Other users' folders are not disclosed
+		return nil, errors.New("folder not found")
+	}
+	return folder, nil
+}
+
+// checkFolderName rejects a name the user already has a folder for,
+// ignoring case
+func (s *mediaService) checkFolderName(ctx context.Context, userID uuid.UUID, name string, excludeID *uuid.UUID) error {
+	if name == "" {
+		return errors.New("folder name is required")
+	}
+	exists, err := s.folderRepo.ExistsByName(ctx, userID, name, excludeID)
+	if err != nil {
+		return fmt.Errorf("failed to check folder name: %w", err)
+	}
+	if exists {
+		return errors.New("folder name already exists")
+	}
+	return nil
+}
+
+// normalizeTags lowercases and trims tags, dropping empty and repeated ones
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+func removeTags(tags, remove []string) []string {
+	kept := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !slices.Contains(remove, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
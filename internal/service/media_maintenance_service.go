@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/config"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// JobStorageGC is the scheduled orphaned file collection
+const JobStorageGC = "storage.gc"
+
+type storageGCJob struct {
+	At time.Time `json:"at"` // the run this job was queued for
+}
+
+// MediaMaintenanceService runs housekeeping over stored files. It is used
+// from the maintenance commands in cmd/api and as a scheduled job.
+type MediaMaintenanceService interface {
+	// BackfillHashes hashes media stored before deduplication, records
+	// their blobs and merges files with identical content
+	BackfillHashes(ctx context.Context, batchSize int) (*dto.MediaBackfillResult, error)
+
+	// CollectGarbage finds stored files that no media row, variant or
+	// avatar refers to and that are older than grace. Unless dryRun, they
+	// are deleted.
+	CollectGarbage(ctx context.Context, grace time.Duration, dryRun bool) (*dto.StorageGCReport, error)
+
+	// CheckConsistency reports media and avatars whose files are missing
+	CheckConsistency(ctx context.Context) (*dto.StorageConsistencyReport, error)
+
+	// ScheduleGC queues the next scheduled collection, if enabled. Safe to
+	// call from every instance; only one job is queued per run.
+	ScheduleGC(ctx context.Context) error
+
+	// MigrateFeaturedMedia links posts to the media behind their
+	// featured_image URL or is_featured flag. Safe to run again.
+	MigrateFeaturedMedia(ctx context.Context) (*dto.FeaturedMediaMigrationResult, error)
+}
+
+type mediaMaintenanceService struct {
+	mediaRepo  repository.MediaRepository
+	blobRepo   repository.MediaBlobRepository
+	userRepo   repository.UserRepository
+	postRepo   repository.PostRepository
+	storage    storage.Storage
+	jobs       JobEnqueuer
+	logger     *logger.Logger
+	gcInterval time.Duration
+	gcGrace    time.Duration
+	gcDelete   bool
+	slotMaxAge time.Duration
+}
+
+func NewMediaMaintenanceService(
+	mediaRepo repository.MediaRepository,
+	blobRepo repository.MediaBlobRepository,
+	userRepo repository.UserRepository,
+	postRepo repository.PostRepository,
+	storage storage.Storage,
+	jobQueue *JobQueue,
+	logger *logger.Logger,
+	cfg *config.Config,
+) MediaMaintenanceService {
+	s := &mediaMaintenanceService{
+		mediaRepo:  mediaRepo,
+		blobRepo:   blobRepo,
+		userRepo:   userRepo,
+		postRepo:   postRepo,
+		storage:    storage,
+		jobs:       jobQueue,
+		logger:     logger,
+		gcInterval: time.Duration(cfg.Storage.GCIntervalHours) * time.Hour,
+		gcGrace:    time.Duration(cfg.Storage.GCGraceHours) * time.Hour,
+		gcDelete:   cfg.Storage.GCDelete,
+		slotMaxAge: time.Duration(cfg.Upload.SlotTTLMin)*time.Minute + slotExpiryGrace,
+	}
+
+	RegisterJob(jobQueue, JobStorageGC, s.runScheduledGC)
+
+	return s
+}
+
+func (s *mediaMaintenanceService) BackfillHashes(ctx context.Context, batchSize int) (*dto.MediaBackfillResult, error) {
+	if batchSize < 1 {
+		batchSize = 100
+	}
+
+	result := &dto.MediaBackfillResult{}
+	after := uuid.Nil
+	for {
+		medias, err := s.mediaRepo.FindUnhashed(ctx, after, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to list media: %w", err)
+		}
+		if len(medias) == 0 {
+			return result, nil
+		}
+
+		for _, media := range medias {
+			after = media.ID
+			if err := s.backfillMedia(ctx, media, result); err != nil {
+				return result, err
+			}
+		}
+	}
+}
+
+func (s *mediaMaintenanceService) backfillMedia(ctx context.Context, media *entity.Media, result *dto.MediaBackfillResult) error {
+	hash, err := s.hashStored(ctx, media.Path)
+	if err != nil {
+		// Left unhashed; deleting it removes the file as before
+		s.logger.Error("Skipping media %s: %v", media.ID, err)
+		result.Missing++
+		return nil
+	}
+
+	blob, err := s.blobRepo.Acquire(ctx, &entity.MediaBlob{
+		Hash:     hash,
+		Path:     media.Path,
+		Size:     media.Size,
+		MimeType: media.MimeType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record blob of media %s: %w", media.ID, err)
+	}
+
+	if err := s.mediaRepo.SetContent(ctx, media.ID, hash, blob.Path, s.storage.GetURL(blob.Path)); err != nil {
+		return fmt.Errorf("failed to update media %s: %w", media.ID, err)
+	}
+	result.Hashed++
+
+	if blob.Path == media.Path {
+		return nil
+	}
+
+	// Same content as a file seen earlier: drop this copy and its variants.
+	// The variants are rendered again next to the shared file.
+	if err := s.mediaRepo.UpdateVariants(ctx, media.ID, nil); err != nil {
+		return fmt.Errorf("failed to update media %s: %w", media.ID, err)
+	}
+	s.storage.Delete(ctx, media.Path)
+	for _, v := range media.Variants {
+		s.storage.Delete(ctx, v.Path)
+	}
+	if _, err := s.jobs.Enqueue(ctx, JobMediaVariants, &mediaVariantsJob{MediaID: media.ID},
+		JobUniqueKey(JobMediaVariants+":"+media.ID.String())); err != nil {
+		s.logger.Error("Failed to queue variants for media %s: %v", media.ID, err)
+	}
+
+	result.Merged++
+	result.BytesFreed += media.Size
+	return nil
+}
+
+func (s *mediaMaintenanceService) hashStored(ctx context.Context, path string) (string, error) {
+	file, err := s.storage.Open(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Storage layout, as written by the media and user services
+const (
+	incomingDir = "incoming/" // two-phase uploads before completion
+	resizedDir  = "resized/"  // on-demand renditions, one folder per media ID
+)
+
+func (s *mediaMaintenanceService) CollectGarbage(ctx context.Context, grace time.Duration, dryRun bool) (*dto.StorageGCReport, error) {
+	// References are loaded before the walk: anything stored after this is
+	// younger than the grace period
+	referenced, mediaIDs, err := s.references(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.StorageGCReport{DryRun: dryRun, Orphans: []dto.StorageObject{}}
+	now := time.Now()
+
+	err = s.storage.Walk(ctx, "", func(obj storage.ObjectInfo) error {
+		report.Scanned++
+		p := cleanStoragePath(obj.Path)
+		if referenced[p] {
+			return nil
+		}
+		if rest, ok := strings.CutPrefix(p, resizedDir); ok {
+			id, _, _ := strings.Cut(rest, "/")
+			if mediaIDs[id] {
+				return nil
+			}
+		}
+
+		// Pending uploads are only referenced by their slot, until it expires
+		minAge := grace
+		if strings.HasPrefix(p, incomingDir) && minAge < s.slotMaxAge {
+			minAge = s.slotMaxAge
+		}
+		if now.Sub(obj.ModTime) < minAge {
+			return nil
+		}
+
+		report.Orphans = append(report.Orphans, dto.StorageObject{Path: obj.Path, Size: obj.Size, ModTime: obj.ModTime})
+		report.OrphanBytes += obj.Size
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to walk storage: %w", err)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	for _, orphan := range report.Orphans {
+		if err := s.storage.Delete(ctx, orphan.Path); err != nil {
+			s.logger.Error("Failed to delete orphaned file %s: %v", orphan.Path, err)
+			continue
+		}
+		report.Deleted++
+	}
+	return report, nil
+}
+
+// references collects every path a media row, variant or avatar points at,
+// and the IDs of live media
+func (s *mediaMaintenanceService) references(ctx context.Context) (map[string]bool, map[string]bool, error) {
+	paths := make(map[string]bool)
+	mediaIDs := make(map[string]bool)
+
+	err := s.eachMedia(ctx, func(media *entity.Media) error {
+		mediaIDs[media.ID.String()] = true
+		paths[cleanStoragePath(media.Path)] = true
+		for _, v := range media.Variants {
+			paths[cleanStoragePath(v.Path)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users, err := s.userRepo.FindWithAvatar(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list avatars: %w", err)
+	}
+	for _, user := range users {
+		if p := avatarPath(user.Avatar); p != "" {
+			paths[p] = true
+		}
+	}
+
+	return paths, mediaIDs, nil
+}
+
+func (s *mediaMaintenanceService) MigrateFeaturedMedia(ctx context.Context) (*dto.FeaturedMediaMigrationResult, error) {
+	migration, err := s.postRepo.MigrateFeaturedMedia(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate featured media: %w", err)
+	}
+
+	return &dto.FeaturedMediaMigrationResult{
+		FromURLs:  migration.FromURLs,
+		FromFlags: migration.FromFlags,
+		External:  migration.External,
+	}, nil
+}
+
+func (s *mediaMaintenanceService) CheckConsistency(ctx context.Context) (*dto.StorageConsistencyReport, error) {
+	report := &dto.StorageConsistencyReport{Missing: []dto.MissingFile{}}
+	missing := func(kind string, id uuid.UUID, p string) {
+		if !s.storage.Exists(ctx, p) {
+			report.Missing = append(report.Missing, dto.MissingFile{Kind: kind, ID: id, Path: p})
+		}
+	}
+
+	err := s.eachMedia(ctx, func(media *entity.Media) error {
+		report.CheckedMedia++
+		missing("media", media.ID, media.Path)
+		for _, v := range media.Variants {
+			missing("variant", media.ID, v.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	users, err := s.userRepo.FindWithAvatar(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to list avatars: %w", err)
+	}
+	for _, user := range users {
+		// External avatars (Gravatar etc.) have no stored file
+		if p := avatarPath(user.Avatar); p != "" {
+			report.CheckedAvatars++
+			missing("avatar", user.ID, p)
+		}
+	}
+
+	return report, nil
+}
+
+func (s *mediaMaintenanceService) eachMedia(ctx context.Context, fn func(*entity.Media) error) error {
+	after := uuid.Nil
+	for {
+		medias, err := s.mediaRepo.FindBatch(ctx, after, 500)
+		if err != nil {
+			return fmt.Errorf("failed to list media: %w", err)
+		}
+		if len(medias) == 0 {
+			return nil
+		}
+		for _, media := range medias {
+			after = media.ID
+			if err := fn(media); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *mediaMaintenanceService) ScheduleGC(ctx context.Context) error {
+	return s.scheduleGCAfter(ctx, time.Now())
+}
+
+// scheduleGCAfter queues the first run after t. Runs are aligned to the
+// interval, so every instance queues the same one.
+func (s *mediaMaintenanceService) scheduleGCAfter(ctx context.Context, t time.Time) error {
+	if s.gcInterval <= 0 {
+		return nil
+	}
+
+	next := t.Truncate(s.gcInterval).Add(s.gcInterval)
+	_, err := s.jobs.Enqueue(ctx, JobStorageGC, &storageGCJob{At: next},
+		JobRunAt(next),
+		JobUniqueKey(fmt.Sprintf("%s:%d", JobStorageGC, next.Unix())))
+	return err
+}
+
+func (s *mediaMaintenanceService) runScheduledGC(ctx context.Context, job storageGCJob) error {
+	// Queue the next run first, so a failing run doesn't end the schedule
+	after := job.At
+	if now := time.Now(); now.After(after) {
+		after = now
+	}
+	if err := s.scheduleGCAfter(ctx, after); err != nil {
+		s.logger.Error("Failed to schedule storage collection: %v", err)
+	}
+
+	report, err := s.CollectGarbage(ctx, s.gcGrace, !s.gcDelete)
+	if err != nil {
+		return err
+	}
+	if report.DryRun {
+		s.logger.Info("Storage collection: %d files scanned, %d orphaned (%d bytes), not deleted",
+			report.Scanned, len(report.Orphans), report.OrphanBytes)
+	} else {
+		s.logger.Info("Storage collection: %d files scanned, %d of %d orphans deleted (%d bytes)",
+			report.Scanned, report.Deleted, len(report.Orphans), report.OrphanBytes)
+	}
+
+	check, err := s.CheckConsistency(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range check.Missing {
+		s.logger.Error("Missing %s file for %s: %s", m.Kind, m.ID, m.Path)
+	}
+	return nil
+}
+
+// cleanStoragePath normalises a relative path for comparison
+func cleanStoragePath(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// avatarPath returns the storage path of an uploaded avatar, or "" for
+// external avatars. Avatars are saved as URLs: local ones contain
+// "/uploads/", S3 ones end in the object key.
+func avatarPath(avatar string) string {
+	if isDefaultAvatar(avatar) {
+		return ""
+	}
+	if p := extractPathFromURL(avatar); p != "" {
+		return cleanStoragePath(p)
+	}
+	avatar, _, _ = strings.Cut(avatar, "?")
+	if i := strings.LastIndex(avatar, "/avatars/"); i != -1 {
+		return avatar[i+1:]
+	}
+	return ""
+}
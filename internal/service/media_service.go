@@ -3,134 +3,747 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	goimage "image"
 	"io"
 	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
+	"github.com/afdhali/GolangBlogpostServer/config"
 	"github.com/afdhali/GolangBlogpostServer/internal/dto"
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/afdhali/GolangBlogpostServer/internal/repository"
 	"github.com/afdhali/GolangBlogpostServer/pkg/image"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/afdhali/GolangBlogpostServer/pkg/mediafile"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
 	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
 	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
 	"github.com/google/uuid"
 )
 
+// JobMediaVariants renders the configured variants of an uploaded image
+const JobMediaVariants = "media.variants"
+
+// Resize modes accepted by the on-demand resize endpoint
+const (
+	ResizeFitContain = "contain"
+	ResizeFitCover   = "cover"
+)
+
+type mediaVariantsJob struct {
+	MediaID uuid.UUID `json:"media_id"`
+}
+
 type MediaService interface {
-	Upload(ctx context.Context, userID uuid.UUID, file multipart.File, header *multipart.FileHeader, req *dto.UploadMediaRequest) (*dto.MediaResponse, error)
+	Upload(ctx context.Context, user *entity.User, file multipart.File, header *multipart.FileHeader, req *dto.UploadMediaRequest) (*dto.MediaResponse, error)
 	// GetAll(ctx context.Context, params *dto.MediaQueryParams) ([]*dto.MediaListResponse, int64, error)
-	GetAll(ctx context.Context, params *dto.MediaQueryParams, currentUser *entity.User) ([]*dto.MediaListResponse, int64, error)
+	GetAll(ctx context.Context, params *dto.MediaQueryParams, currentUser *entity.User) ([]*dto.MediaListResponse, *dto.PageInfo, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*dto.MediaResponse, error)
-	GetByPostID(ctx context.Context, postID uuid.UUID) ([]*dto.MediaResponse, error)
-	GetFeaturedByPostID(ctx context.Context, postID uuid.UUID) (*dto.MediaResponse, error)
+	GetByPostID(ctx context.Context, postID uuid.UUID, currentUser *entity.User) ([]*dto.MediaResponse, error)
+	GetFeaturedByPostID(ctx context.Context, postID uuid.UUID, currentUser *entity.User) (*dto.MediaResponse, error)
 	Update(ctx context.Context, id uuid.UUID, req *dto.UpdateMediaRequest, user *entity.User) (*dto.MediaResponse, error)
 	Delete(ctx context.Context, id uuid.UUID, user *entity.User) error
+
+	// Media library: folders, tags and bulk actions
+	GetFolders(ctx context.Context, userID *uuid.UUID, currentUser *entity.User) ([]*dto.MediaFolderResponse, error)
+	CreateFolder(ctx context.Context, req *dto.CreateMediaFolderRequest, user *entity.User) (*dto.MediaFolderResponse, error)
+	UpdateFolder(ctx context.Context, id uuid.UUID, req *dto.UpdateMediaFolderRequest, user *entity.User) (*dto.MediaFolderResponse, error)
+	DeleteFolder(ctx context.Context, id uuid.UUID, user *entity.User) error
+	GetTags(ctx context.Context, currentUser *entity.User) ([]*dto.MediaTagResponse, error)
+	BulkUpdate(ctx context.Context, req *dto.BulkMediaRequest, user *entity.User) (*dto.BulkMediaResponse, error)
+
+	// Two-phase uploads straight to storage
+	CreateUploadSlot(ctx context.Context, user *entity.User, req *dto.CreateUploadSlotRequest) (*dto.UploadSlotResponse, error)
+	ReceiveSlotUpload(ctx context.Context, id uuid.UUID, expires int64, signature string, body io.Reader) error
+	CompleteUploadSlot(ctx context.Context, id uuid.UUID, user *entity.User) (*dto.MediaResponse, error)
+
+	// On-demand resizing
+	SignResizeURL(ctx context.Context, id uuid.UUID, width, height uint, fit string, user *entity.User) (*dto.ResizeURLResponse, error)
+	Resize(ctx context.Context, id uuid.UUID, width, height uint, fit, signature string) (*dto.ResizedImage, error)
+
+	// File delivery with access control for media of unpublished posts
+	SignFileURL(ctx context.Context, id uuid.UUID, variant string, download bool, user *entity.User) (*dto.FileURLResponse, error)
+	OpenFile(ctx context.Context, filePath string, user *entity.User, expires int64, signature string) (*dto.MediaFile, error)
 }
 
 type mediaService struct {
+	txManager      repository.TxManager
 	mediaRepo      repository.MediaRepository
+	slotRepo       repository.UploadSlotRepository
+	blobRepo       repository.MediaBlobRepository
 	postRepo       repository.PostRepository
+	folderRepo     repository.MediaFolderRepository
+	quota          StorageQuotaService
 	storage        storage.Storage
 	imageValidator *image.Validator
-	imageProcessor *image.Processor
+	fileValidator  *mediafile.Validator
+	mediaProcessor *image.MediaProcessor
+	thumbnailer    mediafile.Thumbnailer
+	resizeSigner   *image.ResizeSigner
+	cursors        *pagination.Signer
 	validator      *validator.CustomValidator
+	jobs           JobEnqueuer
+	logger         *logger.Logger
+	maxResize      uint
+	slotTTL        time.Duration
+	uploadSecret   []byte
+	signFileURLs   bool // files are served by OpenFile rather than by the storage
+	fileURLTTL     time.Duration
 }
 
 func NewMediaService(
+	txManager repository.TxManager,
 	mediaRepo repository.MediaRepository,
+	slotRepo repository.UploadSlotRepository,
+	blobRepo repository.MediaBlobRepository,
 	postRepo repository.PostRepository,
+	folderRepo repository.MediaFolderRepository,
+	quota StorageQuotaService,
 	storage storage.Storage,
 	imageValidator *image.Validator,
-	imageProcessor *image.Processor,
+	fileValidator *mediafile.Validator,
+	mediaProcessor *image.MediaProcessor,
+	thumbnailer mediafile.Thumbnailer,
+	resizeSigner *image.ResizeSigner,
+	cursors *pagination.Signer,
 	validator *validator.CustomValidator,
+	jobQueue *JobQueue,
+	logger *logger.Logger,
+	cfg *config.Config,
 ) MediaService {
-	return &mediaService{
+	uploadSecret := cfg.Upload.SigningSecret
+	if uploadSecret == "" {
+		uploadSecret = cfg.JWT.Secret
+	}
+
+	s := &mediaService{
+		txManager:      txManager,
 		mediaRepo:      mediaRepo,
+		slotRepo:       slotRepo,
+		blobRepo:       blobRepo,
 		postRepo:       postRepo,
+		folderRepo:     folderRepo,
+		quota:          quota,
 		storage:        storage,
 		imageValidator: imageValidator,
-		imageProcessor: imageProcessor,
+		fileValidator:  fileValidator,
+		mediaProcessor: mediaProcessor,
+		thumbnailer:    thumbnailer,
+		resizeSigner:   resizeSigner,
+		cursors:        cursors,
 		validator:      validator,
+		jobs:           jobQueue,
+		logger:         logger,
+		maxResize:      uint(cfg.Image.ResizeMaxDimension),
+		slotTTL:        time.Duration(cfg.Upload.SlotTTLMin) * time.Minute,
+		uploadSecret:   []byte(uploadSecret),
+		signFileURLs:   cfg.Storage.Driver != "s3",
+		fileURLTTL:     time.Duration(cfg.Storage.SignedURLTTLMin) * time.Minute,
 	}
+
+	RegisterJob(jobQueue, JobMediaVariants, s.generateVariants)
+	RegisterJob(jobQueue, JobExpireUploadSlot, s.expireUploadSlot)
+
+	return s
 }
 
-func (s *mediaService) Upload(ctx context.Context, userID uuid.UUID, file multipart.File, header *multipart.FileHeader, req *dto.UploadMediaRequest) (*dto.MediaResponse, error) {
+func (s *mediaService) Upload(ctx context.Context, user *entity.User, file multipart.File, header *multipart.FileHeader, req *dto.UploadMediaRequest) (*dto.MediaResponse, error) {
 	// Validate request
 	if err := s.validator.Validate(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
-	// Validate image
-	if err := s.imageValidator.Validate(header); err != nil {
-		return nil, fmt.Errorf("invalid image: %w", err)
+	if req.PostID != nil {
+		if err := s.checkPostAccess(ctx, *req.PostID, user); err != nil {
+			return nil, err
+		}
 	}
 
+	return s.createMedia(ctx, user.ID, file, header, req, nil)
+}
+
+// createMedia validates and stores an upload and creates its Media row.
+// Shared by direct and two-phase uploads, which check access to the post
+// beforehand; then, if given, runs in the same transaction as the row is
+// created in.
+func (s *mediaService) createMedia(ctx context.Context, userID uuid.UUID, file multipart.File, header *multipart.FileHeader, req *dto.UploadMediaRequest, then func(ctx context.Context, media *entity.Media) error) (*dto.MediaResponse, error) {
 	// Check if post exists (if post_id provided)
 	if req.PostID != nil {
-		_, err := s.postRepo.FindByID(ctx, *req.PostID)
-		if err != nil {
+		if _, err := s.postRepo.FindByID(ctx, *req.PostID); err != nil {
 			return nil, errors.New("post not found")
 		}
 	}
 
-	// Process image (compress & resize)
-	processedFile, err := s.imageProcessor.Process(file, header)
+	// Uploads go into the uploader's own folders
+	if req.FolderID != nil {
+		folder, err := s.folderRepo.FindByID(ctx, *req.FolderID)
+		if err != nil || folder.UserID != userID {
+			return nil, errors.New("folder not found")
+		}
+	}
+
+	// Checked against the upload's size; stored images are usually smaller.
+	// This only saves storing files that can't fit: the stored size is
+	// checked again under the user's lock below.
+	if err := s.quota.Check(ctx, userID, header.Size, 1); err != nil {
+		return nil, err
+	}
+
+	// Images are re-encoded; video, audio and documents are kept as uploaded
+	store := s.storeImage
+	if isMediaFile(file) {
+		store = s.storeFile
+	}
+
+	media, blob, err := store(ctx, file, header)
 	if err != nil {
-		return nil, fmt.Errorf("failed to process image: %w", err)
+		return nil, err
 	}
 
-	// Convert io.Reader to multipart.File
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, processedFile); err != nil {
-		return nil, fmt.Errorf("failed to read processed image: %w", err)
+	media.OriginalName = header.Filename
+	media.AltText = req.AltText
+	media.Description = req.Description
+	media.PostID = req.PostID
+	media.UserID = userID
+	media.FolderID = req.FolderID
+	media.Tags = normalizeTags(req.Tags)
+
+	// The file reference, the row, the featured switch and the variants job
+	// are committed together
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.quota.CheckLocked(ctx, userID, media.Size, 1); err != nil {
+			return err
+		}
+
+		if err := s.acquireBlob(ctx, media, blob); err != nil {
+			return err
+		}
+
+		if err := s.mediaRepo.Create(ctx, media); err != nil {
+			return fmt.Errorf("failed to save media to database: %w", err)
+		}
+
+		// Featuring replaces the post's current featured item; it only
+		// applies to images uploaded for a post
+		if req.IsFeatured && req.PostID != nil && media.IsImage() {
+			if err := s.postRepo.SetFeaturedMedia(ctx, *req.PostID, media); err != nil {
+				return fmt.Errorf("failed to feature media: %w", err)
+			}
+		}
+
+		// Variants (and stills for video and documents) are rendered in the background
+		if _, err := s.jobs.Enqueue(ctx, JobMediaVariants, &mediaVariantsJob{MediaID: media.ID},
+			JobUniqueKey(JobMediaVariants+":"+media.ID.String())); err != nil {
+			return fmt.Errorf("failed to queue variants: %w", err)
+		}
+
+		if then != nil {
+			return then(ctx, media)
+		}
+		return nil
+	})
+	if err != nil {
+		// Nothing refers to a file stored for this upload
+		if blob.stored {
+			s.storage.Delete(ctx, blob.path)
+		}
+		return nil, err
 	}
 
-	// Create a new multipart.File from buffer
+	// Reload with relations
+	media, _ = s.mediaRepo.FindByID(ctx, media.ID)
+
+	return dto.ToMediaResponse(media), nil
+}
+
+// storeImage validates, compresses and resizes an image and saves it
+func (s *mediaService) storeImage(ctx context.Context, file multipart.File, header *multipart.FileHeader) (*entity.Media, *blobUpload, error) {
+	// Validate image
+	if err := s.imageValidator.Validate(ctx, file, header); err != nil {
+		return nil, nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	// Process image (compress & resize)
+	processed, err := s.mediaProcessor.ProcessImage(file, header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to process image: %w", err)
+	}
+
+	// Create a new multipart.File from the encoded image
 	processedMultipart := &bytesFileMedia{
-		Reader: bytes.NewReader(buf.Bytes()),
-		size:   int64(buf.Len()),
+		Reader: bytes.NewReader(processed.Data),
+		size:   int64(len(processed.Data)),
 	}
 
 	// Save to storage
-	fileInfo, err := s.storage.Save(ctx, processedMultipart, header, "posts")
+	// The header is rewritten so the extension and content type match the
+	// encoded bytes, which may differ from the upload
+	sum := sha256.Sum256(processed.Data)
+	hash := hex.EncodeToString(sum[:])
+	fileInfo, blob, err := s.saveBlob(ctx, processedMultipart, processed.FileHeader(header), hash)
 	if err != nil {
-		return nil, fmt.Errorf("failed to save media: %w", err)
+		return nil, nil, err
 	}
 
-	// Create media entity
 	media := &entity.Media{
-		Filename:     fileInfo.Filename,
-		OriginalName: fileInfo.OriginalName,
-		MimeType:     fileInfo.MimeType,
-		Path:         fileInfo.Path,
-		URL:          fileInfo.URL,
-		Size:         fileInfo.Size,
-		AltText:      req.AltText,
-		Description:  req.Description,
-		MediaType:    entity.MediaTypeImage,
-		PostID:       req.PostID,
-		UserID:       userID,
-		IsFeatured:   req.IsFeatured,
-	}
-
-	// Save media to database
-	if err := s.mediaRepo.Create(ctx, media); err != nil {
-		// Rollback: delete uploaded file
-		s.storage.Delete(ctx, fileInfo.Path)
-		return nil, fmt.Errorf("failed to save media to database: %w", err)
+		Filename:    fileInfo.Filename,
+		MimeType:    processed.MimeType,
+		Path:        fileInfo.Path,
+		URL:         fileInfo.URL,
+		Size:        fileInfo.Size,
+		ContentHash: hash,
+		MediaType:   entity.MediaTypeImage,
 	}
+	media.SetDimensions(processed.Width, processed.Height)
+	media.OriginalWidth = &processed.OriginalWidth
+	media.OriginalHeight = &processed.OriginalHeight
+	if meta := processed.Metadata; meta != nil {
+		media.CameraMake = meta.CameraMake
+		media.CameraModel = meta.CameraModel
+		media.CapturedAt = meta.CapturedAt
+	}
+	return media, blob, nil
+}
 
-	// Reload with relations
-	media, _ = s.mediaRepo.FindByID(ctx, media.ID)
+// storeFile validates a video, audio or document upload, reads its
+// duration, size or page count, and saves it unchanged
+func (s *mediaService) storeFile(ctx context.Context, file multipart.File, header *multipart.FileHeader) (*entity.Media, *blobUpload, error) {
+	validated, err := s.fileValidator.Validate(ctx, file, header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid file: %w", err)
+	}
 
-	return dto.ToMediaResponse(media), nil
+	// Store under the sniffed type, with an extension to match
+	filename := header.Filename
+	if path.Ext(filename) == "" {
+		filename += mediafile.Extension(validated.MimeType)
+	}
+	stored := &multipart.FileHeader{
+		Filename: filename,
+		Header:   textproto.MIMEHeader{"Content-Type": {validated.MimeType}},
+		Size:     header.Size,
+	}
+
+	hash, err := hashFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	fileInfo, blob, err := s.saveBlob(ctx, file, stored, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	media := &entity.Media{
+		Filename:    fileInfo.Filename,
+		MimeType:    validated.MimeType,
+		Path:        fileInfo.Path,
+		URL:         fileInfo.URL,
+		Size:        fileInfo.Size,
+		ContentHash: hash,
+		MediaType:   entity.MediaType(validated.Kind),
+	}
+	info := validated.Info
+	if info.Width > 0 && info.Height > 0 {
+		media.SetDimensions(info.Width, info.Height)
+	}
+	if info.Duration > 0 {
+		seconds := info.Duration.Seconds()
+		media.Duration = &seconds
+	}
+	if info.Pages > 0 {
+		media.PageCount = &info.Pages
+	}
+	return media, blob, nil
+}
+
+// blobUpload is the content of an upload, and whether it was stored for the
+// upload (at path) or an identical upload's file is reused
+type blobUpload struct {
+	file   multipart.File
+	header *multipart.FileHeader
+	stored bool
+	path   string
+}
+
+// saveBlob stores a file under "posts", unless a file with the same
+// SHA-256 is already stored. Identical uploads share one file and, since
+// variant paths follow the file's, its variants. The reference is taken by
+// acquireBlob along with the media row.
+func (s *mediaService) saveBlob(ctx context.Context, file multipart.File, header *multipart.FileHeader, hash string) (*storage.FileInfo, *blobUpload, error) {
+	upload := &blobUpload{file: file, header: header}
+	if blob, err := s.blobRepo.FindByHash(ctx, hash); err == nil && s.storage.Exists(ctx, blob.Path) {
+		return s.blobInfo(blob), upload, nil
+	}
+
+	fileInfo, err := s.storeBlob(ctx, upload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fileInfo, upload, nil
+}
+
+func (s *mediaService) storeBlob(ctx context.Context, upload *blobUpload) (*storage.FileInfo, error) {
+	if _, err := upload.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	fileInfo, err := s.storage.Save(ctx, upload.file, upload.header, "posts")
+	if err != nil {
+		return nil, fmt.Errorf("failed to save media: %w", err)
+	}
+	upload.stored, upload.path = true, fileInfo.Path
+	return fileInfo, nil
+}
+
+// acquireBlob takes a reference on the media's file. If a concurrent upload
+// of the same content was recorded first, the media uses that file instead
+// and the copy stored for it is deleted once committed. A reused file may
+// have lost its last media since it was looked up, and is deleted then; the
+// upload is stored after all.
+func (s *mediaService) acquireBlob(ctx context.Context, media *entity.Media, upload *blobUpload) error {
+	if !upload.stored {
+		referenced, err := s.blobRepo.Reference(ctx, media.ContentHash)
+		if err != nil {
+			return fmt.Errorf("failed to reference media file: %w", err)
+		}
+		if referenced {
+			return nil
+		}
+
+		fileInfo, err := s.storeBlob(ctx, upload)
+		if err != nil {
+			return err
+		}
+		media.Filename = fileInfo.Filename
+		media.Path = fileInfo.Path
+		media.URL = fileInfo.URL
+	}
+
+	blob, err := s.blobRepo.Acquire(ctx, &entity.MediaBlob{
+		Hash:     media.ContentHash,
+		Path:     media.Path,
+		Size:     media.Size,
+		MimeType: media.MimeType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reference media file: %w", err)
+	}
+
+	if blob.Path != media.Path {
+		duplicate := media.Path
+		repository.AfterCommit(ctx, func(ctx context.Context) {
+			s.storage.Delete(ctx, duplicate)
+		})
+		media.Filename = path.Base(blob.Path)
+		media.Path = blob.Path
+		media.URL = s.storage.GetURL(blob.Path)
+	}
+	return nil
 }
 
-// func (s *mediaService) GetAll(ctx context.Context, params *dto.MediaQueryParams) ([]*dto.MediaListResponse, int64, error) {
+func (s *mediaService) blobInfo(blob *entity.MediaBlob) *storage.FileInfo {
+	return &storage.FileInfo{
+		Filename: path.Base(blob.Path),
+		Path:     blob.Path,
+		URL:      s.storage.GetURL(blob.Path),
+		Size:     blob.Size,
+		MimeType: blob.MimeType,
+	}
+}
+
+// releaseFiles drops a media row's reference to its file, and deletes the
+// file and its variants, once committed, if no other media uses them.
+// Failures are logged: leftover files are only wasted space.
+func (s *mediaService) releaseFiles(ctx context.Context, media *entity.Media) {
+	if media.ContentHash != "" {
+		last, err := s.blobRepo.Release(ctx, media.ContentHash)
+		if err != nil {
+			s.logger.Error("Failed to release file of media %s: %v", media.ID, err)
+			return
+		}
+		if !last {
+			return
+		}
+	}
+
+	repository.AfterCommit(ctx, func(ctx context.Context) {
+		if err := s.storage.Delete(ctx, media.Path); err != nil {
+			s.logger.Error("Failed to delete file of media %s: %v", media.ID, err)
+		}
+		for _, v := range media.Variants {
+			s.storage.Delete(ctx, v.Path)
+		}
+	})
+}
+
+// hashFile returns the hex SHA-256 of an upload and rewinds it
+func hashFile(file multipart.File) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	defer file.Seek(0, io.SeekStart)
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// isMediaFile reports whether an upload is video, audio or a document
+// rather than an image, going by its content
+func isMediaFile(file multipart.File) bool {
+	head := make([]byte, 512)
+	n, _ := file.ReadAt(head, 0)
+	return mediafile.Detect(head[:n]) != ""
+}
+
+// generateVariants renders every configured variant from the stored
+// original and saves them next to it
+func (s *mediaService) generateVariants(ctx context.Context, job mediaVariantsJob) error {
+	media, err := s.mediaRepo.FindByID(ctx, job.MediaID)
+	if err != nil {
+		return fmt.Errorf("failed to load media: %w", err)
+	}
+
+	var renditions []*image.Rendition
+	var img goimage.Image
+	sourceFormat := image.FormatOf(media.MimeType)
+
+	if media.IsImage() {
+		if img, err = s.openImage(ctx, media); err != nil {
+			return err
+		}
+	} else {
+		// Other media get a still, and variants are rendered from that
+		img, err = s.renderStill(ctx, media)
+		if errors.Is(err, mediafile.ErrNoThumbnail) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		poster, err := s.mediaProcessor.Poster(img)
+		if err != nil {
+			return PermanentJobError(err)
+		}
+		poster.Variant = image.Variant{Name: entity.MediaVariantPoster}
+		renditions = append(renditions, poster)
+		sourceFormat = image.FormatJPEG
+	}
+
+	sized, err := s.mediaProcessor.GenerateVariants(img, sourceFormat)
+	if err != nil {
+		return PermanentJobError(err)
+	}
+	renditions = append(renditions, sized...)
+
+	base := strings.TrimSuffix(media.Path, path.Ext(media.Path))
+	variants := make(entity.MediaVariants, 0, len(renditions))
+	kept := make(map[string]bool, len(renditions))
+
+	for _, r := range renditions {
+		variantPath := fmt.Sprintf("%s_%s%s", base, r.Variant.Name, image.Extension(r.MimeType))
+		info, err := s.storage.Put(ctx, bytes.NewReader(r.Data), variantPath, r.MimeType)
+		if err != nil {
+			return fmt.Errorf("failed to save %s variant: %w", r.Variant.Name, err)
+		}
+
+		kept[info.Path] = true
+		variants = append(variants, entity.MediaVariant{
+			Name:     r.Variant.Name,
+			Path:     info.Path,
+			URL:      info.URL,
+			Width:    r.Width,
+			Height:   r.Height,
+			Size:     info.Size,
+			MimeType: r.MimeType,
+			Crop:     r.Variant.Crop,
+		})
+	}
+
+	if err := s.mediaRepo.UpdateVariants(ctx, media.ID, variants); err != nil {
+		return fmt.Errorf("failed to save variants: %w", err)
+	}
+
+	// Drop files of variants that are no longer configured
+	for _, old := range media.Variants {
+		if !kept[old.Path] {
+			s.storage.Delete(ctx, old.Path)
+		}
+	}
+
+	return nil
+}
+
+// SignResizeURL hands out a signed URL for an arbitrary size
+func (s *mediaService) SignResizeURL(ctx context.Context, id uuid.UUID, width, height uint, fit string, user *entity.User) (*dto.ResizeURLResponse, error) {
+	fit, err := s.checkResize(width, height, fit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only sign images the caller may see, like SignFileURL
+	media, err := s.mediaRepo.FindByID(ctx, id)
+	if err != nil || !s.canViewMedia(user, media) {
+		return nil, errors.New("media not found")
+	}
+	if !media.IsImage() {
+		return nil, errors.New("media is not an image")
+	}
+
+	query := url.Values{}
+	if width > 0 {
+		query.Set("w", fmt.Sprint(width))
+	}
+	if height > 0 {
+		query.Set("h", fmt.Sprint(height))
+	}
+	query.Set("fit", fit)
+	query.Set("sig", s.resizeSigner.Sign(id.String(), width, height, fit))
+
+	return &dto.ResizeURLResponse{
+		URL:    fmt.Sprintf("/media/%s/resize?%s", id, query.Encode()),
+		Width:  width,
+		Height: height,
+		Fit:    fit,
+	}, nil
+}
+
+// Resize renders a signed size of an image. Renditions are cached in
+// storage, so each size is only computed once.
+func (s *mediaService) Resize(ctx context.Context, id uuid.UUID, width, height uint, fit, signature string) (*dto.ResizedImage, error) {
+	fit, err := s.checkResize(width, height, fit)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.resizeSigner.Verify(id.String(), width, height, fit, signature) {
+		return nil, errors.New("invalid signature")
+	}
+
+	media, err := s.mediaRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("media not found")
+	}
+	if !media.IsImage() {
+		return nil, errors.New("media is not an image")
+	}
+
+	// Renditions keep the original's format
+	format := s.mediaProcessor.OutputFormat(image.FormatOf(media.MimeType))
+	mimeType := image.MimeType(format)
+	cachePath := fmt.Sprintf("resized/%s/%dx%d_%s%s", media.ID, width, height, fit, image.Extension(mimeType))
+	etag := fmt.Sprintf(`"%s-%dx%d-%s-%s-%d"`, media.ID, width, height, fit, format, media.UpdatedAt.Unix())
+
+	resized := &dto.ResizedImage{
+		MimeType: mimeType,
+		ETag:     etag,
+		ModTime:  media.UpdatedAt,
+	}
+
+	if cached, err := s.storage.Open(ctx, cachePath); err == nil {
+		defer cached.Close()
+		if resized.Data, err = io.ReadAll(cached); err == nil {
+			return resized, nil
+		}
+	}
+
+	img, err := s.openImage(ctx, media)
+	if err != nil {
+		return nil, err
+	}
+
+	if fit == ResizeFitCover {
+		img = image.Cover(img, width, height)
+	} else {
+		img = image.Fit(img, width, height)
+	}
+
+	rendition, err := s.mediaProcessor.Render(img, image.FormatOf(media.MimeType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render image: %w", err)
+	}
+	resized.Data = rendition.Data
+	resized.MimeType = rendition.MimeType
+
+	// Caching is best-effort; the rendition is served either way
+	s.storage.Put(ctx, bytes.NewReader(rendition.Data), cachePath, rendition.MimeType)
+
+	return resized, nil
+}
+
+// checkResize validates resize parameters and returns the effective fit
+func (s *mediaService) checkResize(width, height uint, fit string) (string, error) {
+	if fit == "" {
+		fit = ResizeFitContain
+	}
+	if fit != ResizeFitContain && fit != ResizeFitCover {
+		return "", errors.New("fit must be contain or cover")
+	}
+	if width == 0 && height == 0 {
+		return "", errors.New("width or height is required")
+	}
+	if fit == ResizeFitCover && (width == 0 || height == 0) {
+		return "", errors.New("cover needs both width and height")
+	}
+	if width > s.maxResize || height > s.maxResize {
+		return "", fmt.Errorf("width and height must not exceed %d", s.maxResize)
+	}
+	return fit, nil
+}
+
+// refreshURLs rebuilds file URLs from their paths. With presigned storage
+// URLs expire, so the ones saved at upload time cannot be handed out as is.
+func (s *mediaService) refreshURLs(medias ...*entity.Media) {
+	for _, media := range medias {
+		if media == nil {
+			continue
+		}
+		media.URL = s.storage.GetURL(media.Path)
+		for i := range media.Variants {
+			media.Variants[i].URL = s.storage.GetURL(media.Variants[i].Path)
+		}
+	}
+}
+
+// renderStill asks the thumbnailer for a poster frame or first page
+func (s *mediaService) renderStill(ctx context.Context, media *entity.Media) (goimage.Image, error) {
+	file, err := s.storage.Open(ctx, media.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open media file: %w", err)
+	}
+	defer file.Close()
+
+	return s.thumbnailer.Thumbnail(ctx, file, media.MimeType)
+}
+
+func (s *mediaService) openImage(ctx context.Context, media *entity.Media) (goimage.Image, error) {
+	file, err := s.storage.Open(ctx, media.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open media file: %w", err)
+	}
+	defer file.Close()
+
+	img, err := s.mediaProcessor.Decode(file, media.MimeType)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// func (s *mediaService) GetAll(ctx context.Context, params *dto.MediaQueryParams) ([]*dto.MediaListResponse, *dto.PageInfo, error) {
 // 	// Validate params
 // 	if err := s.validator.Validate(params); err != nil {
-// 		return nil, 0, fmt.Errorf("validation error: %w", err)
+// 		return nil, nil, fmt.Errorf("validation error: %w", err)
 // 	}
 
 // 	// Default pagination
@@ -144,7 +757,7 @@ func (s *mediaService) Upload(ctx context.Context, userID uuid.UUID, file multip
 // 	// Get medias
 // 	medias, total, err := s.mediaRepo.FindAll(ctx, params.Page, params.Limit, params.MediaType, params.PostID, params.UserID, params.IsFeatured, params.SortBy, params.SortOrder)
 // 	if err != nil {
-// 		return nil, 0, fmt.Errorf("failed to get medias: %w", err)
+// 		return nil, nil, fmt.Errorf("failed to get medias: %w", err)
 // 	}
 
 // 	// Convert to response
@@ -154,63 +767,86 @@ func (s *mediaService) Upload(ctx context.Context, userID uuid.UUID, file multip
 // }
 
 func (s *mediaService) GetAll(
-    ctx context.Context,
-    params *dto.MediaQueryParams,
-    currentUser *entity.User, // bisa nil untuk public call
-) ([]*dto.MediaListResponse, int64, error) {
-    // Validate params
-    if err := s.validator.Validate(params); err != nil {
-        return nil, 0, fmt.Errorf("validation error: %w", err)
-    }
-
-    // Default pagination
-    if params.Page < 1 {
-        params.Page = 1
-    }
-    if params.Limit < 1 {
-        params.Limit = 10
-    }
-    if params.Limit > 100 {
-        params.Limit = 100
-    }
-
-    // ---------- LOGIKA FILTER USER_ID BERDASARKAN ROLE ----------
-    var effectiveUserID *uuid.UUID
-
-    if currentUser != nil && !currentUser.IsAdmin() {
-        // ✅ User biasa WAJIB hanya lihat media miliknya
-        // Abaikan params.UserID jika ada, ganti dengan currentUser.ID
-        if params.UserID != nil && *params.UserID != currentUser.ID {
-            return nil, 0, errors.New("you can only view your own media")
-        }
-        effectiveUserID = &currentUser.ID
-    } else if params.UserID != nil {
-        // Admin atau public call - boleh filter per user_id
-        effectiveUserID = params.UserID
-    }
-    // else: Public call tanpa user_id, atau admin tanpa filter â†' semua media
-    // jika currentUser == nil (public) atau admin dan tidak ada user_id → effectiveUserID tetap nil (lihat semua)
-
-    // ---------- PANGGIL REPOSITORY ----------
-    medias, total, err := s.mediaRepo.FindAll(
-        ctx,
-        params.Page,
-        params.Limit,
-        params.MediaType,
-        params.PostID,
-        effectiveUserID,        // ← ini yang sudah di-adjust
-        params.IsFeatured,
-        params.SortBy,
-        params.SortOrder,
-    )
-    if err != nil {
-        return nil, 0, fmt.Errorf("failed to get medias: %w", err)
-    }
-
-    // Konversi ke response (User sudah di-preload di repository)
-    responses := dto.ToMediaListResponses(medias)
-
-    return responses, total, nil
+	ctx context.Context,
+	params *dto.MediaQueryParams,
+	currentUser *entity.User, // bisa nil untuk public call
+) ([]*dto.MediaListResponse, *dto.PageInfo, error) {
+	// Validate params
+	if err := s.validator.Validate(params); err != nil {
+		return nil, nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	// Default pagination
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 10
+	}
+	if params.Limit > 100 {
+		params.Limit = 100
+	}
+
+	// ---------- LOGIKA FILTER USER_ID BERDASARKAN ROLE ----------
+	var effectiveUserID *uuid.UUID
+
+	if currentUser != nil && !currentUser.IsAdmin() {
+		// ✅ User biasa WAJIB hanya lihat media miliknya
+		// Abaikan params.UserID jika ada, ganti dengan currentUser.ID
+		if params.UserID != nil && *params.UserID != currentUser.ID {
+			return nil, nil, errors.New("you can only view your own media")
+		}
+		effectiveUserID = &currentUser.ID
+	} else if params.UserID != nil {
+		// Admin atau public call - boleh filter per user_id
+		effectiveUserID = params.UserID
+	}
+	// else: Public call tanpa user_id, atau admin tanpa filter â†' semua media
+	// jika currentUser == nil (public) atau admin dan tidak ada user_id → effectiveUserID tetap nil (lihat semua)
+
+	filter := &repository.MediaFilter{
+		MediaType:  params.MediaType,
+		PostID:     params.PostID,
+		UserID:     effectiveUserID, // ← ini yang sudah di-adjust
+		FolderID:   params.FolderID,
+		Unfiled:    params.Unfiled,
+		IsFeatured: params.IsFeatured,
+		Search:     strings.TrimSpace(params.Search),
+		Tags:       normalizeTags(params.Tags),
+		MinWidth:   params.MinWidth,
+		MaxWidth:   params.MaxWidth,
+		MinHeight:  params.MinHeight,
+		MaxHeight:  params.MaxHeight,
+		From:       params.UploadedFrom,
+		To:         params.UploadedTo,
+	}
+
+	paging, err := listPaging(s.cursors, params.CursorParams, params.Page, params.Limit, params.SortBy, params.SortOrder)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// ---------- PANGGIL REPOSITORY ----------
+	medias, total, err := s.mediaRepo.FindAll(ctx, paging, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get medias: %w", err)
+	}
+	medias, page := listPage(s.cursors, paging, medias, total, func(media *entity.Media) (interface{}, uuid.UUID) {
+		switch paging.SortBy {
+		case "updated_at":
+			return media.UpdatedAt, media.ID
+		case "size":
+			return media.Size, media.ID
+		default:
+			return media.CreatedAt, media.ID
+		}
+	})
+
+	// Konversi ke response (User sudah di-preload di repository)
+	s.refreshURLs(medias...)
+	responses := dto.ToMediaListResponses(medias)
+
+	return responses, page, nil
 }
 
 func (s *mediaService) GetByID(ctx context.Context, id uuid.UUID) (*dto.MediaResponse, error) {
@@ -219,12 +855,13 @@ func (s *mediaService) GetByID(ctx context.Context, id uuid.UUID) (*dto.MediaRes
 		return nil, errors.New("media not found")
 	}
 
+	s.refreshURLs(media)
 	return dto.ToMediaResponse(media), nil
 }
 
-func (s *mediaService) GetByPostID(ctx context.Context, postID uuid.UUID) ([]*dto.MediaResponse, error) {
-	// Check if post exists
-	_, err := s.postRepo.FindByID(ctx, postID)
+func (s *mediaService) GetByPostID(ctx context.Context, postID uuid.UUID, currentUser *entity.User) ([]*dto.MediaResponse, error) {
+	// Media of a post the viewer may not read stays hidden with it
+	_, err := s.postRepo.FindVisibleByID(ctx, postID, repository.PostViewerFor(currentUser))
 	if err != nil {
 		return nil, errors.New("post not found")
 	}
@@ -234,12 +871,13 @@ func (s *mediaService) GetByPostID(ctx context.Context, postID uuid.UUID) ([]*dt
 		return nil, fmt.Errorf("failed to get medias: %w", err)
 	}
 
+	s.refreshURLs(medias...)
 	return dto.ToMediaResponses(medias), nil
 }
 
-func (s *mediaService) GetFeaturedByPostID(ctx context.Context, postID uuid.UUID) (*dto.MediaResponse, error) {
-	// Check if post exists
-	_, err := s.postRepo.FindByID(ctx, postID)
+func (s *mediaService) GetFeaturedByPostID(ctx context.Context, postID uuid.UUID, currentUser *entity.User) (*dto.MediaResponse, error) {
+	// Media of a post the viewer may not read stays hidden with it
+	_, err := s.postRepo.FindVisibleByID(ctx, postID, repository.PostViewerFor(currentUser))
 	if err != nil {
 		return nil, errors.New("post not found")
 	}
@@ -249,6 +887,7 @@ func (s *mediaService) GetFeaturedByPostID(ctx context.Context, postID uuid.UUID
 		return nil, errors.New("featured media not found")
 	}
 
+	s.refreshURLs(media)
 	return dto.ToMediaResponse(media), nil
 }
 
@@ -272,13 +911,27 @@ func (s *mediaService) Update(ctx context.Context, id uuid.UUID, req *dto.Update
 		return nil, errors.New("you don't have permission to update this media")
 	}
 
-	// Check post if changed
+	// Check post if changed; featured media stops being featured on its old post
+	unfeature := false
 	if req.PostID != nil && (media.PostID == nil || *req.PostID != *media.PostID) {
-		_, err := s.postRepo.FindByID(ctx, *req.PostID)
-		if err != nil {
-			return nil, errors.New("post not found")
+		if err := s.checkPostAccess(ctx, *req.PostID, user); err != nil {
+			return nil, err
 		}
 		media.PostID = req.PostID
+		unfeature = media.IsFeatured
+	}
+
+	// Check folder if changed; it has to be one of the owner's
+	if req.FolderID != nil && (media.FolderID == nil || *req.FolderID != *media.FolderID) {
+		folder, err := s.folderRepo.FindByID(ctx, *req.FolderID)
+		if err != nil || folder.UserID != media.UserID {
+			return nil, errors.New("folder not found")
+		}
+		media.FolderID = req.FolderID
+	}
+
+	if req.Tags != nil {
+		media.Tags = normalizeTags(req.Tags)
 	}
 
 	// Update fields
@@ -290,14 +943,45 @@ func (s *mediaService) Update(ctx context.Context, id uuid.UUID, req *dto.Update
 		media.Description = req.Description
 	}
 
+	// The flag is switched by the post repository, which keeps one
+	// featured item per post
+	feature := false
 	if req.IsFeatured != nil {
-		media.IsFeatured = *req.IsFeatured
+		if *req.IsFeatured {
+			if media.PostID == nil {
+				return nil, errors.New("media is not attached to a post")
+			}
+			if !media.IsImage() {
+				return nil, errors.New("only images can be featured")
+			}
+			// Featuring changes the post, so it takes access to it
+			if err := s.checkPostAccess(ctx, *media.PostID, user); err != nil {
+				return nil, err
+			}
+			feature = true
+		} else if media.IsFeatured {
+			unfeature = true
+		}
+	}
+	if unfeature {
+		media.IsFeatured = false
 	}
 
 	if err := s.mediaRepo.Update(ctx, media); err != nil {
 		return nil, fmt.Errorf("failed to update media: %w", err)
 	}
 
+	if unfeature {
+		if err := s.postRepo.UnsetFeaturedMedia(ctx, []uuid.UUID{media.ID}); err != nil {
+			return nil, fmt.Errorf("failed to update featured media: %w", err)
+		}
+	}
+	if feature {
+		if err := s.postRepo.SetFeaturedMedia(ctx, *media.PostID, media); err != nil {
+			return nil, fmt.Errorf("failed to update featured media: %w", err)
+		}
+	}
+
 	// Reload with relations
 	media, _ = s.mediaRepo.FindByID(ctx, media.ID)
 
@@ -318,13 +1002,27 @@ func (s *mediaService) Delete(ctx context.Context, id uuid.UUID, user *entity.Us
 		return errors.New("you don't have permission to delete this media")
 	}
 
-	// Delete file from storage
-	if err := s.storage.Delete(ctx, media.Path); err != nil {
-		return fmt.Errorf("failed to delete media file: %w", err)
-	}
+	return s.deleteMedia(ctx, media)
+}
+
+// deleteMedia removes a media row; the file goes once no other media shares it
+func (s *mediaService) deleteMedia(ctx context.Context, media *entity.Media) error {
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Delete from database
+		if err := s.mediaRepo.Delete(ctx, media.ID); err != nil {
+			return err
+		}
+
+		// Soft deletes don't trigger the posts foreign key
+		if media.IsFeatured {
+			if err := s.postRepo.UnsetFeaturedMedia(ctx, []uuid.UUID{media.ID}); err != nil {
+				return fmt.Errorf("failed to unset featured media: %w", err)
+			}
+		}
 
-	// Delete from database
-	return s.mediaRepo.Delete(ctx, id)
+		s.releaseFiles(ctx, media)
+		return nil
+	})
 }
 
 // 👇 HELPER METHOD - Permission check
@@ -342,6 +1040,19 @@ func (s *mediaService) canManageMedia(user *entity.User, media *entity.Media) bo
 	return user.ID == media.UserID
 }
 
+// checkPostAccess checks that user may attach media to a post or feature it
+// there: only its author and admins may
+func (s *mediaService) checkPostAccess(ctx context.Context, postID uuid.UUID, user *entity.User) error {
+	post, err := s.postRepo.FindByID(ctx, postID)
+	if err != nil {
+		return errors.New("post not found")
+	}
+	if post.AuthorID != user.ID && !user.IsAdmin() {
+		return errors.New("you can only add media to your own posts")
+	}
+	return nil
+}
+
 // Helper type
 type bytesFileMedia struct {
 	*bytes.Reader
@@ -350,4 +1061,4 @@ type bytesFileMedia struct {
 
 func (b *bytesFileMedia) Close() error {
 	return nil
-}
\ No newline at end of file
+}
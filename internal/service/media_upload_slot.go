@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/pkg/mediafile"
+	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
+	"github.com/google/uuid"
+)
+
+// JobExpireUploadSlot removes the file of a slot that was never completed
+const JobExpireUploadSlot = "media.upload_slot.expire"
+
+// slotExpiryGrace keeps the raw file around for a while after the slot
+// expires, so a complete call that started just in time can still finish
+const slotExpiryGrace = 10 * time.Minute
+
+type expireUploadSlotJob struct {
+	SlotID uuid.UUID `json:"slot_id"`
+}
+
+// CreateUploadSlot reserves a storage path for a file the client uploads
+// itself. The declared type and size are checked now; the content is
+// checked again on completion.
+func (s *mediaService) CreateUploadSlot(ctx context.Context, user *entity.User, req *dto.CreateUploadSlotRequest) (*dto.UploadSlotResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	validateDeclared := s.imageValidator.ValidateDeclared
+	if mediafile.KindOf(req.ContentType) != "" {
+		validateDeclared = s.fileValidator.ValidateDeclared
+	}
+	if err := validateDeclared(req.Filename, req.ContentType, req.Size); err != nil {
+		return nil, fmt.Errorf("invalid upload: %w", err)
+	}
+
+	// The post must be the uploader's own (if post_id provided)
+	if req.PostID != nil {
+		if err := s.checkPostAccess(ctx, *req.PostID, user); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fail early; completing the upload checks again
+	if err := s.quota.Check(ctx, user.ID, req.Size, 1); err != nil {
+		return nil, err
+	}
+
+	slot := &entity.UploadSlot{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		Status:      entity.UploadSlotPending,
+		Filename:    path.Base(req.Filename),
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		AltText:     req.AltText,
+		Description: req.Description,
+		PostID:      req.PostID,
+		IsFeatured:  req.IsFeatured,
+		ExpiresAt:   time.Now().Add(s.slotTTL),
+	}
+	slot.Path = "incoming/" + slot.ID.String() + strings.ToLower(path.Ext(slot.Filename))
+
+	target, err := s.uploadTarget(slot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload url: %w", err)
+	}
+
+	if err := s.slotRepo.Create(ctx, slot); err != nil {
+		return nil, fmt.Errorf("failed to create upload slot: %w", err)
+	}
+
+	// Clean up if the client never completes
+	if _, err := s.jobs.Enqueue(ctx, JobExpireUploadSlot, &expireUploadSlotJob{SlotID: slot.ID},
+		JobRunAt(slot.ExpiresAt.Add(slotExpiryGrace)),
+		JobUniqueKey(JobExpireUploadSlot+":"+slot.ID.String())); err != nil {
+		s.logger.Error("Failed to schedule expiry for upload slot %s: %v", slot.ID, err)
+	}
+
+	return &dto.UploadSlotResponse{
+		ID:        slot.ID,
+		Upload:    target,
+		ExpiresAt: slot.ExpiresAt,
+	}, nil
+}
+
+// uploadTarget presigns a PUT when the storage supports it, and otherwise
+// hands out a signed URL to our own upload endpoint
+func (s *mediaService) uploadTarget(slot *entity.UploadSlot) (*dto.UploadTargetResponse, error) {
+	if direct, ok := s.storage.(storage.DirectUploader); ok {
+		target, err := direct.PresignPut(slot.Path, slot.ContentType, time.Until(slot.ExpiresAt))
+		if err != nil {
+			return nil, err
+		}
+		return &dto.UploadTargetResponse{
+			Method:  target.Method,
+			URL:     target.URL,
+			Headers: target.Headers,
+		}, nil
+	}
+
+	expires := slot.ExpiresAt.Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.signSlot(slot.ID, expires))
+
+	return &dto.UploadTargetResponse{
+		Method:  http.MethodPut,
+		URL:     fmt.Sprintf("/upload-slots/%s?%s", slot.ID, query.Encode()),
+		Headers: map[string]string{"Content-Type": slot.ContentType},
+	}, nil
+}
+
+func (s *mediaService) signSlot(id uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, s.uploadSecret)
+	fmt.Fprintf(mac, "%s\n%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ReceiveSlotUpload stores the body of a signed local upload. It stands in
+// for a presigned PUT when the storage is not directly reachable.
+func (s *mediaService) ReceiveSlotUpload(ctx context.Context, id uuid.UUID, expires int64, signature string, body io.Reader) error {
+	if !hmac.Equal([]byte(signature), []byte(s.signSlot(id, expires))) {
+		return errors.New("invalid signature")
+	}
+	if time.Now().Unix() > expires {
+		return errors.New("upload slot expired")
+	}
+
+	slot, err := s.slotRepo.FindByID(ctx, id)
+	if err != nil {
+		return errors.New("upload slot not found")
+	}
+	if slot.Status != entity.UploadSlotPending || slot.IsExpired(time.Now()) {
+		return errors.New("upload slot expired")
+	}
+
+	// One byte over the declared size is enough to reject the upload
+	info, err := s.storage.Put(ctx, io.LimitReader(body, slot.Size+1), slot.Path, slot.ContentType)
+	if err != nil {
+		return fmt.Errorf("failed to save upload: %w", err)
+	}
+	if info.Size > slot.Size {
+		s.storage.Delete(ctx, slot.Path)
+		return errors.New("upload exceeds declared size")
+	}
+
+	return nil
+}
+
+// CompleteUploadSlot verifies the uploaded file and turns it into media,
+// running the same validation and processing as a regular upload. Calling
+// it again for a completed slot returns the same media.
+func (s *mediaService) CompleteUploadSlot(ctx context.Context, id uuid.UUID, user *entity.User) (*dto.MediaResponse, error) {
+	slot, err := s.slotRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("upload slot not found")
+	}
+	if slot.UserID != user.ID {
+		return nil, errors.New("you don't have permission to complete this upload")
+	}
+
+	if slot.Status == entity.UploadSlotCompleted && slot.MediaID != nil {
+		media, err := s.mediaRepo.FindByID(ctx, *slot.MediaID)
+		if err != nil {
+			return nil, errors.New("media not found")
+		}
+		s.refreshURLs(media)
+		return dto.ToMediaResponse(media), nil
+	}
+	if slot.IsExpired(time.Now()) {
+		return nil, errors.New("upload slot expired")
+	}
+
+	file, err := s.spoolSlotUpload(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	header := &multipart.FileHeader{
+		Filename: slot.Filename,
+		Header:   textproto.MIMEHeader{"Content-Type": {slot.ContentType}},
+		Size:     slot.Size,
+	}
+
+	media, err := s.createMedia(ctx, slot.UserID, file, header, &dto.UploadMediaRequest{
+		AltText:     slot.AltText,
+		Description: slot.Description,
+		PostID:      slot.PostID,
+		IsFeatured:  slot.IsFeatured,
+	}, func(ctx context.Context, media *entity.Media) error {
+		// Completing the slot twice must not create two media rows
+		ok, err := s.slotRepo.MarkCompleted(ctx, slot.ID, media.ID)
+		if err != nil {
+			return fmt.Errorf("failed to complete upload slot: %w", err)
+		}
+		if !ok {
+			return errors.New("upload slot expired")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The processed copy is stored separately, so the raw upload can go
+	s.storage.Delete(ctx, slot.Path)
+
+	return media, nil
+}
+
+// spoolSlotUpload copies the uploaded file to a temp file, checking it
+// against the declared size. Videos can be large, so it is not read into
+// memory.
+func (s *mediaService) spoolSlotUpload(ctx context.Context, slot *entity.UploadSlot) (*os.File, error) {
+	src, err := s.storage.Open(ctx, slot.Path)
+	if err != nil {
+		return nil, errors.New("file has not been uploaded")
+	}
+	defer src.Close()
+
+	file, err := os.CreateTemp("", "upload-slot-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	n, err := io.Copy(file, io.LimitReader(src, slot.Size+1))
+	switch {
+	case err != nil:
+		err = fmt.Errorf("failed to read upload: %w", err)
+	case n > slot.Size:
+		s.storage.Delete(ctx, slot.Path)
+		err = errors.New("upload exceeds declared size")
+	case n != slot.Size:
+		err = errors.New("upload does not match declared size")
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// expireUploadSlot drops a slot that was never completed, with its file
+func (s *mediaService) expireUploadSlot(ctx context.Context, job expireUploadSlotJob) error {
+	slot, err := s.slotRepo.FindByID(ctx, job.SlotID)
+	if err != nil {
+		return nil // gone already
+	}
+	if slot.Status != entity.UploadSlotPending {
+		return nil
+	}
+
+	ok, err := s.slotRepo.MarkExpired(ctx, slot.ID)
+	if err != nil {
+		return fmt.Errorf("failed to expire upload slot: %w", err)
+	}
+	if !ok {
+		return nil // completed in the meantime
+	}
+
+	if err := s.storage.Delete(ctx, slot.Path); err != nil {
+		return fmt.Errorf("failed to delete expired upload: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/broker"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+)
+
+type NotificationService interface {
+	// Event hooks. Delivery is best-effort: failures are logged, never returned,
+	// so they can't break the request that triggered them.
+	NotifyComment(ctx context.Context, comment *entity.Comment, parent *entity.Comment)
+	NotifyPostPublished(ctx context.Context, post *entity.Post, actor *entity.User)
+	NotifyNewFollower(ctx context.Context, follower *entity.User, followingID uuid.UUID)
+
+	GetAll(ctx context.Context, params *dto.NotificationQueryParams, user *entity.User) ([]*dto.NotificationResponse, int64, error)
+	GetUnreadCount(ctx context.Context, user *entity.User) (*dto.UnreadCountResponse, error)
+	MarkRead(ctx context.Context, id uuid.UUID, user *entity.User) error
+	MarkAllRead(ctx context.Context, user *entity.User) (int64, error)
+	GetPreferences(ctx context.Context, user *entity.User) (*dto.NotificationPreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, req *dto.UpdateNotificationPreferencesRequest, user *entity.User) (*dto.NotificationPreferencesResponse, error)
+}
+
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+	followRepo       repository.FollowRepository
+	validator        *validator.CustomValidator
+	logger           *logger.Logger
+	broker           broker.Broker
+}
+
+func NewNotificationService(
+	notificationRepo repository.NotificationRepository,
+	followRepo repository.FollowRepository,
+	validator *validator.CustomValidator,
+	logger *logger.Logger,
+	broker broker.Broker,
+) NotificationService {
+	return &notificationService{
+		notificationRepo: notificationRepo,
+		followRepo:       followRepo,
+		validator:        validator,
+		logger:           logger,
+		broker:           broker,
+	}
+}
+
+// NotifyComment tells the parent comment's author about a reply and the
+// post's author about a new comment. Nobody is notified about their own
+// action, and nobody gets two notifications for the same comment.
+func (s *notificationService) NotifyComment(ctx context.Context, comment *entity.Comment, parent *entity.Comment) {
+	actorName := displayName(comment.User)
+	var notifications []*entity.Notification
+
+	notified := map[uuid.UUID]bool{comment.UserID: true}
+
+	if parent != nil && !notified[parent.UserID] {
+		notified[parent.UserID] = true
+		notifications = append(notifications, &entity.Notification{
+			UserID:    parent.UserID,
+			ActorID:   &comment.UserID,
+			Type:      entity.NotificationTypeCommentReply,
+			PostID:    &comment.PostID,
+			CommentID: &comment.ID,
+			Message:   fmt.Sprintf("%s replied to your comment", actorName),
+		})
+	}
+
+	if comment.Post != nil && !notified[comment.Post.AuthorID] {
+		notifications = append(notifications, &entity.Notification{
+			UserID:    comment.Post.AuthorID,
+			ActorID:   &comment.UserID,
+			Type:      entity.NotificationTypePostComment,
+			PostID:    &comment.PostID,
+			CommentID: &comment.ID,
+			Message:   fmt.Sprintf("%s commented on your post \"%s\"", actorName, comment.Post.Title),
+		})
+	}
+
+	s.deliver(ctx, notifications)
+}
+
+// NotifyPostPublished tells the author their post was approved (when someone
+// else published it) and tells the author's followers about the new post.
+func (s *notificationService) NotifyPostPublished(ctx context.Context, post *entity.Post, actor *entity.User) {
+	var notifications []*entity.Notification
+
+	if actor != nil && actor.ID != post.AuthorID {
+		notifications = append(notifications, &entity.Notification{
+			UserID:  post.AuthorID,
+			ActorID: &actor.ID,
+			Type:    entity.NotificationTypePostPublished,
+			PostID:  &post.ID,
+			Message: fmt.Sprintf("Your post \"%s\" has been published", post.Title),
+		})
+	}
+
+	followerIDs, err := s.followRepo.FindFollowerIDs(ctx, post.AuthorID)
+	if err != nil {
+		s.logger.Error("Failed to load followers for post %s: %v", post.ID, err)
+	}
+
+	authorName := displayName(post.Author)
+	for _, followerID := range followerIDs {
+		if followerID == post.AuthorID {
+			continue
+		}
+		notifications = append(notifications, &entity.Notification{
+			UserID:  followerID,
+			ActorID: &post.AuthorID,
+			Type:    entity.NotificationTypeFollowedPost,
+			PostID:  &post.ID,
+			Message: fmt.Sprintf("%s published \"%s\"", authorName, post.Title),
+		})
+	}
+
+	s.deliver(ctx, notifications)
+}
+
+func (s *notificationService) NotifyNewFollower(ctx context.Context, follower *entity.User, followingID uuid.UUID) {
+	s.deliver(ctx, []*entity.Notification{{
+		UserID:  followingID,
+		ActorID: &follower.ID,
+		Type:    entity.NotificationTypeNewFollower,
+		Message: fmt.Sprintf("%s started following you", displayName(follower)),
+	}})
+}
+
+// deliver drops notifications the recipients opted out of, stores the rest
+// and pushes them to any open notification streams
+func (s *notificationService) deliver(ctx context.Context, notifications []*entity.Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+
+	recipientIDs := make([]uuid.UUID, 0, len(notifications))
+	for _, n := range notifications {
+		recipientIDs = append(recipientIDs, n.UserID)
+	}
+
+	prefs, err := s.notificationRepo.FindPreferences(ctx, recipientIDs)
+	if err != nil {
+		s.logger.Error("Failed to load notification preferences: %v", err)
+		return
+	}
+
+	allowed := notifications[:0]
+	for _, n := range notifications {
+		if prefs[n.UserID].Allows(n.Type) {
+			allowed = append(allowed, n)
+		}
+	}
+
+	if err := s.notificationRepo.CreateBatch(ctx, allowed); err != nil {
+		s.logger.Error("Failed to create %d notifications: %v", len(allowed), err)
+		return
+	}
+
+	for _, n := range allowed {
+		_ = s.broker.Publish(ctx, UserNotificationsTopic(n.UserID), EventNotificationCreated, dto.ToNotificationResponse(n))
+	}
+}
+
+func (s *notificationService) GetAll(ctx context.Context, params *dto.NotificationQueryParams, user *entity.User) ([]*dto.NotificationResponse, int64, error) {
+	// Validate params
+	if err := s.validator.Validate(params); err != nil {
+		return nil, 0, fmt.Errorf("validation error: %w", err)
+	}
+
+	// Default pagination
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 20
+	}
+
+	notifications, total, err := s.notificationRepo.FindByUserID(ctx, user.ID, params.Unread, params.Page, params.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	responses := make([]*dto.NotificationResponse, len(notifications))
+	for i, notification := range notifications {
+		responses[i] = dto.ToNotificationResponse(notification)
+	}
+
+	return responses, total, nil
+}
+
+func (s *notificationService) GetUnreadCount(ctx context.Context, user *entity.User) (*dto.UnreadCountResponse, error) {
+	count, err := s.notificationRepo.CountUnread(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return &dto.UnreadCountResponse{UnreadCount: count}, nil
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, id uuid.UUID, user *entity.User) error {
+	found, err := s.notificationRepo.MarkRead(ctx, id, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification as read: %w", err)
+	}
+	if !found {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+func (s *notificationService) MarkAllRead(ctx context.Context, user *entity.User) (int64, error) {
+	count, err := s.notificationRepo.MarkAllRead(ctx, user.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	return count, nil
+}
+
+func (s *notificationService) GetPreferences(ctx context.Context, user *entity.User) (*dto.NotificationPreferencesResponse, error) {
+	prefs, err := s.notificationRepo.FindPreferences(ctx, []uuid.UUID{user.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return dto.ToNotificationPreferencesResponse(prefs[user.ID]), nil
+}
+
+func (s *notificationService) UpdatePreferences(ctx context.Context, req *dto.UpdateNotificationPreferencesRequest, user *entity.User) (*dto.NotificationPreferencesResponse, error) {
+	prefs, err := s.notificationRepo.FindPreferences(ctx, []uuid.UUID{user.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	pref := prefs[user.ID]
+
+	// Update fields
+	if req.PostComment != nil {
+		pref.PostComment = *req.PostComment
+	}
+	if req.CommentReply != nil {
+		pref.CommentReply = *req.CommentReply
+	}
+	if req.PostPublished != nil {
+		pref.PostPublished = *req.PostPublished
+	}
+	if req.FollowedPost != nil {
+		pref.FollowedPost = *req.FollowedPost
+	}
+	if req.NewFollower != nil {
+		pref.NewFollower = *req.NewFollower
+	}
+
+	if err := s.notificationRepo.SavePreference(ctx, pref); err != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %w", err)
+	}
+
+	return dto.ToNotificationPreferencesResponse(pref), nil
+}
+
+// displayName is how a user is named in notification messages
+func displayName(user *entity.User) string {
+	if user == nil {
+		return "Someone"
+	}
+	if user.FullName != "" {
+		return user.FullName
+	}
+	return user.Username
+}
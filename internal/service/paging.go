@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
+	"github.com/google/uuid"
+)
+
+// listPaging builds the repository paging of a listing; sortBy is a column
+// its params validated. In cursor mode the cursor must be one the listing
+// handed out for the same order.
+func listPaging(cursors *pagination.Signer, params dto.CursorParams, page, limit int, sortBy, sortOrder string) (repository.Paging, error) {
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	sortOrder = strings.ToLower(sortOrder)
+	if sortOrder != "asc" {
+		sortOrder = "desc"
+	}
+
+	paging := repository.Paging{Page: page, Limit: limit, SortBy: sortBy, SortOrder: sortOrder}
+	if !params.UseCursor {
+		return paging, nil
+	}
+
+	cursor, err := cursors.Decode(params.Cursor)
+	if err != nil {
+		return paging, err
+	}
+	if cursor != nil && cursor.Sort != sortBy+" "+sortOrder {
+		return paging, pagination.ErrInvalidCursor
+	}
+
+	paging.Keyset = true
+	paging.Cursor = cursor
+	paging.SkipTotal = params.NoTotal
+	return paging, nil
+}
+
+// listPage cuts the rows a repository returned for paging down to the page
+// and describes it. key returns a row's value of the sort column and its ID.
+func listPage[T any](cursors *pagination.Signer, paging repository.Paging, rows []T, total int64, key func(T) (interface{}, uuid.UUID)) ([]T, *dto.PageInfo) {
+	if !paging.Keyset {
+		return rows, &dto.PageInfo{Total: &total}
+	}
+
+	info := &dto.PageInfo{}
+	if !paging.SkipTotal {
+		info.Total = &total
+	}
+
+	rows, more := pagination.Trim(rows, paging.Limit, paging.Cursor != nil && paging.Cursor.Prev)
+	if len(rows) == 0 {
+		return rows, info
+	}
+
+	keyset := func(row T) pagination.Keyset {
+		value, id := key(row)
+		return pagination.Keyset{Sort: paging.SortBy + " " + paging.SortOrder, Value: value, ID: id}
+	}
+	info.NextCursor, info.PrevCursor = cursors.Cursors(paging.Cursor, keyset(rows[0]), keyset(rows[len(rows)-1]), more)
+	return rows, info
+}
@@ -9,45 +9,73 @@ import (
 	"github.com/afdhali/GolangBlogpostServer/internal/dto"
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
 	"github.com/afdhali/GolangBlogpostServer/pkg/security"
+	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
 	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/afdhali/GolangBlogpostServer/pkg/viewcounter"
 	"github.com/google/uuid"
 )
 
 type PostService interface {
 	// GetAll(ctx context.Context, params *dto.PostQueryParams) ([]*dto.PostListResponse, int64, error)
-	GetAll(ctx context.Context, params *dto.PostQueryParams, currentUser *entity.User) ([]*dto.PostListResponse, int64, error)
-	GetByID(ctx context.Context, id uuid.UUID) (*dto.PostResponse, error)
-	GetBySlug(ctx context.Context, slug string) (*dto.PostResponse, error)
+	GetAll(ctx context.Context, params *dto.PostQueryParams, currentUser *entity.User) ([]*dto.PostListResponse, *dto.PageInfo, error)
+	GetByID(ctx context.Context, id uuid.UUID, currentUser *entity.User) (*dto.PostResponse, error)
+	GetBySlug(ctx context.Context, slug string, currentUser *entity.User) (*dto.PostResponse, error)
 	Create(ctx context.Context, req *dto.CreatePostRequest, userID uuid.UUID) (*dto.PostResponse, error)
 	Update(ctx context.Context, id uuid.UUID, req *dto.UpdatePostRequest, user *entity.User) (*dto.PostResponse, error)
-	Delete(ctx context.Context, id uuid.UUID, user *entity.User) error
+	Delete(ctx context.Context, id uuid.UUID, version *int64, user *entity.User) error
 	Publish(ctx context.Context, id uuid.UUID, user *entity.User) (*dto.PostResponse, error)
 	Unpublish(ctx context.Context, id uuid.UUID, user *entity.User) (*dto.PostResponse, error)
-	IncrementViews(ctx context.Context, id uuid.UUID) error
+	IncrementViews(ctx context.Context, id uuid.UUID, req *dto.PostViewRequest) (bool, error)
+	GetFeed(ctx context.Context, params *dto.FeedQueryParams, user *entity.User) ([]*dto.PostListResponse, *dto.PageInfo, error)
 }
 
 type postService struct {
+	txManager    repository.TxManager
 	postRepo     repository.PostRepository
 	categoryRepo repository.CategoryRepository
 	commentRepo  repository.CommentRepository
+	bookmarkRepo repository.BookmarkRepository
+	mediaRepo    repository.MediaRepository
+	storage      storage.Storage
 	sanitizer    security.Sanitizer
 	validator    *validator.CustomValidator
+	viewCounter  *viewcounter.Counter
+	notifier     NotificationService
+	webhooks     WebhookService
+	cursors      *pagination.Signer
 }
 
 func NewPostService(
+	txManager repository.TxManager,
 	postRepo repository.PostRepository,
 	categoryRepo repository.CategoryRepository,
 	commentRepo repository.CommentRepository,
+	bookmarkRepo repository.BookmarkRepository,
+	mediaRepo repository.MediaRepository,
+	storage storage.Storage,
 	sanitizer security.Sanitizer,
 	validator *validator.CustomValidator,
+	viewCounter *viewcounter.Counter,
+	notifier NotificationService,
+	webhooks WebhookService,
+	cursors *pagination.Signer,
 ) PostService {
 	return &postService{
+		txManager:    txManager,
 		postRepo:     postRepo,
 		categoryRepo: categoryRepo,
 		commentRepo:  commentRepo,
+		bookmarkRepo: bookmarkRepo,
+		mediaRepo:    mediaRepo,
+		storage:      storage,
 		sanitizer:    sanitizer,
 		validator:    validator,
+		viewCounter:  viewCounter,
+		notifier:     notifier,
+		webhooks:     webhooks,
+		cursors:      cursors,
 	}
 }
 
@@ -92,10 +120,10 @@ func NewPostService(
 // 	return responses, total, nil
 // }
 
-func (s *postService) GetAll(ctx context.Context, params *dto.PostQueryParams, currentUser *entity.User) ([]*dto.PostListResponse, int64, error) {
+func (s *postService) GetAll(ctx context.Context, params *dto.PostQueryParams, currentUser *entity.User) ([]*dto.PostListResponse, *dto.PageInfo, error) {
 	// Validate params
 	if err := s.validator.Validate(params); err != nil {
-		return nil, 0, fmt.Errorf("validation error: %w", err)
+		return nil, nil, fmt.Errorf("validation error: %w", err)
 	}
 
 	// Default pagination
@@ -106,84 +134,208 @@ func (s *postService) GetAll(ctx context.Context, params *dto.PostQueryParams, c
 		params.Limit = 10
 	}
 
-	// Get posts
-	posts, total, err := s.postRepo.FindAll(ctx, params.Page, params.Limit, params.Search, params.Status, params.CategoryID, params.Tag, params.AuthorID, params.SortBy, params.SortOrder)
+	sortBy := params.SortBy
+	if sortBy == "views" {
+		sortBy = "view_count"
+	}
+	paging, err := listPaging(s.cursors, params.CursorParams, params.Page, params.Limit, sortBy, params.SortOrder)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get posts: %w", err)
-	}
-
-	// ✅ Filter posts based on user role
-	var filteredPosts []*entity.Post
-	if currentUser != nil && !currentUser.IsAdmin() {
-		// Non-admin user: show published posts + own draft/archived posts
-		for _, post := range posts {
-			// Show published posts from anyone
-			if post.Status == entity.PostStatusPublished {
-				filteredPosts = append(filteredPosts, post)
-				continue
-			}
-			// Show own draft/archived posts
-			if post.AuthorID == currentUser.ID {
-				filteredPosts = append(filteredPosts, post)
-				continue
-			}
+		return nil, nil, err
+	}
+
+	// Get posts; drafts and archived posts only show to their authors and admins
+	viewer := repository.PostViewerFor(currentUser)
+	posts, total, err := s.postRepo.FindAll(ctx, viewer, paging, params.Search, params.Status, params.CategoryID, params.Tag, params.AuthorID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get posts: %w", err)
+	}
+	posts, page := listPage(s.cursors, paging, posts, total, postSortKey(paging.SortBy))
+
+	responses, err := s.toPostListResponses(ctx, posts, currentUser)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return responses, page, nil
+}
+
+// postSortKey returns a post's value of the column posts are listed by
+func postSortKey(sortBy string) func(*entity.Post) (interface{}, uuid.UUID) {
+	return func(post *entity.Post) (interface{}, uuid.UUID) {
+		switch sortBy {
+		case "updated_at":
+			return post.UpdatedAt, post.ID
+		case "title":
+			return post.Title, post.ID
+		case "view_count":
+			return post.ViewCount, post.ID
+		default:
+			return post.CreatedAt, post.ID
 		}
-		// Update total count after filtering
-		total = int64(len(filteredPosts))
-	} else {
-		// Admin/SuperAdmin or public user: show all posts
-		filteredPosts = posts
+	}
+}
+
+func (s *postService) GetByID(ctx context.Context, id uuid.UUID, currentUser *entity.User) (*dto.PostResponse, error) {
+	post, err := s.postRepo.FindVisibleByID(ctx, id, repository.PostViewerFor(currentUser))
+	if err != nil {
+		return nil, errors.New("post not found")
 	}
 
+	return s.toPostResponse(ctx, post, currentUser)
+}
+
+func (s *postService) GetBySlug(ctx context.Context, slug string, currentUser *entity.User) (*dto.PostResponse, error) {
+	post, err := s.postRepo.FindVisibleBySlug(ctx, slug, repository.PostViewerFor(currentUser))
+	if err != nil {
+		return nil, errors.New("post not found")
+	}
+
+	return s.toPostResponse(ctx, post, currentUser)
+}
+
+// GetFeed returns published posts from followed authors and categories,
+// paged with keyset cursors. The feed is never counted.
+func (s *postService) GetFeed(ctx context.Context, params *dto.FeedQueryParams, user *entity.User) ([]*dto.PostListResponse, *dto.PageInfo, error) {
+	// Validate params
+	if err := s.validator.Validate(params); err != nil {
+		return nil, nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	if params.Limit < 1 {
+		params.Limit = 10
+	}
+
+	cursorParams := dto.CursorParams{Cursor: params.Cursor, UseCursor: true, NoTotal: true}
+	paging, err := listPaging(s.cursors, cursorParams, 1, params.Limit, "published_at", "desc")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	posts, err := s.postRepo.FindFeed(ctx, user.ID, paging)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get feed: %w", err)
+	}
+	posts, page := listPage(s.cursors, paging, posts, 0, feedSortKey)
+
+	responses, err := s.toPostListResponses(ctx, posts, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return responses, page, nil
+}
+
+// feedSortKey matches the feed's ordering: legacy published rows may lack
+// published_at and sort by created_at instead
+func feedSortKey(post *entity.Post) (interface{}, uuid.UUID) {
+	if post.PublishedAt != nil {
+		return *post.PublishedAt, post.ID
+	}
+	return post.CreatedAt, post.ID
+}
+
+// toPostListResponses adds comment counts and, for logged-in readers, bookmark flags
+func (s *postService) toPostListResponses(ctx context.Context, posts []*entity.Post, currentUser *entity.User) ([]*dto.PostListResponse, error) {
 	// Bulk count comments for all posts
-	postIDs := make([]uuid.UUID, len(filteredPosts))
-	for i, post := range filteredPosts {
+	postIDs := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
 		postIDs[i] = post.ID
 	}
 
 	commentCounts, err := s.commentRepo.CountByPostIDs(ctx, postIDs)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count comments: %w", err)
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	// Bookmark flags for the logged-in reader
+	var bookmarked map[uuid.UUID]bool
+	if currentUser != nil {
+		bookmarked, err = s.bookmarkRepo.FindBookmarkedPostIDs(ctx, currentUser.ID, postIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check bookmarks: %w", err)
+		}
 	}
 
 	// Convert to response with comment counts
-	responses := make([]*dto.PostListResponse, len(filteredPosts))
-	for i, post := range filteredPosts {
+	responses := make([]*dto.PostListResponse, len(posts))
+	for i, post := range posts {
 		commentCount := commentCounts[post.ID]
+		s.refreshFeaturedURL(post)
 		responses[i] = dto.ToPostListResponse(post, commentCount)
+		if currentUser != nil {
+			isBookmarked := bookmarked[post.ID]
+			responses[i].IsBookmarked = &isBookmarked
+		}
 	}
 
-	return responses, total, nil
+	return responses, nil
 }
 
-func (s *postService) GetByID(ctx context.Context, id uuid.UUID) (*dto.PostResponse, error) {
-	post, err := s.postRepo.FindByID(ctx, id)
-	if err != nil {
-		return nil, errors.New("post not found")
-	}
-
+// toPostResponse adds comment count and, for logged-in readers, the bookmark flag
+func (s *postService) toPostResponse(ctx context.Context, post *entity.Post, currentUser *entity.User) (*dto.PostResponse, error) {
 	// Count comments
 	commentCount, err := s.commentRepo.CountByPostID(ctx, post.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count comments: %w", err)
 	}
 
-	return dto.ToPostResponse(post, commentCount), nil
+	resp := s.newPostResponse(post, commentCount)
+
+	if currentUser != nil {
+		bookmarked, err := s.bookmarkRepo.FindBookmarkedPostIDs(ctx, currentUser.ID, []uuid.UUID{post.ID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to check bookmarks: %w", err)
+		}
+		isBookmarked := bookmarked[post.ID]
+		resp.IsBookmarked = &isBookmarked
+	}
+
+	return resp, nil
 }
 
-func (s *postService) GetBySlug(ctx context.Context, slug string) (*dto.PostResponse, error) {
-	post, err := s.postRepo.FindBySlug(ctx, slug)
-	if err != nil {
-		return nil, errors.New("post not found")
+func (s *postService) newPostResponse(post *entity.Post, commentCount int64) *dto.PostResponse {
+	s.refreshFeaturedURL(post)
+	return dto.ToPostResponse(post, commentCount)
+}
+
+// refreshFeaturedURL rebuilds the featured media's URLs from their paths,
+// as presigned storage URLs expire
+func (s *postService) refreshFeaturedURL(post *entity.Post) {
+	media := post.FeaturedMedia
+	if media == nil {
+		return
+	}
+	media.URL = s.storage.GetURL(media.Path)
+	for i := range media.Variants {
+		media.Variants[i].URL = s.storage.GetURL(media.Variants[i].Path)
 	}
+}
 
-	// Count comments
-	commentCount, err := s.commentRepo.CountByPostID(ctx, post.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to count comments: %w", err)
+// findFeaturedMedia checks that a media can be featured on a post: an image
+// the user may manage that is unattached or already on the post (postID is
+// nil for a new post)
+func (s *postService) findFeaturedMedia(ctx context.Context, mediaID uuid.UUID, postID *uuid.UUID, user *entity.User) (*entity.Media, error) {
+	media, err := s.mediaRepo.FindByID(ctx, mediaID)
+	if err != nil || (media.UserID != user.ID && !user.IsAdmin()) {
+		return nil, errors.New("featured media not found")
+	}
+	if !media.IsImage() {
+		return nil, errors.New("featured media must be an image")
+	}
+	if media.PostID != nil && (postID == nil || *media.PostID != *postID) {
+		return nil, errors.New("featured media belongs to another post")
 	}
+	return media, nil
+}
 
-	return dto.ToPostResponse(post, commentCount), nil
+// postConflict reports a version conflict along with the post as it is now
+func (s *postService) postConflict(ctx context.Context, id uuid.UUID) error {
+	post, err := s.postRepo.FindByID(ctx, id)
+	if err != nil {
+		return errors.New("post not found")
+	}
+	commentCount, _ := s.commentRepo.CountByPostID(ctx, post.ID)
+	return &ConflictError{Version: post.Version, Current: s.newPostResponse(post, commentCount)}
 }
 
 func (s *postService) Create(ctx context.Context, req *dto.CreatePostRequest, userID uuid.UUID) (*dto.PostResponse, error) {
@@ -198,10 +350,12 @@ func (s *postService) Create(ctx context.Context, req *dto.CreatePostRequest, us
 		return nil, errors.New("category not found")
 	}
 
-	// Check if slug exists
-	existingPost, _ := s.postRepo.FindBySlug(ctx, req.Slug)
-	if existingPost != nil {
-		return nil, errors.New("slug already exists")
+	var featured *entity.Media
+	if req.FeaturedMediaID != nil {
+		featured, err = s.findFeaturedMedia(ctx, *req.FeaturedMediaID, nil, &entity.User{BaseEntity: entity.BaseEntity{ID: userID}})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Sanitize content
@@ -233,14 +387,40 @@ func (s *postService) Create(ctx context.Context, req *dto.CreatePostRequest, us
 		post.PublishedAt = &now
 	}
 
-	if err := s.postRepo.Create(ctx, post); err != nil {
-		return nil, fmt.Errorf("failed to create post: %w", err)
+	// The slug check, the insert and the featured media are one unit
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// Check if slug exists
+		existingPost, _ := s.postRepo.FindBySlug(ctx, req.Slug)
+		if existingPost != nil {
+			return errors.New("slug already exists")
+		}
+
+		if err := s.postRepo.Create(ctx, post); err != nil {
+			return fmt.Errorf("failed to create post: %w", err)
+		}
+
+		if featured != nil {
+			if err := s.postRepo.SetFeaturedMedia(ctx, post.ID, featured); err != nil {
+				return fmt.Errorf("failed to set featured media: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Reload with relations
 	post, _ = s.postRepo.FindByID(ctx, post.ID)
 
-	return dto.ToPostResponse(post, 0), nil
+	resp := s.newPostResponse(post, 0)
+
+	if post.IsPublished() {
+		s.notifier.NotifyPostPublished(ctx, post, nil)
+		s.webhooks.Enqueue(ctx, entity.WebhookEventPostPublished, resp)
+	}
+
+	return resp, nil
 }
 
 func (s *postService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdatePostRequest, user *entity.User) (*dto.PostResponse, error) {
@@ -263,6 +443,9 @@ func (s *postService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateP
 		return nil, errors.New("you don't have permission to update this post")
 	}
 
+	if !checkVersion(req.Version, post.Version) {
+		return nil, s.postConflict(ctx, post.ID)
+	}
 
 	// Check category if provided
 	if req.CategoryID != nil {
@@ -273,12 +456,9 @@ func (s *postService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateP
 		post.CategoryID = *req.CategoryID
 	}
 
-	// Check slug uniqueness if changed
-	if req.Slug != "" && req.Slug != post.Slug {
-		existingPost, _ := s.postRepo.FindBySlug(ctx, req.Slug)
-		if existingPost != nil && existingPost.ID != post.ID {
-			return nil, errors.New("slug already exists")
-		}
+	// Slug uniqueness is checked when saving
+	slugChanged := req.Slug != "" && req.Slug != post.Slug
+	if slugChanged {
 		post.Slug = req.Slug
 	}
 
@@ -295,7 +475,19 @@ func (s *postService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateP
 		post.Excerpt = req.Excerpt
 	}
 
+	// Featured media is switched after saving; an external image replaces it
+	var featured *entity.Media
+	if req.FeaturedMediaID != nil && (post.FeaturedMediaID == nil || *req.FeaturedMediaID != *post.FeaturedMediaID) {
+		featured, err = s.findFeaturedMedia(ctx, *req.FeaturedMediaID, &post.ID, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	unfeature := false
 	if req.FeaturedImage != "" {
+		unfeature = post.FeaturedMediaID != nil
+		post.FeaturedMediaID, post.FeaturedMedia = nil, nil
 		post.FeaturedImage = req.FeaturedImage
 	}
 
@@ -303,6 +495,8 @@ func (s *postService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateP
 		post.Tags = req.Tags
 	}
 
+	wasPublished := post.IsPublished()
+
 	if req.Status != "" {
 		newStatus := entity.PostStatus(req.Status)
 		// Set published_at when changing to published
@@ -313,20 +507,65 @@ func (s *postService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateP
 		post.Status = newStatus
 	}
 
-	if err := s.postRepo.Update(ctx, post); err != nil {
-		return nil, fmt.Errorf("failed to update post: %w", err)
+	// Saved against the loaded version; the featured media switch comes
+	// after, as it bumps the version too
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if slugChanged {
+			existingPost, _ := s.postRepo.FindBySlug(ctx, post.Slug)
+			if existingPost != nil && existingPost.ID != post.ID {
+				return errors.New("slug already exists")
+			}
+		}
+
+		if err := s.postRepo.Update(ctx, post); err != nil {
+			return fmt.Errorf("failed to update post: %w", err)
+		}
+
+		if unfeature {
+			if err := s.postRepo.SetFeaturedMedia(ctx, post.ID, nil); err != nil {
+				return fmt.Errorf("failed to unset featured media: %w", err)
+			}
+		}
+
+		if featured != nil {
+			if err := s.postRepo.SetFeaturedMedia(ctx, post.ID, featured); err != nil {
+				return fmt.Errorf("failed to set featured media: %w", err)
+			}
+		}
+		return nil
+	})
+	if isVersionConflict(err) {
+		return nil, s.postConflict(ctx, post.ID)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	// Reload with relations
 	post, _ = s.postRepo.FindByID(ctx, post.ID)
 
+	if !wasPublished && post.IsPublished() {
+		s.notifier.NotifyPostPublished(ctx, post, user)
+	}
+
 	// Count comments
 	commentCount, _ := s.commentRepo.CountByPostID(ctx, post.ID)
 
-	return dto.ToPostResponse(post, commentCount), nil
+	resp := s.newPostResponse(post, commentCount)
+
+	switch {
+	case !wasPublished && post.IsPublished():
+		s.webhooks.Enqueue(ctx, entity.WebhookEventPostPublished, resp)
+	case wasPublished && !post.IsPublished():
+		s.webhooks.Enqueue(ctx, entity.WebhookEventPostUnpublished, resp)
+	case post.IsPublished():
+		s.webhooks.Enqueue(ctx, entity.WebhookEventPostUpdated, resp)
+	}
+
+	return resp, nil
 }
 
-func (s *postService) Delete(ctx context.Context, id uuid.UUID, user *entity.User) error {
+func (s *postService) Delete(ctx context.Context, id uuid.UUID, version *int64, user *entity.User) error {
 	post, err := s.postRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("post not found")
@@ -340,7 +579,23 @@ func (s *postService) Delete(ctx context.Context, id uuid.UUID, user *entity.Use
 		return errors.New("you don't have permission to delete this post")
 	}
 
-	return s.postRepo.Delete(ctx, id)
+	if !checkVersion(version, post.Version) {
+		return s.postConflict(ctx, post.ID)
+	}
+
+	if err := s.postRepo.Delete(ctx, id, post.Version); err != nil {
+		if isVersionConflict(err) {
+			return s.postConflict(ctx, post.ID)
+		}
+		return err
+	}
+
+	s.webhooks.Enqueue(ctx, entity.WebhookEventPostDeleted, &dto.PostDeletedEvent{
+		ID:   post.ID,
+		Slug: post.Slug,
+	})
+
+	return nil
 }
 
 // Publish post - only Super Admin and Admin can publish
@@ -364,16 +619,24 @@ func (s *postService) Publish(ctx context.Context, id uuid.UUID, user *entity.Us
 	post.Publish()
 
 	if err := s.postRepo.Update(ctx, post); err != nil {
+		if isVersionConflict(err) {
+			return nil, s.postConflict(ctx, post.ID)
+		}
 		return nil, fmt.Errorf("failed to publish post: %w", err)
 	}
 
 	// Reload with relations
 	post, _ = s.postRepo.FindByID(ctx, post.ID)
 
+	s.notifier.NotifyPostPublished(ctx, post, user)
+
 	// Count comments
 	commentCount, _ := s.commentRepo.CountByPostID(ctx, post.ID)
 
-	return dto.ToPostResponse(post, commentCount), nil
+	resp := s.newPostResponse(post, commentCount)
+	s.webhooks.Enqueue(ctx, entity.WebhookEventPostPublished, resp)
+
+	return resp, nil
 }
 
 // Unpublish post - only Super Admin and Admin can unpublish
@@ -397,6 +660,9 @@ func (s *postService) Unpublish(ctx context.Context, id uuid.UUID, user *entity.
 	post.Status = entity.PostStatusDraft
 
 	if err := s.postRepo.Update(ctx, post); err != nil {
+		if isVersionConflict(err) {
+			return nil, s.postConflict(ctx, post.ID)
+		}
 		return nil, fmt.Errorf("failed to unpublish post: %w", err)
 	}
 
@@ -406,17 +672,35 @@ func (s *postService) Unpublish(ctx context.Context, id uuid.UUID, user *entity.
 	// Count comments
 	commentCount, _ := s.commentRepo.CountByPostID(ctx, post.ID)
 
-	return dto.ToPostResponse(post, commentCount), nil
+	resp := s.newPostResponse(post, commentCount)
+	s.webhooks.Enqueue(ctx, entity.WebhookEventPostUnpublished, resp)
+
+	return resp, nil
 }
 
-func (s *postService) IncrementViews(ctx context.Context, id uuid.UUID) error {
+// IncrementViews records a view in the buffered counter. Bots, repeat
+// views within the dedup window and unpublished posts are not counted.
+func (s *postService) IncrementViews(ctx context.Context, id uuid.UUID, req *dto.PostViewRequest) (bool, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return false, fmt.Errorf("validation error: %w", err)
+	}
+
 	post, err := s.postRepo.FindByID(ctx, id)
 	if err != nil {
-		return errors.New("post not found")
+		return false, errors.New("post not found")
 	}
 
-	// Increment view count
-	post.ViewCount++
+	if !post.IsPublished() || viewcounter.IsBot(req.UserAgent) {
+		return false, nil
+	}
+
+	event := viewcounter.ViewEvent{
+		PostID:        post.ID,
+		ReferrerHost:  req.ReferrerHost,
+		Country:       req.Country,
+		Authenticated: req.Authenticated,
+	}
 
-	return s.postRepo.Update(ctx, post)
-}
\ No newline at end of file
+	return s.viewCounter.Record(event, req.VisitorKey), nil
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+)
+
+type ReadingListService interface {
+	Create(ctx context.Context, req *dto.CreateReadingListRequest, user *entity.User) (*dto.ReadingListResponse, error)
+	GetMine(ctx context.Context, user *entity.User) ([]*dto.ReadingListResponse, error)
+	GetPublicByUserID(ctx context.Context, userID uuid.UUID) ([]*dto.ReadingListResponse, error)
+	GetByID(ctx context.Context, id uuid.UUID, currentUser *entity.User) (*dto.ReadingListResponse, error)
+	Update(ctx context.Context, id uuid.UUID, req *dto.UpdateReadingListRequest, user *entity.User) (*dto.ReadingListResponse, error)
+	Delete(ctx context.Context, id uuid.UUID, user *entity.User) error
+	AddItem(ctx context.Context, id uuid.UUID, req *dto.AddReadingListItemRequest, user *entity.User) (*dto.ReadingListResponse, error)
+	RemoveItem(ctx context.Context, id, postID uuid.UUID, user *entity.User) (*dto.ReadingListResponse, error)
+	Reorder(ctx context.Context, id uuid.UUID, req *dto.ReorderReadingListRequest, user *entity.User) (*dto.ReadingListResponse, error)
+}
+
+type readingListService struct {
+	readingListRepo repository.ReadingListRepository
+	postRepo        repository.PostRepository
+	commentRepo     repository.CommentRepository
+	validator       *validator.CustomValidator
+}
+
+func NewReadingListService(
+	readingListRepo repository.ReadingListRepository,
+	postRepo repository.PostRepository,
+	commentRepo repository.CommentRepository,
+	validator *validator.CustomValidator,
+) ReadingListService {
+	return &readingListService{
+		readingListRepo: readingListRepo,
+		postRepo:        postRepo,
+		commentRepo:     commentRepo,
+		validator:       validator,
+	}
+}
+
+func (s *readingListService) Create(ctx context.Context, req *dto.CreateReadingListRequest, user *entity.User) (*dto.ReadingListResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	list := &entity.ReadingList{
+		UserID:      user.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		IsPublic:    req.IsPublic,
+	}
+
+	if err := s.readingListRepo.Create(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to create reading list: %w", err)
+	}
+
+	list.User = user
+	return dto.ToReadingListResponse(list, 0), nil
+}
+
+func (s *readingListService) GetMine(ctx context.Context, user *entity.User) ([]*dto.ReadingListResponse, error) {
+	lists, err := s.readingListRepo.FindByUserID(ctx, user.ID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reading lists: %w", err)
+	}
+	return s.toSummaries(ctx, lists)
+}
+
+func (s *readingListService) GetPublicByUserID(ctx context.Context, userID uuid.UUID) ([]*dto.ReadingListResponse, error) {
+	lists, err := s.readingListRepo.FindByUserID(ctx, userID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reading lists: %w", err)
+	}
+	return s.toSummaries(ctx, lists)
+}
+
+// GetByID returns public lists to anyone and private lists to their owner only
+func (s *readingListService) GetByID(ctx context.Context, id uuid.UUID, currentUser *entity.User) (*dto.ReadingListResponse, error) {
+	list, err := s.readingListRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("reading list not found")
+	}
+
+	if !list.IsPublic && (currentUser == nil || !list.IsOwnedBy(currentUser.ID)) {
+		return nil, errors.New("reading list not found")
+	}
+
+	return s.toDetail(ctx, list, currentUser)
+}
+
+func (s *readingListService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateReadingListRequest, user *entity.User) (*dto.ReadingListResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	list, err := s.findOwned(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update fields
+	if req.Name != "" {
+		list.Name = req.Name
+	}
+
+	if req.Description != "" {
+		list.Description = req.Description
+	}
+
+	if req.IsPublic != nil {
+		list.IsPublic = *req.IsPublic
+	}
+
+	if err := s.readingListRepo.Update(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to update reading list: %w", err)
+	}
+
+	return s.toDetail(ctx, list, user)
+}
+
+func (s *readingListService) Delete(ctx context.Context, id uuid.UUID, user *entity.User) error {
+	if _, err := s.findOwned(ctx, id, user); err != nil {
+		return err
+	}
+
+	return s.readingListRepo.Delete(ctx, id)
+}
+
+func (s *readingListService) AddItem(ctx context.Context, id uuid.UUID, req *dto.AddReadingListItemRequest, user *entity.User) (*dto.ReadingListResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	if _, err := s.findOwned(ctx, id, user); err != nil {
+		return nil, err
+	}
+
+	post, err := s.postRepo.FindByID(ctx, req.PostID)
+	if err != nil || !post.IsVisibleTo(user) {
+		return nil, errors.New("post not found")
+	}
+
+	if existing, _ := s.readingListRepo.FindItem(ctx, id, post.ID); existing != nil {
+		return nil, errors.New("post is already in this reading list")
+	}
+
+	// Append to the end of the list
+	maxPosition, err := s.readingListRepo.MaxPosition(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add item: %w", err)
+	}
+
+	item := &entity.ReadingListItem{
+		ReadingListID: id,
+		PostID:        post.ID,
+		Position:      maxPosition + 1,
+		Note:          req.Note,
+	}
+
+	if err := s.readingListRepo.AddItem(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to add item: %w", err)
+	}
+
+	return s.reload(ctx, id, user)
+}
+
+func (s *readingListService) RemoveItem(ctx context.Context, id, postID uuid.UUID, user *entity.User) (*dto.ReadingListResponse, error) {
+	if _, err := s.findOwned(ctx, id, user); err != nil {
+		return nil, err
+	}
+
+	removed, err := s.readingListRepo.RemoveItem(ctx, id, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove item: %w", err)
+	}
+	if !removed {
+		return nil, errors.New("post is not in this reading list")
+	}
+
+	return s.reload(ctx, id, user)
+}
+
+// Reorder expects every post in the list exactly once, in the new order
+func (s *readingListService) Reorder(ctx context.Context, id uuid.UUID, req *dto.ReorderReadingListRequest, user *entity.User) (*dto.ReadingListResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	list, err := s.findOwned(ctx, id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[uuid.UUID]bool, len(list.Items))
+	for _, item := range list.Items {
+		current[item.PostID] = true
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.PostIDs))
+	for _, postID := range req.PostIDs {
+		if !current[postID] || seen[postID] {
+			return nil, errors.New("post_ids must contain every post in the list exactly once")
+		}
+		seen[postID] = true
+	}
+	if len(seen) != len(current) {
+		return nil, errors.New("post_ids must contain every post in the list exactly once")
+	}
+
+	if err := s.readingListRepo.ReorderItems(ctx, id, req.PostIDs); err != nil {
+		return nil, fmt.Errorf("failed to reorder reading list: %w", err)
+	}
+
+	return s.reload(ctx, id, user)
+}
+
+// findOwned loads a list and checks that user owns it
+func (s *readingListService) findOwned(ctx context.Context, id uuid.UUID, user *entity.User) (*entity.ReadingList, error) {
+	list, err := s.readingListRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("reading list not found")
+	}
+
+	if !list.IsOwnedBy(user.ID) {
+		return nil, errors.New("you don't have permission to modify this reading list")
+	}
+
+	return list, nil
+}
+
+func (s *readingListService) reload(ctx context.Context, id uuid.UUID, user *entity.User) (*dto.ReadingListResponse, error) {
+	list, err := s.readingListRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("reading list not found")
+	}
+	return s.toDetail(ctx, list, user)
+}
+
+func (s *readingListService) toDetail(ctx context.Context, list *entity.ReadingList, viewer *entity.User) (*dto.ReadingListResponse, error) {
+	postIDs := make([]uuid.UUID, len(list.Items))
+	for i, item := range list.Items {
+		postIDs[i] = item.PostID
+	}
+
+	commentCounts, err := s.commentRepo.CountByPostIDs(ctx, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	return dto.ToReadingListDetailResponse(list, viewer, commentCounts), nil
+}
+
+func (s *readingListService) toSummaries(ctx context.Context, lists []*entity.ReadingList) ([]*dto.ReadingListResponse, error) {
+	listIDs := make([]uuid.UUID, len(lists))
+	for i, list := range lists {
+		listIDs[i] = list.ID
+	}
+
+	itemCounts, err := s.readingListRepo.CountItemsByListIDs(ctx, listIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count items: %w", err)
+	}
+
+	responses := make([]*dto.ReadingListResponse, len(lists))
+	for i, list := range lists {
+		responses[i] = dto.ToReadingListResponse(list, itemCounts[list.ID])
+	}
+	return responses, nil
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/afdhali/GolangBlogpostServer/config"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+)
+
+// ErrQuotaExceeded rejects an upload that would take a user over their
+// storage limits
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// StorageQuotaService enforces per-user storage limits and reports usage
+type StorageQuotaService interface {
+	// Check fails with ErrQuotaExceeded if adding the given bytes and number
+	// of files would take the user over a limit
+	Check(ctx context.Context, userID uuid.UUID, bytes, files int64) error
+	// CheckLocked is Check inside a transaction: it locks the user first, so
+	// concurrent uploads can't both fit in the same remaining space
+	CheckLocked(ctx context.Context, userID uuid.UUID, bytes, files int64) error
+	GetUsage(ctx context.Context, userID uuid.UUID) (*dto.StorageUsageResponse, error)
+	GetUsageReport(ctx context.Context, page, limit int) ([]*dto.StorageUsageResponse, int64, error)
+
+	// Per-user overrides
+	SetQuota(ctx context.Context, userID uuid.UUID, req *dto.SetStorageQuotaRequest, currentUser *entity.User) (*dto.StorageUsageResponse, error)
+	ResetQuota(ctx context.Context, userID uuid.UUID, currentUser *entity.User) (*dto.StorageUsageResponse, error)
+}
+
+type storageQuotaService struct {
+	quotaRepo repository.StorageQuotaRepository
+	userRepo  repository.UserRepository
+	validator *validator.CustomValidator
+	cfg       config.QuotaConfig
+}
+
+func NewStorageQuotaService(
+	quotaRepo repository.StorageQuotaRepository,
+	userRepo repository.UserRepository,
+	validator *validator.CustomValidator,
+	cfg *config.Config,
+) StorageQuotaService {
+	return &storageQuotaService{
+		quotaRepo: quotaRepo,
+		userRepo:  userRepo,
+		validator: validator,
+		cfg:       cfg.Quota,
+	}
+}
+
+func (s *storageQuotaService) Check(ctx context.Context, userID uuid.UUID, bytes, files int64) error {
+	usage, err := s.quotaRepo.UsageByUserID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	resp := s.toResponse(usage)
+	if resp.MaxBytes > 0 && resp.UsedBytes+bytes > resp.MaxBytes {
+		return fmt.Errorf("%w: %d of %d bytes used, the upload needs %d more",
+			ErrQuotaExceeded, resp.UsedBytes, resp.MaxBytes, bytes)
+	}
+	if resp.MaxFiles > 0 && resp.UsedFiles+files > resp.MaxFiles {
+		return fmt.Errorf("%w: %d of %d files used", ErrQuotaExceeded, resp.UsedFiles, resp.MaxFiles)
+	}
+	return nil
+}
+
+func (s *storageQuotaService) CheckLocked(ctx context.Context, userID uuid.UUID, bytes, files int64) error {
+	if err := s.quotaRepo.LockUser(ctx, userID); err != nil {
+		return errors.New("user not found")
+	}
+	return s.Check(ctx, userID, bytes, files)
+}
+
+func (s *storageQuotaService) GetUsage(ctx context.Context, userID uuid.UUID) (*dto.StorageUsageResponse, error) {
+	usage, err := s.quotaRepo.UsageByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.toResponse(usage), nil
+}
+
+func (s *storageQuotaService) GetUsageReport(ctx context.Context, page, limit int) ([]*dto.StorageUsageResponse, int64, error) {
+	// Default pagination
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	usages, total, err := s.quotaRepo.FindUsage(ctx, page, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	responses := make([]*dto.StorageUsageResponse, len(usages))
+	for i := range usages {
+		responses[i] = s.toResponse(&usages[i])
+	}
+	return responses, total, nil
+}
+
+func (s *storageQuotaService) SetQuota(ctx context.Context, userID uuid.UUID, req *dto.SetStorageQuotaRequest, currentUser *entity.User) (*dto.StorageUsageResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if req.MaxBytes == nil && req.MaxFiles == nil {
+		return nil, errors.New("max_bytes or max_files is required")
+	}
+
+	if err := s.checkManage(ctx, userID, currentUser); err != nil {
+		return nil, err
+	}
+
+	quota := &entity.StorageQuota{
+		UserID:    userID,
+		MaxBytes:  req.MaxBytes,
+		MaxFiles:  req.MaxFiles,
+		Note:      req.Note,
+		UpdatedBy: &currentUser.ID,
+	}
+	if err := s.quotaRepo.Upsert(ctx, quota); err != nil {
+		return nil, fmt.Errorf("failed to save storage quota: %w", err)
+	}
+
+	return s.GetUsage(ctx, userID)
+}
+
+// ResetQuota drops the override, going back to the role's limits
+func (s *storageQuotaService) ResetQuota(ctx context.Context, userID uuid.UUID, currentUser *entity.User) (*dto.StorageUsageResponse, error) {
+	if err := s.checkManage(ctx, userID, currentUser); err != nil {
+		return nil, err
+	}
+
+	if err := s.quotaRepo.Delete(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to reset storage quota: %w", err)
+	}
+
+	return s.GetUsage(ctx, userID)
+}
+
+// checkManage lets admins change the quotas of regular users; only super
+// admins can change those of admins, including their own
+func (s *storageQuotaService) checkManage(ctx context.Context, userID uuid.UUID, currentUser *entity.User) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.IsAdmin() && !currentUser.IsSuperAdmin() {
+		return errors.New("you don't have permission to change this user's quota")
+	}
+	return nil
+}
+
+// limits returns the configured limits of a role
+func (s *storageQuotaService) limits(role entity.UserRole) (int64, int64) {
+	const mb = 1024 * 1024
+	switch role {
+	case entity.RoleSuperAdmin:
+		return int64(s.cfg.SuperAdminMaxMB) * mb, int64(s.cfg.SuperAdminMaxFiles)
+	case entity.RoleAdmin:
+		return int64(s.cfg.AdminMaxMB) * mb, int64(s.cfg.AdminMaxFiles)
+	default:
+		return int64(s.cfg.UserMaxMB) * mb, int64(s.cfg.UserMaxFiles)
+	}
+}
+
+func (s *storageQuotaService) toResponse(usage *repository.StorageUsage) *dto.StorageUsageResponse {
+	maxBytes, maxFiles := s.limits(usage.Role)
+	if usage.MaxBytes != nil {
+		maxBytes = *usage.MaxBytes
+	}
+	if usage.MaxFiles != nil {
+		maxFiles = *usage.MaxFiles
+	}
+
+	// An avatar counts as one file
+	usedFiles := usage.MediaFiles
+	if usage.AvatarBytes > 0 {
+		usedFiles++
+	}
+
+	resp := &dto.StorageUsageResponse{
+		UserID:      usage.UserID,
+		Username:    usage.Username,
+		Email:       usage.Email,
+		Role:        string(usage.Role),
+		UsedBytes:   usage.MediaBytes + usage.AvatarBytes,
+		UsedFiles:   usedFiles,
+		MediaBytes:  usage.MediaBytes,
+		MediaFiles:  usage.MediaFiles,
+		AvatarBytes: usage.AvatarBytes,
+		MaxBytes:    maxBytes,
+		MaxFiles:    maxFiles,
+		Overridden:  usage.MaxBytes != nil || usage.MaxFiles != nil,
+	}
+	if maxBytes > 0 {
+		remaining := max(maxBytes-resp.UsedBytes, 0)
+		resp.RemainingBytes = &remaining
+	}
+	if maxFiles > 0 {
+		remaining := max(maxFiles-resp.UsedFiles, 0)
+		resp.RemainingFiles = &remaining
+	}
+	return resp
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/broker"
+	"github.com/google/uuid"
+)
+
+// Event types pushed to stream clients
+const (
+	EventCommentCreated      = "comment.created"
+	EventCommentUpdated      = "comment.updated"
+	EventCommentDeleted      = "comment.deleted"
+	EventNotificationCreated = "notification.created"
+)
+
+// PostCommentsTopic carries comment events for one post
+func PostCommentsTopic(postID uuid.UUID) string {
+	return "post:" + postID.String() + ":comments"
+}
+
+// UserNotificationsTopic carries notifications for one user
+func UserNotificationsTopic(userID uuid.UUID) string {
+	return "user:" + userID.String() + ":notifications"
+}
+
+type StreamService interface {
+	SubscribePostComments(ctx context.Context, postID uuid.UUID, currentUser *entity.User, lastEventID string) (broker.Subscription, error)
+	SubscribeNotifications(ctx context.Context, user *entity.User, lastEventID string) (broker.Subscription, error)
+}
+
+type streamService struct {
+	broker   broker.Broker
+	postRepo repository.PostRepository
+}
+
+func NewStreamService(broker broker.Broker, postRepo repository.PostRepository) StreamService {
+	return &streamService{
+		broker:   broker,
+		postRepo: postRepo,
+	}
+}
+
+// SubscribePostComments only streams posts the viewer is allowed to read
+func (s *streamService) SubscribePostComments(ctx context.Context, postID uuid.UUID, currentUser *entity.User, lastEventID string) (broker.Subscription, error) {
+	post, err := s.postRepo.FindVisibleByID(ctx, postID, repository.PostViewerFor(currentUser))
+	if err != nil {
+		return nil, errors.New("post not found")
+	}
+
+	return s.broker.Subscribe(PostCommentsTopic(post.ID), lastEventID)
+}
+
+func (s *streamService) SubscribeNotifications(ctx context.Context, user *entity.User, lastEventID string) (broker.Subscription, error) {
+	return s.broker.Subscribe(UserNotificationsTopic(user.ID), lastEventID)
+}
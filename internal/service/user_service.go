@@ -5,7 +5,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"mime/multipart"
 	"strings"
 
@@ -13,6 +12,7 @@ import (
 	"github.com/afdhali/GolangBlogpostServer/internal/entity"
 	"github.com/afdhali/GolangBlogpostServer/internal/repository"
 	"github.com/afdhali/GolangBlogpostServer/pkg/image"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
 	"github.com/afdhali/GolangBlogpostServer/pkg/security"
 	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
 	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
@@ -20,7 +20,7 @@ import (
 )
 
 type UserService interface {
-	GetAll(ctx context.Context, page, limit int, search, role string) ([]*dto.UserListResponse, int64, error)
+	GetAll(ctx context.Context, params *dto.UserQueryParams) ([]*dto.UserListResponse, *dto.PageInfo, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*dto.UserResponse, error)
 	GetByUsername(ctx context.Context, username string) (*dto.UserResponse, error)
 	Create(ctx context.Context, req *dto.CreateUserRequest) (*dto.UserResponse, error)
@@ -33,45 +33,54 @@ type UserService interface {
 }
 
 type userService struct {
+	txManager      repository.TxManager
 	userRepo       repository.UserRepository
+	tokenRepo      repository.RefreshTokenRepository
 	postRepo       repository.PostRepository
+	followRepo     repository.FollowRepository
+	quota          StorageQuotaService
 	passwordHasher security.PasswordHasher
 	validator      *validator.CustomValidator
 	storage        storage.Storage
 	imageValidator *image.Validator
 	imageProcessor *image.Processor
+	cursors        *pagination.Signer
 }
 
 func NewUserService(
+	txManager repository.TxManager,
 	userRepo repository.UserRepository,
+	tokenRepo repository.RefreshTokenRepository,
 	postRepo repository.PostRepository,
+	followRepo repository.FollowRepository,
+	quota StorageQuotaService,
 	passwordHasher security.PasswordHasher,
 	validator *validator.CustomValidator,
 	storage storage.Storage,
 	imageValidator *image.Validator,
 	imageProcessor *image.Processor,
+	cursors *pagination.Signer,
 ) UserService {
 	return &userService{
+		txManager:      txManager,
 		userRepo:       userRepo,
+		tokenRepo:      tokenRepo,
 		postRepo:       postRepo,
+		followRepo:     followRepo,
+		quota:          quota,
 		passwordHasher: passwordHasher,
 		validator:      validator,
 		storage:        storage,
 		imageValidator: imageValidator,
 		imageProcessor: imageProcessor,
+		cursors:        cursors,
 	}
 }
 
-func (s *userService) GetAll(ctx context.Context, page, limit int, search, role string) ([]*dto.UserListResponse, int64, error) {
-	params := &dto.UserQueryParams{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
-		Role:   role,
-	}
+func (s *userService) GetAll(ctx context.Context, params *dto.UserQueryParams) ([]*dto.UserListResponse, *dto.PageInfo, error) {
 	// Validate params
 	if err := s.validator.Validate(params); err != nil {
-		return nil, 0, fmt.Errorf("validation error: %w", err)
+		return nil, nil, fmt.Errorf("validation error: %w", err)
 	}
 
 	// Default pagination
@@ -82,12 +91,29 @@ func (s *userService) GetAll(ctx context.Context, page, limit int, search, role
 		params.Limit = 10
 	}
 
-	// Get users from repository
-	users, total, err := s.userRepo.FindAll(ctx, params.Page, params.Limit, params.Search, params.Role, params.IsActive, params.SortBy, params.SortOrder)
+	paging, err := listPaging(s.cursors, params.CursorParams, params.Page, params.Limit, params.SortBy, params.SortOrder)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get users: %w", err)
+		return nil, nil, err
 	}
 
+	// Get users from repository
+	users, total, err := s.userRepo.FindAll(ctx, paging, params.Search, params.Role, params.IsActive)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	users, page := listPage(s.cursors, paging, users, total, func(user *entity.User) (interface{}, uuid.UUID) {
+		switch paging.SortBy {
+		case "updated_at":
+			return user.UpdatedAt, user.ID
+		case "username":
+			return user.Username, user.ID
+		case "email":
+			return user.Email, user.ID
+		default:
+			return user.CreatedAt, user.ID
+		}
+	})
+
 	// Bulk count posts for all users
 	userIDs := make([]uuid.UUID, len(users))
 	for i, user := range users {
@@ -96,7 +122,7 @@ func (s *userService) GetAll(ctx context.Context, page, limit int, search, role
 
 	postCounts, err := s.postRepo.CountByAuthorIDs(ctx, userIDs)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count posts: %w", err)
+		return nil, nil, fmt.Errorf("failed to count posts: %w", err)
 	}
 
 	// Convert to response with post counts
@@ -106,7 +132,7 @@ func (s *userService) GetAll(ctx context.Context, page, limit int, search, role
 		responses[i] = dto.ToUserListResponse(user, postCount)
 	}
 
-	return responses, total, nil
+	return responses, page, nil
 }
 
 func (s *userService) GetByID(ctx context.Context, id uuid.UUID) (*dto.UserResponse, error) {
@@ -121,7 +147,14 @@ func (s *userService) GetByID(ctx context.Context, id uuid.UUID) (*dto.UserRespo
 		return nil, fmt.Errorf("failed to count posts: %w", err)
 	}
 
-	return dto.ToUserResponse(user, postCount), nil
+	return s.withFollowCounts(ctx, dto.ToUserResponse(user, postCount)), nil
+}
+
+// withFollowCounts fills follower/following counts; counting is best-effort
+func (s *userService) withFollowCounts(ctx context.Context, resp *dto.UserResponse) *dto.UserResponse {
+	resp.FollowerCount, _ = s.followRepo.CountFollowers(ctx, resp.ID)
+	resp.FollowingCount, _ = s.followRepo.CountFollowing(ctx, resp.ID)
+	return resp
 }
 
 func (s *userService) GetByUsername(ctx context.Context, username string) (*dto.UserResponse, error) {
@@ -136,7 +169,7 @@ func (s *userService) GetByUsername(ctx context.Context, username string) (*dto.
 		return nil, fmt.Errorf("failed to count posts: %w", err)
 	}
 
-	return dto.ToUserResponse(user, postCount), nil
+	return s.withFollowCounts(ctx, dto.ToUserResponse(user, postCount)), nil
 }
 
 func (s *userService) Create(ctx context.Context, req *dto.CreateUserRequest) (*dto.UserResponse, error) {
@@ -259,7 +292,7 @@ func (s *userService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateU
 	// Count posts
 	postCount, _ := s.postRepo.CountByAuthorID(ctx, user.ID)
 
-	return dto.ToUserResponse(user, postCount), nil
+	return s.withFollowCounts(ctx, dto.ToUserResponse(user, postCount)), nil
 }
 
 func (s *userService) UpdateProfile(ctx context.Context, id uuid.UUID, req *dto.UpdateProfileRequest) (*dto.UserResponse, error) {
@@ -299,7 +332,7 @@ func (s *userService) UpdateProfile(ctx context.Context, id uuid.UUID, req *dto.
 	// Count posts
 	postCount, _ := s.postRepo.CountByAuthorID(ctx, user.ID)
 
-	return dto.ToUserResponse(user, postCount), nil
+	return s.withFollowCounts(ctx, dto.ToUserResponse(user, postCount)), nil
 }
 
 func (s *userService) ChangePassword(ctx context.Context, id uuid.UUID, req *dto.ChangePasswordRequest) error {
@@ -348,7 +381,24 @@ func (s *userService) Delete(ctx context.Context, id uuid.UUID, currentUser *ent
 		return errors.New("cannot delete your own account")
 	}
 
-	return s.userRepo.Delete(ctx, id)
+	// The account and its sessions go together; the avatar file once committed
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if err := s.tokenRepo.RevokeAllByUserID(ctx, id); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+
+		if user.Avatar != "" && !isDefaultAvatar(user.Avatar) {
+			if avatarPath := extractPathFromURL(user.Avatar); avatarPath != "" {
+				repository.AfterCommit(ctx, func(ctx context.Context) {
+					s.storage.Delete(ctx, avatarPath)
+				})
+			}
+		}
+		return nil
+	})
 }
 
 func (s *userService) UploadAvatar(ctx context.Context, userID uuid.UUID, file multipart.File, header *multipart.FileHeader) (*dto.UserResponse, error) {
@@ -359,30 +409,34 @@ func (s *userService) UploadAvatar(ctx context.Context, userID uuid.UUID, file m
 	}
 
 	// Validate image
-	if err := s.imageValidator.Validate(header); err != nil {
+	if err := s.imageValidator.Validate(ctx, file, header); err != nil {
 		return nil, fmt.Errorf("invalid image: %w", err)
 	}
 
 	// Process image (compress & resize)
-	processedFile, err := s.imageProcessor.Process(file, header)
+	processed, err := s.imageProcessor.ProcessImage(file, header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process image: %w", err)
 	}
 
-	// Convert io.Reader to multipart.File
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, processedFile); err != nil {
-		return nil, fmt.Errorf("failed to read processed image: %w", err)
+	// The new avatar replaces the old one, so only the difference counts
+	size := int64(len(processed.Data))
+	newFiles := int64(1)
+	if user.AvatarSize > 0 {
+		newFiles = 0
+	}
+	if err := s.quota.Check(ctx, user.ID, size-user.AvatarSize, newFiles); err != nil {
+		return nil, err
 	}
 
-	// Create a new multipart.File from buffer
+	// Create a new multipart.File from the encoded image
 	processedMultipart := &bytesFile{
-		Reader: bytes.NewReader(buf.Bytes()),
-		size:   int64(buf.Len()),
+		Reader: bytes.NewReader(processed.Data),
+		size:   size,
 	}
 
-	// Save to storage
-	fileInfo, err := s.storage.Save(ctx, processedMultipart, header, "avatars")
+	// Save to storage under the encoded format's extension and content type
+	fileInfo, err := s.storage.Save(ctx, processedMultipart, processed.FileHeader(header), "avatars")
 	if err != nil {
 		return nil, fmt.Errorf("failed to save avatar: %w", err)
 	}
@@ -398,6 +452,7 @@ func (s *userService) UploadAvatar(ctx context.Context, userID uuid.UUID, file m
 
 	// Update user avatar
 	user.Avatar = fileInfo.URL
+	user.AvatarSize = fileInfo.Size
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		// Rollback: delete uploaded file
@@ -408,7 +463,7 @@ func (s *userService) UploadAvatar(ctx context.Context, userID uuid.UUID, file m
 	// Count posts
 	postCount, _ := s.postRepo.CountByAuthorID(ctx, user.ID)
 
-	return dto.ToUserResponse(user, postCount), nil
+	return s.withFollowCounts(ctx, dto.ToUserResponse(user, postCount)), nil
 }
 
 func (s *userService) DeleteAvatar(ctx context.Context, userID uuid.UUID) (*dto.UserResponse, error) {
@@ -427,6 +482,7 @@ func (s *userService) DeleteAvatar(ctx context.Context, userID uuid.UUID) (*dto.
 
 	// Set to empty or default avatar
 	user.Avatar = ""
+	user.AvatarSize = 0
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
@@ -435,7 +491,7 @@ func (s *userService) DeleteAvatar(ctx context.Context, userID uuid.UUID) (*dto.
 	// Count posts
 	postCount, _ := s.postRepo.CountByAuthorID(ctx, user.ID)
 
-	return dto.ToUserResponse(user, postCount), nil
+	return s.withFollowCounts(ctx, dto.ToUserResponse(user, postCount)), nil
 }
 
 
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+)
+
+// ConflictError rejects an update or delete of a post, category or comment
+// made against a version other than its current one. Current is the
+// resource as it is now, for the client to merge its changes into.
+type ConflictError struct {
+	Version int64
+	Current interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return "version conflict"
+}
+
+// checkVersion fails if the client edited another version than the loaded
+// one; a nil expected version matches any (If-Match: *)
+func checkVersion(expected *int64, current int64) bool {
+	return expected == nil || *expected == current
+}
+
+// isVersionConflict reports whether a save lost a race with another one
+func isVersionConflict(err error) bool {
+	return errors.Is(err, repository.ErrVersionConflict)
+}
@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/config"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/backoff"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/afdhali/GolangBlogpostServer/pkg/webhook"
+	"github.com/google/uuid"
+)
+
+// JobWebhookDelivery sends one queued webhook delivery
+const JobWebhookDelivery = "webhook.deliver"
+
+type webhookDeliveryJob struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+}
+
+type WebhookService interface {
+	// Enqueue queues event for every active subscription. It is best-effort:
+	// failures are logged, never returned, so they can't break the request.
+	Enqueue(ctx context.Context, event string, data interface{})
+
+	Create(ctx context.Context, req *dto.CreateWebhookRequest, user *entity.User) (*dto.WebhookResponse, error)
+	GetAll(ctx context.Context) ([]*dto.WebhookResponse, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*dto.WebhookResponse, error)
+	Update(ctx context.Context, id uuid.UUID, req *dto.UpdateWebhookRequest) (*dto.WebhookResponse, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	GetDeliveries(ctx context.Context, webhookID uuid.UUID, params *dto.WebhookDeliveryQueryParams) ([]*dto.WebhookDeliveryResponse, int64, error)
+	GetDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*dto.WebhookDeliveryResponse, error)
+	Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*dto.WebhookDeliveryResponse, error)
+}
+
+// WebhookPayload is the JSON body POSTed to subscribers. ID identifies the
+// event and is shared by every subscription, so receivers can de-duplicate.
+type WebhookPayload struct {
+	ID        uuid.UUID   `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type webhookService struct {
+	txManager   repository.TxManager
+	webhookRepo repository.WebhookRepository
+	jobs        JobEnqueuer
+	sender      *webhook.Sender
+	validator   *validator.CustomValidator
+	logger      *logger.Logger
+
+	maxAttempts int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+}
+
+func NewWebhookService(
+	txManager repository.TxManager,
+	webhookRepo repository.WebhookRepository,
+	jobQueue *JobQueue,
+	sender *webhook.Sender,
+	validator *validator.CustomValidator,
+	logger *logger.Logger,
+	cfg *config.Config,
+) WebhookService {
+	s := &webhookService{
+		txManager:   txManager,
+		webhookRepo: webhookRepo,
+		jobs:        jobQueue,
+		sender:      sender,
+		validator:   validator,
+		logger:      logger,
+		maxAttempts: cfg.Webhook.MaxAttempts,
+		backoffBase: time.Duration(cfg.Webhook.BackoffBaseSec) * time.Second,
+		backoffMax:  time.Duration(cfg.Webhook.BackoffMaxSec) * time.Second,
+	}
+
+	RegisterJob(jobQueue, JobWebhookDelivery, s.deliver)
+
+	return s
+}
+
+func (s *webhookService) Enqueue(ctx context.Context, event string, data interface{}) {
+	webhooks, err := s.webhookRepo.FindActive(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load webhooks for %s: %v", event, err)
+		return
+	}
+
+	var targets []*entity.Webhook
+	for _, w := range webhooks {
+		if w.Subscribes(event) {
+			targets = append(targets, w)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	now := time.Now()
+	body, err := json.Marshal(&WebhookPayload{
+		ID:        uuid.New(),
+		Event:     event,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		s.logger.Error("Failed to encode webhook payload for %s: %v", event, err)
+		return
+	}
+
+	deliveries := make([]*entity.WebhookDelivery, len(targets))
+	for i, w := range targets {
+		deliveries[i] = &entity.WebhookDelivery{
+			WebhookID:     w.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        entity.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		}
+	}
+
+	// Deliveries are stored with their jobs, so none is left pending with
+	// nothing to send it
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.webhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+			return err
+		}
+		for _, delivery := range deliveries {
+			if err := s.queueDelivery(ctx, delivery); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to queue %d webhook deliveries for %s: %v", len(deliveries), event, err)
+	}
+}
+
+// queueDelivery hands a stored delivery to the job queue, which sends it
+// and schedules its retries
+func (s *webhookService) queueDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	_, err := s.jobs.Enqueue(ctx, JobWebhookDelivery, &webhookDeliveryJob{DeliveryID: delivery.ID},
+		JobUniqueKey(JobWebhookDelivery+":"+delivery.ID.String()),
+		JobMaxAttempts(s.maxAttempts))
+	return err
+}
+
+// deliver sends one attempt of a delivery and records the outcome. Failed
+// attempts are retried by the queue on the webhook backoff until the
+// delivery runs out of attempts.
+func (s *webhookService) deliver(ctx context.Context, job webhookDeliveryJob) error {
+	delivery, err := s.webhookRepo.FindDeliveryToSend(ctx, job.DeliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to load delivery: %w", err)
+	}
+	if delivery.Status != entity.WebhookDeliveryPending {
+		// Settled by an earlier run whose job outcome wasn't recorded
+		return nil
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	log := &entity.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+	}
+
+	var runErr error
+	if delivery.Webhook == nil || !delivery.Webhook.IsActive {
+		// Subscription removed or disabled since the event was queued
+		log.Error = "webhook deleted or disabled"
+		delivery.Status = entity.WebhookDeliveryFailed
+	} else {
+		result := s.sender.Send(ctx, webhook.Request{
+			URL:        delivery.Webhook.URL,
+			Secret:     delivery.Webhook.Secret,
+			Event:      delivery.Event,
+			DeliveryID: delivery.ID.String(),
+			Body:       []byte(delivery.Payload),
+		})
+
+		log.ResponseCode = result.StatusCode
+		log.ResponseBody = result.Body
+		log.DurationMs = result.Duration.Milliseconds()
+		if result.Err != nil {
+			log.Error = result.Err.Error()
+		} else if !result.OK() {
+			log.Error = fmt.Sprintf("unexpected status %d", result.StatusCode)
+		}
+		delivery.ResponseCode = result.StatusCode
+
+		switch {
+		case result.OK():
+			delivery.Status = entity.WebhookDeliverySucceeded
+			delivery.DeliveredAt = &now
+		case delivery.Attempts >= s.maxAttempts:
+			delivery.Status = entity.WebhookDeliveryFailed
+			runErr = PermanentJobError(errors.New(log.Error))
+		default:
+			delay := backoff.Exponential(delivery.Attempts, s.backoffBase, s.backoffMax)
+			delivery.NextAttemptAt = now.Add(delay)
+			runErr = RetryJobAfter(errors.New(log.Error), delay)
+		}
+	}
+
+	if err := s.webhookRepo.RecordAttempt(ctx, delivery, log); err != nil {
+		return fmt.Errorf("failed to record delivery: %w", err)
+	}
+	return runErr
+}
+
+func (s *webhookService) Create(ctx context.Context, req *dto.CreateWebhookRequest, user *entity.User) (*dto.WebhookResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate secret: %w", err)
+		}
+		secret = generated
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	webhook := &entity.Webhook{
+		Name:        req.Name,
+		URL:         req.URL,
+		Secret:      secret,
+		Events:      req.Events,
+		IsActive:    isActive,
+		CreatedByID: user.ID,
+	}
+
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	// The secret is shown once so the receiver can be configured
+	resp := dto.ToWebhookResponse(webhook)
+	resp.Secret = secret
+	return resp, nil
+}
+
+func (s *webhookService) GetAll(ctx context.Context) ([]*dto.WebhookResponse, error) {
+	webhooks, err := s.webhookRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	responses := make([]*dto.WebhookResponse, len(webhooks))
+	for i, w := range webhooks {
+		responses[i] = dto.ToWebhookResponse(w)
+	}
+	return responses, nil
+}
+
+func (s *webhookService) GetByID(ctx context.Context, id uuid.UUID) (*dto.WebhookResponse, error) {
+	webhook, err := s.webhookRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("webhook not found")
+	}
+	return dto.ToWebhookResponse(webhook), nil
+}
+
+func (s *webhookService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateWebhookRequest) (*dto.WebhookResponse, error) {
+	// Validate request
+	if err := s.validator.Validate(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	webhook, err := s.webhookRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("webhook not found")
+	}
+
+	// Update fields
+	if req.Name != "" {
+		webhook.Name = req.Name
+	}
+
+	if req.URL != "" {
+		webhook.URL = req.URL
+	}
+
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+
+	if req.Events != nil {
+		webhook.Events = req.Events
+	}
+
+	if req.IsActive != nil {
+		webhook.IsActive = *req.IsActive
+	}
+
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	resp := dto.ToWebhookResponse(webhook)
+	if req.Secret != "" {
+		resp.Secret = req.Secret
+	}
+	return resp, nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.webhookRepo.FindByID(ctx, id); err != nil {
+		return errors.New("webhook not found")
+	}
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, webhookID uuid.UUID, params *dto.WebhookDeliveryQueryParams) ([]*dto.WebhookDeliveryResponse, int64, error) {
+	// Validate params
+	if err := s.validator.Validate(params); err != nil {
+		return nil, 0, fmt.Errorf("validation error: %w", err)
+	}
+
+	// Default pagination
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 20
+	}
+
+	if _, err := s.webhookRepo.FindByID(ctx, webhookID); err != nil {
+		return nil, 0, errors.New("webhook not found")
+	}
+
+	deliveries, total, err := s.webhookRepo.FindDeliveries(ctx, webhookID, params.Status, params.Page, params.Limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get deliveries: %w", err)
+	}
+
+	responses := make([]*dto.WebhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		responses[i] = dto.ToWebhookDeliveryResponse(d, false)
+	}
+	return responses, total, nil
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*dto.WebhookDeliveryResponse, error) {
+	delivery, err := s.webhookRepo.FindDeliveryByID(ctx, deliveryID)
+	if err != nil || delivery.WebhookID != webhookID {
+		return nil, errors.New("delivery not found")
+	}
+	return dto.ToWebhookDeliveryResponse(delivery, true), nil
+}
+
+// Redeliver queues a fresh copy of a past delivery; the original and its
+// attempt log are kept as they were
+func (s *webhookService) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*dto.WebhookDeliveryResponse, error) {
+	original, err := s.webhookRepo.FindDeliveryByID(ctx, deliveryID)
+	if err != nil || original.WebhookID != webhookID {
+		return nil, errors.New("delivery not found")
+	}
+
+	webhook, err := s.webhookRepo.FindByID(ctx, webhookID)
+	if err != nil {
+		return nil, errors.New("webhook not found")
+	}
+	if !webhook.IsActive {
+		return nil, errors.New("webhook is disabled")
+	}
+
+	delivery := &entity.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        entity.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  &original.ID,
+	}
+
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.webhookRepo.CreateDeliveries(ctx, []*entity.WebhookDelivery{delivery}); err != nil {
+			return err
+		}
+		return s.queueDelivery(ctx, delivery)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue redelivery: %w", err)
+	}
+
+	return dto.ToWebhookDeliveryResponse(delivery, false), nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,22 @@
+package backoff
+
+import "time"
+
+// Exponential returns the wait before retry number attempt (1-based):
+// base, 2*base, 4*base, ... capped at max.
+func Exponential(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,44 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrClosed is returned once the broker has been shut down
+var ErrClosed = errors.New("broker closed")
+
+// Event is a single message published on a topic.
+// ID is opaque to clients and only meaningful to the broker that issued it.
+type Event struct {
+	ID    string
+	Topic string
+	Type  string
+	Data  json.RawMessage
+}
+
+// Broker fans events out to subscribers of a topic.
+//
+// The in-process MemoryBroker is enough for a single instance; a
+// multi-instance deployment can implement the same interface on top of
+// Redis streams, Postgres LISTEN/NOTIFY or similar.
+type Broker interface {
+	// Publish marshals payload to JSON and delivers it to current subscribers.
+	Publish(ctx context.Context, topic, eventType string, payload interface{}) error
+
+	// Subscribe starts receiving events on topic. When lastEventID is set,
+	// buffered events published after it are replayed first.
+	Subscribe(topic, lastEventID string) (Subscription, error)
+
+	// Close ends every open subscription and rejects new ones.
+	Close() error
+}
+
+// Subscription is a live feed of events on one topic
+type Subscription interface {
+	// Events is closed when the subscription ends, either via Close, broker
+	// shutdown, or because the subscriber fell too far behind.
+	Events() <-chan Event
+	Close()
+}
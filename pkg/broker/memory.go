@@ -0,0 +1,205 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// idleTopicTTL is how long a topic without subscribers keeps its replay buffer
+const idleTopicTTL = 10 * time.Minute
+
+// MemoryBroker is an in-process Broker with a bounded replay buffer per topic
+type MemoryBroker struct {
+	mu         sync.Mutex
+	topics     map[string]*topic
+	replaySize int
+	clientBuf  int
+	closed     bool
+	lastSweep  time.Time
+}
+
+type topic struct {
+	seq        uint64
+	buffer     []Event // ring of the last replaySize events, oldest first
+	subs       map[*memorySubscription]struct{}
+	lastActive time.Time
+}
+
+type memorySubscription struct {
+	broker *MemoryBroker
+	topic  string
+	events chan Event
+	once   sync.Once
+}
+
+// NewMemoryBroker keeps replaySize events per topic and queues up to
+// clientBuffer events per subscriber before dropping it as too slow.
+func NewMemoryBroker(replaySize, clientBuffer int) *MemoryBroker {
+	if replaySize < 0 {
+		replaySize = 0
+	}
+	if clientBuffer < 1 {
+		clientBuffer = 32
+	}
+	return &MemoryBroker{
+		topics:     make(map[string]*topic),
+		replaySize: replaySize,
+		clientBuf:  clientBuffer,
+		lastSweep:  time.Now(),
+	}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, topicName, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	t := b.topic(topicName)
+	t.seq++
+	event := Event{
+		ID:    strconv.FormatUint(t.seq, 10),
+		Topic: topicName,
+		Type:  eventType,
+		Data:  data,
+	}
+
+	if b.replaySize > 0 {
+		if len(t.buffer) >= b.replaySize {
+			t.buffer = append(t.buffer[:0], t.buffer[1:]...)
+		}
+		t.buffer = append(t.buffer, event)
+	}
+
+	for sub := range t.subs {
+		select {
+		case sub.events <- event:
+		default:
+			// Too slow: drop it; the client reconnects with Last-Event-ID
+			b.removeLocked(sub)
+		}
+	}
+
+	b.sweepLocked()
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(topicName, lastEventID string) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	t := b.topic(topicName)
+	sub := &memorySubscription{
+		broker: b,
+		topic:  topicName,
+		events: make(chan Event, b.clientBuf+len(t.buffer)),
+	}
+
+	// Replay whatever is still buffered after lastEventID
+	if lastEventID != "" {
+		if last, err := strconv.ParseUint(lastEventID, 10, 64); err == nil {
+			for _, event := range t.buffer {
+				if id, _ := strconv.ParseUint(event.ID, 10, 64); id > last {
+					sub.events <- event
+				}
+			}
+		}
+	}
+
+	t.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Close ends all subscriptions; their Events channels are closed
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	for _, t := range b.topics {
+		for sub := range t.subs {
+			b.removeLocked(sub)
+		}
+	}
+	b.topics = make(map[string]*topic)
+	return nil
+}
+
+// SubscriberCount reports open subscriptions on a topic
+func (b *MemoryBroker) SubscriberCount(topicName string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t, ok := b.topics[topicName]; ok {
+		return len(t.subs)
+	}
+	return 0
+}
+
+func (b *MemoryBroker) topic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{subs: make(map[*memorySubscription]struct{})}
+		b.topics[name] = t
+	}
+	t.lastActive = time.Now()
+	return t
+}
+
+func (b *MemoryBroker) removeLocked(sub *memorySubscription) {
+	t, ok := b.topics[sub.topic]
+	if !ok {
+		return
+	}
+	if _, ok := t.subs[sub]; !ok {
+		return
+	}
+	delete(t.subs, sub)
+	close(sub.events)
+	t.lastActive = time.Now()
+}
+
+// sweepLocked forgets idle topics so per-post topics don't accumulate forever
+func (b *MemoryBroker) sweepLocked() {
+	now := time.Now()
+	if now.Sub(b.lastSweep) < time.Minute {
+		return
+	}
+	b.lastSweep = now
+
+	for name, t := range b.topics {
+		if len(t.subs) == 0 && now.Sub(t.lastActive) > idleTopicTTL {
+			delete(b.topics, name)
+		}
+	}
+}
+
+func (s *memorySubscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *memorySubscription) Close() {
+	s.once.Do(func() {
+		s.broker.mu.Lock()
+		defer s.broker.mu.Unlock()
+		s.broker.removeLocked(s)
+	})
+}
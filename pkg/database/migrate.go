@@ -0,0 +1,39 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// deferredConstraints is implemented by models with foreign keys that can't
+// be created along with their table, such as circular references
+type deferredConstraints interface {
+	DeferredConstraints() []string
+}
+
+// Migrate creates or updates the tables for the given models
+func Migrate(db *gorm.DB, models ...interface{}) error {
+	if err := db.AutoMigrate(models...); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	for _, model := range models {
+		m, ok := model.(deferredConstraints)
+		if !ok {
+			continue
+		}
+		for _, name := range m.DeferredConstraints() {
+			if db.Migrator().HasConstraint(model, name) {
+				continue
+			}
+			if err := db.Migrator().CreateConstraint(model, name); err != nil {
+				return fmt.Errorf("failed to create constraint %s: %w", name, err)
+			}
+		}
+	}
+
+	log.Printf("Migrated %d tables successfully", len(models))
+	return nil
+}
@@ -0,0 +1,342 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"strings"
+	"time"
+)
+
+// EXIF tags that are read
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagOrientation      = 0x0112
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagOffsetTimeOrig   = 0x9011
+)
+
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+var exifHeader = []byte("Exif\x00\x00")
+
+var errNoExif = errors.New("no exif data")
+
+// Metadata is what is read from an upload's EXIF block. Only the fields
+// below are kept; everything else (GPS, serial numbers, thumbnails) is
+// dropped when the image is re-encoded.
+type Metadata struct {
+	Orientation int // 1-8, 1 is upright
+	CameraMake  string
+	CameraModel string
+	CapturedAt  *time.Time // DateTimeOriginal, UTC unless the offset is recorded
+	HasGPS      bool
+
+	raw               []byte // TIFF block, for re-embedding when not stripping
+	byteOrder         binary.ByteOrder
+	orientationOffset int // offset of the orientation value in raw, 0 if absent
+}
+
+// ReadMetadata extracts EXIF metadata from JPEG, PNG or WebP data. Images
+// without EXIF return an empty Metadata.
+func ReadMetadata(data []byte) *Metadata {
+	raw, err := findExif(data)
+	if err != nil {
+		return &Metadata{Orientation: 1}
+	}
+
+	meta, err := parseExif(raw)
+	if err != nil {
+		return &Metadata{Orientation: 1}
+	}
+	return meta
+}
+
+// findExif returns the TIFF block from the container's EXIF segment
+func findExif(data []byte) ([]byte, error) {
+	switch {
+	case len(data) > 4 && data[0] == 0xFF && data[1] == 0xD8:
+		return findJPEGExif(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return findPNGExif(data)
+	case len(data) > 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return findWebPExif(data)
+	default:
+		return nil, errNoExif
+	}
+}
+
+func findJPEGExif(data []byte) ([]byte, error) {
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, errNoExif
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan, end of image
+			return nil, errNoExif
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errNoExif
+		}
+		segment := data[pos+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, exifHeader) {
+			return segment[len(exifHeader):], nil
+		}
+		pos = end
+	}
+	return nil, errNoExif
+}
+
+func findPNGExif(data []byte) ([]byte, error) {
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunk := string(data[pos+4 : pos+8])
+		end := pos + 8 + length
+		if length < 0 || end+4 > len(data) {
+			return nil, errNoExif
+		}
+		if chunk == "eXIf" {
+			return data[pos+8 : end], nil
+		}
+		if chunk == "IDAT" || chunk == "IEND" {
+			// eXIf has to come before the image data
+			return nil, errNoExif
+		}
+		pos = end + 4 // skip CRC
+	}
+	return nil, errNoExif
+}
+
+func findWebPExif(data []byte) ([]byte, error) {
+	pos := 12
+	for pos+8 <= len(data) {
+		chunk := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + length
+		if length < 0 || end > len(data) {
+			return nil, errNoExif
+		}
+		if chunk == "EXIF" {
+			// Some writers keep the JPEG-style prefix
+			return bytes.TrimPrefix(data[pos+8:end], exifHeader), nil
+		}
+		pos = end + length%2
+	}
+	return nil, errNoExif
+}
+
+// parseExif reads the tags we care about from a TIFF block
+func parseExif(raw []byte) (*Metadata, error) {
+	if len(raw) < 8 {
+		return nil, errNoExif
+	}
+
+	meta := &Metadata{Orientation: 1, raw: raw}
+	switch string(raw[0:4]) {
+	case "II*\x00":
+		meta.byteOrder = binary.LittleEndian
+	case "MM\x00*":
+		meta.byteOrder = binary.BigEndian
+	default:
+		return nil, errNoExif
+	}
+
+	ifd0, err := meta.readIFD(int(meta.byteOrder.Uint32(raw[4:])))
+	if err != nil {
+		return nil, err
+	}
+
+	if e, ok := ifd0[tagOrientation]; ok {
+		if o := int(e.short()); o >= 1 && o <= 8 {
+			meta.Orientation = o
+			meta.orientationOffset = e.valueOffset
+		}
+	}
+	meta.CameraMake = ifd0[tagMake].ascii()
+	meta.CameraModel = ifd0[tagModel].ascii()
+	_, meta.HasGPS = ifd0[tagGPSIFD]
+
+	captured := ifd0[tagDateTime].ascii()
+	offset := ""
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if sub, err := meta.readIFD(int(e.long())); err == nil {
+			if original := sub[tagDateTimeOriginal].ascii(); original != "" {
+				captured = original
+			}
+			offset = sub[tagOffsetTimeOrig].ascii()
+		}
+	}
+	meta.CapturedAt = parseExifTime(captured, offset)
+
+	return meta, nil
+}
+
+type ifdEntry struct {
+	typ         uint16
+	count       uint32
+	value       []byte
+	valueOffset int
+	order       binary.ByteOrder
+}
+
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func (m *Metadata) readIFD(offset int) (map[uint16]*ifdEntry, error) {
+	raw := m.raw
+	if offset < 8 || offset+2 > len(raw) {
+		return nil, errNoExif
+	}
+
+	count := int(m.byteOrder.Uint16(raw[offset:]))
+	entries := make(map[uint16]*ifdEntry, count)
+	for i := 0; i < count; i++ {
+		pos := offset + 2 + i*12
+		if pos+12 > len(raw) {
+			break
+		}
+
+		tag := m.byteOrder.Uint16(raw[pos:])
+		typ := m.byteOrder.Uint16(raw[pos+2:])
+		n := m.byteOrder.Uint32(raw[pos+4:])
+		size, ok := typeSizes[typ]
+		if !ok || n > uint32(len(raw)) {
+			continue
+		}
+
+		total := size * int(n)
+		valueOffset := pos + 8
+		if total > 4 {
+			valueOffset = int(m.byteOrder.Uint32(raw[pos+8:]))
+		}
+		if valueOffset < 0 || valueOffset+total > len(raw) {
+			continue
+		}
+
+		entries[tag] = &ifdEntry{
+			typ:         typ,
+			count:       n,
+			value:       raw[valueOffset : valueOffset+total],
+			valueOffset: valueOffset,
+			order:       m.byteOrder,
+		}
+	}
+	return entries, nil
+}
+
+func (e *ifdEntry) short() uint16 {
+	if e == nil || e.typ != 3 || len(e.value) < 2 {
+		return 0
+	}
+	return e.order.Uint16(e.value)
+}
+
+func (e *ifdEntry) long() uint32 {
+	if e == nil || e.typ != 4 || len(e.value) < 4 {
+		return 0
+	}
+	return e.order.Uint32(e.value)
+}
+
+func (e *ifdEntry) ascii() string {
+	if e == nil || e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+// parseExifTime reads "2006:01:02 15:04:05" with an optional "+07:00" offset
+func parseExifTime(value, offset string) *time.Time {
+	if value == "" || strings.HasPrefix(value, "0000") {
+		return nil
+	}
+
+	loc := time.UTC
+	if offset != "" {
+		if t, err := time.Parse("-07:00", offset); err == nil {
+			_, secs := t.Zone()
+			loc = time.FixedZone(offset, secs)
+		}
+	}
+
+	t, err := time.ParseInLocation(exifTimeLayout, value, loc)
+	if err != nil {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// exifSegment returns a JPEG APP1 segment holding the original EXIF block
+// with the orientation reset to upright, since the pixels are already
+// rotated. Nil if there is nothing to embed or it does not fit a segment.
+func (m *Metadata) exifSegment() []byte {
+	if m == nil || len(m.raw) == 0 {
+		return nil
+	}
+	payload := len(exifHeader) + len(m.raw)
+	if payload+2 > 0xFFFF {
+		return nil
+	}
+
+	segment := make([]byte, 0, 4+payload)
+	segment = append(segment, 0xFF, 0xE1, byte((payload+2)>>8), byte(payload+2))
+	segment = append(segment, exifHeader...)
+	start := len(segment)
+	segment = append(segment, m.raw...)
+
+	if m.orientationOffset > 0 {
+		m.byteOrder.PutUint16(segment[start+m.orientationOffset:], 1)
+	}
+	return segment
+}
+
+// Orient rotates and flips img so that it displays upright for an EXIF
+// orientation value
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// Orientations 5-8 swap width and height
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
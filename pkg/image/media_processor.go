@@ -0,0 +1,100 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+)
+
+// MediaProcessor handles post media: the original is capped by the embedded
+// Processor and each configured variant is rendered from it
+type MediaProcessor struct {
+    *Processor
+    variants []Variant
+}
+
+func NewMediaProcessor(processor *Processor, variants []Variant) *MediaProcessor {
+    return &MediaProcessor{
+        Processor: processor,
+        variants:  variants,
+    }
+}
+
+// Variants returns the configured variants
+func (p *MediaProcessor) Variants() []Variant {
+    return p.variants
+}
+
+// Decode reads an image of the given content type
+func (p *MediaProcessor) Decode(r io.Reader, contentType string) (image.Image, error) {
+    img, _, err := p.decodeImage(r, contentType)
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode image: %w", err)
+    }
+    return img, nil
+}
+
+// Render encodes img in the output format for an original of sourceFormat
+func (p *MediaProcessor) Render(img image.Image, sourceFormat string) (*Rendition, error) {
+    processed, err := p.Encode(img, sourceFormat)
+    if err != nil {
+        return nil, err
+    }
+
+    return &Rendition{
+        Width:    processed.Width,
+        Height:   processed.Height,
+        MimeType: processed.MimeType,
+        Data:     processed.Data,
+    }, nil
+}
+
+// Poster encodes a still taken from a video or document, capped like an
+// uploaded image. Stills have no source format, so they become JPEG unless
+// an output format is configured.
+func (p *MediaProcessor) Poster(img image.Image) (*Rendition, error) {
+    return p.Render(p.resizeImage(img), FormatJPEG)
+}
+
+// GenerateVariants renders every variant that applies to img
+func (p *MediaProcessor) GenerateVariants(img image.Image, sourceFormat string) ([]*Rendition, error) {
+    bounds := img.Bounds()
+
+    var renditions []*Rendition
+    for _, v := range p.variants {
+        if !v.applies(bounds.Dx(), bounds.Dy()) {
+            continue
+        }
+
+        rendition, err := p.Render(v.apply(img), sourceFormat)
+        if err != nil {
+            return nil, fmt.Errorf("failed to render %s variant: %w", v.Name, err)
+        }
+        rendition.Variant = v
+        renditions = append(renditions, rendition)
+    }
+
+    return renditions, nil
+}
+
+// Dimensions reads the width and height from encoded image data
+func Dimensions(data []byte) (int, int, error) {
+    cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+    if err != nil {
+        return 0, 0, err
+    }
+    return cfg.Width, cfg.Height, nil
+}
+
+// Extension returns the file extension for an output MIME type
+func Extension(mimeType string) string {
+    switch FormatOf(mimeType) {
+    case FormatPNG:
+        return ".png"
+    case FormatWebP:
+        return ".webp"
+    default:
+        return ".jpg"
+    }
+}
@@ -4,19 +4,36 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/jpeg"
 	"image/png"
 	"io"
 	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"strings"
 
+	"github.com/HugoSmits86/nativewebp"
 	"github.com/nfnt/resize"
 	"golang.org/x/image/webp"
 )
 
+// Output formats. FormatOriginal keeps whatever format was uploaded.
+const (
+    FormatOriginal = ""
+    FormatJPEG     = "jpeg"
+    FormatPNG      = "png"
+    FormatWebP     = "webp"
+)
+
 type Processor struct {
-    quality    int  // JPEG quality (1-100)
-    maxWidth   uint // Maximum width
-    maxHeight  uint // Maximum height
+    quality      int    // JPEG quality (1-100)
+    maxWidth     uint   // Maximum width
+    maxHeight    uint   // Maximum height
+    outputFormat string // Preferred output format, FormatOriginal to keep the source format
+    optimizePNG  bool   // Lossless PNG optimization (palette reduction, best compression)
+    keepMetadata bool   // Re-embed the original EXIF block (JPEG output only)
 }
 
 func NewProcessor(quality int, maxWidth, maxHeight uint) *Processor {
@@ -27,37 +44,172 @@ func NewProcessor(quality int, maxWidth, maxHeight uint) *Processor {
     }
 }
 
+// WithOutputFormat converts every processed image to format
+// ("jpeg", "png" or "webp"); "" or "original" keeps the source format
+func (p *Processor) WithOutputFormat(format string) (*Processor, error) {
+    format, err := ParseFormat(format)
+    if err != nil {
+        return nil, err
+    }
+    p.outputFormat = format
+    return p, nil
+}
+
+// WithPNGOptimization enables lossless PNG optimization
+func (p *Processor) WithPNGOptimization(enabled bool) *Processor {
+    p.optimizePNG = enabled
+    return p
+}
+
+// WithMetadataStripping controls whether EXIF metadata (GPS, camera serial
+// numbers, ...) is dropped from the output. It is stripped by default; when
+// kept, it is only re-embedded in JPEG output.
+func (p *Processor) WithMetadataStripping(strip bool) *Processor {
+    p.keepMetadata = !strip
+    return p
+}
+
+// Processed is an encoded image together with what it was encoded as
+type Processed struct {
+    Data     []byte
+    Format   string // jpeg, png or webp
+    MimeType string
+    Width    int
+    Height   int
+
+    // Set by ProcessImage: the upright size before downscaling and the EXIF
+    // metadata that was read from the upload
+    OriginalWidth  int
+    OriginalHeight int
+    Metadata       *Metadata
+}
+
+// Reader returns the encoded bytes
+func (p *Processed) Reader() io.Reader {
+    return bytes.NewReader(p.Data)
+}
+
+// FileHeader returns a copy of header describing the encoded output, so
+// storage names the file with the right extension and content type
+func (p *Processed) FileHeader(header *multipart.FileHeader) *multipart.FileHeader {
+    ext := Extension(p.MimeType)
+    name := strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename)) + ext
+
+    mimeHeader := make(textproto.MIMEHeader, len(header.Header))
+    for k, v := range header.Header {
+        mimeHeader[k] = v
+    }
+    mimeHeader.Set("Content-Type", p.MimeType)
+
+    return &multipart.FileHeader{
+        Filename: name,
+        Header:   mimeHeader,
+        Size:     int64(len(p.Data)),
+    }
+}
+
 // Process compresses and resizes image
 func (p *Processor) Process(file multipart.File, header *multipart.FileHeader) (io.Reader, error) {
+    processed, err := p.ProcessImage(file, header)
+    if err != nil {
+        return nil, err
+    }
+    return processed.Reader(), nil
+}
+
+// ProcessImage compresses and resizes image, keeping its format unless an
+// output format is configured. EXIF orientation is applied to the pixels
+// and the metadata is dropped, since the image is always re-encoded.
+func (p *Processor) ProcessImage(file multipart.File, header *multipart.FileHeader) (*Processed, error) {
     // Reset file pointer
     if _, err := file.Seek(0, 0); err != nil {
         return nil, err
     }
 
+    data, err := io.ReadAll(file)
+    if err != nil {
+        return nil, err
+    }
+
     // Decode image based on content type
-    img, format, err := p.decodeImage(file, header.Header.Get("Content-Type"))
+    img, format, err := p.decodeImage(bytes.NewReader(data), header.Header.Get("Content-Type"))
     if err != nil {
         return nil, err
     }
 
+    // Phone photos are stored sideways with an orientation tag
+    meta := ReadMetadata(data)
+    img = Orient(img, meta.Orientation)
+    bounds := img.Bounds()
+
     // Resize if needed
     img = p.resizeImage(img)
 
-    // Encode to JPEG with compression
-    return p.encodeImage(img, format)
+    processed, err := p.Encode(img, format)
+    if err != nil {
+        return nil, err
+    }
+
+    if p.keepMetadata && processed.Format == FormatJPEG {
+        if segment := meta.exifSegment(); segment != nil {
+            // Right after the SOI marker
+            withExif := make([]byte, 0, len(processed.Data)+len(segment))
+            withExif = append(withExif, processed.Data[:2]...)
+            withExif = append(withExif, segment...)
+            processed.Data = append(withExif, processed.Data[2:]...)
+        }
+    }
+
+    processed.OriginalWidth = bounds.Dx()
+    processed.OriginalHeight = bounds.Dy()
+    processed.Metadata = meta
+    return processed, nil
+}
+
+// Encode encodes img in the output format for an image that came in as
+// sourceFormat
+func (p *Processor) Encode(img image.Image, sourceFormat string) (*Processed, error) {
+    format := p.OutputFormat(sourceFormat)
+
+    buf, err := p.encodeImage(img, format)
+    if err != nil {
+        return nil, err
+    }
+
+    bounds := img.Bounds()
+    return &Processed{
+        Data:     buf.Bytes(),
+        Format:   format,
+        MimeType: MimeType(format),
+        Width:    bounds.Dx(),
+        Height:   bounds.Dy(),
+    }, nil
+}
+
+// OutputFormat returns the format an image of sourceFormat is encoded as
+func (p *Processor) OutputFormat(sourceFormat string) string {
+    if p.outputFormat != FormatOriginal {
+        return p.outputFormat
+    }
+    switch sourceFormat {
+    case FormatPNG, FormatWebP:
+        return sourceFormat
+    default:
+        return FormatJPEG
+    }
 }
 
 func (p *Processor) decodeImage(file io.Reader, contentType string) (image.Image, string, error) {
     switch contentType {
     case "image/jpeg", "image/jpg":
         img, err := jpeg.Decode(file)
-        return img, "jpeg", err
+        return img, FormatJPEG, err
     case "image/png":
         img, err := png.Decode(file)
-        return img, "png", err
+        return img, FormatPNG, err
     case "image/webp":
         img, err := webp.Decode(file)
-        return img, "webp", err
+        return img, FormatWebP, err
     default:
         // Try to decode anyway
         img, format, err := image.Decode(file)
@@ -82,18 +234,119 @@ func (p *Processor) resizeImage(img image.Image) image.Image {
     return resize.Resize(0, p.maxHeight, img, resize.Lanczos3)
 }
 
-func (p *Processor) encodeImage(img image.Image, format string) (io.Reader, error) {
+func (p *Processor) encodeImage(img image.Image, format string) (*bytes.Buffer, error) {
     var buf bytes.Buffer
-    
-    // Always encode to JPEG for better compression
-    opts := &jpeg.Options{Quality: p.quality}
-    if err := jpeg.Encode(&buf, img, opts); err != nil {
+    var err error
+
+    switch format {
+    case FormatPNG:
+        encoder := &png.Encoder{CompressionLevel: png.DefaultCompression}
+        if p.optimizePNG {
+            encoder.CompressionLevel = png.BestCompression
+            img = reducePalette(img)
+        }
+        err = encoder.Encode(&buf, img)
+    case FormatWebP:
+        // The encoder is lossless (VP8L), so quality does not apply
+        err = nativewebp.Encode(&buf, img, nil)
+    default:
+        // JPEG has no alpha channel: flatten onto white instead of black
+        err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: p.quality})
+    }
+    if err != nil {
         return nil, fmt.Errorf("failed to encode image: %w", err)
     }
 
     return &buf, nil
 }
 
+// flatten composites images with transparency onto a white background
+func flatten(img image.Image) image.Image {
+    if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+        return img
+    }
+    bounds := img.Bounds()
+    dst := image.NewRGBA(bounds)
+    draw.Draw(dst, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+    draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+    return dst
+}
+
+// reducePalette converts images with at most 256 distinct colors to a
+// paletted image. The pixels are unchanged, but the PNG is a fraction of
+// the size.
+func reducePalette(img image.Image) image.Image {
+    if _, ok := img.(*image.Paletted); ok {
+        return img
+    }
+
+    bounds := img.Bounds()
+    index := make(map[color.NRGBA]uint8, 256)
+    palette := make(color.Palette, 0, 256)
+    for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+        for x := bounds.Min.X; x < bounds.Max.X; x++ {
+            c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+            if _, ok := index[c]; ok {
+                continue
+            }
+            if len(palette) == 256 {
+                return img
+            }
+            index[c] = uint8(len(palette))
+            palette = append(palette, c)
+        }
+    }
+
+    paletted := image.NewPaletted(bounds, palette)
+    for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+        for x := bounds.Min.X; x < bounds.Max.X; x++ {
+            c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+            paletted.SetColorIndex(x, y, index[c])
+        }
+    }
+    return paletted
+}
+
+// ParseFormat normalizes an output format name
+func ParseFormat(format string) (string, error) {
+    switch strings.ToLower(strings.TrimSpace(format)) {
+    case "", "original":
+        return FormatOriginal, nil
+    case "jpeg", "jpg":
+        return FormatJPEG, nil
+    case "png":
+        return FormatPNG, nil
+    case "webp":
+        return FormatWebP, nil
+    default:
+        return "", fmt.Errorf("unsupported output format %q", format)
+    }
+}
+
+// FormatOf returns the format for a MIME type
+func FormatOf(mimeType string) string {
+    switch mimeType {
+    case "image/png":
+        return FormatPNG
+    case "image/webp":
+        return FormatWebP
+    default:
+        return FormatJPEG
+    }
+}
+
+// MimeType returns the MIME type for a format
+func MimeType(format string) string {
+    switch format {
+    case FormatPNG:
+        return "image/png"
+    case FormatWebP:
+        return "image/webp"
+    default:
+        return "image/jpeg"
+    }
+}
+
 // Default processors
 func DefaultAvatarProcessor() *Processor {
     // Avatar: 400x400, quality 85
@@ -103,4 +356,4 @@ func DefaultAvatarProcessor() *Processor {
 func DefaultImageProcessor() *Processor {
     // Post image: 1200x1200, quality 90
     return NewProcessor(90, 1200, 1200)
-}
\ No newline at end of file
+}
@@ -0,0 +1,31 @@
+package image
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// ResizeSigner signs on-demand resize parameters so clients can only
+// request sizes the API handed out
+type ResizeSigner struct {
+	secret []byte
+}
+
+func NewResizeSigner(secret string) *ResizeSigner {
+	return &ResizeSigner{secret: []byte(secret)}
+}
+
+// Sign returns the signature for resizing media id to width x height
+func (s *ResizeSigner) Sign(id string, width, height uint, fit string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s:%d:%d:%s", id, width, height, fit)
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// Verify checks a signature produced by Sign
+func (s *ResizeSigner) Verify(id string, width, height uint, fit, signature string) bool {
+	expected := s.Sign(id, width, height, fit)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
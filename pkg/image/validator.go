@@ -1,52 +1,191 @@
 package image
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"image"
+	"io"
+	"mime"
 	"mime/multipart"
+	"net/http"
+	"path/filepath"
 	"strings"
+
+	"github.com/afdhali/GolangBlogpostServer/pkg/scanner"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
 )
 
 var (
     ErrInvalidImageType = errors.New("invalid image type")
     ErrImageTooLarge    = errors.New("image size too large")
+    ErrTypeMismatch     = errors.New("file type does not match its name or content type")
+    ErrCorruptImage     = errors.New("image could not be decoded")
+    ErrTooManyPixels    = errors.New("image dimensions too large")
+    ErrInfected         = errors.New("file rejected by virus scan")
 )
 
+// DefaultMaxPixels caps width*height so a small file cannot decode into
+// gigabytes of pixels
+const DefaultMaxPixels = 40_000_000
+
+// sniffLen is how much http.DetectContentType looks at
+const sniffLen = 512
+
+// extensionTypes maps accepted file extensions to the type they must contain
+var extensionTypes = map[string]string{
+    ".jpg":  "image/jpeg",
+    ".jpeg": "image/jpeg",
+    ".png":  "image/png",
+    ".webp": "image/webp",
+}
+
 type Validator struct {
     maxSize       int64    // in bytes
     allowedTypes  []string
+    maxPixels     int
+    scanner       scanner.Scanner
 }
 
 func NewValidator(maxSizeMB int, allowedTypes []string) *Validator {
     return &Validator{
         maxSize:      int64(maxSizeMB * 1024 * 1024),
         allowedTypes: allowedTypes,
+        maxPixels:    DefaultMaxPixels,
+        scanner:      scanner.NopScanner{},
     }
 }
 
-func (v *Validator) Validate(header *multipart.FileHeader) error {
+// WithMaxPixels sets the largest width*height that is accepted
+func (v *Validator) WithMaxPixels(maxPixels int) *Validator {
+    v.maxPixels = maxPixels
+    return v
+}
+
+// WithScanner runs every file that passes the other checks through s
+func (v *Validator) WithScanner(s scanner.Scanner) *Validator {
+    v.scanner = s
+    return v
+}
+
+// Validate checks an upload by its content rather than what the client
+// claims: the type is sniffed from the magic bytes, the image header is
+// decoded to check the dimensions, and the name and declared type must agree
+// with the content. Rejections are *validator.FieldError.
+func (v *Validator) Validate(ctx context.Context, file multipart.File, header *multipart.FileHeader) error {
+    size, err := file.Seek(0, io.SeekEnd)
+    if err != nil {
+        return fmt.Errorf("failed to read upload: %w", err)
+    }
+    defer file.Seek(0, io.SeekStart)
+
     // Check file size
-    if header.Size > v.maxSize {
-        return ErrImageTooLarge
+    if size > v.maxSize || header.Size > v.maxSize {
+        return fieldError("file_too_large", fmt.Sprintf("file must be at most %d MB", v.maxSize/(1024*1024)), ErrImageTooLarge)
+    }
+
+    // Check content type from the magic bytes
+    head := make([]byte, sniffLen)
+    n, err := file.ReadAt(head, 0)
+    if err != nil && err != io.EOF {
+        return fmt.Errorf("failed to read upload: %w", err)
+    }
+    contentType := http.DetectContentType(head[:n])
+    if !v.isAllowedType(contentType) {
+        return fieldError("unsupported_type", fmt.Sprintf("file content is %s, allowed types are %s", contentType, strings.Join(v.allowedTypes, ", ")), ErrInvalidImageType)
+    }
+
+    // Name and declared type must describe the same thing
+    if err := checkConsistency(header, contentType); err != nil {
+        return err
+    }
+
+    // Decode the header only, which is enough to catch decompression bombs
+    cfg, _, err := image.DecodeConfig(io.NewSectionReader(file, 0, size))
+    if err != nil {
+        return fieldError("corrupt_image", "file is not a readable image", ErrCorruptImage)
+    }
+    if cfg.Width <= 0 || cfg.Height <= 0 {
+        return fieldError("corrupt_image", "image has no pixels", ErrCorruptImage)
+    }
+    if int64(cfg.Width)*int64(cfg.Height) > int64(v.maxPixels) {
+        return fieldError("dimensions_too_large", fmt.Sprintf("image is %dx%d, at most %d pixels are allowed", cfg.Width, cfg.Height, v.maxPixels), ErrTooManyPixels)
+    }
+
+    if err := v.scanner.Scan(ctx, io.NewSectionReader(file, 0, size)); err != nil {
+        if errors.Is(err, scanner.ErrInfected) {
+            return fieldError("infected", "file was rejected by the virus scanner", ErrInfected)
+        }
+        return err
+    }
+
+    return nil
+}
+
+// ValidateDeclared checks what a client says it is about to upload, before
+// any bytes arrive. The content itself still goes through Validate.
+func (v *Validator) ValidateDeclared(filename, contentType string, size int64) error {
+    if size > v.maxSize {
+        return validator.NewFieldError("size", "file_too_large", fmt.Sprintf("file must be at most %d MB", v.maxSize/(1024*1024)), ErrImageTooLarge)
     }
 
-    // Check content type
-    contentType := header.Header.Get("Content-Type")
+    contentType = normalizeType(contentType)
     if !v.isAllowedType(contentType) {
-        return ErrInvalidImageType
+        return validator.NewFieldError("content_type", "unsupported_type", fmt.Sprintf("allowed types are %s", strings.Join(v.allowedTypes, ", ")), ErrInvalidImageType)
+    }
+
+    ext := strings.ToLower(filepath.Ext(filename))
+    if ext == "" || extensionTypes[ext] != contentType {
+        return validator.NewFieldError("filename", "type_mismatch", fmt.Sprintf("file extension %q does not match %s", ext, contentType), ErrTypeMismatch)
     }
 
     return nil
 }
 
 func (v *Validator) isAllowedType(contentType string) bool {
+    contentType = normalizeType(contentType)
     for _, allowed := range v.allowedTypes {
-        if strings.Contains(contentType, allowed) {
+        if contentType == normalizeType(allowed) {
             return true
         }
     }
     return false
 }
 
+// checkConsistency rejects files whose extension or declared Content-Type
+// disagree with the sniffed type. A missing extension or a generic declared
+// type is not an error.
+func checkConsistency(header *multipart.FileHeader, contentType string) error {
+    if ext := strings.ToLower(filepath.Ext(header.Filename)); ext != "" {
+        if extensionTypes[ext] != contentType {
+            return fieldError("type_mismatch", fmt.Sprintf("file extension %s does not match its content (%s)", ext, contentType), ErrTypeMismatch)
+        }
+    }
+
+    declared := normalizeType(header.Header.Get("Content-Type"))
+    if declared != "" && declared != "application/octet-stream" && declared != contentType {
+        return fieldError("type_mismatch", fmt.Sprintf("declared content type %s does not match the file content (%s)", declared, contentType), ErrTypeMismatch)
+    }
+
+    return nil
+}
+
+// normalizeType drops parameters and folds the image/jpg alias
+func normalizeType(contentType string) string {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return ""
+    }
+    if mediaType == "image/jpg" {
+        return "image/jpeg"
+    }
+    return mediaType
+}
+
+func fieldError(code, message string, err error) error {
+    return validator.NewFieldError("file", code, message, err)
+}
+
 // Default validators
 func DefaultAvatarValidator() *Validator {
     return NewValidator(5, []string{"image/jpeg", "image/jpg", "image/png", "image/webp"})
@@ -54,4 +193,4 @@ func DefaultAvatarValidator() *Validator {
 
 func DefaultImageValidator() *Validator {
     return NewValidator(10, []string{"image/jpeg", "image/jpg", "image/png", "image/webp"})
-}
\ No newline at end of file
+}
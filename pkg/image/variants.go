@@ -0,0 +1,158 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/nfnt/resize"
+)
+
+// DefaultVariantSpec is used when IMAGE_VARIANTS is not set
+const DefaultVariantSpec = "thumb:150x150:crop,small:480,medium:960,large:1600,og-image:1200x630:crop"
+
+// Variant is a named rendition generated for every uploaded image. A zero
+// Height means "scale to Width"; Crop fills the exact box, cutting the
+// overflow from the center.
+type Variant struct {
+	Name   string
+	Width  uint
+	Height uint
+	Crop   bool
+}
+
+// Rendition is an encoded variant ready to be stored
+type Rendition struct {
+	Variant  Variant
+	Width    int
+	Height   int
+	MimeType string
+	Data     []byte
+}
+
+// ParseVariants parses a spec like "thumb:150x150:crop,small:480"
+func ParseVariants(spec string) ([]Variant, error) {
+	var variants []Variant
+	seen := make(map[string]bool)
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid image variant %q", item)
+		}
+
+		v := Variant{Name: parts[0]}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("duplicate image variant %q", v.Name)
+		}
+		seen[v.Name] = true
+
+		dims := strings.SplitN(parts[1], "x", 2)
+		width, err := strconv.ParseUint(dims[0], 10, 32)
+		if err != nil || width == 0 {
+			return nil, fmt.Errorf("invalid width in image variant %q", item)
+		}
+		v.Width = uint(width)
+
+		if len(dims) == 2 {
+			height, err := strconv.ParseUint(dims[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid height in image variant %q", item)
+			}
+			v.Height = uint(height)
+		}
+
+		if len(parts) == 3 {
+			if parts[2] != "crop" {
+				return nil, fmt.Errorf("unknown option %q in image variant %q", parts[2], item)
+			}
+			if v.Height == 0 {
+				return nil, fmt.Errorf("crop needs both width and height in image variant %q", item)
+			}
+			v.Crop = true
+		}
+
+		variants = append(variants, v)
+	}
+
+	return variants, nil
+}
+
+// Fit scales img down to fit inside width x height, keeping the aspect
+// ratio. A zero dimension is unconstrained. Images are never upscaled.
+func Fit(img image.Image, width, height uint) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := uint(bounds.Dx()), uint(bounds.Dy())
+
+	if width == 0 {
+		width = srcW
+	}
+	if height == 0 {
+		height = srcH
+	}
+	if srcW <= width && srcH <= height {
+		return img
+	}
+	return resize.Thumbnail(width, height, img, resize.Lanczos3)
+}
+
+// Cover scales img to fill width x height and crops the overflow around the
+// center. When the source is smaller than the box the box is shrunk to
+// the same aspect ratio instead of upscaling.
+func Cover(img image.Image, width, height uint) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := float64(bounds.Dx()), float64(bounds.Dy())
+	w, h := float64(width), float64(height)
+
+	scale := math.Max(w/srcW, h/srcH)
+	if scale > 1 {
+		w, h = w/scale, h/scale
+		scale = 1
+	}
+
+	scaled := img
+	if scale < 1 {
+		scaled = resize.Resize(uint(math.Round(srcW*scale)), uint(math.Round(srcH*scale)), img, resize.Lanczos3)
+	}
+
+	cropW, cropH := int(math.Round(w)), int(math.Round(h))
+	sb := scaled.Bounds()
+	if cropW > sb.Dx() {
+		cropW = sb.Dx()
+	}
+	if cropH > sb.Dy() {
+		cropH = sb.Dy()
+	}
+
+	offset := image.Pt(sb.Min.X+(sb.Dx()-cropW)/2, sb.Min.Y+(sb.Dy()-cropH)/2)
+	dst := image.NewRGBA(image.Rect(0, 0, cropW, cropH))
+	draw.Draw(dst, dst.Bounds(), scaled, offset, draw.Src)
+	return dst
+}
+
+// applies reports whether the variant is worth generating for a source of
+// this size; fitted variants wider than the source would be identical to it
+func (v Variant) applies(srcW, srcH int) bool {
+	if v.Crop {
+		return true
+	}
+	if v.Height == 0 {
+		return uint(srcW) > v.Width
+	}
+	return uint(srcW) > v.Width || uint(srcH) > v.Height
+}
+
+func (v Variant) apply(img image.Image) image.Image {
+	if v.Crop {
+		return Cover(img, v.Width, v.Height)
+	}
+	return Fit(img, v.Width, v.Height)
+}
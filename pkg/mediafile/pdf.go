@@ -0,0 +1,147 @@
+package mediafile
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// maxObjectStream caps how much a single compressed object stream may
+// inflate to
+const maxObjectStream = 16 << 20
+
+var (
+	pdfPagesType  = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfObjStmType = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+	pdfCount      = regexp.MustCompile(`/Count\s+(\d+)`)
+	pdfLength     = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+)
+
+// probePDF counts pages by finding the page tree. The root Pages node
+// counts every page, so the largest /Count of any Pages node is the total.
+// Page trees inside compressed object streams (PDF 1.5+) are found by
+// inflating those streams.
+func probePDF(r io.ReaderAt, size int64) (*Info, error) {
+	data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, errors.New("missing PDF header")
+	}
+
+	// A truncated upload has no trailer
+	tail := data[max(0, len(data)-1024):]
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		return nil, errors.New("missing end-of-file marker")
+	}
+
+	pages := pdfPageCount(data)
+	for _, loc := range pdfObjStmType.FindAllIndex(data, -1) {
+		if stream := pdfStream(data, loc[0]); stream != nil {
+			pages = max(pages, pdfPageCount(stream))
+		}
+	}
+
+	// Encrypted documents keep their page tree out of reach; that is not
+	// a reason to reject them
+	return &Info{Pages: pages}, nil
+}
+
+func pdfPageCount(data []byte) int {
+	pages := 0
+	for _, loc := range pdfPagesType.FindAllIndex(data, -1) {
+		start, end := pdfDictionary(data, loc[0])
+		if end < 0 {
+			continue
+		}
+		if m := pdfCount.FindSubmatch(data[start:end]); m != nil {
+			if n, err := strconv.Atoi(string(m[1])); err == nil {
+				pages = max(pages, n)
+			}
+		}
+	}
+	return pages
+}
+
+// pdfDictionary returns the bounds of the << ... >> dictionary that
+// contains pos; end is -1 when there is none
+func pdfDictionary(data []byte, pos int) (start, end int) {
+	start = -1
+	for i, depth := pos-1, 0; i > 0; i-- {
+		if data[i-1] == '>' && data[i] == '>' {
+			depth++
+			i--
+		} else if data[i-1] == '<' && data[i] == '<' {
+			if depth == 0 {
+				start = i - 1
+				break
+			}
+			depth--
+			i--
+		}
+	}
+	if start < 0 {
+		return 0, -1
+	}
+
+	for i, depth := start+2, 0; i+1 < len(data); i++ {
+		if data[i] == '<' && data[i+1] == '<' {
+			depth++
+			i++
+		} else if data[i] == '>' && data[i+1] == '>' {
+			if depth == 0 {
+				return start, i + 2
+			}
+			depth--
+			i++
+		}
+	}
+	return 0, -1
+}
+
+// pdfStream inflates the Flate-encoded stream whose dictionary contains pos
+func pdfStream(data []byte, pos int) []byte {
+	start, end := pdfDictionary(data, pos)
+	if end < 0 {
+		return nil
+	}
+	dict := data[start:end]
+	if !bytes.Contains(dict, []byte("/FlateDecode")) {
+		return nil
+	}
+
+	rest := data[end:]
+	keyword := bytes.Index(rest, []byte("stream"))
+	if keyword < 0 || len(bytes.TrimSpace(rest[:keyword])) > 0 {
+		return nil
+	}
+	body := rest[keyword+len("stream"):]
+	body = bytes.TrimPrefix(body, []byte("\r"))
+	body = bytes.TrimPrefix(body, []byte("\n"))
+
+	// A direct /Length is exact; an indirect one needs the object, so the
+	// endstream keyword marks the end instead
+	if m := pdfLength.FindSubmatch(dict); m != nil && len(m[2]) == 0 {
+		if n, err := strconv.Atoi(string(m[1])); err == nil && n <= len(body) {
+			body = body[:n]
+		}
+	} else if i := bytes.Index(body, []byte("endstream")); i >= 0 {
+		body = body[:i]
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	defer zr.Close()
+
+	inflated, err := io.ReadAll(io.LimitReader(zr, maxObjectStream))
+	if err != nil && len(inflated) == 0 {
+		return nil
+	}
+	return inflated
+}
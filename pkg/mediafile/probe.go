@@ -0,0 +1,428 @@
+package mediafile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ErrMalformed means the content does not parse as the container it claims to be
+var ErrMalformed = errors.New("malformed media file")
+
+// Info is what Probe reads from a file. Fields that do not apply to the
+// file's kind, or could not be determined, are zero.
+type Info struct {
+	Duration time.Duration // video and audio
+	Width    int           // video
+	Height   int           // video
+	Pages    int           // documents
+}
+
+// Probe reads the metadata of a file of a supported type
+func Probe(r io.ReaderAt, size int64, mimeType string) (*Info, error) {
+	var info *Info
+	var err error
+
+	switch NormalizeType(mimeType) {
+	case "video/mp4", "video/quicktime", "audio/mp4":
+		info, err = probeMP4(r, size)
+	case "video/webm":
+		info, err = probeWebM(r, size)
+	case "audio/mpeg":
+		info, err = probeMP3(r, size)
+	case "audio/wav":
+		info, err = probeWAV(r, size)
+	case "audio/ogg":
+		info, err = probeOgg(r, size)
+	case "application/pdf":
+		info, err = probePDF(r, size)
+	default:
+		return nil, fmt.Errorf("cannot probe %s", mimeType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return info, nil
+}
+
+func readAt(r io.ReaderAt, off int64, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, off); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
+
+// ISO base media (MP4, MOV, M4A)
+
+// mp4Box is a box header; start is where its payload begins
+type mp4Box struct {
+	kind  string
+	start int64
+	end   int64
+}
+
+// mp4Boxes lists the boxes between start and end
+func mp4Boxes(r io.ReaderAt, start, end int64) ([]mp4Box, error) {
+	var boxes []mp4Box
+	for off := start; off+8 <= end; {
+		header, err := readAt(r, off, 8)
+		if err != nil {
+			return nil, err
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		box := mp4Box{kind: string(header[4:8]), start: off + 8}
+
+		switch size {
+		case 0: // extends to the end of the file
+			size = end - off
+		case 1: // 64-bit size follows the type
+			large, err := readAt(r, off+8, 8)
+			if err != nil {
+				return nil, err
+			}
+			size = int64(binary.BigEndian.Uint64(large))
+			box.start += 8
+		}
+		if size < box.start-off || off+size > end {
+			return nil, fmt.Errorf("box %q overruns its parent", box.kind)
+		}
+
+		box.end = off + size
+		boxes = append(boxes, box)
+		off += size
+	}
+	return boxes, nil
+}
+
+func findBox(boxes []mp4Box, kind string) (mp4Box, bool) {
+	for _, b := range boxes {
+		if b.kind == kind {
+			return b, true
+		}
+	}
+	return mp4Box{}, false
+}
+
+func probeMP4(r io.ReaderAt, size int64) (*Info, error) {
+	top, err := mp4Boxes(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := findBox(top, "ftyp"); !ok {
+		return nil, errors.New("missing ftyp box")
+	}
+	moov, ok := findBox(top, "moov")
+	if !ok {
+		return nil, errors.New("missing moov box")
+	}
+
+	children, err := mp4Boxes(r, moov.start, moov.end)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	mvhd, ok := findBox(children, "mvhd")
+	if !ok {
+		return nil, errors.New("missing mvhd box")
+	}
+	if info.Duration, err = mp4Duration(r, mvhd); err != nil {
+		return nil, err
+	}
+
+	// The first track with a picture gives the video size
+	for _, trak := range children {
+		if trak.kind != "trak" {
+			continue
+		}
+		boxes, err := mp4Boxes(r, trak.start, trak.end)
+		if err != nil {
+			return nil, err
+		}
+		if tkhd, ok := findBox(boxes, "tkhd"); ok {
+			width, height, err := mp4TrackSize(r, tkhd)
+			if err != nil {
+				return nil, err
+			}
+			if width > 0 && height > 0 {
+				info.Width, info.Height = width, height
+				break
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func mp4Duration(r io.ReaderAt, mvhd mp4Box) (time.Duration, error) {
+	version, err := readAt(r, mvhd.start, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	var timescale, duration uint64
+	if version[0] == 1 {
+		b, err := readAt(r, mvhd.start+4+16, 12)
+		if err != nil {
+			return 0, err
+		}
+		timescale = uint64(binary.BigEndian.Uint32(b))
+		duration = binary.BigEndian.Uint64(b[4:])
+	} else {
+		b, err := readAt(r, mvhd.start+4+8, 8)
+		if err != nil {
+			return 0, err
+		}
+		timescale = uint64(binary.BigEndian.Uint32(b))
+		duration = uint64(binary.BigEndian.Uint32(b[4:]))
+	}
+	if timescale == 0 {
+		return 0, errors.New("mvhd has no timescale")
+	}
+	return seconds(float64(duration) / float64(timescale)), nil
+}
+
+// mp4TrackSize reads the 16.16 fixed-point presentation size of a track
+func mp4TrackSize(r io.ReaderAt, tkhd mp4Box) (int, int, error) {
+	version, err := readAt(r, tkhd.start, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// version/flags, times, track ID, reserved, duration, reserved,
+	// layer, group, volume, reserved, matrix
+	off := tkhd.start + 4 + 20 + 8 + 8 + 36
+	if version[0] == 1 {
+		off += 12
+	}
+	b, err := readAt(r, off, 8)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(binary.BigEndian.Uint32(b) >> 16), int(binary.BigEndian.Uint32(b[4:]) >> 16), nil
+}
+
+// WebM (Matroska)
+
+const (
+	ebmlHeader       = 0x1A45DFA3
+	ebmlDocType      = 0x4282
+	mkvSegment       = 0x18538067
+	mkvInfo          = 0x1549A966
+	mkvTimecodeScale = 0x2AD7B1
+	mkvDuration      = 0x4489
+	mkvTracks        = 0x1654AE6B
+	mkvTrackEntry    = 0xAE
+	mkvVideo         = 0xE0
+	mkvPixelWidth    = 0xB0
+	mkvPixelHeight   = 0xBA
+	mkvCluster       = 0x1F43B675
+)
+
+type ebmlElement struct {
+	id    uint64
+	start int64
+	end   int64
+}
+
+// ebmlVint reads a variable-length integer; the length marker is kept for
+// IDs and dropped for sizes
+func ebmlVint(r io.ReaderAt, off int64, keepMarker bool) (uint64, int, error) {
+	first, err := readAt(r, off, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	length := 1
+	for mask := byte(0x80); length <= 8 && first[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 {
+		return 0, 0, errors.New("invalid EBML integer")
+	}
+
+	b, err := readAt(r, off, length)
+	if err != nil {
+		return 0, 0, err
+	}
+	value := uint64(b[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	allOnes := value == uint64(0xFF>>length)
+	for _, c := range b[1:] {
+		value = value<<8 | uint64(c)
+		allOnes = allOnes && c == 0xFF
+	}
+	if !keepMarker && allOnes {
+		return math.MaxUint64, length, nil // unknown size
+	}
+	return value, length, nil
+}
+
+// ebmlChildren lists the elements between start and end, stopping early at
+// stopAt (0 for none)
+func ebmlChildren(r io.ReaderAt, start, end int64, stopAt uint64) ([]ebmlElement, error) {
+	var elements []ebmlElement
+	for off := start; off < end; {
+		id, idLen, err := ebmlVint(r, off, true)
+		if err != nil {
+			return nil, err
+		}
+		size, sizeLen, err := ebmlVint(r, off+int64(idLen), false)
+		if err != nil {
+			return nil, err
+		}
+
+		el := ebmlElement{id: id, start: off + int64(idLen+sizeLen)}
+		if el.start > end {
+			return nil, errors.New("EBML element overruns its parent")
+		}
+		if size == math.MaxUint64 || size > uint64(end-el.start) {
+			el.end = end
+		} else {
+			el.end = el.start + int64(size)
+		}
+		if id == stopAt {
+			break
+		}
+		elements = append(elements, el)
+		off = el.end
+	}
+	return elements, nil
+}
+
+func findElement(elements []ebmlElement, id uint64) (ebmlElement, bool) {
+	for _, el := range elements {
+		if el.id == id {
+			return el, true
+		}
+	}
+	return ebmlElement{}, false
+}
+
+func ebmlUint(r io.ReaderAt, el ebmlElement) (uint64, error) {
+	if el.end-el.start > 8 {
+		return 0, errors.New("EBML integer too long")
+	}
+	b, err := readAt(r, el.start, int(el.end-el.start))
+	if err != nil {
+		return 0, err
+	}
+	var value uint64
+	for _, c := range b {
+		value = value<<8 | uint64(c)
+	}
+	return value, nil
+}
+
+func ebmlFloat(r io.ReaderAt, el ebmlElement) (float64, error) {
+	b, err := readAt(r, el.start, int(el.end-el.start))
+	if err != nil {
+		return 0, err
+	}
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	default:
+		return 0, errors.New("invalid EBML float")
+	}
+}
+
+func probeWebM(r io.ReaderAt, size int64) (*Info, error) {
+	top, err := ebmlChildren(r, 0, size, mkvCluster)
+	if err != nil {
+		return nil, err
+	}
+	header, ok := findElement(top, ebmlHeader)
+	if !ok {
+		return nil, errors.New("missing EBML header")
+	}
+	headerFields, err := ebmlChildren(r, header.start, header.end, 0)
+	if err != nil {
+		return nil, err
+	}
+	if docType, ok := findElement(headerFields, ebmlDocType); ok {
+		b, err := readAt(r, docType.start, int(docType.end-docType.start))
+		if err != nil {
+			return nil, err
+		}
+		if string(b) != "webm" {
+			return nil, fmt.Errorf("document type is %q, not webm", b)
+		}
+	}
+
+	segment, ok := findElement(top, mkvSegment)
+	if !ok {
+		return nil, errors.New("missing segment")
+	}
+	sections, err := ebmlChildren(r, segment.start, segment.end, mkvCluster)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	if section, ok := findElement(sections, mkvInfo); ok {
+		fields, err := ebmlChildren(r, section.start, section.end, 0)
+		if err != nil {
+			return nil, err
+		}
+		scale := uint64(1000000) // nanoseconds per tick
+		if el, ok := findElement(fields, mkvTimecodeScale); ok {
+			if scale, err = ebmlUint(r, el); err != nil {
+				return nil, err
+			}
+		}
+		if el, ok := findElement(fields, mkvDuration); ok {
+			ticks, err := ebmlFloat(r, el)
+			if err != nil {
+				return nil, err
+			}
+			info.Duration = time.Duration(ticks * float64(scale))
+		}
+	}
+
+	if tracks, ok := findElement(sections, mkvTracks); ok {
+		entries, err := ebmlChildren(r, tracks.start, tracks.end, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.id != mkvTrackEntry {
+				continue
+			}
+			fields, err := ebmlChildren(r, entry.start, entry.end, 0)
+			if err != nil {
+				return nil, err
+			}
+			video, ok := findElement(fields, mkvVideo)
+			if !ok {
+				continue
+			}
+			settings, err := ebmlChildren(r, video.start, video.end, 0)
+			if err != nil {
+				return nil, err
+			}
+			if el, ok := findElement(settings, mkvPixelWidth); ok {
+				width, _ := ebmlUint(r, el)
+				info.Width = int(width)
+			}
+			if el, ok := findElement(settings, mkvPixelHeight); ok {
+				height, _ := ebmlUint(r, el)
+				info.Height = int(height)
+			}
+			break
+		}
+	}
+
+	return info, nil
+}
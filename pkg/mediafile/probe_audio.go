@@ -0,0 +1,240 @@
+package mediafile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// MP3
+
+// Layer III bitrates in kbit/s by bitrate index, for MPEG-1 and MPEG-2/2.5
+var (
+	mp3BitratesV1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitratesV2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+)
+
+// Sample rates by version bits (2.5, reserved, 2, 1) and rate index
+var mp3SampleRates = [4][3]int{
+	{11025, 12000, 8000},
+	{},
+	{22050, 24000, 16000},
+	{44100, 48000, 32000},
+}
+
+type mp3Frame struct {
+	mpeg1      bool
+	mono       bool
+	bitrate    int // bit/s
+	sampleRate int
+	length     int // bytes, including the header
+}
+
+// isMP3Frame reports whether header is a valid MPEG audio Layer III frame header
+func isMP3Frame(header uint32) bool {
+	_, ok := parseMP3Frame(header)
+	return ok
+}
+
+func parseMP3Frame(header uint32) (mp3Frame, bool) {
+	if header>>21 != 0x7FF {
+		return mp3Frame{}, false
+	}
+	version := (header >> 19) & 3
+	layer := (header >> 17) & 3
+	bitrateIndex := (header >> 12) & 15
+	rateIndex := (header >> 10) & 3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	frame := mp3Frame{
+		mpeg1:      version == 3,
+		mono:       (header>>6)&3 == 3,
+		sampleRate: mp3SampleRates[version][rateIndex],
+	}
+	padding := int((header >> 9) & 1)
+	if frame.mpeg1 {
+		frame.bitrate = mp3BitratesV1[bitrateIndex] * 1000
+		frame.length = 144*frame.bitrate/frame.sampleRate + padding
+	} else {
+		frame.bitrate = mp3BitratesV2[bitrateIndex] * 1000
+		frame.length = 72*frame.bitrate/frame.sampleRate + padding
+	}
+	return frame, true
+}
+
+func (f mp3Frame) samples() int {
+	if f.mpeg1 {
+		return 1152
+	}
+	return 576
+}
+
+func probeMP3(r io.ReaderAt, size int64) (*Info, error) {
+	// Skip an ID3v2 tag
+	var start int64
+	if head, err := readAt(r, 0, 10); err == nil && string(head[:3]) == "ID3" {
+		tagSize := int64(head[6]&0x7F)<<21 | int64(head[7]&0x7F)<<14 | int64(head[8]&0x7F)<<7 | int64(head[9]&0x7F)
+		start = 10 + tagSize
+		if head[5]&0x10 != 0 {
+			start += 10 // footer
+		}
+	}
+
+	if start >= size {
+		return nil, errors.New("ID3 tag runs past the end of the file")
+	}
+
+	// Find the first frame, and a second one right after it to be sure
+	// the sync word was not part of something else
+	window, err := readAt(r, start, int(min(size-start, 64*1024)))
+	if err != nil {
+		return nil, err
+	}
+	var frame mp3Frame
+	offset := -1
+	for i := 0; i+4 <= len(window); i++ {
+		f, ok := parseMP3Frame(binary.BigEndian.Uint32(window[i:]))
+		if !ok {
+			continue
+		}
+		next := i + f.length
+		if next+4 <= len(window) && !isMP3Frame(binary.BigEndian.Uint32(window[next:])) {
+			continue
+		}
+		frame, offset = f, i
+		break
+	}
+	if offset < 0 {
+		return nil, errors.New("no MPEG audio frames")
+	}
+	audioStart := start + int64(offset)
+
+	// VBR files carry a frame count in a Xing/Info or VBRI header
+	if frames := mp3FrameCount(window[offset:], frame); frames > 0 {
+		return &Info{Duration: seconds(float64(frames) * float64(frame.samples()) / float64(frame.sampleRate))}, nil
+	}
+
+	// Otherwise assume a constant bitrate
+	audioBytes := size - audioStart
+	if tail, err := readAt(r, size-128, 3); err == nil && string(tail) == "TAG" {
+		audioBytes -= 128 // ID3v1 tag
+	}
+	return &Info{Duration: seconds(float64(audioBytes) * 8 / float64(frame.bitrate))}, nil
+}
+
+// mp3FrameCount reads the frame count from a VBR header in the first frame
+func mp3FrameCount(data []byte, frame mp3Frame) int {
+	// The Xing header follows the side information
+	sideInfo := 17
+	switch {
+	case frame.mpeg1 && !frame.mono:
+		sideInfo = 32
+	case !frame.mpeg1 && frame.mono:
+		sideInfo = 9
+	}
+	if x := 4 + sideInfo; len(data) >= x+12 {
+		tag := string(data[x : x+4])
+		flags := binary.BigEndian.Uint32(data[x+4:])
+		if (tag == "Xing" || tag == "Info") && flags&1 != 0 {
+			return int(binary.BigEndian.Uint32(data[x+8:]))
+		}
+	}
+
+	// Fraunhofer's VBRI header sits at a fixed offset
+	if len(data) >= 36+18 && string(data[36:40]) == "VBRI" {
+		return int(binary.BigEndian.Uint32(data[36+14:]))
+	}
+	return 0
+}
+
+// WAV
+
+func probeWAV(r io.ReaderAt, size int64) (*Info, error) {
+	header, err := readAt(r, 0, 12)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF WAVE file")
+	}
+
+	var byteRate uint32
+	for off := int64(12); off+8 <= size; {
+		chunk, err := readAt(r, off, 8)
+		if err != nil {
+			return nil, err
+		}
+		chunkSize := int64(binary.LittleEndian.Uint32(chunk[4:]))
+
+		switch string(chunk[:4]) {
+		case "fmt ":
+			format, err := readAt(r, off+8, 16)
+			if err != nil {
+				return nil, err
+			}
+			byteRate = binary.LittleEndian.Uint32(format[8:])
+		case "data":
+			if byteRate == 0 {
+				return nil, errors.New("data chunk before fmt chunk")
+			}
+			// Streamed files may leave the size unset or too large
+			if chunkSize > size-off-8 {
+				chunkSize = size - off - 8
+			}
+			return &Info{Duration: seconds(float64(chunkSize) / float64(byteRate))}, nil
+		}
+
+		off += 8 + chunkSize + chunkSize%2
+	}
+	return nil, errors.New("missing data chunk")
+}
+
+// Ogg (Vorbis and Opus)
+
+func probeOgg(r io.ReaderAt, size int64) (*Info, error) {
+	first, err := readAt(r, 0, int(min(size, 512)))
+	if err != nil {
+		return nil, err
+	}
+	if len(first) < 27 || string(first[:4]) != "OggS" || 27+int(first[26]) > len(first) {
+		return nil, errors.New("not an Ogg stream")
+	}
+	serial := binary.LittleEndian.Uint32(first[14:])
+	packet := first[27+int(first[26]):]
+
+	// The identification header gives the clock the granule positions use
+	var rate, preSkip uint64
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")) && len(packet) >= 16:
+		rate = uint64(binary.LittleEndian.Uint32(packet[12:]))
+	case bytes.HasPrefix(packet, []byte("OpusHead")) && len(packet) >= 12:
+		rate = 48000 // Opus granules always count 48 kHz samples
+		preSkip = uint64(binary.LittleEndian.Uint16(packet[10:]))
+	default:
+		return nil, errors.New("unsupported Ogg codec")
+	}
+	if rate == 0 {
+		return nil, errors.New("missing sample rate")
+	}
+
+	// The last page of the stream holds the total sample count
+	tailSize := min(size, 64*1024)
+	tail, err := readAt(r, size-tailSize, int(tailSize))
+	if err != nil {
+		return nil, err
+	}
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		if i+27 > len(tail) || binary.LittleEndian.Uint32(tail[i+14:]) != serial {
+			continue
+		}
+		granule := binary.LittleEndian.Uint64(tail[i+6:])
+		if granule == ^uint64(0) || granule < preSkip {
+			continue // no packet ends on this page
+		}
+		return &Info{Duration: seconds(float64(granule-preSkip) / float64(rate))}, nil
+	}
+	return nil, errors.New("missing final Ogg page")
+}
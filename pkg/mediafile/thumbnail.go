@@ -0,0 +1,103 @@
+package mediafile
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ErrNoThumbnail means a thumbnailer cannot render a still for a file;
+// the media is kept without one
+var ErrNoThumbnail = errors.New("no thumbnail available")
+
+// Thumbnailer renders a still for a file: a poster frame for video, the
+// first page for documents
+type Thumbnailer interface {
+	Thumbnail(ctx context.Context, r io.Reader, mimeType string) (image.Image, error)
+}
+
+// NopThumbnailer never renders anything; used when no tools are configured
+type NopThumbnailer struct{}
+
+func (NopThumbnailer) Thumbnail(ctx context.Context, r io.Reader, mimeType string) (image.Image, error) {
+	return nil, ErrNoThumbnail
+}
+
+// ExecThumbnailer renders stills with ffmpeg (video) and pdftoppm from
+// poppler (PDF). An empty path disables that kind.
+type ExecThumbnailer struct {
+	ffmpegPath   string
+	pdftoppmPath string
+	timeout      time.Duration
+}
+
+func NewExecThumbnailer(ffmpegPath, pdftoppmPath string, timeout time.Duration) *ExecThumbnailer {
+	return &ExecThumbnailer{
+		ffmpegPath:   ffmpegPath,
+		pdftoppmPath: pdftoppmPath,
+		timeout:      timeout,
+	}
+}
+
+func (t *ExecThumbnailer) Thumbnail(ctx context.Context, r io.Reader, mimeType string) (image.Image, error) {
+	var name string
+	var args []string
+
+	switch kind := KindOf(mimeType); {
+	case kind == KindVideo && t.ffmpegPath != "":
+		// The thumbnail filter picks a representative frame rather than
+		// the first one, which is often black
+		name = t.ffmpegPath
+		args = []string{"-v", "error", "-i", "{input}", "-vf", "thumbnail", "-frames:v", "1", "-f", "image2pipe", "-vcodec", "png", "-"}
+	case NormalizeType(mimeType) == "application/pdf" && t.pdftoppmPath != "":
+		name = t.pdftoppmPath
+		args = []string{"-png", "-f", "1", "-l", "1", "-singlefile", "-scale-to", "1600", "{input}", "-"}
+	default:
+		return nil, ErrNoThumbnail
+	}
+
+	// Both tools need to seek, so the input goes through a temp file
+	input, err := os.CreateTemp("", "thumbnail-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(input.Name())
+	defer input.Close()
+	if _, err := io.Copy(input, r); err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	for i, arg := range args {
+		if arg == "{input}" {
+			args[i] = input.Name()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.Len() == 0 {
+		return nil, ErrNoThumbnail
+	}
+
+	img, err := png.Decode(&stdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode thumbnail: %w", err)
+	}
+	return img, nil
+}
@@ -0,0 +1,133 @@
+// Package mediafile validates and inspects uploads that are not images:
+// video, audio and documents. Everything is read by content in pure Go;
+// only thumbnails may shell out to external tools.
+package mediafile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"mime"
+)
+
+// Kind is the broad class of a file. The values match entity.MediaType.
+type Kind string
+
+const (
+	KindVideo    Kind = "video"
+	KindAudio    Kind = "audio"
+	KindDocument Kind = "document"
+)
+
+// kinds lists every type Detect can return that is supported
+var kinds = map[string]Kind{
+	"video/mp4":       KindVideo,
+	"video/quicktime": KindVideo,
+	"video/webm":      KindVideo,
+	"audio/mpeg":      KindAudio,
+	"audio/mp4":       KindAudio,
+	"audio/wav":       KindAudio,
+	"audio/ogg":       KindAudio,
+	"application/pdf": KindDocument,
+}
+
+// extensionTypes maps accepted file extensions to the type they must contain
+var extensionTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".wav":  "audio/wav",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".opus": "audio/ogg",
+	".pdf":  "application/pdf",
+}
+
+// extensions is the extension files of each type are stored with
+var extensions = map[string]string{
+	"video/mp4":       ".mp4",
+	"video/quicktime": ".mov",
+	"video/webm":      ".webm",
+	"audio/mpeg":      ".mp3",
+	"audio/mp4":       ".m4a",
+	"audio/wav":       ".wav",
+	"audio/ogg":       ".ogg",
+	"application/pdf": ".pdf",
+}
+
+// aliases folds the non-standard names browsers and tools use
+var aliases = map[string]string{
+	"audio/mp3":         "audio/mpeg",
+	"audio/x-m4a":       "audio/mp4",
+	"audio/x-wav":       "audio/wav",
+	"audio/wave":        "audio/wav",
+	"audio/vnd.wave":    "audio/wav",
+	"audio/opus":        "audio/ogg",
+	"application/ogg":   "audio/ogg",
+	"application/x-pdf": "application/pdf",
+}
+
+// KindOf returns the kind of a supported type, or "" for anything else
+func KindOf(mimeType string) Kind {
+	return kinds[NormalizeType(mimeType)]
+}
+
+// Extension returns the usual file extension for a supported type
+func Extension(mimeType string) string {
+	return extensions[NormalizeType(mimeType)]
+}
+
+// NormalizeType drops parameters and folds aliases
+func NormalizeType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if alias, ok := aliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
+
+// Detect identifies a file from its first bytes. It returns "" when the
+// content is none of the containers this package understands.
+func Detect(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return "application/pdf"
+
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		switch string(head[8:12]) {
+		case "qt  ":
+			return "video/quicktime"
+		case "M4A ", "M4B ", "M4P ":
+			return "audio/mp4"
+		default:
+			return "video/mp4"
+		}
+
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		if bytes.Contains(head, []byte("webm")) {
+			return "video/webm"
+		}
+		return "video/x-matroska"
+
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return "audio/wav"
+
+	case bytes.HasPrefix(head, []byte("OggS")):
+		if bytes.Contains(head, []byte("\x80theora")) {
+			return "video/ogg"
+		}
+		return "audio/ogg"
+
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return "audio/mpeg"
+
+	case len(head) >= 4 && isMP3Frame(binary.BigEndian.Uint32(head)):
+		return "audio/mpeg"
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package mediafile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/afdhali/GolangBlogpostServer/pkg/scanner"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrFileTooLarge    = errors.New("file size too large")
+	ErrTypeMismatch    = errors.New("file type does not match its name or content type")
+	ErrCorruptFile     = errors.New("file could not be read")
+	ErrInfected        = errors.New("file rejected by virus scan")
+)
+
+// sniffLen is enough for every signature Detect looks for
+const sniffLen = 512
+
+// Rule is what is accepted for one kind
+type Rule struct {
+	MaxSize      int64 // in bytes
+	AllowedTypes []string
+}
+
+// File is an upload that passed validation
+type File struct {
+	Kind     Kind
+	MimeType string
+	Info     *Info
+}
+
+// Validator checks video, audio and document uploads by their content.
+// Kinds without a rule are rejected.
+type Validator struct {
+	rules   map[Kind]Rule
+	scanner scanner.Scanner
+}
+
+func NewValidator() *Validator {
+	return &Validator{
+		rules:   make(map[Kind]Rule),
+		scanner: scanner.NopScanner{},
+	}
+}
+
+// Allow accepts files of kind up to maxSizeMB in one of allowedTypes
+func (v *Validator) Allow(kind Kind, maxSizeMB int, allowedTypes []string) *Validator {
+	types := make([]string, 0, len(allowedTypes))
+	for _, t := range allowedTypes {
+		if t = NormalizeType(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	v.rules[kind] = Rule{MaxSize: int64(maxSizeMB) * 1024 * 1024, AllowedTypes: types}
+	return v
+}
+
+// WithScanner runs every file that passes the other checks through s
+func (v *Validator) WithScanner(s scanner.Scanner) *Validator {
+	v.scanner = s
+	return v
+}
+
+// Validate checks an upload the way image.Validator does: the type is
+// sniffed from the content, the name and declared type must agree with it,
+// and the container must parse. Rejections are *validator.FieldError.
+func (v *Validator) Validate(ctx context.Context, file multipart.File, header *multipart.FileHeader) (*File, error) {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	defer file.Seek(0, io.SeekStart)
+
+	head := make([]byte, sniffLen)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	contentType := Detect(head[:n])
+
+	kind := KindOf(contentType)
+	rule, ok := v.rules[kind]
+	if !ok || !rule.allows(contentType) {
+		if contentType == "" {
+			contentType = "unknown"
+		}
+		return nil, fieldError("file", "unsupported_type", fmt.Sprintf("file content is %s, allowed types are %s", contentType, v.allowedTypes()), ErrUnsupportedType)
+	}
+
+	if size > rule.MaxSize || header.Size > rule.MaxSize {
+		return nil, fieldError("file", "file_too_large", fmt.Sprintf("%s files must be at most %d MB", kind, rule.MaxSize/(1024*1024)), ErrFileTooLarge)
+	}
+
+	if ext := strings.ToLower(filepath.Ext(header.Filename)); ext != "" && extensionTypes[ext] != contentType {
+		return nil, fieldError("file", "type_mismatch", fmt.Sprintf("file extension %s does not match its content (%s)", ext, contentType), ErrTypeMismatch)
+	}
+	declared := NormalizeType(header.Header.Get("Content-Type"))
+	if declared != "" && declared != "application/octet-stream" && declared != contentType {
+		return nil, fieldError("file", "type_mismatch", fmt.Sprintf("declared content type %s does not match the file content (%s)", declared, contentType), ErrTypeMismatch)
+	}
+
+	info, err := Probe(io.NewSectionReader(file, 0, size), size, contentType)
+	if err != nil {
+		return nil, fieldError("file", "corrupt_file", fmt.Sprintf("file is not a readable %s", kind), ErrCorruptFile)
+	}
+
+	if err := v.scanner.Scan(ctx, io.NewSectionReader(file, 0, size)); err != nil {
+		if errors.Is(err, scanner.ErrInfected) {
+			return nil, fieldError("file", "infected", "file was rejected by the virus scanner", ErrInfected)
+		}
+		return nil, err
+	}
+
+	return &File{Kind: kind, MimeType: contentType, Info: info}, nil
+}
+
+// ValidateDeclared checks what a client says it is about to upload, before
+// any bytes arrive. The content itself still goes through Validate.
+func (v *Validator) ValidateDeclared(filename, contentType string, size int64) error {
+	contentType = NormalizeType(contentType)
+	kind := KindOf(contentType)
+	rule, ok := v.rules[kind]
+	if !ok || !rule.allows(contentType) {
+		return fieldError("content_type", "unsupported_type", fmt.Sprintf("allowed types are %s", v.allowedTypes()), ErrUnsupportedType)
+	}
+
+	if size > rule.MaxSize {
+		return fieldError("size", "file_too_large", fmt.Sprintf("%s files must be at most %d MB", kind, rule.MaxSize/(1024*1024)), ErrFileTooLarge)
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" || extensionTypes[ext] != contentType {
+		return fieldError("filename", "type_mismatch", fmt.Sprintf("file extension %q does not match %s", ext, contentType), ErrTypeMismatch)
+	}
+
+	return nil
+}
+
+func (r Rule) allows(contentType string) bool {
+	for _, allowed := range r.AllowedTypes {
+		if allowed == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Validator) allowedTypes() string {
+	var types []string
+	for _, rule := range v.rules {
+		types = append(types, rule.AllowedTypes...)
+	}
+	if len(types) == 0 {
+		return "none"
+	}
+	sort.Strings(types)
+	return strings.Join(types, ", ")
+}
+
+func fieldError(field, code, message string, err error) error {
+	return validator.NewFieldError(field, code, message, err)
+}
@@ -0,0 +1,146 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned when a cursor string cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Keyset marks a position in a listing ordered by (sort key, ID): the sort
+// key and ID of one row. Next cursors continue after that row; prev cursors
+// (Prev) go back to the rows before it.
+type Keyset struct {
+	Sort  string      // the order it was made for, e.g. "created_at desc"
+	Value interface{} // the row's sort key: time.Time, int64 or string
+	ID    uuid.UUID
+	Prev  bool
+}
+
+// keysetJSON keeps the sort key's type, so it can be compared to its
+// column again after decoding
+type keysetJSON struct {
+	Sort string     `json:"s"`
+	Time *time.Time `json:"t,omitempty"`
+	Int  *int64     `json:"n,omitempty"`
+	Str  *string    `json:"k,omitempty"`
+	ID   uuid.UUID  `json:"id"`
+	Prev bool       `json:"p,omitempty"`
+}
+
+// Signer turns keysets into opaque cursors and back. Cursors are signed,
+// so clients can't forge positions or inject sort keys.
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Encode returns the cursor for k
+func (s *Signer) Encode(k Keyset) string {
+	data := keysetJSON{Sort: k.Sort, ID: k.ID, Prev: k.Prev}
+	switch v := k.Value.(type) {
+	case time.Time:
+		data.Time = &v
+	case int64:
+		data.Int = &v
+	case int:
+		n := int64(v)
+		data.Int = &n
+	case string:
+		data.Str = &v
+	}
+
+	raw, _ := json.Marshal(data)
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + s.sign(payload)
+}
+
+// Decode verifies and parses a cursor made by Encode. An empty string
+// yields nil.
+func (s *Signer) Decode(value string) (*Keyset, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	payload, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return nil, ErrInvalidCursor
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var data keysetJSON
+	if err := json.Unmarshal(raw, &data); err != nil || data.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	k := &Keyset{Sort: data.Sort, ID: data.ID, Prev: data.Prev}
+	switch {
+	case data.Time != nil:
+		k.Value = *data.Time
+	case data.Int != nil:
+		k.Value = *data.Int
+	case data.Str != nil:
+		k.Value = *data.Str
+	default:
+		return nil, ErrInvalidCursor
+	}
+	return k, nil
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("cursor\n" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Trim cuts the rows of a keyset listing, fetched with one extra row, to
+// limit. It reports whether more rows exist past the page: after it, or
+// before it when paging back with a prev cursor.
+func Trim[T any](rows []T, limit int, prev bool) ([]T, bool) {
+	if len(rows) <= limit {
+		return rows, false
+	}
+	if prev {
+		// Rows are in display order, so the extra one comes first
+		return rows[len(rows)-limit:], true
+	}
+	return rows[:limit], true
+}
+
+// Cursors returns the next and prev cursors of a page, given the keysets
+// of its first and last rows, the cursor it was fetched with and whether
+// Trim found more rows. Empty strings mean there is nothing that way.
+func (s *Signer) Cursors(cursor *Keyset, first, last Keyset, more bool) (next, prev string) {
+	first.Prev, last.Prev = true, false
+
+	if cursor != nil && cursor.Prev {
+		// Paging back: the rows after this page are where the client came from
+		next = s.Encode(last)
+		if more {
+			prev = s.Encode(first)
+		}
+		return next, prev
+	}
+
+	if more {
+		next = s.Encode(last)
+	}
+	if cursor != nil {
+		prev = s.Encode(first)
+	}
+	return next, prev
+}
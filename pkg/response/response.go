@@ -75,17 +75,58 @@ func SuccessWithPagination(c *gin.Context, code int, page, limit int, total int6
 	})
 }
 
+type CursorResponse struct {
+	Limit      int         `json:"limit"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+	TotalData  *int64      `json:"total_data,omitempty"`
+	Data       interface{} `json:"data"`
+}
+
+// SuccessWithCursors answers a listing paged with keyset cursors. total is
+// nil when the client skipped counting.
+func SuccessWithCursors(c *gin.Context, code int, limit int, nextCursor, prevCursor string, total *int64, data interface{}) {
+	status := getStatusText(code)
+	c.JSON(code, Response{
+		Code:   code,
+		Status: status,
+		Data: CursorResponse{
+			Limit:      limit,
+			NextCursor: nextCursor,
+			PrevCursor: prevCursor,
+			HasMore:    nextCursor != "",
+			TotalData:  total,
+			Data:       data,
+		},
+	})
+}
+
+// Conflict answers a write made against an outdated version with the
+// resource as it is now
+func Conflict(c *gin.Context, code int, message string, current interface{}) {
+	c.JSON(code, Response{
+		Code:   code,
+		Status: getStatusText(code),
+		Data:   gin.H{"message": message, "current": current},
+	})
+}
+
 func getStatusText(code int) string {
 	statusMap := map[int]string{
 		200: "OK",
 		201: "CREATED",
+		202: "ACCEPTED",
 		400: "BAD_REQUEST",
 		401: "UNAUTHORIZED",
 		403: "FORBIDDEN",
 		404: "NOT_FOUND",
 		409: "CONFLICT",
+		412: "PRECONDITION_FAILED",
 		422: "UNPROCESSABLE_ENTITY",
+		428: "PRECONDITION_REQUIRED",
 		500: "INTERNAL_SERVER_ERROR",
+		503: "SERVICE_UNAVAILABLE",
 	}
 
 	if status, ok := statusMap[code]; ok {
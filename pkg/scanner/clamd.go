@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is how much is sent per INSTREAM chunk; clamd's default
+// StreamMaxLength is far larger than any single chunk
+const chunkSize = 64 * 1024
+
+// ClamdScanner streams files to a clamd daemon with the INSTREAM command
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner creates a scanner for addr, either "host:port" or
+// "unix:/path/to/clamd.sock"
+func NewClamdScanner(addr string, timeout time.Duration) *ClamdScanner {
+	network := "tcp"
+	if strings.HasPrefix(addr, "unix:") {
+		network, addr = "unix", strings.TrimPrefix(addr, "unix:")
+	}
+	return &ClamdScanner{
+		network: network,
+		address: addr,
+		timeout: timeout,
+	}
+}
+
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) error {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if err := s.stream(conn, r); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return parseReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+func (s *ClamdScanner) stream(w io.Writer, r io.Reader) error {
+	if _, err := io.WriteString(w, "zINSTREAM\x00"); err != nil {
+		return err
+	}
+
+	buf := make([]byte, chunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, werr := w.Write(size); werr != nil {
+				return werr
+			}
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// A zero-length chunk ends the stream
+	_, err := w.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// parseReply reads "stream: OK", "stream: <name> FOUND" or "<msg> ERROR"
+func parseReply(reply string) error {
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return fmt.Errorf("%w: %s", ErrInfected, strings.TrimSuffix(reply, " FOUND"))
+	default:
+		return fmt.Errorf("%w: %s", ErrUnavailable, strings.TrimSpace(reply))
+	}
+}
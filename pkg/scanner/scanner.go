@@ -0,0 +1,27 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrInfected is returned (wrapped with the signature name) when a file
+	// is flagged
+	ErrInfected = errors.New("file is infected")
+	// ErrUnavailable means the file could not be scanned at all
+	ErrUnavailable = errors.New("virus scanner unavailable")
+)
+
+// Scanner checks uploaded content before it is stored
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+// NopScanner accepts everything; used when no scanner is configured
+type NopScanner struct{}
+
+func (NopScanner) Scan(ctx context.Context, r io.Reader) error {
+	return nil
+}
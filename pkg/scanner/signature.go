@@ -0,0 +1,40 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+// EICAR is the standard anti-virus test file. Real scanners flag it, so it
+// can be used to check the whole upload path end to end.
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// SignatureScanner flags content containing any of a set of byte patterns.
+// It is a local stand-in for a real scanner in development and tests.
+type SignatureScanner struct {
+	signatures map[string][]byte
+}
+
+// NewSignatureScanner creates a scanner for the given name → pattern map;
+// nil means just the EICAR test signature
+func NewSignatureScanner(signatures map[string][]byte) *SignatureScanner {
+	if signatures == nil {
+		signatures = map[string][]byte{"Eicar-Test-Signature": []byte(EICAR)}
+	}
+	return &SignatureScanner{signatures: signatures}
+}
+
+func (s *SignatureScanner) Scan(ctx context.Context, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	for name, pattern := range s.signatures {
+		if bytes.Contains(data, pattern) {
+			return fmt.Errorf("%w: %s", ErrInfected, name)
+		}
+	}
+	return nil
+}
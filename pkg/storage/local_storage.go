@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
 	"os"
 	"path/filepath"
@@ -69,6 +71,63 @@ func (s *localStorage) Save(ctx context.Context, file multipart.File, header *mu
     }, nil
 }
 
+func (s *localStorage) Put(ctx context.Context, content io.Reader, path string, contentType string) (*FileInfo, error) {
+    fullPath, err := s.resolve(path)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+        return nil, fmt.Errorf("failed to create directory: %w", err)
+    }
+
+    // Write to a temp file first so readers never see a partial file
+    tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".put-*")
+    if err != nil {
+        return nil, fmt.Errorf("failed to create file: %w", err)
+    }
+    defer os.Remove(tmp.Name())
+
+    size, err := io.Copy(tmp, content)
+    if closeErr := tmp.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to save file: %w", err)
+    }
+
+    if err := os.Rename(tmp.Name(), fullPath); err != nil {
+        return nil, fmt.Errorf("failed to save file: %w", err)
+    }
+
+    relativePath := filepath.Clean(path)
+    return &FileInfo{
+        Filename:     filepath.Base(relativePath),
+        Path:         relativePath,
+        URL:          s.GetURL(relativePath),
+        Size:         size,
+        MimeType:     contentType,
+        OriginalName: filepath.Base(relativePath),
+    }, nil
+}
+
+func (s *localStorage) Open(ctx context.Context, path string) (io.ReadCloser, error) {
+    fullPath, err := s.resolve(path)
+    if err != nil {
+        return nil, err
+    }
+    return os.Open(fullPath)
+}
+
+// resolve maps a relative storage path to a file under basePath
+func (s *localStorage) resolve(path string) (string, error) {
+    cleaned := filepath.Clean(path)
+    if cleaned == "." || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+        return "", ErrInvalidPath
+    }
+    return filepath.Join(s.basePath, cleaned), nil
+}
+
 func (s *localStorage) Delete(ctx context.Context, path string) error {
     fullPath := filepath.Join(s.basePath, path)
     
@@ -92,4 +151,39 @@ func (s *localStorage) Exists(ctx context.Context, path string) bool {
     fullPath := filepath.Join(s.basePath, path)
     _, err := os.Stat(fullPath)
     return err == nil
-}
\ No newline at end of file
+}
+func (s *localStorage) Walk(ctx context.Context, dir string, fn func(ObjectInfo) error) error {
+    root := s.basePath
+    if dir != "" {
+        var err error
+        if root, err = s.resolve(dir); err != nil {
+            return err
+        }
+    }
+
+    err := filepath.WalkDir(root, func(fullPath string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        if d.IsDir() {
+            return nil
+        }
+
+        info, err := d.Info()
+        if err != nil {
+            return err
+        }
+        relativePath, err := filepath.Rel(s.basePath, fullPath)
+        if err != nil {
+            return err
+        }
+        return fn(ObjectInfo{Path: relativePath, Size: info.Size(), ModTime: info.ModTime()})
+    })
+    if errors.Is(err, fs.ErrNotExist) && !s.Exists(ctx, dir) {
+        return nil // nothing stored yet
+    }
+    return err
+}
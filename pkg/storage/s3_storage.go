@@ -0,0 +1,471 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// URL modes for S3Config.URLMode
+const (
+	S3URLPublic    = "public"    // plain object (or CDN) URLs; the bucket must allow reads
+	S3URLPresigned = "presigned" // time-limited signed GET URLs for private buckets
+)
+
+const (
+	// MinPartSize is the smallest part S3 accepts, except for the last one
+	MinPartSize = 5 * 1024 * 1024
+	// DefaultPartSize is used when S3Config.PartSize is not set
+	DefaultPartSize = 8 * 1024 * 1024
+)
+
+// S3Config configures an S3-compatible bucket (AWS, MinIO, R2, ...)
+type S3Config struct {
+	Endpoint     string // e.g. "https://s3.amazonaws.com" or "http://localhost:9000"
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	PathStyle    bool   // endpoint/bucket/key instead of bucket.endpoint/key (MinIO)
+	Prefix       string // key prefix inside the bucket
+	URLMode      string // S3URLPublic or S3URLPresigned
+	PublicURL    string // base for public URLs, e.g. a CDN; defaults to the bucket URL
+	URLExpiry    time.Duration
+	PartSize     int64 // multipart part size; files up to this size use a single PUT
+	HTTPClient   *http.Client
+}
+
+type s3Storage struct {
+	cfg      S3Config
+	endpoint *url.URL
+	signer   *Signer
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Storage creates a storage backed by an S3-compatible bucket
+func NewS3Storage(cfg S3Config) (Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 bucket is required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	switch cfg.URLMode {
+	case "":
+		cfg.URLMode = S3URLPublic
+	case S3URLPublic, S3URLPresigned:
+	default:
+		return nil, fmt.Errorf("unknown s3 url mode %q", cfg.URLMode)
+	}
+	if cfg.URLExpiry <= 0 {
+		cfg.URLExpiry = time.Hour
+	}
+	// SigV4 caps presigned URLs at 7 days
+	if cfg.URLExpiry > 7*24*time.Hour {
+		cfg.URLExpiry = 7 * 24 * time.Hour
+	}
+
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.PartSize < MinPartSize {
+		return nil, fmt.Errorf("s3 part size must be at least %d bytes", MinPartSize)
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+
+	return &s3Storage{
+		cfg:      cfg,
+		endpoint: endpoint,
+		signer:   NewSigner(cfg.AccessKey, cfg.SecretKey, cfg.SessionToken, cfg.Region),
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+func (s *s3Storage) Save(ctx context.Context, file multipart.File, header *multipart.FileHeader, dir string) (*FileInfo, error) {
+	// Same naming scheme as local storage
+	ext := filepath.Ext(header.Filename)
+	filename := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
+
+	info, err := s.Put(ctx, file, path.Join(dir, filename), header.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	info.OriginalName = header.Filename
+	return info, nil
+}
+
+// Put uploads content with a single PUT, or as a multipart upload once it
+// is larger than one part
+func (s *s3Storage) Put(ctx context.Context, content io.Reader, p string, contentType string) (*FileInfo, error) {
+	key, err := cleanKey(p)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := readPart(content, s.cfg.PartSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var size int64
+	if int64(len(first)) < s.cfg.PartSize {
+		if err := s.putObject(ctx, key, first, contentType); err != nil {
+			return nil, fmt.Errorf("failed to save file: %w", err)
+		}
+		size = int64(len(first))
+	} else {
+		size, err = s.putMultipart(ctx, key, first, content, contentType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save file: %w", err)
+		}
+	}
+
+	return &FileInfo{
+		Filename:     path.Base(key),
+		Path:         key,
+		URL:          s.GetURL(key),
+		Size:         size,
+		MimeType:     contentType,
+		OriginalName: path.Base(key),
+	}, nil
+}
+
+func (s *s3Storage) putObject(ctx context.Context, key string, data []byte, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, nil, header, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// putMultipart streams the rest of content in PartSize chunks, so memory
+// use stays at one part no matter how large the file is
+func (s *s3Storage) putMultipart(ctx context.Context, key string, first []byte, content io.Reader, contentType string) (int64, error) {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return 0, err
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+	if err != nil || initiated.UploadID == "" {
+		return 0, fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+
+	uploadID := initiated.UploadID
+	abort := func() {
+		// Best-effort; a bucket lifecycle rule cleans up what is missed
+		if resp, err := s.do(context.Background(), http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil); err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	var parts []completedPart
+	var size int64
+	part := first
+	for number := 1; len(part) > 0; number++ {
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, err := s.do(ctx, http.MethodPut, key, query, nil, part)
+		if err != nil {
+			abort()
+			return 0, fmt.Errorf("failed to upload part %d: %w", number, err)
+		}
+		resp.Body.Close()
+
+		parts = append(parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+		size += int64(len(part))
+
+		if part, err = readPart(content, s.cfg.PartSize); err != nil {
+			abort()
+			return 0, err
+		}
+	}
+
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		abort()
+		return 0, err
+	}
+	resp, err = s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, body)
+	if err != nil {
+		abort()
+		return 0, fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// S3 can report a failed completion with a 200 and an error body
+	var result struct {
+		XMLName xml.Name
+		Message string
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err == nil && result.XMLName.Local == "Error" {
+		abort()
+		return 0, fmt.Errorf("failed to complete multipart upload: %s", result.Message)
+	}
+
+	return size, nil
+}
+
+func (s *s3Storage) Open(ctx context.Context, p string) (io.ReadCloser, error) {
+	key, err := cleanKey(p)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, p string) error {
+	key, err := cleanKey(p)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // File already deleted, no error
+		}
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// GetURL returns the public object URL, or a fresh presigned GET URL in
+// presigned mode
+func (s *s3Storage) GetURL(p string) string {
+	key := s.objectKey(strings.TrimPrefix(filepath.ToSlash(p), "/"))
+
+	if s.cfg.URLMode == S3URLPresigned {
+		return s.signer.Presign(http.MethodGet, s.objectURL(key, nil), s.cfg.URLExpiry, s.now())
+	}
+	if s.cfg.PublicURL != "" {
+		return strings.TrimSuffix(s.cfg.PublicURL, "/") + "/" + uriEncode(key, false)
+	}
+	return s.objectURL(key, nil).String()
+}
+
+// PresignPut lets a client PUT a file straight into the bucket
+func (s *s3Storage) PresignPut(p string, contentType string, expires time.Duration) (*UploadTarget, error) {
+	key, err := cleanKey(p)
+	if err != nil {
+		return nil, err
+	}
+
+	target := &UploadTarget{
+		Method:  http.MethodPut,
+		URL:     s.signer.Presign(http.MethodPut, s.objectURL(s.objectKey(key), nil), expires, s.now()),
+		Headers: map[string]string{},
+	}
+	if contentType != "" {
+		target.Headers["Content-Type"] = contentType
+	}
+	return target, nil
+}
+
+func (s *s3Storage) Exists(ctx context.Context, p string) bool {
+	key, err := cleanKey(p)
+	if err != nil {
+		return false
+	}
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+// listBucketResult is a ListObjectsV2 reply
+type listBucketResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+}
+
+// Walk lists the bucket page by page with ListObjectsV2
+func (s *s3Storage) Walk(ctx context.Context, dir string, fn func(ObjectInfo) error) error {
+	base := s.objectKey("")
+	prefix := base
+	if dir != "" {
+		key, err := cleanKey(dir)
+		if err != nil {
+			return err
+		}
+		prefix = s.objectKey(key) + "/"
+	}
+
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.send(ctx, http.MethodGet, s.objectURL("", query), prefix, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+
+		for _, obj := range page.Contents {
+			if strings.HasSuffix(obj.Key, "/") {
+				continue // folder placeholder
+			}
+			info := ObjectInfo{Path: strings.TrimPrefix(obj.Key, base), Size: obj.Size, ModTime: obj.LastModified}
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// do sends a signed request for key and turns non-2xx replies into errors;
+// 404 wraps fs.ErrNotExist like a missing local file
+func (s *s3Storage) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	return s.send(ctx, method, s.objectURL(s.objectKey(key), query), key, header, body)
+}
+
+// send is do for an arbitrary URL; name identifies the request in errors
+func (s *s3Storage) send(ctx context.Context, method string, u *url.URL, key string, header http.Header, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.signer.Sign(req, PayloadHash(body), s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("s3 %s %s: %w", method, key, fs.ErrNotExist)
+	}
+
+	var s3Err struct {
+		Code    string
+		Message string
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if xml.Unmarshal(data, &s3Err) == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", method, key, s3Err.Code, s3Err.Message)
+	}
+	return nil, fmt.Errorf("s3 %s %s: unexpected status %d", method, key, resp.StatusCode)
+}
+
+func (s *s3Storage) objectKey(key string) string {
+	if s.cfg.Prefix == "" {
+		return key
+	}
+	return strings.Trim(s.cfg.Prefix, "/") + "/" + key
+}
+
+// objectURL addresses key path-style (endpoint/bucket/key) or
+// virtual-hosted-style (bucket.endpoint/key)
+func (s *s3Storage) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	if s.cfg.PathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Bucket + "/" + key
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+	u.RawPath = ""
+	u.RawQuery = ""
+	if len(query) > 0 {
+		u.RawQuery = canonicalQuery(query)
+	}
+	return &u
+}
+
+// cleanKey validates a relative storage path and turns it into an object key
+func cleanKey(p string) (string, error) {
+	cleaned := path.Clean(filepath.ToSlash(p))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "/") || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidPath
+	}
+	return cleaned, nil
+}
+
+// readPart reads up to size bytes, returning fewer only at the end
+func readPart(r io.Reader, size int64) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(r, size))
+}
@@ -2,9 +2,15 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"io"
 	"mime/multipart"
+	"time"
 )
 
+// ErrInvalidPath is returned for paths that escape the storage root
+var ErrInvalidPath = errors.New("invalid storage path")
+
 // FileInfo represents uploaded file information
 type FileInfo struct {
     Filename     string
@@ -19,6 +25,12 @@ type FileInfo struct {
 type Storage interface {
     // Save file and return file info
     Save(ctx context.Context, file multipart.File, header *multipart.FileHeader, dir string) (*FileInfo, error)
+
+    // Put writes content to an exact relative path, replacing any existing file
+    Put(ctx context.Context, content io.Reader, path string, contentType string) (*FileInfo, error)
+
+    // Open returns the content of a stored file
+    Open(ctx context.Context, path string) (io.ReadCloser, error)
     
     // Delete file by path
     Delete(ctx context.Context, path string) error
@@ -28,4 +40,28 @@ type Storage interface {
     
     // Check if file exists
     Exists(ctx context.Context, path string) bool
-}
\ No newline at end of file
+
+    // Walk calls fn for every file under dir ("" for all files), in no
+    // particular order. An error from fn stops the walk and is returned.
+    Walk(ctx context.Context, dir string, fn func(ObjectInfo) error) error
+}
+
+// ObjectInfo describes a stored file found by Walk
+type ObjectInfo struct {
+    Path    string // relative, as used by the other methods
+    Size    int64
+    ModTime time.Time
+}
+
+// UploadTarget is the request a client makes to upload a file directly
+type UploadTarget struct {
+    Method  string
+    URL     string
+    Headers map[string]string
+}
+
+// DirectUploader is implemented by drivers that clients can upload to
+// without the file passing through the API
+type DirectUploader interface {
+    PresignPut(path string, contentType string, expires time.Duration) (*UploadTarget, error)
+}
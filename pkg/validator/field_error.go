@@ -0,0 +1,36 @@
+package validator
+
+// FieldError is a rejected input that is reported back to the client as
+// {field, code, message}. Err is the underlying sentinel, for errors.Is.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+	Err     error
+}
+
+func NewFieldError(field, code, message string, err error) *FieldError {
+	return &FieldError{
+		Field:   field,
+		Code:    code,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Details returns the error in the same shape as CustomValidator.GetErrors
+func (e *FieldError) Details() []map[string]string {
+	return []map[string]string{{
+		"field":   e.Field,
+		"code":    e.Code,
+		"message": e.Message,
+	}}
+}
@@ -0,0 +1,190 @@
+package viewcounter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// ViewEvent describes one counted view. Events with identical fields are
+// aggregated in memory, so Day is truncated to the UTC date.
+type ViewEvent struct {
+	PostID        uuid.UUID
+	Day           time.Time
+	ReferrerHost  string
+	Country       string
+	Authenticated bool
+}
+
+// FlushFunc persists aggregated views (event -> number of views)
+type FlushFunc func(ctx context.Context, views map[ViewEvent]int64) error
+
+// Counter aggregates post views in memory and flushes them periodically.
+// Views from the same visitor on the same post are counted once per window.
+type Counter struct {
+	flush    FlushFunc
+	interval time.Duration
+	window   time.Duration
+	logger   *logger.Logger
+
+	mu      sync.Mutex
+	pending map[ViewEvent]int64
+	seen    map[string]time.Time
+
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewCounter(flush FlushFunc, interval, window time.Duration, log *logger.Logger) *Counter {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	if window <= 0 {
+		window = 30 * time.Minute
+	}
+
+	return &Counter{
+		flush:    flush,
+		interval: interval,
+		window:   window,
+		logger:   log,
+		pending:  make(map[ViewEvent]int64),
+		seen:     make(map[string]time.Time),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+// Start runs the background flush loop
+func (c *Counter) Start() {
+	go c.loop()
+}
+
+// Record registers a view and returns false if it was deduplicated
+func (c *Counter) Record(event ViewEvent, visitorKey string) bool {
+	now := time.Now()
+	key := event.PostID.String() + "|" + visitorKey
+
+	if event.Day.IsZero() {
+		event.Day = now
+	}
+	event.Day = Day(event.Day)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if seenAt, ok := c.seen[key]; ok && now.Sub(seenAt) < c.window {
+		return false
+	}
+
+	c.seen[key] = now
+	c.pending[event]++
+	return true
+}
+
+// Pending returns the number of buffered (not yet flushed) views for a post
+func (c *Counter) Pending(postID uuid.UUID) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total int64
+	for event, n := range c.pending {
+		if event.PostID == postID {
+			total += n
+		}
+	}
+	return total
+}
+
+// Day truncates t to its UTC calendar date
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// CountsByPost sums views per post, ignoring the other event dimensions
+func CountsByPost(views map[ViewEvent]int64) map[uuid.UUID]int64 {
+	counts := make(map[uuid.UUID]int64)
+	for event, n := range views {
+		counts[event.PostID] += n
+	}
+	return counts
+}
+
+// Flush writes all buffered counts. On failure the counts are put back
+// so they are retried on the next flush.
+func (c *Counter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return nil
+	}
+	batch := c.pending
+	c.pending = make(map[ViewEvent]int64)
+	c.mu.Unlock()
+
+	if err := c.flush(ctx, batch); err != nil {
+		c.mu.Lock()
+		for event, n := range batch {
+			c.pending[event] += n
+		}
+		c.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Stop stops the flush loop and flushes whatever is still pending
+func (c *Counter) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+
+	select {
+	case <-c.doneCh:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return c.Flush(ctx)
+}
+
+func (c *Counter) loop() {
+	defer close(c.doneCh)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), c.interval)
+			if err := c.Flush(ctx); err != nil && c.logger != nil {
+				c.logger.Error("Failed to flush view counts: %v", err)
+			}
+			cancel()
+			c.pruneSeen()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// pruneSeen drops dedup entries that are older than the window
+func (c *Counter) pruneSeen() {
+	cutoff := time.Now().Add(-c.window)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, seenAt := range c.seen {
+		if seenAt.Before(cutoff) {
+			delete(c.seen, key)
+		}
+	}
+}
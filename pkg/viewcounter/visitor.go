@@ -0,0 +1,89 @@
+package viewcounter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+// botSignatures are lowercase user-agent fragments of crawlers, link
+// previewers and scripted clients that should never count as a view
+var botSignatures = []string{
+	"bot", "crawl", "spider", "slurp", "scrape", "fetch",
+	"curl", "wget", "httpie", "python-requests", "python-urllib", "go-http-client",
+	"java/", "okhttp", "axios", "node-fetch", "libwww", "headless",
+	"lighthouse", "pingdom", "uptime", "monitor", "preview",
+	"facebookexternalhit", "embedly", "whatsapp", "telegram", "discord", "slack",
+}
+
+// IsBot reports whether the user agent looks automated. Empty user
+// agents are treated as bots.
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+
+	for _, signature := range botSignatures {
+		if strings.Contains(ua, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// VisitorKey builds a stable dedup key: the user ID when logged in,
+// otherwise a hash of IP + user agent. Nothing the client can vary per
+// request goes into it, so repeated views can't be made to count.
+func VisitorKey(userID, clientIP, userAgent string) string {
+	if userID != "" {
+		return "user:" + userID
+	}
+
+	sum := sha256.Sum256([]byte(clientIP + "|" + userAgent))
+	return "anon:" + hex.EncodeToString(sum[:16])
+}
+
+// ReferrerHost extracts the normalized host of a referrer URL. Empty or
+// unparsable referrers, and referrers from ownHost, are reported as "".
+func ReferrerHost(referrer, ownHost string) string {
+	referrer = strings.TrimSpace(referrer)
+	if referrer == "" {
+		return ""
+	}
+
+	u, err := url.Parse(referrer)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	own := strings.TrimPrefix(strings.ToLower(ownHost), "www.")
+	if i := strings.IndexByte(own, ':'); i != -1 {
+		own = own[:i]
+	}
+	if host == own {
+		return ""
+	}
+
+	if len(host) > 255 {
+		host = host[:255]
+	}
+	return host
+}
+
+// CountryCode normalizes a two-letter country header value (e.g. from
+// CF-IPCountry). Unknown or malformed values are reported as "".
+func CountryCode(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) != 2 || value == "XX" || value == "T1" {
+		return ""
+	}
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return ""
+		}
+	}
+	return value
+}
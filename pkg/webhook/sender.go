@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxResponseBody is how much of the receiver's reply is kept for the log
+const maxResponseBody = 2048
+
+// Request is a single signed delivery attempt
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID string
+	Body       []byte
+}
+
+// Result is what the delivery log records about an attempt
+type Result struct {
+	StatusCode int
+	Body       string
+	Err        error
+	Duration   time.Duration
+}
+
+// OK reports whether the receiver accepted the delivery (any 2xx)
+func (r Result) OK() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+type Sender struct {
+	client    *http.Client
+	userAgent string
+}
+
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			// Don't follow redirects: a 3xx is treated as a failed delivery
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		userAgent: "GolangBlogpostServer-Webhooks/1.0",
+	}
+}
+
+// Send POSTs the signed body and never returns a Go error; failures are in Result
+func (s *Sender) Send(ctx context.Context, req Request) Result {
+	start := time.Now()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return Result{Err: err, Duration: time.Since(start)}
+	}
+
+	timestamp := time.Now().Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", s.userAgent)
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return Result{Err: err, Duration: time.Since(start)}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// Drain the rest so the connection can be reused
+	io.Copy(io.Discard, resp.Body)
+
+	return Result{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		Duration:   time.Since(start),
+	}
+}
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpiredTimestamp = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign returns the X-Webhook-Signature value for body sent at timestamp.
+// The MAC covers "<timestamp>.<body>" so a captured request can't be
+// replayed later with a fresh timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a received signature. Receivers should call it with the raw
+// request body and the X-Webhook-Timestamp / X-Webhook-Signature headers.
+func Verify(secret, timestampHeader, signatureHeader string, body []byte, tolerance time.Duration) error {
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(timestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrExpiredTimestamp
+		}
+	}
+
+	if !strings.HasPrefix(signatureHeader, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signatureHeader)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
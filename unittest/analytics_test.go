@@ -0,0 +1,194 @@
+package unittest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/handler"
+	"github.com/afdhali/GolangBlogpostServer/internal/middleware"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/afdhali/GolangBlogpostServer/pkg/viewcounter"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAnalyticsRepository answers reports from fixed rows and remembers
+// the range and limit it was asked for
+type memoryAnalyticsRepository struct {
+	repository.AnalyticsRepository
+	daily    []repository.DailyViewCount
+	from, to time.Time
+	limit    int
+}
+
+func (r *memoryAnalyticsRepository) DailyViews(ctx context.Context, postID uuid.UUID, from, to time.Time) ([]repository.DailyViewCount, error) {
+	r.from, r.to = from, to
+	return r.daily, nil
+}
+
+func (r *memoryAnalyticsRepository) ReferrerViews(ctx context.Context, postID uuid.UUID, from, to time.Time, limit int) ([]repository.ReferrerViewCount, error) {
+	r.from, r.to, r.limit = from, to, limit
+	return nil, nil
+}
+
+func (r *memoryAnalyticsRepository) TopPosts(ctx context.Context, from, to time.Time, limit int) ([]repository.ViewTotal, error) {
+	r.from, r.to, r.limit = from, to, limit
+	return []repository.ViewTotal{{ID: uuid.New(), Name: "Post", Slug: "post", Views: 7}}, nil
+}
+
+func newAnalyticsFixture() (service.AnalyticsService, *memoryAnalyticsRepository, *memoryPostRepository) {
+	analytics := &memoryAnalyticsRepository{}
+	posts := &memoryPostRepository{posts: make(map[uuid.UUID]*entity.Post)}
+	return service.NewAnalyticsService(memoryTxManager{}, analytics, posts, validator.NewValidator()), analytics, posts
+}
+
+func TestAnalyticsService_DateRange(t *testing.T) {
+	analytics, repo, posts := newAnalyticsFixture()
+	ctx := context.Background()
+	author := slotUser()
+	post := posts.add(author.ID)
+
+	// The last 30 days, today included
+	resp, err := analytics.GetPostDailyViews(ctx, post.ID, &dto.AnalyticsQueryParams{}, author)
+	require.NoError(t, err)
+	today := viewcounter.Day(time.Now())
+	require.Equal(t, today, repo.to)
+	require.Equal(t, today.AddDate(0, 0, -29), repo.from)
+	require.Len(t, resp.Days, 30)
+
+	// Days without views are filled in
+	repo.daily = []repository.DailyViewCount{{Day: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC), Views: 5, AuthenticatedViews: 2}}
+	resp, err = analytics.GetPostDailyViews(ctx, post.ID, &dto.AnalyticsQueryParams{From: "2024-06-01", To: "2024-06-03"}, author)
+	require.NoError(t, err)
+	require.Equal(t, "2024-06-01", resp.From)
+	require.Equal(t, "2024-06-03", resp.To)
+	require.Len(t, resp.Days, 3)
+	require.Zero(t, resp.Days[0].Views)
+	require.Equal(t, int64(5), resp.Days[1].Views)
+	require.Equal(t, int64(2), resp.Days[1].AuthenticatedViews)
+	require.Equal(t, int64(5), resp.TotalViews)
+
+	// A range ending before the default start still covers 30 days
+	_, err = analytics.GetPostDailyViews(ctx, post.ID, &dto.AnalyticsQueryParams{To: "2024-06-30"}, author)
+	require.NoError(t, err)
+	require.Equal(t, "2024-06-01", repo.from.Format("2006-01-02"))
+
+	invalid := map[string]*dto.AnalyticsQueryParams{
+		"invalid date range":   {From: "2024-06-03", To: "2024-06-01"},
+		"date range too large": {From: "2023-01-01", To: "2024-06-01"},
+	}
+	for message, params := range invalid {
+		_, err = analytics.GetPostDailyViews(ctx, post.ID, params, author)
+		require.EqualError(t, err, message)
+	}
+	_, err = analytics.GetPostDailyViews(ctx, post.ID, &dto.AnalyticsQueryParams{From: "06/01/2024"}, author)
+	require.ErrorContains(t, err, "validation error")
+}
+
+func TestAnalyticsService_Limit(t *testing.T) {
+	analytics, repo, _ := newAnalyticsFixture()
+	ctx := context.Background()
+
+	resp, err := analytics.GetTopPosts(ctx, &dto.AnalyticsQueryParams{})
+	require.NoError(t, err)
+	require.Equal(t, 10, repo.limit)
+	require.Len(t, resp.Items, 1)
+
+	_, err = analytics.GetTopPosts(ctx, &dto.AnalyticsQueryParams{Limit: 100})
+	require.NoError(t, err)
+	require.Equal(t, 100, repo.limit)
+
+	// Larger limits are refused before any query runs
+	repo.limit = 0
+	_, err = analytics.GetTopPosts(ctx, &dto.AnalyticsQueryParams{Limit: 101})
+	require.ErrorContains(t, err, "validation error")
+	require.Zero(t, repo.limit)
+}
+
+func TestAnalyticsService_PostReportsForAuthorOrAdmin(t *testing.T) {
+	analytics, _, posts := newAnalyticsFixture()
+	ctx := context.Background()
+	author := slotUser()
+	post := posts.add(author.ID)
+
+	admin := slotUser()
+	admin.Role = entity.RoleAdmin
+	for _, user := range []*entity.User{author, admin} {
+		_, err := analytics.GetPostDailyViews(ctx, post.ID, &dto.AnalyticsQueryParams{}, user)
+		require.NoError(t, err)
+		_, err = analytics.GetPostReferrers(ctx, post.ID, &dto.AnalyticsQueryParams{}, user)
+		require.NoError(t, err)
+	}
+
+	stranger := slotUser()
+	stranger.Role = entity.RoleUser
+	_, err := analytics.GetPostDailyViews(ctx, post.ID, &dto.AnalyticsQueryParams{}, stranger)
+	require.EqualError(t, err, "you don't have permission to view analytics for this post")
+	_, err = analytics.GetPostReferrers(ctx, post.ID, &dto.AnalyticsQueryParams{}, stranger)
+	require.EqualError(t, err, "you don't have permission to view analytics for this post")
+
+	_, err = analytics.GetPostDailyViews(ctx, uuid.New(), &dto.AnalyticsQueryParams{}, admin)
+	require.EqualError(t, err, "post not found")
+}
+
+func TestAnalyticsHandler_TopReportsAdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	analytics, _, posts := newAnalyticsFixture()
+	h := handler.NewAnalyticsHandler(analytics)
+
+	var viewer *entity.User
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user", viewer) })
+	// As mounted under /admin
+	router.GET("/admin/analytics/top-posts", middleware.RequireAdmin(), h.GetTopPosts)
+	router.GET("/posts/:id/analytics/daily", h.GetPostDailyViews)
+
+	// A post's author may see its own report, but not the site-wide ones
+	viewer = slotUser()
+	viewer.Role = entity.RoleUser
+	post := posts.add(viewer.ID)
+	require.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/posts/"+post.ID.String()+"/analytics/daily", nil).Code)
+	require.Equal(t, http.StatusForbidden, serve(router, http.MethodGet, "/admin/analytics/top-posts", nil).Code)
+
+	viewer = slotUser()
+	viewer.Role = entity.RoleAdmin
+	require.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/admin/analytics/top-posts?limit=5", nil).Code)
+	require.Equal(t, http.StatusOK, serve(router, http.MethodGet, "/posts/"+post.ID.String()+"/analytics/daily", nil).Code)
+}
+
+func TestAnalyticsService_RecordViewsIsOneUnit(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	analytics := service.NewAnalyticsService(
+		repository.NewTxManager(gormDB),
+		repository.NewAnalyticsRepository(gormDB),
+		repository.NewPostRepository(gormDB),
+		validator.NewValidator(),
+	)
+	postID := uuid.New()
+	views := map[viewcounter.ViewEvent]int64{{PostID: postID, Day: time.Now()}: 3}
+
+	// The view count is rolled back with the failed rollup, so the
+	// counter's retry doesn't count the batch twice
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "posts" SET "view_count"=view_count + $1`)).
+		WithArgs(int64(3), postID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "post_view_stats"`)).
+		WillReturnError(errors.New("connection reset"))
+	sqlMock.ExpectRollback()
+
+	err := analytics.RecordViews(context.Background(), views)
+	require.ErrorContains(t, err, "failed to record view stats")
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
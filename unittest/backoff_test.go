@@ -0,0 +1,19 @@
+package unittest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/pkg/backoff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffExponential(t *testing.T) {
+	base := 30 * time.Second
+	max := 10 * time.Minute
+
+	require.Equal(t, 30*time.Second, backoff.Exponential(1, base, max))
+	require.Equal(t, 60*time.Second, backoff.Exponential(2, base, max))
+	require.Equal(t, 120*time.Second, backoff.Exponential(3, base, max))
+	require.Equal(t, max, backoff.Exponential(10, base, max))
+}
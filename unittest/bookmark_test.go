@@ -0,0 +1,264 @@
+package unittest
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCommentCounts answers comment counts with zero
+type memoryCommentCounts struct {
+	repository.CommentRepository
+}
+
+func (memoryCommentCounts) CountByPostID(ctx context.Context, postID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (memoryCommentCounts) CountByPostIDs(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	return make(map[uuid.UUID]int64), nil
+}
+
+// memoryReadingListRepository keeps reading lists and their items in memory
+type memoryReadingListRepository struct {
+	repository.ReadingListRepository
+	mu    sync.Mutex
+	lists map[uuid.UUID]*entity.ReadingList
+	posts *memoryPostRepository
+}
+
+func (r *memoryReadingListRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.ReadingList, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list, ok := r.lists[id]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	copied := *list
+	copied.Items = append([]entity.ReadingListItem(nil), list.Items...)
+	sort.SliceStable(copied.Items, func(i, j int) bool { return copied.Items[i].Position < copied.Items[j].Position })
+	for i := range copied.Items {
+		post := r.posts.get(copied.Items[i].PostID)
+		copied.Items[i].Post = &post
+	}
+	return &copied, nil
+}
+
+func (r *memoryReadingListRepository) AddItem(ctx context.Context, item *entity.ReadingListItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := r.lists[item.ReadingListID]
+	list.Items = append(list.Items, *item)
+	return nil
+}
+
+func (r *memoryReadingListRepository) FindItem(ctx context.Context, listID, postID uuid.UUID) (*entity.ReadingListItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, item := range r.lists[listID].Items {
+		if item.PostID == postID {
+			copied := item
+			return &copied, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+func (r *memoryReadingListRepository) MaxPosition(ctx context.Context, listID uuid.UUID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	max := -1
+	for _, item := range r.lists[listID].Items {
+		if item.Position > max {
+			max = item.Position
+		}
+	}
+	return max, nil
+}
+
+func (r *memoryReadingListRepository) ReorderItems(ctx context.Context, listID uuid.UUID, postIDs []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items := r.lists[listID].Items
+	for position, postID := range postIDs {
+		for i := range items {
+			if items[i].PostID == postID {
+				items[i].Position = position
+			}
+		}
+	}
+	return nil
+}
+
+type readingListFixture struct {
+	service service.ReadingListService
+	posts   *memoryPostRepository
+	list    *entity.ReadingList
+	owner   *entity.User
+}
+
+func newReadingListFixture(t *testing.T) *readingListFixture {
+	posts := &memoryPostRepository{posts: make(map[uuid.UUID]*entity.Post)}
+	owner := &entity.User{Role: entity.RoleUser}
+	owner.ID = uuid.New()
+
+	list := &entity.ReadingList{UserID: owner.ID, Name: "Later"}
+	list.ID = uuid.New()
+	lists := &memoryReadingListRepository{
+		lists: map[uuid.UUID]*entity.ReadingList{list.ID: list},
+		posts: posts,
+	}
+
+	return &readingListFixture{
+		service: service.NewReadingListService(lists, posts, memoryCommentCounts{}, validator.NewValidator()),
+		posts:   posts,
+		list:    list,
+		owner:   owner,
+	}
+}
+
+// publish adds a published post by someone other than the list's owner
+func (f *readingListFixture) publish() *entity.Post {
+	post := f.posts.add(uuid.New())
+	post.Publish()
+	return post
+}
+
+func itemPostIDs(resp *dto.ReadingListResponse) []uuid.UUID {
+	ids := make([]uuid.UUID, len(resp.Items))
+	for i, item := range resp.Items {
+		ids[i] = item.PostID
+	}
+	return ids
+}
+
+func TestBookmarkService_RebookmarkReturnsExistingBookmark(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	posts := &memoryPostRepository{posts: make(map[uuid.UUID]*entity.Post)}
+	bookmarks := service.NewBookmarkService(repository.NewBookmarkRepository(gormDB), posts, memoryCommentCounts{}, validator.NewValidator())
+
+	user := &entity.User{Role: entity.RoleUser}
+	user.ID = uuid.New()
+	post := posts.add(uuid.New())
+	post.Publish()
+
+	// The insert hits the (user, post) unique index and the first bookmark is read back
+	bookmarkedAt := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "bookmarks"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	sqlMock.ExpectCommit()
+	sqlMock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "bookmarks" WHERE (user_id = $1 AND post_id = $2) AND "bookmarks"."deleted_at" IS NULL ORDER BY "bookmarks"."id" LIMIT $3`)).
+		WithArgs(user.ID, post.ID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "post_id", "created_at"}).
+			AddRow(uuid.New(), user.ID, post.ID, bookmarkedAt))
+
+	resp, err := bookmarks.Add(context.Background(), &dto.CreateBookmarkRequest{PostID: post.ID}, user)
+	require.NoError(t, err)
+	require.Equal(t, post.ID, resp.PostID)
+	require.True(t, bookmarkedAt.Equal(resp.BookmarkedAt))
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestBookmarkService_RefusesHiddenPosts(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	posts := &memoryPostRepository{posts: make(map[uuid.UUID]*entity.Post)}
+	bookmarks := service.NewBookmarkService(repository.NewBookmarkRepository(gormDB), posts, memoryCommentCounts{}, validator.NewValidator())
+
+	user := &entity.User{Role: entity.RoleUser}
+	user.ID = uuid.New()
+	draft := posts.add(uuid.New())
+	draft.Status = entity.PostStatusDraft
+
+	// Someone else's draft looks like a missing post, and nothing is written
+	_, err := bookmarks.Add(context.Background(), &dto.CreateBookmarkRequest{PostID: draft.ID}, user)
+	require.EqualError(t, err, "post not found")
+	_, err = bookmarks.Add(context.Background(), &dto.CreateBookmarkRequest{PostID: uuid.New()}, user)
+	require.EqualError(t, err, "post not found")
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestReadingListService_AddItemRefusesHiddenPosts(t *testing.T) {
+	f := newReadingListFixture(t)
+	ctx := context.Background()
+
+	draft := f.posts.add(uuid.New())
+	draft.Status = entity.PostStatusDraft
+	_, err := f.service.AddItem(ctx, f.list.ID, &dto.AddReadingListItemRequest{PostID: draft.ID}, f.owner)
+	require.EqualError(t, err, "post not found")
+
+	// The owner's own draft may be saved
+	own := f.posts.add(f.owner.ID)
+	own.Status = entity.PostStatusDraft
+	resp, err := f.service.AddItem(ctx, f.list.ID, &dto.AddReadingListItemRequest{PostID: own.ID}, f.owner)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{own.ID}, itemPostIDs(resp))
+}
+
+func TestReadingListService_Reorder(t *testing.T) {
+	f := newReadingListFixture(t)
+	ctx := context.Background()
+
+	a, b, c := f.publish(), f.publish(), f.publish()
+	for _, post := range []*entity.Post{a, b, c} {
+		_, err := f.service.AddItem(ctx, f.list.ID, &dto.AddReadingListItemRequest{PostID: post.ID}, f.owner)
+		require.NoError(t, err)
+	}
+
+	_, err := f.service.AddItem(ctx, f.list.ID, &dto.AddReadingListItemRequest{PostID: a.ID}, f.owner)
+	require.EqualError(t, err, "post is already in this reading list")
+
+	resp, err := f.service.Reorder(ctx, f.list.ID, &dto.ReorderReadingListRequest{PostIDs: []uuid.UUID{c.ID, a.ID, b.ID}}, f.owner)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{c.ID, a.ID, b.ID}, itemPostIDs(resp))
+
+	// Every post exactly once
+	invalid := [][]uuid.UUID{
+		{c.ID, a.ID},
+		{c.ID, a.ID, a.ID},
+		{c.ID, a.ID, b.ID, uuid.New()},
+	}
+	for _, postIDs := range invalid {
+		_, err = f.service.Reorder(ctx, f.list.ID, &dto.ReorderReadingListRequest{PostIDs: postIDs}, f.owner)
+		require.EqualError(t, err, "post_ids must contain every post in the list exactly once")
+	}
+
+	// Only the owner may reorder
+	stranger := &entity.User{Role: entity.RoleUser}
+	stranger.ID = uuid.New()
+	_, err = f.service.Reorder(ctx, f.list.ID, &dto.ReorderReadingListRequest{PostIDs: []uuid.UUID{a.ID, b.ID, c.ID}}, stranger)
+	require.EqualError(t, err, "you don't have permission to modify this reading list")
+}
+
+func TestReadingListRepository_ReorderItems(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewReadingListRepository(gormDB)
+	listID, first, second := uuid.New(), uuid.New(), uuid.New()
+
+	// Positions follow the requested order, all or nothing
+	sqlMock.ExpectBegin()
+	for position, postID := range []uuid.UUID{first, second} {
+		sqlMock.ExpectExec(regexp.QuoteMeta(
+			`UPDATE "reading_list_items" SET "position"=$1 WHERE reading_list_id = $2 AND post_id = $3`)).
+			WithArgs(position, listID, postID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	sqlMock.ExpectCommit()
+
+	require.NoError(t, repo.ReorderItems(context.Background(), listID, []uuid.UUID{first, second}))
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
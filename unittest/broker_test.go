@@ -0,0 +1,64 @@
+package unittest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/afdhali/GolangBlogpostServer/pkg/broker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBroker_ReplayAfterLastEventID(t *testing.T) {
+	b := broker.NewMemoryBroker(2, 8)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, b.Publish(ctx, "post:1", "comment.created", map[string]int{"n": i}))
+	}
+
+	// Buffer holds events 2 and 3; resuming after 2 replays only 3
+	sub, err := b.Subscribe("post:1", "2")
+	require.NoError(t, err)
+	defer sub.Close()
+
+	event := <-sub.Events()
+	require.Equal(t, "3", event.ID)
+	require.JSONEq(t, `{"n":3}`, string(event.Data))
+
+	require.NoError(t, b.Publish(ctx, "post:1", "comment.deleted", map[string]int{"n": 4}))
+	event = <-sub.Events()
+	require.Equal(t, "4", event.ID)
+	require.Equal(t, "comment.deleted", event.Type)
+}
+
+func TestMemoryBroker_SlowSubscriberIsDropped(t *testing.T) {
+	b := broker.NewMemoryBroker(0, 1)
+	ctx := context.Background()
+
+	sub, err := b.Subscribe("user:1", "")
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish(ctx, "user:1", "notification.created", 1))
+	require.NoError(t, b.Publish(ctx, "user:1", "notification.created", 2)) // buffer full
+
+	<-sub.Events()
+	_, open := <-sub.Events()
+	require.False(t, open)
+	require.Equal(t, 0, b.SubscriberCount("user:1"))
+	sub.Close() // safe after drop
+}
+
+func TestMemoryBroker_CloseEndsSubscriptions(t *testing.T) {
+	b := broker.NewMemoryBroker(10, 4)
+
+	sub, err := b.Subscribe("post:1", "")
+	require.NoError(t, err)
+
+	require.NoError(t, b.Close())
+	_, open := <-sub.Events()
+	require.False(t, open)
+
+	_, err = b.Subscribe("post:1", "")
+	require.ErrorIs(t, err, broker.ErrClosed)
+	require.ErrorIs(t, b.Publish(context.Background(), "post:1", "x", nil), broker.ErrClosed)
+}
@@ -0,0 +1,304 @@
+package unittest
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// memoryPostRepository keeps posts' featured media in step with the
+// fixture's media repository, like the transaction in postRepository
+type memoryPostRepository struct {
+	repository.PostRepository
+	mu    sync.Mutex
+	posts map[uuid.UUID]*entity.Post
+	media *memoryMediaRepository
+}
+
+func (r *memoryPostRepository) add(authorID uuid.UUID) *entity.Post {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	post := &entity.Post{AuthorID: authorID, Title: "Post", Slug: uuid.NewString()}
+	post.ID = uuid.New()
+	r.posts[post.ID] = post
+	return post
+}
+
+func (r *memoryPostRepository) get(id uuid.UUID) entity.Post {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.posts[id]
+}
+
+func (r *memoryPostRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	post, ok := r.posts[id]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	copied := *post
+	return &copied, nil
+}
+
+func (r *memoryPostRepository) FindVisibleByID(ctx context.Context, id uuid.UUID, viewer repository.PostViewer) (*entity.Post, error) {
+	post, err := r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !viewer.Admin && !post.IsPublished() && (viewer.UserID == nil || *viewer.UserID != post.AuthorID) {
+		return nil, os.ErrNotExist
+	}
+	return post, nil
+}
+
+func (r *memoryPostRepository) SetFeaturedMedia(ctx context.Context, postID uuid.UUID, media *entity.Media) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.media.mu.Lock()
+	defer r.media.mu.Unlock()
+
+	post, ok := r.posts[postID]
+	if !ok {
+		return os.ErrNotExist
+	}
+	for _, m := range r.media.media {
+		if m.PostID != nil && *m.PostID == postID {
+			m.IsFeatured = false
+		}
+	}
+	post.FeaturedMediaID, post.FeaturedImage = nil, ""
+	if media != nil {
+		m := r.media.media[media.ID]
+		m.PostID, m.IsFeatured = &postID, true
+		post.FeaturedMediaID, post.FeaturedImage = &m.ID, m.URL
+	}
+	return nil
+}
+
+func (r *memoryPostRepository) UnsetFeaturedMedia(ctx context.Context, mediaIDs []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.media.mu.Lock()
+	defer r.media.mu.Unlock()
+
+	for _, id := range mediaIDs {
+		for _, post := range r.posts {
+			if post.FeaturedMediaID != nil && *post.FeaturedMediaID == id {
+				post.FeaturedMediaID, post.FeaturedImage = nil, ""
+			}
+		}
+		if m, ok := r.media.media[id]; ok {
+			m.IsFeatured = false
+		}
+	}
+	return nil
+}
+
+func (r *memoryMediaRepository) Update(ctx context.Context, media *entity.Media) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *media
+	r.media[media.ID] = &copied
+	return nil
+}
+
+func (r *memoryMediaRepository) FindByPostID(ctx context.Context, postID uuid.UUID) ([]*entity.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var medias []*entity.Media
+	for _, media := range r.media {
+		if media.PostID != nil && *media.PostID == postID {
+			copied := *media
+			medias = append(medias, &copied)
+		}
+	}
+	return medias, nil
+}
+
+func (r *memoryMediaRepository) FindFeaturedByPostID(ctx context.Context, postID uuid.UUID) (*entity.Media, error) {
+	medias, _ := r.FindByPostID(ctx, postID)
+	for _, media := range medias {
+		if media.IsFeatured {
+			return media, nil
+		}
+	}
+	return nil, os.ErrNotExist
+}
+
+func TestFeaturedMedia_OnePerPost(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	user := slotUser()
+	post := f.posts.add(user.ID)
+
+	file, header := upload(jpegBytes(t, 64, 48), "first.jpg", "image/jpeg")
+	first, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{PostID: &post.ID, IsFeatured: true})
+	require.NoError(t, err)
+	require.True(t, first.IsFeatured)
+	require.Equal(t, first.ID, *f.posts.get(post.ID).FeaturedMediaID)
+	require.Equal(t, first.URL, f.posts.get(post.ID).FeaturedImage)
+
+	// Featuring another item replaces the first
+	file, header = upload(jpegBytes(t, 80, 60), "second.jpg", "image/jpeg")
+	second, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{PostID: &post.ID})
+	require.NoError(t, err)
+	require.False(t, second.IsFeatured)
+
+	yes := true
+	updated, err := f.service.Update(ctx, second.ID, &dto.UpdateMediaRequest{IsFeatured: &yes}, user)
+	require.NoError(t, err)
+	require.True(t, updated.IsFeatured)
+	require.Equal(t, second.ID, *f.posts.get(post.ID).FeaturedMediaID)
+
+	reloaded, err := f.service.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	require.False(t, reloaded.IsFeatured)
+
+	// Deleting the featured item clears the post's
+	require.NoError(t, f.service.Delete(ctx, second.ID, user))
+	require.Nil(t, f.posts.get(post.ID).FeaturedMediaID)
+	require.Empty(t, f.posts.get(post.ID).FeaturedImage)
+}
+
+func TestFeaturedMedia_Unfeature(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	user := slotUser()
+	post := f.posts.add(user.ID)
+
+	// Media without a post can't be featured
+	file, header := upload(jpegBytes(t, 64, 48), "loose.jpg", "image/jpeg")
+	loose, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{IsFeatured: true})
+	require.NoError(t, err)
+	require.False(t, loose.IsFeatured)
+
+	yes, no := true, false
+	_, err = f.service.Update(ctx, loose.ID, &dto.UpdateMediaRequest{IsFeatured: &yes}, user)
+	require.EqualError(t, err, "media is not attached to a post")
+
+	file, header = upload(jpegBytes(t, 80, 60), "cover.jpg", "image/jpeg")
+	cover, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{PostID: &post.ID, IsFeatured: true})
+	require.NoError(t, err)
+	require.NotNil(t, f.posts.get(post.ID).FeaturedMediaID)
+
+	updated, err := f.service.Update(ctx, cover.ID, &dto.UpdateMediaRequest{IsFeatured: &no}, user)
+	require.NoError(t, err)
+	require.False(t, updated.IsFeatured)
+	require.Nil(t, f.posts.get(post.ID).FeaturedMediaID)
+}
+
+func TestFeaturedMedia_OnlyPostAuthor(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	author, other := slotUser(), slotUser()
+	post := f.posts.add(author.ID)
+
+	// Another user can't upload media onto the post, featured or not
+	file, header := upload(jpegBytes(t, 64, 48), "cover.jpg", "image/jpeg")
+	_, err := f.service.Upload(ctx, other, file, header, &dto.UploadMediaRequest{PostID: &post.ID, IsFeatured: true})
+	require.EqualError(t, err, "you can only add media to your own posts")
+	require.Nil(t, f.posts.get(post.ID).FeaturedMediaID)
+
+	_, err = f.service.CreateUploadSlot(ctx, other, &dto.CreateUploadSlotRequest{
+		Filename: "cover.jpg", ContentType: "image/jpeg", Size: 1024, PostID: &post.ID,
+	})
+	require.EqualError(t, err, "you can only add media to your own posts")
+
+	// Nor attach their own media to it
+	file, header = upload(jpegBytes(t, 64, 48), "mine.jpg", "image/jpeg")
+	mine, err := f.service.Upload(ctx, other, file, header, &dto.UploadMediaRequest{})
+	require.NoError(t, err)
+
+	yes := true
+	_, err = f.service.Update(ctx, mine.ID, &dto.UpdateMediaRequest{PostID: &post.ID, IsFeatured: &yes}, other)
+	require.EqualError(t, err, "you can only add media to your own posts")
+
+	_, err = f.service.BulkUpdate(ctx, &dto.BulkMediaRequest{
+		Action: dto.MediaBulkAttach, MediaIDs: []uuid.UUID{mine.ID}, PostID: &post.ID,
+	}, other)
+	require.EqualError(t, err, "you can only add media to your own posts")
+	require.Nil(t, f.posts.get(post.ID).FeaturedMediaID)
+
+	// Admins may
+	admin := slotUser()
+	admin.Role = entity.RoleAdmin
+	updated, err := f.service.Update(ctx, mine.ID, &dto.UpdateMediaRequest{PostID: &post.ID, IsFeatured: &yes}, admin)
+	require.NoError(t, err)
+	require.True(t, updated.IsFeatured)
+	require.Equal(t, mine.ID, *f.posts.get(post.ID).FeaturedMediaID)
+}
+
+func TestPostRepository_SetFeaturedMedia(t *testing.T) {
+	dbMock, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer dbMock.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: dbMock,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	repo := repository.NewPostRepository(gormDB)
+	postID := uuid.New()
+	media := &entity.Media{URL: "http://localhost:5000/uploads/cover.jpg"}
+	media.ID = uuid.New()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "posts" WHERE id = $1 AND "posts"."deleted_at" IS NULL ORDER BY "posts"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs(postID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(postID))
+	sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "media" SET "is_featured"=$1,"updated_at"=$2 WHERE (post_id = $3 AND is_featured = $4) AND "media"."deleted_at" IS NULL`)).
+		WithArgs(false, sqlmock.AnyArg(), postID, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "media" SET "is_featured"=$1,"post_id"=$2,"updated_at"=$3 WHERE id = $4 AND "media"."deleted_at" IS NULL`)).
+		WithArgs(true, postID, sqlmock.AnyArg(), media.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "posts" SET "featured_image"=$1,"featured_media_id"=$2,"version"=version + 1,"updated_at"=$3 WHERE id = $4 AND "posts"."deleted_at" IS NULL`)).
+		WithArgs(media.URL, media.ID, sqlmock.AnyArg(), postID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	require.NoError(t, repo.SetFeaturedMedia(context.Background(), postID, media))
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostMedia_HiddenWithDraftPost(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	author := slotUser()
+	draft := f.posts.add(author.ID)
+	draft.Status = entity.PostStatusDraft
+
+	file, header := upload(jpegBytes(t, 64, 48), "cover.jpg", "image/jpeg")
+	cover, err := f.service.Upload(ctx, author, file, header, &dto.UploadMediaRequest{PostID: &draft.ID, IsFeatured: true})
+	require.NoError(t, err)
+
+	// Anonymous readers and other users can't tell the draft exists
+	stranger := slotUser()
+	for _, viewer := range []*entity.User{nil, stranger} {
+		_, err = f.service.GetByPostID(ctx, draft.ID, viewer)
+		require.EqualError(t, err, "post not found")
+		_, err = f.service.GetFeaturedByPostID(ctx, draft.ID, viewer)
+		require.EqualError(t, err, "post not found")
+	}
+
+	// The author still sees it
+	medias, err := f.service.GetByPostID(ctx, draft.ID, author)
+	require.NoError(t, err)
+	require.Len(t, medias, 1)
+	featured, err := f.service.GetFeaturedByPostID(ctx, draft.ID, author)
+	require.NoError(t, err)
+	require.Equal(t, cover.ID, featured.ID)
+}
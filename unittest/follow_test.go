@@ -0,0 +1,198 @@
+package unittest
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryFollowRepository keeps user follows as follower -> following pairs
+type memoryFollowRepository struct {
+	repository.FollowRepository
+	mu      sync.Mutex
+	follows map[[2]uuid.UUID]bool
+}
+
+func (r *memoryFollowRepository) FollowUser(ctx context.Context, follow *entity.UserFollow) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]uuid.UUID{follow.FollowerID, follow.FollowingID}
+	if r.follows[key] {
+		return false, nil
+	}
+	r.follows[key] = true
+	return true, nil
+}
+
+func (r *memoryFollowRepository) UnfollowUser(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]uuid.UUID{followerID, followingID}
+	if !r.follows[key] {
+		return false, nil
+	}
+	delete(r.follows, key)
+	return true, nil
+}
+
+func (r *memoryFollowRepository) CountFollowers(ctx context.Context, userID uuid.UUID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for key := range r.follows {
+		if key[1] == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// recordingNotifier remembers who was told about new followers
+type recordingNotifier struct {
+	service.NotificationService
+	followed []uuid.UUID
+}
+
+func (n *recordingNotifier) NotifyNewFollower(ctx context.Context, follower *entity.User, followingID uuid.UUID) {
+	n.followed = append(n.followed, followingID)
+}
+
+func newFollowFixture() (service.FollowService, *memoryUserRepository, *recordingNotifier) {
+	users := &memoryUserRepository{users: make(map[uuid.UUID]*entity.User)}
+	notifier := &recordingNotifier{}
+	follows := &memoryFollowRepository{follows: make(map[[2]uuid.UUID]bool)}
+	return service.NewFollowService(follows, users, nil, validator.NewValidator(), notifier), users, notifier
+}
+
+func activeUser(users *memoryUserRepository) *entity.User {
+	user := users.add(entity.RoleUser)
+	user.IsActive = true
+	users.Update(context.Background(), user)
+	return user
+}
+
+func TestFollowService_FollowAndUnfollow(t *testing.T) {
+	follows, users, notifier := newFollowFixture()
+	ctx := context.Background()
+	reader, author := activeUser(users), activeUser(users)
+
+	resp, err := follows.FollowUser(ctx, author.ID, reader)
+	require.NoError(t, err)
+	require.True(t, resp.Following)
+	require.Equal(t, int64(1), resp.FollowerCount)
+
+	// Following again is a no-op and isn't announced twice
+	resp, err = follows.FollowUser(ctx, author.ID, reader)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resp.FollowerCount)
+	require.Equal(t, []uuid.UUID{author.ID}, notifier.followed)
+
+	resp, err = follows.UnfollowUser(ctx, author.ID, reader)
+	require.NoError(t, err)
+	require.False(t, resp.Following)
+	require.Zero(t, resp.FollowerCount)
+
+	_, err = follows.UnfollowUser(ctx, author.ID, reader)
+	require.EqualError(t, err, "you are not following this user")
+}
+
+func TestFollowService_RejectsSelfAndInactiveUsers(t *testing.T) {
+	follows, users, notifier := newFollowFixture()
+	ctx := context.Background()
+	reader := activeUser(users)
+
+	_, err := follows.FollowUser(ctx, reader.ID, reader)
+	require.EqualError(t, err, "you cannot follow yourself")
+
+	inactive := users.add(entity.RoleUser)
+	_, err = follows.FollowUser(ctx, inactive.ID, reader)
+	require.EqualError(t, err, "user not found")
+	_, err = follows.FollowUser(ctx, uuid.New(), reader)
+	require.EqualError(t, err, "user not found")
+	require.Empty(t, notifier.followed)
+}
+
+// noBookmarks reports no post as bookmarked
+type noBookmarks struct {
+	repository.BookmarkRepository
+}
+
+func (noBookmarks) FindBookmarkedPostIDs(ctx context.Context, userID uuid.UUID, postIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	return make(map[uuid.UUID]bool), nil
+}
+
+// feedOrder is the tail of the feed query: the keyset predicate, when
+// paging from a cursor, and the (published_at, id) order
+func feedOrder(cursor bool) string {
+	if cursor {
+		return `) AND (COALESCE(posts.published_at, posts.created_at), posts.id) < ($4, $5) AND "posts"."deleted_at" IS NULL ORDER BY COALESCE(posts.published_at, posts.created_at) DESC,posts.id DESC LIMIT $6`
+	}
+	return `) AND "posts"."deleted_at" IS NULL ORDER BY COALESCE(posts.published_at, posts.created_at) DESC,posts.id DESC LIMIT $4`
+}
+
+func TestPostService_GetFeedKeysetPages(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	cursors := pagination.NewSigner("cursor-secret")
+	posts := service.NewPostService(nil, repository.NewPostRepository(gormDB), nil, memoryCommentCounts{}, noBookmarks{},
+		nil, nil, nil, validator.NewValidator(), nil, nil, nil, cursors)
+
+	reader := slotUser()
+	newest := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+	tied := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	// Two posts published at the same instant are told apart by id, descending
+	first := uuid.MustParse("00000000-0000-0000-0000-000000000003")
+	second := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	third := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	columns := []string{"id", "status", "published_at"}
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(feedOrder(false))).
+		WithArgs(entity.PostStatusPublished, reader.ID, reader.ID, 3).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(first, "published", newest).
+			AddRow(second, "published", tied).
+			AddRow(third, "published", tied))
+
+	page, info, err := posts.GetFeed(context.Background(), &dto.FeedQueryParams{Limit: 2}, reader)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	require.Equal(t, first, page[0].ID)
+	require.Equal(t, second, page[1].ID)
+	require.Empty(t, info.PrevCursor)
+	require.Nil(t, info.Total)
+
+	// The next page starts right after the last row's (published_at, id)
+	next, err := cursors.Decode(info.NextCursor)
+	require.NoError(t, err)
+	require.True(t, tied.Equal(next.Value.(time.Time)))
+	require.Equal(t, second, next.ID)
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(feedOrder(true))).
+		WithArgs(entity.PostStatusPublished, reader.ID, reader.ID, tied, second, 3).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(third, "published", tied))
+
+	page, info, err = posts.GetFeed(context.Background(), &dto.FeedQueryParams{Cursor: info.NextCursor, Limit: 2}, reader)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, third, page[0].ID)
+
+	// Last page: nothing further, but the way back
+	require.Empty(t, info.NextCursor)
+	require.NotEmpty(t, info.PrevCursor)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+
+	// Cursors of other listings or forged ones are refused
+	_, _, err = posts.GetFeed(context.Background(), &dto.FeedQueryParams{Cursor: "forged", Limit: 2}, reader)
+	require.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
@@ -0,0 +1,201 @@
+package unittest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	imgpkg "github.com/afdhali/GolangBlogpostServer/pkg/image"
+	"github.com/stretchr/testify/require"
+)
+
+type tiffEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func asciiEntry(tag uint16, value string) tiffEntry {
+	return tiffEntry{tag: tag, typ: 2, count: uint32(len(value) + 1), data: append([]byte(value), 0)}
+}
+
+func shortEntry(tag, value uint16) tiffEntry {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint16(data, value)
+	return tiffEntry{tag: tag, typ: 3, count: 1, data: data}
+}
+
+func longEntry(tag uint16, value uint32) tiffEntry {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	return tiffEntry{tag: tag, typ: 4, count: 1, data: data}
+}
+
+// tiffIFD lays out an IFD placed at offset, with values that do not fit an
+// entry stored right after it
+func tiffIFD(offset int, entries []tiffEntry) []byte {
+	head := make([]byte, 2+12*len(entries)+4)
+	binary.LittleEndian.PutUint16(head, uint16(len(entries)))
+
+	var extra []byte
+	dataOffset := offset + len(head)
+	for i, e := range entries {
+		pos := 2 + 12*i
+		binary.LittleEndian.PutUint16(head[pos:], e.tag)
+		binary.LittleEndian.PutUint16(head[pos+2:], e.typ)
+		binary.LittleEndian.PutUint32(head[pos+4:], e.count)
+		if len(e.data) <= 4 {
+			copy(head[pos+8:], e.data)
+			continue
+		}
+		binary.LittleEndian.PutUint32(head[pos+8:], uint32(dataOffset+len(extra)))
+		extra = append(extra, e.data...)
+	}
+	return append(head, extra...)
+}
+
+// exifTIFF builds a little-endian EXIF block like a phone camera writes
+func exifTIFF(orientation uint16) []byte {
+	ifd0 := func(exifOffset, gpsOffset uint32) []tiffEntry {
+		return []tiffEntry{
+			asciiEntry(0x010F, "Apple"),
+			asciiEntry(0x0110, "iPhone 15 Pro"),
+			shortEntry(0x0112, orientation),
+			longEntry(0x8769, exifOffset),
+			longEntry(0x8825, gpsOffset),
+		}
+	}
+
+	size := len(tiffIFD(8, ifd0(0, 0)))
+	exifOffset := 8 + size
+	exifIFD := tiffIFD(exifOffset, []tiffEntry{
+		asciiEntry(0x9003, "2024:06:01 14:30:00"),
+		asciiEntry(0x9011, "+07:00"),
+	})
+	gpsOffset := exifOffset + len(exifIFD)
+	gpsIFD := tiffIFD(gpsOffset, []tiffEntry{asciiEntry(0x0001, "S")})
+
+	out := []byte("II*\x00\x08\x00\x00\x00")
+	out = append(out, tiffIFD(8, ifd0(uint32(exifOffset), uint32(gpsOffset)))...)
+	out = append(out, exifIFD...)
+	return append(out, gpsIFD...)
+}
+
+// jpegWithExif is a 40x20 JPEG, red on the left and blue on the right,
+// carrying the given EXIF block
+func jpegWithExif(t *testing.T, tiff []byte) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 20 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	data := buf.Bytes()
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestReadMetadata(t *testing.T) {
+	meta := imgpkg.ReadMetadata(jpegWithExif(t, exifTIFF(6)))
+
+	require.Equal(t, 6, meta.Orientation)
+	require.Equal(t, "Apple", meta.CameraMake)
+	require.Equal(t, "iPhone 15 Pro", meta.CameraModel)
+	require.True(t, meta.HasGPS)
+	require.NotNil(t, meta.CapturedAt)
+	require.True(t, meta.CapturedAt.Equal(time.Date(2024, 6, 1, 7, 30, 0, 0, time.UTC)))
+
+	// No EXIF at all
+	plain := imgpkg.ReadMetadata(jpegBytes(t, 8, 8))
+	require.Equal(t, 1, plain.Orientation)
+	require.Empty(t, plain.CameraMake)
+	require.Nil(t, plain.CapturedAt)
+
+	// Garbage after the header must not panic
+	broken := jpegWithExif(t, append([]byte("II*\x00\xff\xff\x00\x00"), make([]byte, 16)...))
+	require.Equal(t, 1, imgpkg.ReadMetadata(broken).Orientation)
+}
+
+func TestProcessor_AppliesOrientationAndStripsMetadata(t *testing.T) {
+	file, header := upload(jpegWithExif(t, exifTIFF(6)), "phone.jpg", "image/jpeg")
+
+	processed, err := imgpkg.NewProcessor(95, 1000, 1000).ProcessImage(file, header)
+	require.NoError(t, err)
+
+	// Rotated 90° clockwise: 40x20 becomes 20x40 with red on top
+	require.Equal(t, 20, processed.Width)
+	require.Equal(t, 40, processed.Height)
+	require.Equal(t, 20, processed.OriginalWidth)
+	require.Equal(t, 40, processed.OriginalHeight)
+	require.Equal(t, "Apple", processed.Metadata.CameraMake)
+
+	img, err := jpeg.Decode(bytes.NewReader(processed.Data))
+	require.NoError(t, err)
+	top := color.RGBAModel.Convert(img.At(10, 5)).(color.RGBA)
+	bottom := color.RGBAModel.Convert(img.At(10, 35)).(color.RGBA)
+	require.Greater(t, top.R, top.B)
+	require.Greater(t, bottom.B, bottom.R)
+
+	// Nothing of the original EXIF survives
+	require.NotContains(t, string(processed.Data), "Exif\x00\x00")
+	require.NotContains(t, string(processed.Data), "iPhone")
+}
+
+func TestProcessor_KeepsMetadataWhenAsked(t *testing.T) {
+	file, header := upload(jpegWithExif(t, exifTIFF(6)), "phone.jpg", "image/jpeg")
+
+	processed, err := imgpkg.NewProcessor(95, 1000, 1000).WithMetadataStripping(false).ProcessImage(file, header)
+	require.NoError(t, err)
+
+	// Pixels are upright already, so the stored orientation is reset
+	meta := imgpkg.ReadMetadata(processed.Data)
+	require.Equal(t, 1, meta.Orientation)
+	require.Equal(t, "iPhone 15 Pro", meta.CameraModel)
+
+	_, err = jpeg.Decode(bytes.NewReader(processed.Data))
+	require.NoError(t, err)
+}
+
+func TestOrient(t *testing.T) {
+	// 3x2 with a marker in the top-left corner
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, color.White)
+
+	corners := map[int]image.Point{
+		1: {0, 0},
+		2: {2, 0},
+		3: {2, 1},
+		4: {0, 1},
+		5: {0, 0},
+		6: {1, 0},
+		7: {1, 2},
+		8: {0, 2},
+	}
+	for orientation, want := range corners {
+		out := imgpkg.Orient(src, orientation)
+		if orientation >= 5 {
+			require.Equal(t, image.Rect(0, 0, 2, 3), out.Bounds(), orientation)
+		} else {
+			require.Equal(t, image.Rect(0, 0, 3, 2), out.Bounds(), orientation)
+		}
+		_, _, _, a := out.At(want.X, want.Y).RGBA()
+		require.NotZero(t, a, "orientation %d", orientation)
+	}
+}
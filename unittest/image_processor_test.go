@@ -0,0 +1,123 @@
+package unittest
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+
+	imgpkg "github.com/afdhali/GolangBlogpostServer/pkg/image"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error { return nil }
+
+// transparentPNG is a red square on a fully transparent background
+func transparentPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := height / 4; y < height*3/4; y++ {
+		for x := width / 4; x < width*3/4; x++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func upload(data []byte, filename, contentType string) (multipart.File, *multipart.FileHeader) {
+	header := &multipart.FileHeader{
+		Filename: filename,
+		Header:   textproto.MIMEHeader{"Content-Type": {contentType}},
+		Size:     int64(len(data)),
+	}
+	return memoryFile{bytes.NewReader(data)}, header
+}
+
+func TestProcessor_PreservesPNGTransparency(t *testing.T) {
+	file, header := upload(transparentPNG(t, 400, 200), "logo.png", "image/png")
+
+	processed, err := imgpkg.NewProcessor(85, 200, 200).ProcessImage(file, header)
+	require.NoError(t, err)
+	require.Equal(t, imgpkg.FormatPNG, processed.Format)
+	require.Equal(t, "image/png", processed.MimeType)
+	require.Equal(t, 200, processed.Width)
+	require.Equal(t, 100, processed.Height)
+
+	img, err := png.Decode(bytes.NewReader(processed.Data))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 200, 100), img.Bounds())
+	_, _, _, a := img.At(0, 0).RGBA()
+	require.Zero(t, a, "corner should stay transparent")
+}
+
+func TestProcessor_OptimizedPNGIsLossless(t *testing.T) {
+	data := transparentPNG(t, 300, 300)
+	file, header := upload(data, "logo.png", "image/png")
+
+	plain, err := imgpkg.NewProcessor(85, 1000, 1000).ProcessImage(file, header)
+	require.NoError(t, err)
+	optimized, err := imgpkg.NewProcessor(85, 1000, 1000).WithPNGOptimization(true).ProcessImage(file, header)
+	require.NoError(t, err)
+	require.Less(t, len(optimized.Data), len(plain.Data))
+
+	want, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	got, err := png.Decode(bytes.NewReader(optimized.Data))
+	require.NoError(t, err)
+	for _, pt := range []image.Point{{0, 0}, {150, 150}, {74, 74}, {75, 75}, {299, 299}} {
+		require.Equal(t,
+			color.NRGBAModel.Convert(want.At(pt.X, pt.Y)),
+			color.NRGBAModel.Convert(got.At(pt.X, pt.Y)), pt)
+	}
+}
+
+func TestProcessor_ConvertsToPreferredFormat(t *testing.T) {
+	file, header := upload(transparentPNG(t, 64, 32), "logo.png", "image/png")
+
+	processor, err := imgpkg.NewProcessor(85, 1000, 1000).WithOutputFormat("webp")
+	require.NoError(t, err)
+	processed, err := processor.ProcessImage(file, header)
+	require.NoError(t, err)
+	require.Equal(t, "image/webp", processed.MimeType)
+
+	img, err := webp.Decode(bytes.NewReader(processed.Data))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 64, 32), img.Bounds())
+
+	// Storage names the file after what was actually encoded
+	stored := processed.FileHeader(header)
+	require.Equal(t, "logo.webp", stored.Filename)
+	require.Equal(t, "image/webp", stored.Header.Get("Content-Type"))
+	require.Equal(t, int64(len(processed.Data)), stored.Size)
+	require.Equal(t, "image/png", header.Header.Get("Content-Type"), "original header is left alone")
+
+	_, err = imgpkg.NewProcessor(85, 1000, 1000).WithOutputFormat("gif")
+	require.Error(t, err)
+}
+
+func TestProcessor_JPEGFlattensTransparency(t *testing.T) {
+	file, header := upload(transparentPNG(t, 40, 40), "logo.png", "image/png")
+
+	processor, err := imgpkg.NewProcessor(95, 1000, 1000).WithOutputFormat("jpg")
+	require.NoError(t, err)
+	processed, err := processor.ProcessImage(file, header)
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", processed.MimeType)
+	require.Equal(t, "logo.jpg", processed.FileHeader(header).Filename)
+
+	img, _, err := image.Decode(bytes.NewReader(processed.Data))
+	require.NoError(t, err)
+	r, g, b, _ := img.At(0, 0).RGBA()
+	require.Greater(t, r>>8, uint32(240))
+	require.Greater(t, g>>8, uint32(240))
+	require.Greater(t, b>>8, uint32(240))
+}
@@ -0,0 +1,84 @@
+package unittest
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	imgpkg "github.com/afdhali/GolangBlogpostServer/pkg/image"
+	"github.com/stretchr/testify/require"
+)
+
+func solidImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 80, B: 40, A: 255})
+		}
+	}
+	return img
+}
+
+func TestParseVariants(t *testing.T) {
+	variants, err := imgpkg.ParseVariants(imgpkg.DefaultVariantSpec)
+	require.NoError(t, err)
+	require.Len(t, variants, 5)
+	require.Equal(t, imgpkg.Variant{Name: "thumb", Width: 150, Height: 150, Crop: true}, variants[0])
+	require.Equal(t, imgpkg.Variant{Name: "small", Width: 480}, variants[1])
+
+	for _, spec := range []string{"thumb", "thumb:0", "thumb:abc", "a:10,a:20", "og:1200:crop", "og:1200x630:zoom"} {
+		_, err := imgpkg.ParseVariants(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestMediaProcessor_GenerateVariants(t *testing.T) {
+	variants, err := imgpkg.ParseVariants(imgpkg.DefaultVariantSpec)
+	require.NoError(t, err)
+	processor := imgpkg.NewMediaProcessor(imgpkg.NewProcessor(80, 2048, 2048), variants)
+
+	renditions, err := processor.GenerateVariants(solidImage(1000, 500), imgpkg.FormatJPEG)
+	require.NoError(t, err)
+
+	// "large" (1600) would be wider than the source, so it is skipped
+	got := map[string][2]int{}
+	for _, r := range renditions {
+		require.Equal(t, "image/jpeg", r.MimeType)
+		require.NotEmpty(t, r.Data)
+
+		width, height, err := imgpkg.Dimensions(r.Data)
+		require.NoError(t, err)
+		require.Equal(t, [2]int{r.Width, r.Height}, [2]int{width, height})
+		got[r.Variant.Name] = [2]int{width, height}
+	}
+
+	require.Equal(t, map[string][2]int{
+		"thumb":    {150, 150},
+		"small":    {480, 240},
+		"medium":   {960, 480},
+		"og-image": {952, 500}, // 1200x630 box shrunk to fit the source instead of upscaling
+	}, got)
+}
+
+func TestCoverAndFit(t *testing.T) {
+	covered := imgpkg.Cover(solidImage(800, 400), 200, 200)
+	require.Equal(t, image.Rect(0, 0, 200, 200), covered.Bounds())
+
+	fitted := imgpkg.Fit(solidImage(800, 400), 200, 0)
+	require.Equal(t, 200, fitted.Bounds().Dx())
+	require.Equal(t, 100, fitted.Bounds().Dy())
+
+	// Never upscales
+	small := solidImage(50, 40)
+	require.Equal(t, small.Bounds(), imgpkg.Fit(small, 200, 200).Bounds())
+}
+
+func TestResizeSigner(t *testing.T) {
+	signer := imgpkg.NewResizeSigner("secret")
+	sig := signer.Sign("media-1", 300, 200, "cover")
+
+	require.True(t, signer.Verify("media-1", 300, 200, "cover", sig))
+	require.False(t, signer.Verify("media-1", 301, 200, "cover", sig))
+	require.False(t, signer.Verify("media-2", 300, 200, "cover", sig))
+	require.False(t, imgpkg.NewResizeSigner("other").Verify("media-1", 300, 200, "cover", sig))
+}
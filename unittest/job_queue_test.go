@@ -0,0 +1,246 @@
+package unittest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/config"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryJobRepository is an in-memory stand-in for the jobs table
+type memoryJobRepository struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*entity.Job
+}
+
+func newMemoryJobRepository() *memoryJobRepository {
+	return &memoryJobRepository{jobs: make(map[uuid.UUID]*entity.Job)}
+}
+
+func (r *memoryJobRepository) Create(ctx context.Context, job *entity.Job) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job.UniqueKey != nil && r.activeByKey(*job.UniqueKey) != nil {
+		return false, nil
+	}
+	job.ID = uuid.New()
+	job.CreatedAt = time.Now()
+	copied := *job
+	r.jobs[job.ID] = &copied
+	return true, nil
+}
+
+func (r *memoryJobRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *memoryJobRepository) FindActiveByUniqueKey(ctx context.Context, key string) (*entity.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.activeByKey(key)
+	if job == nil {
+		return nil, errors.New("record not found")
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *memoryJobRepository) activeByKey(key string) *entity.Job {
+	for _, job := range r.jobs {
+		if job.UniqueKey != nil && *job.UniqueKey == key &&
+			(job.Status == entity.JobStatusPending || job.Status == entity.JobStatusRunning) {
+			return job
+		}
+	}
+	return nil
+}
+
+func (r *memoryJobRepository) Claim(ctx context.Context, types []string, workerID string, now time.Time, lease time.Duration) (*entity.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, job := range r.jobs {
+		known := false
+		for _, t := range types {
+			known = known || t == job.Type
+		}
+		if !known || job.Status != entity.JobStatusPending || job.RunAt.After(now) {
+			continue
+		}
+		job.Status = entity.JobStatusRunning
+		job.Attempts++
+		job.LockedAt = &now
+		job.LockedBy = workerID
+		copied := *job
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryJobRepository) Finish(ctx context.Context, job *entity.Job, workerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *job
+	r.jobs[job.ID] = &copied
+	return nil
+}
+
+func (r *memoryJobRepository) DeleteSucceededBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryJobRepository) FindAll(ctx context.Context, status, jobType string, page, limit int) ([]*entity.Job, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *memoryJobRepository) CountByStatus(ctx context.Context) (map[entity.JobStatus]int64, error) {
+	return nil, nil
+}
+
+func (r *memoryJobRepository) Retry(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	return false, nil
+}
+
+// makeDue lets a scheduled retry run now
+func (r *memoryJobRepository) makeDue(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[id].RunAt = time.Now()
+}
+
+func newTestJobQueue(t *testing.T, repo *memoryJobRepository) *service.JobQueue {
+	log, err := logger.NewLogger(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { log.Close() })
+
+	cfg := &config.Config{Jobs: config.JobsConfig{
+		Workers:         1,
+		PollIntervalSec: 1,
+		TimeoutSec:      5,
+		MaxAttempts:     3,
+		BackoffBaseSec:  10,
+		BackoffMaxSec:   60,
+	}}
+	return service.NewJobQueue(repo, log, cfg)
+}
+
+type greetPayload struct {
+	Name string `json:"name"`
+}
+
+func TestJobQueue_RunsTypedHandler(t *testing.T) {
+	repo := newMemoryJobRepository()
+	q := newTestJobQueue(t, repo)
+	ctx := context.Background()
+
+	var got string
+	service.RegisterJob(q, "greet", func(ctx context.Context, p greetPayload) error {
+		got = p.Name
+		return nil
+	})
+
+	job, err := q.Enqueue(ctx, "greet", greetPayload{Name: "ada"})
+	require.NoError(t, err)
+
+	ran, err := q.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+	require.Equal(t, "ada", got)
+
+	stored, _ := repo.FindByID(ctx, job.ID)
+	require.Equal(t, entity.JobStatusSucceeded, stored.Status)
+	require.NotNil(t, stored.CompletedAt)
+}
+
+func TestJobQueue_RetriesWithBackoffThenDeadLetters(t *testing.T) {
+	repo := newMemoryJobRepository()
+	q := newTestJobQueue(t, repo)
+	ctx := context.Background()
+
+	q.Register("flaky", func(ctx context.Context, job *entity.Job) error {
+		return errors.New("upstream unavailable")
+	})
+
+	job, err := q.Enqueue(ctx, "flaky", map[string]string{})
+	require.NoError(t, err)
+
+	before := time.Now()
+	_, err = q.RunNext(ctx)
+	require.NoError(t, err)
+
+	stored, _ := repo.FindByID(ctx, job.ID)
+	require.Equal(t, entity.JobStatusPending, stored.Status)
+	require.Equal(t, 1, stored.Attempts)
+	require.Equal(t, "upstream unavailable", stored.LastError)
+	require.WithinDuration(t, before.Add(10*time.Second), stored.RunAt, 2*time.Second)
+
+	// Not due yet
+	ran, _ := q.RunNext(ctx)
+	require.False(t, ran)
+
+	for i := 0; i < 2; i++ {
+		repo.makeDue(job.ID)
+		_, err = q.RunNext(ctx)
+		require.NoError(t, err)
+	}
+
+	stored, _ = repo.FindByID(ctx, job.ID)
+	require.Equal(t, entity.JobStatusDead, stored.Status)
+	require.Equal(t, 3, stored.Attempts)
+}
+
+func TestJobQueue_PermanentErrorSkipsRetries(t *testing.T) {
+	repo := newMemoryJobRepository()
+	q := newTestJobQueue(t, repo)
+	ctx := context.Background()
+
+	service.RegisterJob(q, "greet", func(ctx context.Context, p greetPayload) error {
+		return nil
+	})
+
+	// Payload that doesn't decode into greetPayload
+	job, err := q.Enqueue(ctx, "greet", []int{1, 2})
+	require.NoError(t, err)
+
+	_, err = q.RunNext(ctx)
+	require.NoError(t, err)
+
+	stored, _ := repo.FindByID(ctx, job.ID)
+	require.Equal(t, entity.JobStatusDead, stored.Status)
+	require.Equal(t, 1, stored.Attempts)
+	require.Contains(t, stored.LastError, "invalid payload")
+}
+
+func TestJobQueue_UniqueKeyAndScheduling(t *testing.T) {
+	repo := newMemoryJobRepository()
+	q := newTestJobQueue(t, repo)
+	ctx := context.Background()
+
+	q.Register("digest", func(ctx context.Context, job *entity.Job) error { return nil })
+
+	first, err := q.Enqueue(ctx, "digest", nil, service.JobUniqueKey("digest:user-1"), service.JobDelay(time.Hour))
+	require.NoError(t, err)
+
+	second, err := q.Enqueue(ctx, "digest", nil, service.JobUniqueKey("digest:user-1"))
+	require.NoError(t, err)
+	require.Equal(t, first.ID, second.ID)
+
+	// Scheduled an hour out, so nothing is due
+	ran, err := q.RunNext(ctx)
+	require.NoError(t, err)
+	require.False(t, ran)
+}
@@ -0,0 +1,143 @@
+package unittest
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorSigner_RoundTrip(t *testing.T) {
+	signer := pagination.NewSigner("cursor-secret")
+	at := time.Date(2024, 6, 1, 12, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+
+	cursor := signer.Encode(pagination.Keyset{Sort: "created_at desc", Value: at, ID: id, Prev: true})
+	keyset, err := signer.Decode(cursor)
+	require.NoError(t, err)
+	require.Equal(t, "created_at desc", keyset.Sort)
+	require.True(t, at.Equal(keyset.Value.(time.Time)))
+	require.Equal(t, id, keyset.ID)
+	require.True(t, keyset.Prev)
+
+	keyset, err = signer.Decode(signer.Encode(pagination.Keyset{Sort: "view_count desc", Value: int64(42), ID: id}))
+	require.NoError(t, err)
+	require.Equal(t, int64(42), keyset.Value)
+
+	// Forged or foreign cursors are rejected
+	_, err = signer.Decode(cursor[:len(cursor)-2] + "xx")
+	require.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	_, err = pagination.NewSigner("other-secret").Decode(cursor)
+	require.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	_, err = signer.Decode("not-a-cursor")
+	require.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
+
+func TestCursorSigner_Cursors(t *testing.T) {
+	signer := pagination.NewSigner("cursor-secret")
+	first := pagination.Keyset{Sort: "created_at desc", Value: time.Now(), ID: uuid.New()}
+	last := pagination.Keyset{Sort: "created_at desc", Value: time.Now(), ID: uuid.New()}
+
+	// First page: nothing before it
+	next, prev := signer.Cursors(nil, first, last, true)
+	require.NotEmpty(t, next)
+	require.Empty(t, prev)
+
+	// Last page reached going forward
+	after := &pagination.Keyset{Sort: "created_at desc", Value: time.Now(), ID: uuid.New()}
+	next, prev = signer.Cursors(after, first, last, false)
+	require.Empty(t, next)
+	require.NotEmpty(t, prev)
+
+	keyset, err := signer.Decode(prev)
+	require.NoError(t, err)
+	require.True(t, keyset.Prev)
+	require.Equal(t, first.ID, keyset.ID)
+
+	// Back at the first page going backward
+	before := &pagination.Keyset{Sort: "created_at desc", Value: time.Now(), ID: uuid.New(), Prev: true}
+	next, prev = signer.Cursors(before, first, last, false)
+	require.NotEmpty(t, next)
+	require.Empty(t, prev)
+}
+
+func TestPaginationTrim(t *testing.T) {
+	rows, more := pagination.Trim([]int{1, 2, 3}, 2, false)
+	require.Equal(t, []int{1, 2}, rows)
+	require.True(t, more)
+
+	rows, more = pagination.Trim([]int{1, 2, 3}, 2, true)
+	require.Equal(t, []int{2, 3}, rows)
+	require.True(t, more)
+
+	rows, more = pagination.Trim([]int{1, 2}, 2, false)
+	require.Equal(t, []int{1, 2}, rows)
+	require.False(t, more)
+}
+
+func TestUserRepository_FindAllKeyset(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewUserRepository(gormDB)
+
+	at := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cursorID := uuid.New()
+	paging := repository.Paging{
+		Limit:     2,
+		SortBy:    "created_at",
+		SortOrder: "desc",
+		Keyset:    true,
+		Cursor:    &pagination.Keyset{Sort: "created_at desc", Value: at, ID: cursorID},
+		SkipTotal: true,
+	}
+
+	// No count query: the client opted out of the total
+	sqlMock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "users" WHERE (users.created_at, users.id) < ($1, $2) AND "users"."deleted_at" IS NULL ORDER BY users.created_at DESC,users.id DESC LIMIT $3`)).
+		WithArgs(at, cursorID, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+	users, total, err := repo.FindAll(context.Background(), paging, "", "", nil)
+	require.NoError(t, err)
+	require.Zero(t, total)
+	require.Len(t, users, 1)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserRepository_FindAllKeysetBackward(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewUserRepository(gormDB)
+
+	at := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cursorID := uuid.New()
+	paging := repository.Paging{
+		Limit:     2,
+		SortBy:    "created_at",
+		SortOrder: "desc",
+		Keyset:    true,
+		Cursor:    &pagination.Keyset{Sort: "created_at desc", Value: at, ID: cursorID, Prev: true},
+	}
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	// Rows before the cursor are fetched nearest first, then put back in order
+	newer, older := uuid.New(), uuid.New()
+	sqlMock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "users" WHERE "users"."deleted_at" IS NULL AND (users.created_at, users.id) > ($1, $2) ORDER BY users.created_at ASC,users.id ASC LIMIT $3`)).
+		WithArgs(at, cursorID, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(older).AddRow(newer))
+
+	users, total, err := repo.FindAll(context.Background(), paging, "", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), total)
+	require.Len(t, users, 2)
+	require.Equal(t, newer, users[0].ID)
+	require.Equal(t, older, users[1].ID)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
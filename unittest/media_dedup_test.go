@@ -0,0 +1,300 @@
+package unittest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryMediaBlobRepository is an in-memory stand-in for the media_blobs table
+type memoryMediaBlobRepository struct {
+	mu    sync.Mutex
+	blobs map[string]*entity.MediaBlob
+	// beforeReference runs ahead of referencing an existing blob, to
+	// interleave other work between an upload deciding to reuse a file and
+	// referencing it
+	beforeReference func()
+}
+
+func (r *memoryMediaBlobRepository) FindByHash(ctx context.Context, hash string) (*entity.MediaBlob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	blob, ok := r.blobs[hash]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	copied := *blob
+	return &copied, nil
+}
+
+func (r *memoryMediaBlobRepository) Acquire(ctx context.Context, blob *entity.MediaBlob) (*entity.MediaBlob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.blobs[blob.Hash]
+	if !ok {
+		copied := *blob
+		stored = &copied
+		r.blobs[blob.Hash] = stored
+	}
+	stored.RefCount++
+	copied := *stored
+	return &copied, nil
+}
+
+func (r *memoryMediaBlobRepository) Reference(ctx context.Context, hash string) (bool, error) {
+	if before := r.beforeReference; before != nil {
+		r.beforeReference = nil
+		before()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	blob, ok := r.blobs[hash]
+	if ok {
+		blob.RefCount++
+	}
+	return ok, nil
+}
+
+func (r *memoryMediaBlobRepository) Release(ctx context.Context, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	blob, ok := r.blobs[hash]
+	if !ok {
+		return true, nil
+	}
+	if blob.RefCount--; blob.RefCount > 0 {
+		return false, nil
+	}
+	delete(r.blobs, hash)
+	return true, nil
+}
+
+func (r *memoryMediaBlobRepository) refCount(hash string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if blob, ok := r.blobs[hash]; ok {
+		return blob.RefCount
+	}
+	return 0
+}
+
+func (r *memoryMediaRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.media, id)
+	return nil
+}
+
+func (r *memoryMediaRepository) FindUnhashed(ctx context.Context, afterID uuid.UUID, limit int) ([]*entity.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var medias []*entity.Media
+	for _, media := range r.media {
+		if media.ContentHash == "" && bytes.Compare(media.ID[:], afterID[:]) > 0 {
+			copied := *media
+			medias = append(medias, &copied)
+		}
+	}
+	sort.Slice(medias, func(i, j int) bool { return bytes.Compare(medias[i].ID[:], medias[j].ID[:]) < 0 })
+	if len(medias) > limit {
+		medias = medias[:limit]
+	}
+	return medias, nil
+}
+
+func (r *memoryMediaRepository) SetContent(ctx context.Context, id uuid.UUID, hash, path, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	media := r.media[id]
+	media.ContentHash, media.Path, media.URL = hash, path, url
+	return nil
+}
+
+// storedFiles lists every file under the storage root
+func storedFiles(t *testing.T, basePath string) []string {
+	var files []string
+	err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(basePath, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return err
+	})
+	require.NoError(t, err)
+	sort.Strings(files)
+	return files
+}
+
+func runJobs(t *testing.T, q *service.JobQueue) {
+	for {
+		ran, err := q.RunNext(context.Background())
+		require.NoError(t, err)
+		if !ran {
+			return
+		}
+	}
+}
+
+func TestMediaService_DeduplicatesIdenticalUploads(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	user := slotUser()
+	photo := jpegBytes(t, 320, 240)
+
+	file, header := upload(photo, "first.jpg", "image/jpeg")
+	first, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.NoError(t, err)
+	runJobs(t, f.queue)
+
+	file, header = upload(photo, "second.jpg", "image/jpeg")
+	second, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.NoError(t, err)
+	runJobs(t, f.queue)
+
+	require.NotEqual(t, first.ID, second.ID)
+	require.Equal(t, "second.jpg", second.OriginalName)
+
+	stored, err := f.media.FindByID(ctx, second.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.ContentHash, 64)
+	require.Equal(t, 2, f.blobs.refCount(stored.ContentHash))
+
+	// One original and one thumbnail, shared by both rows
+	files := storedFiles(t, f.basePath)
+	require.Len(t, files, 2)
+	require.Contains(t, files, stored.Path)
+
+	// Different content is stored separately
+	file, header = upload(jpegBytes(t, 64, 64), "other.jpg", "image/jpeg")
+	other, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.NoError(t, err)
+	runJobs(t, f.queue)
+	require.Len(t, storedFiles(t, f.basePath), 4)
+	require.NoError(t, f.service.Delete(ctx, other.ID, user))
+	require.Len(t, storedFiles(t, f.basePath), 2)
+
+	// The shared file stays until the last row referencing it is deleted
+	require.NoError(t, f.service.Delete(ctx, first.ID, user))
+	require.Equal(t, files, storedFiles(t, f.basePath))
+	require.Equal(t, 1, f.blobs.refCount(stored.ContentHash))
+
+	require.NoError(t, f.service.Delete(ctx, second.ID, user))
+	require.Empty(t, storedFiles(t, f.basePath))
+	require.Equal(t, 0, f.blobs.refCount(stored.ContentHash))
+}
+
+func TestMediaService_UploadOutlivesDeleteOfReusedFile(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	user := slotUser()
+	photo := jpegBytes(t, 320, 240)
+
+	file, header := upload(photo, "first.jpg", "image/jpeg")
+	first, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.NoError(t, err)
+
+	// The only other copy is deleted after the upload found it, but before
+	// the upload took its reference
+	f.blobs.beforeReference = func() {
+		require.NoError(t, f.service.Delete(ctx, first.ID, user))
+	}
+	file, header = upload(photo, "second.jpg", "image/jpeg")
+	second, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.NoError(t, err)
+
+	// The upload stored the file again instead of pointing at the deleted one
+	stored, err := f.media.FindByID(ctx, second.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, first.Path, stored.Path)
+	require.Equal(t, []string{stored.Path}, storedFiles(t, f.basePath))
+	require.Equal(t, 1, f.blobs.refCount(stored.ContentHash))
+}
+
+func TestMediaMaintenance_BackfillHashes(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	store := storage.NewLocalStorage(f.basePath, "/uploads")
+	maintenance := newMaintenanceService(t, f, &memoryAvatarRepository{})
+
+	// Files uploaded before hashing: two copies of one photo and another
+	photo := jpegBytes(t, 32, 32)
+	seed := func(id, path string, content []byte, variants ...string) *entity.Media {
+		_, err := store.Put(ctx, bytes.NewReader(content), path, "image/jpeg")
+		require.NoError(t, err)
+
+		media := &entity.Media{Path: path, URL: store.GetURL(path), Size: int64(len(content)), MimeType: "image/jpeg"}
+		media.ID = uuid.MustParse(id)
+		for _, v := range variants {
+			_, err := store.Put(ctx, bytes.NewReader(content), v, "image/jpeg")
+			require.NoError(t, err)
+			media.Variants = append(media.Variants, entity.MediaVariant{Name: "thumb", Path: v})
+		}
+		f.media.media[media.ID] = media
+		return media
+	}
+	original := seed("00000000-0000-0000-0000-000000000001", "posts/a.jpg", photo, "posts/a_thumb.jpg")
+	duplicate := seed("00000000-0000-0000-0000-000000000002", "posts/b.jpg", photo, "posts/b_thumb.jpg")
+	other := seed("00000000-0000-0000-0000-000000000003", "posts/c.jpg", jpegBytes(t, 16, 16))
+	missing := &entity.Media{Path: "posts/gone.jpg"}
+	missing.ID = uuid.MustParse("00000000-0000-0000-0000-000000000004")
+	f.media.media[missing.ID] = missing
+
+	result, err := maintenance.BackfillHashes(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, &dto.MediaBackfillResult{Hashed: 3, Merged: 1, Missing: 1, BytesFreed: int64(len(photo))}, result)
+
+	// The duplicate now points at the first copy, and its own files are gone
+	merged, _ := f.media.FindByID(ctx, duplicate.ID)
+	kept, _ := f.media.FindByID(ctx, original.ID)
+	require.Equal(t, kept.ContentHash, merged.ContentHash)
+	require.Equal(t, "posts/a.jpg", merged.Path)
+	require.Empty(t, merged.Variants)
+	require.Equal(t, 2, f.blobs.refCount(kept.ContentHash))
+	require.Equal(t, []string{"posts/a.jpg", "posts/a_thumb.jpg", "posts/c.jpg"}, storedFiles(t, f.basePath))
+
+	// Its variants are rendered again by the job workers
+	_, err = f.jobs.FindActiveByUniqueKey(ctx, service.JobMediaVariants+":"+duplicate.ID.String())
+	require.NoError(t, err)
+
+	separate, _ := f.media.FindByID(ctx, other.ID)
+	require.NotEqual(t, kept.ContentHash, separate.ContentHash)
+	require.Equal(t, 1, f.blobs.refCount(separate.ContentHash))
+
+	// Running again only retries the file that could not be read
+	result, err = maintenance.BackfillHashes(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, &dto.MediaBackfillResult{Missing: 1}, result)
+}
+
+func TestMediaBlobRepository_ReferenceOnlyExisting(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewMediaBlobRepository(gormDB)
+
+	// A blob deleted by its last release isn't brought back
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE "media_blobs" SET "ref_count"=ref_count + 1,"updated_at"=$1 WHERE hash = $2`)).
+		WithArgs(sqlmock.AnyArg(), "abc").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+
+	referenced, err := repo.Reference(context.Background(), "abc")
+	require.NoError(t, err)
+	require.False(t, referenced)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
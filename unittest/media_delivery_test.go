@@ -0,0 +1,180 @@
+package unittest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/handler"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *memoryMediaRepository) FindByFilePath(ctx context.Context, path string) ([]*entity.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var medias []*entity.Media
+	for _, media := range r.media {
+		match := media.Path == path
+		for _, v := range media.Variants {
+			match = match || v.Path == path
+		}
+		if match {
+			copied := *media
+			medias = append(medias, &copied)
+		}
+	}
+	return medias, nil
+}
+
+// deliveryRouter mounts the file routes; viewer stands in for the session
+func deliveryRouter(f *uploadSlotFixture, viewer **entity.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := handler.NewMediaHandler(f.service)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if *viewer != nil {
+			c.Set("user", *viewer)
+		}
+	})
+	router.GET("/uploads/*filepath", h.ServeFile)
+	router.HEAD("/uploads/*filepath", h.ServeFile)
+	router.GET("/media/:id/file-url", h.GetFileURL)
+	router.GET("/media/:id/resize-url", h.GetResizeURL)
+	return router
+}
+
+func serve(router *gin.Engine, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMediaDelivery_PublicFile(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	var viewer *entity.User
+	router := deliveryRouter(f, &viewer)
+	user := slotUser()
+
+	file, header := upload(jpegBytes(t, 320, 240), "Strand Ümlaut.jpg", "image/jpeg")
+	media, err := f.service.Upload(context.Background(), user, file, header, &dto.UploadMediaRequest{})
+	require.NoError(t, err)
+	runJobs(t, f.queue)
+	stored := f.media.media[media.ID]
+
+	rec := serve(router, http.MethodGet, "/uploads/"+stored.Path, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "public, max-age=31536000, immutable", rec.Header().Get("Cache-Control"))
+	require.Equal(t, `"`+stored.ContentHash+`"`, rec.Header().Get("ETag"))
+	require.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
+	require.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+	require.Contains(t, rec.Header().Get("Content-Disposition"), "inline;")
+	size := rec.Body.Len()
+
+	rec = serve(router, http.MethodGet, "/uploads/"+stored.Path, map[string]string{"Range": "bytes=0-9"})
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+	require.Equal(t, fmt.Sprintf("bytes 0-9/%d", size), rec.Header().Get("Content-Range"))
+	require.Equal(t, 10, rec.Body.Len())
+
+	rec = serve(router, http.MethodGet, "/uploads/"+stored.Path, map[string]string{"If-None-Match": rec.Header().Get("ETag")})
+	require.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = serve(router, http.MethodGet, "/uploads/"+stored.Path+"?download=1", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "attachment; filename*=utf-8''Strand%20%C3%9Cmlaut.jpg", rec.Header().Get("Content-Disposition"))
+
+	// Variants may be rendered again, so they are revalidated
+	thumb, ok := stored.Variants.Variant("thumb")
+	require.True(t, ok)
+	rec = serve(router, http.MethodGet, "/uploads/"+thumb.Path+"?download=1", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "public, max-age=86400", rec.Header().Get("Cache-Control"))
+	require.Contains(t, rec.Header().Get("ETag"), "-thumb-150x150-")
+	require.Contains(t, rec.Header().Get("Content-Disposition"), "Strand%20%C3%9Cmlaut-thumb.jpg")
+
+	// Files no media refers to are not served
+	require.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/uploads/posts/unknown.jpg", nil).Code)
+	require.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/uploads/../secret", nil).Code)
+}
+
+func TestMediaDelivery_DraftFile(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	var viewer *entity.User
+	router := deliveryRouter(f, &viewer)
+	owner, stranger := slotUser(), slotUser()
+	post := f.posts.add(owner.ID)
+
+	file, header := upload(jpegBytes(t, 64, 48), "draft.jpg", "image/jpeg")
+	media, err := f.service.Upload(context.Background(), owner, file, header, &dto.UploadMediaRequest{PostID: &post.ID})
+	require.NoError(t, err)
+	stored := f.media.media[media.ID]
+	stored.Post = &entity.Post{AuthorID: owner.ID, Status: entity.PostStatusDraft}
+	target := "/uploads/" + stored.Path
+
+	require.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, target, nil).Code)
+
+	viewer = stranger
+	require.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, target, nil).Code)
+	require.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, "/media/"+media.ID.String()+"/file-url", nil).Code)
+
+	viewer = owner
+	rec := serve(router, http.MethodGet, target, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+
+	signed, err := f.service.SignFileURL(context.Background(), media.ID, "", true, owner)
+	require.NoError(t, err)
+	require.NotNil(t, signed.ExpiresAt)
+
+	// The signed link works without a session
+	viewer = nil
+	rec = serve(router, http.MethodGet, signed.URL, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "private, no-cache", rec.Header().Get("Cache-Control"))
+	require.Equal(t, `attachment; filename=draft.jpg`, rec.Header().Get("Content-Disposition"))
+
+	rec = serve(router, http.MethodGet, target+"?expires=9999999999&sig=forged", nil)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Published, it is public again
+	stored.Post.Status = entity.PostStatusPublished
+	rec = serve(router, http.MethodGet, target, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "public, max-age=31536000, immutable", rec.Header().Get("Cache-Control"))
+
+	_, err = f.service.SignFileURL(context.Background(), uuid.New(), "", false, owner)
+	require.EqualError(t, err, "media not found")
+}
+
+func TestMediaDelivery_DraftResizeURL(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	var viewer *entity.User
+	router := deliveryRouter(f, &viewer)
+	owner, stranger := slotUser(), slotUser()
+	post := f.posts.add(owner.ID)
+
+	file, header := upload(jpegBytes(t, 64, 48), "draft.jpg", "image/jpeg")
+	media, err := f.service.Upload(context.Background(), owner, file, header, &dto.UploadMediaRequest{PostID: &post.ID})
+	require.NoError(t, err)
+	f.media.media[media.ID].Post = &entity.Post{AuthorID: owner.ID, Status: entity.PostStatusDraft}
+	target := "/media/" + media.ID.String() + "/resize-url?w=32"
+
+	// Images of drafts are not signed for anyone but their owner
+	require.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, target, nil).Code)
+
+	viewer = stranger
+	require.Equal(t, http.StatusNotFound, serve(router, http.MethodGet, target, nil).Code)
+
+	viewer = owner
+	require.Equal(t, http.StatusOK, serve(router, http.MethodGet, target, nil).Code)
+}
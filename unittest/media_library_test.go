@@ -0,0 +1,306 @@
+package unittest
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// memoryMediaFolderRepository is an in-memory stand-in for the media_folders
+// table; it counts and unfiles media in the fixture's media repository
+type memoryMediaFolderRepository struct {
+	mu      sync.Mutex
+	folders map[uuid.UUID]*entity.MediaFolder
+	media   *memoryMediaRepository
+}
+
+func (r *memoryMediaFolderRepository) Create(ctx context.Context, folder *entity.MediaFolder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	folder.ID = uuid.New()
+	copied := *folder
+	r.folders[folder.ID] = &copied
+	return nil
+}
+
+func (r *memoryMediaFolderRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.MediaFolder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	folder, ok := r.folders[id]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return r.counted(folder), nil
+}
+
+func (r *memoryMediaFolderRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]*entity.MediaFolder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var folders []*entity.MediaFolder
+	for _, folder := range r.folders {
+		if folder.UserID == userID {
+			folders = append(folders, r.counted(folder))
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Name < folders[j].Name })
+	return folders, nil
+}
+
+func (r *memoryMediaFolderRepository) ExistsByName(ctx context.Context, userID uuid.UUID, name string, excludeID *uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, folder := range r.folders {
+		if folder.UserID == userID && strings.EqualFold(folder.Name, name) && (excludeID == nil || folder.ID != *excludeID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryMediaFolderRepository) Update(ctx context.Context, folder *entity.MediaFolder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *folder
+	r.folders[folder.ID] = &copied
+	return nil
+}
+
+func (r *memoryMediaFolderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.media.MoveToFolder(ctx, r.media.inFolder(id), nil)
+	delete(r.folders, id)
+	return nil
+}
+
+func (r *memoryMediaFolderRepository) counted(folder *entity.MediaFolder) *entity.MediaFolder {
+	copied := *folder
+	copied.MediaCount = int64(len(r.media.inFolder(folder.ID)))
+	return &copied
+}
+
+func (r *memoryMediaRepository) inFolder(folderID uuid.UUID) []uuid.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uuid.UUID
+	for _, media := range r.media {
+		if media.FolderID != nil && *media.FolderID == folderID {
+			ids = append(ids, media.ID)
+		}
+	}
+	return ids
+}
+
+func (r *memoryMediaRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]*entity.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var medias []*entity.Media
+	for _, id := range ids {
+		if media, ok := r.media[id]; ok {
+			copied := *media
+			medias = append(medias, &copied)
+		}
+	}
+	return medias, nil
+}
+
+func (r *memoryMediaRepository) SetTags(ctx context.Context, id uuid.UUID, tags []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.media[id].Tags = tags
+	return nil
+}
+
+func (r *memoryMediaRepository) MoveToFolder(ctx context.Context, ids []uuid.UUID, folderID *uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		r.media[id].FolderID = folderID
+	}
+	return nil
+}
+
+// libraryMedia adds a media row owned by userID
+func libraryMedia(f *uploadSlotFixture, userID uuid.UUID, tags ...string) uuid.UUID {
+	media := &entity.Media{UserID: userID, Tags: tags}
+	media.ID = uuid.New()
+	f.media.media[media.ID] = media
+	return media.ID
+}
+
+func TestMediaLibrary_Folders(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	owner, other := slotUser(), slotUser()
+
+	folder, err := f.service.CreateFolder(ctx, &dto.CreateMediaFolderRequest{Name: " Heroes "}, owner)
+	require.NoError(t, err)
+	require.Equal(t, "Heroes", folder.Name)
+
+	_, err = f.service.CreateFolder(ctx, &dto.CreateMediaFolderRequest{Name: "heroes"}, owner)
+	require.EqualError(t, err, "folder name already exists")
+
+	// Same name is fine for someone else, whose folders stay hidden
+	_, err = f.service.CreateFolder(ctx, &dto.CreateMediaFolderRequest{Name: "Heroes"}, other)
+	require.NoError(t, err)
+	_, err = f.service.UpdateFolder(ctx, folder.ID, &dto.UpdateMediaFolderRequest{Name: "Mine"}, other)
+	require.EqualError(t, err, "folder not found")
+
+	// Uploads go into the uploader's folders, with normalised tags
+	file, header := upload(jpegBytes(t, 32, 32), "hero.jpg", "image/jpeg")
+	media, err := f.service.Upload(ctx, owner, file, header, &dto.UploadMediaRequest{
+		FolderID: &folder.ID,
+		Tags:     []string{" Hero", "hero", "Banner "},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &folder.ID, media.FolderID)
+	require.Equal(t, []string{"hero", "banner"}, media.Tags)
+
+	file, header = upload(jpegBytes(t, 32, 32), "sneaky.jpg", "image/jpeg")
+	_, err = f.service.Upload(ctx, other, file, header, &dto.UploadMediaRequest{FolderID: &folder.ID})
+	require.EqualError(t, err, "folder not found")
+
+	folders, err := f.service.GetFolders(ctx, nil, owner)
+	require.NoError(t, err)
+	require.Len(t, folders, 1)
+	require.EqualValues(t, 1, folders[0].MediaCount)
+
+	_, err = f.service.GetFolders(ctx, &owner.ID, other)
+	require.EqualError(t, err, "you can only view your own media")
+
+	// Deleting a folder keeps its media, unfiled
+	require.NoError(t, f.service.DeleteFolder(ctx, folder.ID, owner))
+	stored, err := f.media.FindByID(ctx, media.ID)
+	require.NoError(t, err)
+	require.Nil(t, stored.FolderID)
+	folders, err = f.service.GetFolders(ctx, nil, owner)
+	require.NoError(t, err)
+	require.Empty(t, folders)
+}
+
+func TestMediaLibrary_BulkActions(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	owner, other := slotUser(), slotUser()
+	admin := slotUser()
+	admin.Role = entity.RoleAdmin
+
+	first := libraryMedia(f, owner.ID, "old")
+	second := libraryMedia(f, owner.ID)
+	others := libraryMedia(f, other.ID)
+	missing := uuid.New()
+
+	result, err := f.service.BulkUpdate(ctx, &dto.BulkMediaRequest{
+		Action:   dto.MediaBulkTag,
+		MediaIDs: []uuid.UUID{first, second, others, missing, first},
+		Tags:     []string{"Summer", "old"},
+	}, owner)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{first, second}, result.Succeeded)
+	require.Equal(t, []dto.BulkMediaFailure{
+		{ID: others, Error: "you don't have permission to change this media"},
+		{ID: missing, Error: "media not found"},
+	}, result.Failed)
+	require.Equal(t, []string{"old", "summer"}, []string(f.media.media[first].Tags))
+	require.Equal(t, []string{"summer", "old"}, []string(f.media.media[second].Tags))
+	require.Empty(t, f.media.media[others].Tags)
+
+	result, err = f.service.BulkUpdate(ctx, &dto.BulkMediaRequest{
+		Action:   dto.MediaBulkUntag,
+		MediaIDs: []uuid.UUID{first, second},
+		Tags:     []string{"OLD"},
+	}, owner)
+	require.NoError(t, err)
+	require.Len(t, result.Succeeded, 2)
+	require.Equal(t, []string{"summer"}, []string(f.media.media[first].Tags))
+
+	_, err = f.service.BulkUpdate(ctx, &dto.BulkMediaRequest{Action: dto.MediaBulkTag, MediaIDs: []uuid.UUID{first}}, owner)
+	require.EqualError(t, err, "tags are required")
+
+	// Folders only take their owner's media, even from an admin
+	folder, err := f.service.CreateFolder(ctx, &dto.CreateMediaFolderRequest{Name: "Summer"}, owner)
+	require.NoError(t, err)
+	result, err = f.service.BulkUpdate(ctx, &dto.BulkMediaRequest{
+		Action:   dto.MediaBulkMove,
+		MediaIDs: []uuid.UUID{first, others},
+		FolderID: &folder.ID,
+	}, admin)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{first}, result.Succeeded)
+	require.Equal(t, "folder belongs to another user", result.Failed[0].Error)
+	require.Equal(t, &folder.ID, f.media.media[first].FolderID)
+	require.Nil(t, f.media.media[others].FolderID)
+
+	_, err = f.service.BulkUpdate(ctx, &dto.BulkMediaRequest{
+		Action:   dto.MediaBulkMove,
+		MediaIDs: []uuid.UUID{first},
+		FolderID: &folder.ID,
+	}, other)
+	require.EqualError(t, err, "folder not found")
+
+	// A null folder takes media out of its folder
+	_, err = f.service.BulkUpdate(ctx, &dto.BulkMediaRequest{Action: dto.MediaBulkMove, MediaIDs: []uuid.UUID{first}}, owner)
+	require.NoError(t, err)
+	require.Nil(t, f.media.media[first].FolderID)
+
+	result, err = f.service.BulkUpdate(ctx, &dto.BulkMediaRequest{
+		Action:   dto.MediaBulkDelete,
+		MediaIDs: []uuid.UUID{second, others},
+	}, owner)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{second}, result.Succeeded)
+	require.NotContains(t, f.media.media, second)
+	require.Contains(t, f.media.media, others)
+}
+
+func TestMediaRepository_FindAllFilters(t *testing.T) {
+	dbMock, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer dbMock.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: dbMock,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	repo := repository.NewMediaRepository(gormDB)
+	folderID := uuid.New()
+	minWidth, maxHeight := 800, 1200
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT count(*) FROM "media" WHERE folder_id = $1 AND (original_name ILIKE $2 OR alt_text ILIKE $3 OR description ILIKE $4) AND tags @> $5 AND width >= $6 AND height <= $7 AND created_at >= $8 AND created_at < $9 AND "media"."deleted_at" IS NULL`)).
+		WithArgs(folderID, "%beach%", "%beach%", "%beach%", sqlmock.AnyArg(), minWidth, maxHeight, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "media" WHERE folder_id = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	medias, total, err := repo.FindAll(context.Background(), repository.Paging{Page: 1, Limit: 10}, &repository.MediaFilter{
+		FolderID:  &folderID,
+		Search:    "beach",
+		Tags:      []string{"summer", "sea"},
+		MinWidth:  &minWidth,
+		MaxHeight: &maxHeight,
+		From:      &from,
+		To:        &to,
+	})
+	require.NoError(t, err)
+	require.Zero(t, total)
+	require.Empty(t, medias)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,310 @@
+package unittest
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	imgpkg "github.com/afdhali/GolangBlogpostServer/pkg/image"
+	"github.com/afdhali/GolangBlogpostServer/pkg/mediafile"
+	"github.com/stretchr/testify/require"
+)
+
+// box builds an ISO base media box
+func box(kind string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, kind...), body...)
+}
+
+// mp4Bytes is a movie of the given length with one video track
+func mp4Bytes(duration time.Duration, width, height int) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000) // timescale
+	binary.BigEndian.PutUint32(mvhd[16:], uint32(duration.Milliseconds()))
+
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], uint32(width)<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], uint32(height)<<16)
+
+	return append(
+		box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41")),
+		box("moov", box("mvhd", mvhd), box("trak", box("tkhd", tkhd)))...,
+	)
+}
+
+// ebml builds a Matroska element; sizes are always written in 8 bytes
+func ebml(id uint32, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	var out []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(out) > 0 {
+			out = append(out, b)
+		}
+	}
+	out = binary.BigEndian.AppendUint64(out, 1<<56|uint64(len(body)))
+	return append(out, body...)
+}
+
+func webmBytes(duration time.Duration, width, height int) []byte {
+	durationTicks := binary.BigEndian.AppendUint64(nil, math.Float64bits(float64(duration.Milliseconds())))
+	return append(
+		ebml(0x1A45DFA3, ebml(0x4282, []byte("webm"))),
+		ebml(0x18538067,
+			ebml(0x1549A966, ebml(0x2AD7B1, []byte{0x0F, 0x42, 0x40}), ebml(0x4489, durationTicks)),
+			ebml(0x1654AE6B, ebml(0xAE, ebml(0xE0, ebml(0xB0, []byte{byte(width >> 8), byte(width)}), ebml(0xBA, []byte{byte(height >> 8), byte(height)})))),
+			ebml(0x1F43B675, make([]byte, 64)),
+		)...,
+	)
+}
+
+// mp3Bytes is frames of 128 kbit/s, 44.1 kHz MPEG-1 Layer III
+func mp3Bytes(frames int, xingFrames int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+
+	var out []byte
+	out = append(out, "ID3\x03\x00\x00\x00\x00\x00\x0A"...) // 10-byte tag
+	out = append(out, make([]byte, 10)...)
+	for i := 0; i < frames; i++ {
+		f := append([]byte(nil), frame...)
+		if i == 0 && xingFrames > 0 {
+			copy(f[36:], "Xing\x00\x00\x00\x01")
+			binary.BigEndian.PutUint32(f[44:], uint32(xingFrames))
+		}
+		out = append(out, f...)
+	}
+	return out
+}
+
+func wavBytes(duration time.Duration) []byte {
+	const byteRate = 16000 // 8 kHz, 16-bit mono
+	data := make([]byte, int(duration.Seconds()*byteRate))
+
+	out := []byte("RIFF")
+	out = binary.LittleEndian.AppendUint32(out, uint32(36+len(data)))
+	out = append(out, "WAVEfmt "...)
+	out = binary.LittleEndian.AppendUint32(out, 16)
+	out = binary.LittleEndian.AppendUint16(out, 1) // PCM
+	out = binary.LittleEndian.AppendUint16(out, 1)
+	out = binary.LittleEndian.AppendUint32(out, 8000)
+	out = binary.LittleEndian.AppendUint32(out, byteRate)
+	out = binary.LittleEndian.AppendUint16(out, 2)
+	out = binary.LittleEndian.AppendUint16(out, 16)
+	out = append(out, "data"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(data)))
+	return append(out, data...)
+}
+
+func oggPage(granule uint64, packet []byte) []byte {
+	out := []byte("OggS\x00\x00")
+	out = binary.LittleEndian.AppendUint64(out, granule)
+	out = binary.LittleEndian.AppendUint32(out, 7) // serial
+	out = append(out, make([]byte, 8)...)          // sequence, checksum
+	out = append(out, 1, byte(len(packet)))
+	return append(out, packet...)
+}
+
+func opusBytes(duration time.Duration) []byte {
+	head := []byte("OpusHead\x01\x01")
+	head = binary.LittleEndian.AppendUint16(head, 312) // pre-skip
+	head = binary.LittleEndian.AppendUint32(head, 48000)
+	head = append(head, 0, 0, 0)
+
+	samples := uint64(duration.Seconds()*48000) + 312
+	return append(append(oggPage(0, head), oggPage(0, make([]byte, 100))...), oggPage(samples, make([]byte, 100))...)
+}
+
+func pdfBytes(pages int) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	fmt.Fprintf(&b, "2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count %d /Resources << /Font << >> >> >>\nendobj\n", pages)
+	b.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R >>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+// pdfObjectStreamBytes keeps the page tree in a compressed object stream
+func pdfObjectStreamBytes(pages int) []byte {
+	var inner bytes.Buffer
+	zw := zlib.NewWriter(&inner)
+	fmt.Fprintf(zw, "2 0 << /Type /Pages /Kids [3 0 R] /Count %d >>", pages)
+	zw.Close()
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	fmt.Fprintf(&b, "5 0 obj\n<< /Type /ObjStm /N 1 /First 4 /Filter /FlateDecode /Length %d >>\nstream\n", inner.Len())
+	b.Write(inner.Bytes())
+	b.WriteString("\nendstream\nendobj\n%%EOF\n")
+	return b.Bytes()
+}
+
+func probe(t *testing.T, data []byte, mimeType string) *mediafile.Info {
+	info, err := mediafile.Probe(bytes.NewReader(data), int64(len(data)), mimeType)
+	require.NoError(t, err)
+	return info
+}
+
+func TestMediafile_Detect(t *testing.T) {
+	require.Equal(t, "video/mp4", mediafile.Detect(mp4Bytes(time.Second, 16, 16)))
+	require.Equal(t, "video/webm", mediafile.Detect(webmBytes(time.Second, 16, 16)))
+	require.Equal(t, "audio/mpeg", mediafile.Detect(mp3Bytes(2, 0)))
+	require.Equal(t, "audio/mpeg", mediafile.Detect(mp3Bytes(2, 0)[20:])) // no ID3 tag
+	require.Equal(t, "audio/wav", mediafile.Detect(wavBytes(time.Second)))
+	require.Equal(t, "audio/ogg", mediafile.Detect(opusBytes(time.Second)))
+	require.Equal(t, "application/pdf", mediafile.Detect(pdfBytes(1)))
+
+	require.Empty(t, mediafile.Detect(jpegBytes(t, 8, 8)))
+	require.Empty(t, mediafile.Detect([]byte("plain text")))
+}
+
+func TestMediafile_Probe(t *testing.T) {
+	info := probe(t, mp4Bytes(12500*time.Millisecond, 1280, 720), "video/mp4")
+	require.Equal(t, 12500*time.Millisecond, info.Duration)
+	require.Equal(t, [2]int{1280, 720}, [2]int{info.Width, info.Height})
+
+	info = probe(t, webmBytes(4500*time.Millisecond, 640, 360), "video/webm")
+	require.Equal(t, 4500*time.Millisecond, info.Duration)
+	require.Equal(t, [2]int{640, 360}, [2]int{info.Width, info.Height})
+
+	// Constant bitrate: 100 frames of 417 bytes at 128 kbit/s
+	info = probe(t, mp3Bytes(100, 0), "audio/mpeg")
+	require.InDelta(t, 2.606, info.Duration.Seconds(), 0.01)
+
+	// VBR header: 1000 frames of 1152 samples at 44.1 kHz
+	info = probe(t, mp3Bytes(10, 1000), "audio/mpeg")
+	require.InDelta(t, 26.12, info.Duration.Seconds(), 0.01)
+
+	require.Equal(t, 2*time.Second, probe(t, wavBytes(2*time.Second), "audio/x-wav").Duration)
+	require.Equal(t, 3*time.Second, probe(t, opusBytes(3*time.Second), "audio/ogg").Duration)
+
+	require.Equal(t, 12, probe(t, pdfBytes(12), "application/pdf").Pages)
+	require.Equal(t, 7, probe(t, pdfObjectStreamBytes(7), "application/pdf").Pages)
+}
+
+func TestMediafile_ProbeRejectsMalformedFiles(t *testing.T) {
+	truncated := pdfBytes(3)
+	truncated = truncated[:len(truncated)-10]
+
+	for name, tc := range map[string]struct {
+		data     []byte
+		mimeType string
+	}{
+		"mp4 without moov":   {box("ftyp", []byte("isom")), "video/mp4"},
+		"mp4 box overrun":    {mp4Bytes(time.Second, 16, 16)[:60], "video/mp4"},
+		"matroska, not webm": {bytes.Replace(webmBytes(time.Second, 16, 16), []byte("webm"), []byte("mkv!"), 1), "video/webm"},
+		"truncated pdf":      {truncated, "application/pdf"},
+		"wav without data":   {wavBytes(time.Second)[:36], "audio/wav"},
+	} {
+		_, err := mediafile.Probe(bytes.NewReader(tc.data), int64(len(tc.data)), tc.mimeType)
+		require.ErrorIs(t, err, mediafile.ErrMalformed, name)
+	}
+}
+
+func TestMediafile_Validator(t *testing.T) {
+	ctx := context.Background()
+	v := mediafile.NewValidator().
+		Allow(mediafile.KindVideo, 1, []string{"video/mp4"}).
+		Allow(mediafile.KindAudio, 1, []string{"audio/mpeg", "audio/wav"})
+
+	file, header := upload(mp4Bytes(3*time.Second, 320, 240), "clip.mp4", "video/mp4")
+	validated, err := v.Validate(ctx, file, header)
+	require.NoError(t, err)
+	require.Equal(t, mediafile.KindVideo, validated.Kind)
+	require.Equal(t, "video/mp4", validated.MimeType)
+	require.Equal(t, 3*time.Second, validated.Info.Duration)
+
+	// Browsers send audio/x-wav
+	file, header = upload(wavBytes(time.Second), "voice.wav", "audio/x-wav")
+	_, err = v.Validate(ctx, file, header)
+	require.NoError(t, err)
+
+	file, header = upload(mp4Bytes(time.Second, 16, 16), "clip.mp3", "video/mp4")
+	_, err = v.Validate(ctx, file, header)
+	requireFieldError(t, err, "type_mismatch", mediafile.ErrTypeMismatch)
+
+	file, header = upload(wavBytes(70*time.Second), "long.wav", "audio/wav")
+	_, err = v.Validate(ctx, file, header)
+	requireFieldError(t, err, "file_too_large", mediafile.ErrFileTooLarge)
+
+	// Documents are not allowed by this validator
+	file, header = upload(pdfBytes(1), "doc.pdf", "application/pdf")
+	_, err = v.Validate(ctx, file, header)
+	requireFieldError(t, err, "unsupported_type", mediafile.ErrUnsupportedType)
+
+	file, header = upload(mp4Bytes(time.Second, 16, 16)[:60], "cut.mp4", "video/mp4")
+	_, err = v.Validate(ctx, file, header)
+	requireFieldError(t, err, "corrupt_file", mediafile.ErrCorruptFile)
+
+	require.NoError(t, v.ValidateDeclared("clip.mp4", "video/mp4", 1024))
+	require.ErrorIs(t, v.ValidateDeclared("clip.mp4", "video/mp4", 2<<20), mediafile.ErrFileTooLarge)
+	require.ErrorIs(t, v.ValidateDeclared("clip.webm", "video/webm", 1024), mediafile.ErrUnsupportedType)
+	require.ErrorIs(t, v.ValidateDeclared("clip.mov", "video/mp4", 1024), mediafile.ErrTypeMismatch)
+}
+
+// stillThumbnailer renders a plain frame for anything
+type stillThumbnailer struct{}
+
+func (stillThumbnailer) Thumbnail(ctx context.Context, r io.Reader, mimeType string) (image.Image, error) {
+	io.Copy(io.Discard, r)
+	return solidImage(800, 600), nil
+}
+
+func TestMediaService_UploadsAudioAndDocuments(t *testing.T) {
+	f := newMediaFixture(t, stillThumbnailer{})
+	ctx := context.Background()
+	user := slotUser()
+
+	file, header := upload(wavBytes(2*time.Second), "voice.wav", "audio/wav")
+	audio, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{AltText: "voice memo"})
+	require.NoError(t, err)
+	require.Equal(t, string(entity.MediaTypeAudio), audio.MediaType)
+	require.Equal(t, "audio/wav", audio.MimeType)
+	require.Equal(t, 2.0, *audio.Duration)
+	require.Nil(t, audio.Width)
+
+	// Stored as uploaded
+	require.Equal(t, int64(len(wavBytes(2*time.Second))), audio.Size)
+
+	file, header = upload(pdfBytes(4), "slides.pdf", "application/pdf")
+	doc, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.NoError(t, err)
+	require.Equal(t, string(entity.MediaTypeDocument), doc.MediaType)
+	require.Equal(t, 4, *doc.PageCount)
+
+	// The variants job renders a poster and variants from the still
+	for {
+		ran, err := f.queue.RunNext(ctx)
+		require.NoError(t, err)
+		if !ran {
+			break
+		}
+	}
+	stored, err := f.media.FindByID(ctx, doc.ID)
+	require.NoError(t, err)
+
+	poster, ok := stored.Variants.Variant(entity.MediaVariantPoster)
+	require.True(t, ok)
+	require.Equal(t, "image/jpeg", poster.MimeType)
+	require.Equal(t, [2]int{800, 600}, [2]int{poster.Width, poster.Height})
+	thumb, ok := stored.Variants.Variant("thumb")
+	require.True(t, ok)
+	require.Equal(t, [2]int{150, 150}, [2]int{thumb.Width, thumb.Height})
+
+	response := dto.ToMediaResponse(stored)
+	require.Equal(t, poster.URL, response.Poster)
+	require.NotContains(t, response.Srcset, stored.URL)
+
+	// Anything else is still checked as an image
+	file, header = upload([]byte("plain text"), "notes.txt", "text/plain")
+	_, err = f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	requireFieldError(t, err, "unsupported_type", imgpkg.ErrInvalidImageType)
+}
@@ -0,0 +1,256 @@
+package unittest
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/broker"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryNotificationRepository keeps notifications and stored preferences
+type memoryNotificationRepository struct {
+	repository.NotificationRepository
+	mu            sync.Mutex
+	notifications []*entity.Notification
+	prefs         map[uuid.UUID]*entity.NotificationPreference
+}
+
+func (r *memoryNotificationRepository) CreateBatch(ctx context.Context, notifications []*entity.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range notifications {
+		n.ID = uuid.New()
+		r.notifications = append(r.notifications, n)
+	}
+	return nil
+}
+
+func (r *memoryNotificationRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, n := range r.notifications {
+		if n.UserID == userID && !n.IsRead() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryNotificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range r.notifications {
+		if n.ID == id && n.UserID == userID {
+			if n.ReadAt == nil {
+				now := time.Now()
+				n.ReadAt = &now
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryNotificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	now := time.Now()
+	for _, n := range r.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &now
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryNotificationRepository) FindPreferences(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*entity.NotificationPreference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prefs := make(map[uuid.UUID]*entity.NotificationPreference, len(userIDs))
+	for _, id := range userIDs {
+		if pref, ok := r.prefs[id]; ok {
+			copied := *pref
+			prefs[id] = &copied
+		} else {
+			prefs[id] = entity.DefaultNotificationPreference(id)
+		}
+	}
+	return prefs, nil
+}
+
+func (r *memoryNotificationRepository) SavePreference(ctx context.Context, pref *entity.NotificationPreference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *pref
+	r.prefs[pref.UserID] = &copied
+	return nil
+}
+
+// received lists the types of the notifications stored for userID
+func (r *memoryNotificationRepository) received(userID uuid.UUID) []entity.NotificationType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []entity.NotificationType
+	for _, n := range r.notifications {
+		if n.UserID == userID {
+			types = append(types, n.Type)
+		}
+	}
+	return types
+}
+
+func (r *memoryFollowRepository) FindFollowerIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uuid.UUID
+	for key := range r.follows {
+		if key[1] == userID {
+			ids = append(ids, key[0])
+		}
+	}
+	return ids, nil
+}
+
+type notificationFixture struct {
+	service       service.NotificationService
+	notifications *memoryNotificationRepository
+	follows       *memoryFollowRepository
+}
+
+func newNotificationFixture(t *testing.T) *notificationFixture {
+	log, err := logger.NewLogger(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { log.Close() })
+
+	notifications := &memoryNotificationRepository{prefs: make(map[uuid.UUID]*entity.NotificationPreference)}
+	follows := &memoryFollowRepository{follows: make(map[[2]uuid.UUID]bool)}
+	return &notificationFixture{
+		service:       service.NewNotificationService(notifications, follows, validator.NewValidator(), log, broker.NewMemoryBroker(0, 1)),
+		notifications: notifications,
+		follows:       follows,
+	}
+}
+
+// comment is a comment by userID on a post by authorID
+func comment(userID, authorID uuid.UUID) *entity.Comment {
+	c := &entity.Comment{UserID: userID, Post: &entity.Post{AuthorID: authorID, Title: "Post"}}
+	c.ID = uuid.New()
+	c.PostID = uuid.New()
+	return c
+}
+
+func TestNotificationService_SkipsSelfActions(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	author, reader := uuid.New(), uuid.New()
+
+	// The author replying to their own comment on their own post
+	own := comment(author, author)
+	f.service.NotifyComment(ctx, own, &entity.Comment{UserID: author})
+	require.Empty(t, f.notifications.received(author))
+
+	// A reply to the author's comment on their post notifies them once
+	f.service.NotifyComment(ctx, comment(reader, author), &entity.Comment{UserID: author})
+	require.Equal(t, []entity.NotificationType{entity.NotificationTypeCommentReply}, f.notifications.received(author))
+	require.Empty(t, f.notifications.received(reader))
+
+	// Publishing one's own post doesn't announce it to oneself
+	post := &entity.Post{AuthorID: author, Title: "Post"}
+	f.follows.FollowUser(ctx, &entity.UserFollow{FollowerID: author, FollowingID: author})
+	f.follows.FollowUser(ctx, &entity.UserFollow{FollowerID: reader, FollowingID: author})
+	actor := &entity.User{}
+	actor.ID = author
+	f.service.NotifyPostPublished(ctx, post, actor)
+	require.Equal(t, []entity.NotificationType{entity.NotificationTypeCommentReply}, f.notifications.received(author))
+	require.Equal(t, []entity.NotificationType{entity.NotificationTypeFollowedPost}, f.notifications.received(reader))
+}
+
+func TestNotificationService_HonorsPreferences(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	author := &entity.User{}
+	author.ID = uuid.New()
+	off := false
+
+	prefs, err := f.service.UpdatePreferences(ctx, &dto.UpdateNotificationPreferencesRequest{PostComment: &off}, author)
+	require.NoError(t, err)
+	require.False(t, prefs.PostComment)
+	require.True(t, prefs.CommentReply)
+
+	// Comments on their posts are dropped, replies still arrive
+	f.service.NotifyComment(ctx, comment(uuid.New(), author.ID), nil)
+	require.Empty(t, f.notifications.received(author.ID))
+	f.service.NotifyComment(ctx, comment(uuid.New(), uuid.New()), &entity.Comment{UserID: author.ID})
+	require.Equal(t, []entity.NotificationType{entity.NotificationTypeCommentReply}, f.notifications.received(author.ID))
+
+	// Preferences are per recipient
+	follower := uuid.New()
+	f.service.UpdatePreferences(ctx, &dto.UpdateNotificationPreferencesRequest{NewFollower: &off}, author)
+	f.service.NotifyNewFollower(ctx, &entity.User{}, author.ID)
+	f.service.NotifyNewFollower(ctx, &entity.User{}, follower)
+	require.Len(t, f.notifications.received(author.ID), 1)
+	require.Equal(t, []entity.NotificationType{entity.NotificationTypeNewFollower}, f.notifications.received(follower))
+}
+
+func TestNotificationService_MarkRead(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+	owner, other := &entity.User{}, &entity.User{}
+	owner.ID, other.ID = uuid.New(), uuid.New()
+
+	f.service.NotifyNewFollower(ctx, other, owner.ID)
+	f.service.NotifyNewFollower(ctx, other, owner.ID)
+	id := f.notifications.notifications[0].ID
+
+	// Someone else's notification looks missing
+	require.EqualError(t, f.service.MarkRead(ctx, id, other), "notification not found")
+	require.EqualError(t, f.service.MarkRead(ctx, uuid.New(), owner), "notification not found")
+
+	require.NoError(t, f.service.MarkRead(ctx, id, owner))
+	readAt := *f.notifications.notifications[0].ReadAt
+	require.NoError(t, f.service.MarkRead(ctx, id, owner))
+	require.Equal(t, readAt, *f.notifications.notifications[0].ReadAt)
+
+	unread, err := f.service.GetUnreadCount(ctx, owner)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), unread.UnreadCount)
+
+	marked, err := f.service.MarkAllRead(ctx, owner)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), marked)
+	unread, _ = f.service.GetUnreadCount(ctx, owner)
+	require.Zero(t, unread.UnreadCount)
+}
+
+func TestNotificationRepository_MarkReadIsScopedToOwner(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewNotificationRepository(gormDB)
+	id, userID := uuid.New(), uuid.New()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE "notifications" SET "read_at"=COALESCE(read_at, $1) WHERE (id = $2 AND user_id = $3) AND "notifications"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), id, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+
+	found, err := repo.MarkRead(context.Background(), id, userID)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
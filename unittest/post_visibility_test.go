@@ -0,0 +1,123 @@
+package unittest
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostViewerFor(t *testing.T) {
+	require.Equal(t, repository.PostViewer{}, repository.PostViewerFor(nil))
+
+	author := &entity.User{Role: entity.RoleUser}
+	author.ID = uuid.New()
+	viewer := repository.PostViewerFor(author)
+	require.Equal(t, author.ID, *viewer.UserID)
+	require.False(t, viewer.Admin)
+
+	admin := &entity.User{Role: entity.RoleAdmin}
+	admin.ID = uuid.New()
+	require.True(t, repository.PostViewerFor(admin).Admin)
+}
+
+func TestPostRepository_FindAllVisibility(t *testing.T) {
+	authorID := uuid.New()
+	tests := []struct {
+		name   string
+		viewer repository.PostViewer
+		where  string
+		args   []driver.Value
+	}{
+		{
+			name:  "anonymous",
+			where: `WHERE posts.status = $1 AND "posts"."deleted_at" IS NULL`,
+			args:  []driver.Value{"published"},
+		},
+		{
+			name:   "author",
+			viewer: repository.PostViewer{UserID: &authorID},
+			where:  `WHERE (posts.status = $1 OR posts.author_id = $2) AND "posts"."deleted_at" IS NULL`,
+			args:   []driver.Value{"published", authorID},
+		},
+		{
+			name:   "admin",
+			viewer: repository.PostViewer{UserID: &authorID, Admin: true},
+			where:  `WHERE "posts"."deleted_at" IS NULL`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, sqlMock := newTxFixture(t)
+			repo := repository.NewPostRepository(gormDB)
+
+			// The total counts the visible posts only
+			sqlMock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "posts" ` + tt.where)).
+				WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			sqlMock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "posts" ` + tt.where + ` ORDER BY posts.created_at DESC,posts.id DESC`)).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+			posts, total, err := repo.FindAll(context.Background(), tt.viewer, repository.Paging{Page: 1, Limit: 10}, "", "", nil, "", nil)
+			require.NoError(t, err)
+			require.Zero(t, total)
+			require.Empty(t, posts)
+			require.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostRepository_FindVisibleBySlugHidesDrafts(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewPostRepository(gormDB)
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT * FROM "posts" WHERE posts.slug = $1 AND posts.status = $2 AND "posts"."deleted_at" IS NULL`)).
+		WithArgs("draft-post", "published", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.FindVisibleBySlug(context.Background(), "draft-post", repository.PostViewer{})
+	require.Error(t, err)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestComments_HiddenWithTheirPost(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	posts := repository.NewPostRepository(gormDB)
+	comments := service.NewCommentService(nil, posts, nil, validator.NewValidator(), nil, nil, nil, nil)
+	streams := service.NewStreamService(nil, posts)
+	draftID := uuid.New()
+	reader := &entity.User{Role: entity.RoleUser}
+	reader.ID = uuid.New()
+
+	// The draft is filtered out by the query, for anonymous and signed-in readers alike
+	anonymous := `SELECT * FROM "posts" WHERE posts.id = $1 AND posts.status = $2 AND "posts"."deleted_at" IS NULL`
+	signedIn := `SELECT * FROM "posts" WHERE posts.id = $1 AND (posts.status = $2 OR posts.author_id = $3) AND "posts"."deleted_at" IS NULL`
+	sqlMock.ExpectQuery(regexp.QuoteMeta(anonymous)).
+		WithArgs(draftID, "published", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	sqlMock.ExpectQuery(regexp.QuoteMeta(signedIn)).
+		WithArgs(draftID, "published", reader.ID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	sqlMock.ExpectQuery(regexp.QuoteMeta(signedIn)).
+		WithArgs(draftID, "published", reader.ID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, _, err := comments.GetByPostID(context.Background(), draftID, &dto.CommentQueryParams{Page: 1, Limit: 10}, nil)
+	require.EqualError(t, err, "post not found")
+	_, err = comments.Create(context.Background(), draftID, &dto.CreateCommentRequest{Content: "Nice"}, reader)
+	require.EqualError(t, err, "post not found")
+	_, err = streams.SubscribePostComments(context.Background(), draftID, reader, "")
+	require.EqualError(t, err, "post not found")
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -46,7 +46,7 @@ func TestUserRepository_Create(t *testing.T) {
 
 	// Mock the INSERT query with the actual order from log, including "avatar"
 	sqlMock.ExpectQuery(regexp.QuoteMeta(
-		`INSERT INTO "users" ("created_at","updated_at","deleted_at","username","email","password","full_name","role","is_active","avatar","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING "id"`)).
+		`INSERT INTO "users" ("created_at","updated_at","deleted_at","username","email","password","full_name","role","is_active","avatar","avatar_size","id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) RETURNING "id"`)).
 		WithArgs(
 			sqlmock.AnyArg(), // created_at
 			sqlmock.AnyArg(), // updated_at
@@ -58,6 +58,7 @@ func TestUserRepository_Create(t *testing.T) {
 			string(user.Role),
 			user.IsActive,
 			user.Avatar,      // avatar
+			user.AvatarSize,  // avatar_size
 			sqlmock.AnyArg(), // id
 		).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
@@ -0,0 +1,198 @@
+package unittest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/config"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAvatarRepository only answers FindWithAvatar
+type memoryAvatarRepository struct {
+	repository.UserRepository
+	users []*entity.User
+}
+
+func (r *memoryAvatarRepository) FindWithAvatar(ctx context.Context) ([]*entity.User, error) {
+	return r.users, nil
+}
+
+func avatarUser(avatar string) *entity.User {
+	user := slotUser()
+	user.Avatar = avatar
+	return user
+}
+
+func (r *memoryMediaRepository) FindBatch(ctx context.Context, afterID uuid.UUID, limit int) ([]*entity.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var medias []*entity.Media
+	for _, media := range r.media {
+		if bytes.Compare(media.ID[:], afterID[:]) > 0 {
+			copied := *media
+			medias = append(medias, &copied)
+		}
+	}
+	sort.Slice(medias, func(i, j int) bool { return bytes.Compare(medias[i].ID[:], medias[j].ID[:]) < 0 })
+	if len(medias) > limit {
+		medias = medias[:limit]
+	}
+	return medias, nil
+}
+
+func newMaintenanceService(t *testing.T, f *uploadSlotFixture, users *memoryAvatarRepository) service.MediaMaintenanceService {
+	log, err := logger.NewLogger(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { log.Close() })
+
+	cfg := &config.Config{
+		Storage: config.StorageConfig{GCIntervalHours: 24, GCGraceHours: 1},
+		Upload:  config.UploadConfig{SlotTTLMin: 15},
+	}
+	store := storage.NewLocalStorage(f.basePath, "http://localhost:5000/uploads")
+	return service.NewMediaMaintenanceService(f.media, f.blobs, users, nil, store, f.queue, log, cfg)
+}
+
+// storeAged writes a file and backdates it
+func storeAged(t *testing.T, basePath, path string, age time.Duration) {
+	full := filepath.Join(basePath, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+	require.NoError(t, os.WriteFile(full, []byte(path), 0644))
+	modTime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(full, modTime, modTime))
+}
+
+func orphanPaths(report *dto.StorageGCReport) []string {
+	var paths []string
+	for _, o := range report.Orphans {
+		paths = append(paths, filepath.ToSlash(o.Path))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestMediaMaintenance_CollectGarbage(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+
+	media := &entity.Media{Path: "posts/kept.jpg", Variants: entity.MediaVariants{{Name: "thumb", Path: "posts/kept_thumb.jpg"}}}
+	media.ID = uuid.New()
+	f.media.media[media.ID] = media
+	users := &memoryAvatarRepository{users: []*entity.User{
+		avatarUser("http://localhost:5000/uploads/avatars/current.jpg"),
+		avatarUser("https://www.gravatar.com/avatar/abc"),
+	}}
+	maintenance := newMaintenanceService(t, f, users)
+
+	old := 3 * time.Hour
+	for _, p := range []string{
+		"posts/kept.jpg",
+		"posts/kept_thumb.jpg",
+		"avatars/current.jpg",
+		"resized/" + media.ID.String() + "/100x100_contain.jpg",
+		"posts/stray.jpg",
+		"avatars/replaced.jpg",
+		"resized/" + uuid.NewString() + "/100x100_contain.jpg",
+		"incoming/" + uuid.NewString() + ".jpg",
+	} {
+		storeAged(t, f.basePath, p, old)
+	}
+	// Too recent: may belong to an upload still in progress
+	storeAged(t, f.basePath, "posts/new.jpg", time.Minute)
+	storeAged(t, f.basePath, "incoming/pending.jpg", 20*time.Minute)
+
+	report, err := maintenance.CollectGarbage(ctx, 10*time.Minute, true)
+	require.NoError(t, err)
+	require.True(t, report.DryRun)
+	require.Equal(t, 10, report.Scanned)
+	orphans := orphanPaths(report)
+	require.Len(t, orphans, 4)
+	require.Equal(t, "avatars/replaced.jpg", orphans[0])
+	require.Regexp(t, `^incoming/.+\.jpg$`, orphans[1])
+	require.Equal(t, "posts/stray.jpg", orphans[2])
+	require.Regexp(t, `^resized/.+/100x100_contain\.jpg$`, orphans[3])
+	require.NotContains(t, orphans, "incoming/pending.jpg")
+	require.Zero(t, report.Deleted)
+	require.Len(t, storedFiles(t, f.basePath), 10)
+
+	report, err = maintenance.CollectGarbage(ctx, 10*time.Minute, false)
+	require.NoError(t, err)
+	require.Equal(t, 4, report.Deleted)
+	remaining := storedFiles(t, f.basePath)
+	require.Len(t, remaining, 6)
+	for _, orphan := range orphans {
+		require.NotContains(t, remaining, orphan)
+	}
+}
+
+func TestMediaMaintenance_CheckConsistency(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+
+	media := &entity.Media{Path: "posts/photo.jpg", Variants: entity.MediaVariants{{Name: "thumb", Path: "posts/photo_thumb.jpg"}}}
+	media.ID = uuid.New()
+	f.media.media[media.ID] = media
+	lost := avatarUser("http://localhost:5000/uploads/avatars/lost.jpg")
+	users := &memoryAvatarRepository{users: []*entity.User{
+		avatarUser("http://localhost:5000/uploads/avatars/here.jpg"),
+		lost,
+		avatarUser("https://ui-avatars.com/api/?name=A"),
+	}}
+	storeAged(t, f.basePath, "posts/photo.jpg", 0)
+	storeAged(t, f.basePath, "avatars/here.jpg", 0)
+
+	report, err := newMaintenanceService(t, f, users).CheckConsistency(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.CheckedMedia)
+	require.Equal(t, 2, report.CheckedAvatars)
+	require.Equal(t, []dto.MissingFile{
+		{Kind: "variant", ID: media.ID, Path: "posts/photo_thumb.jpg"},
+		{Kind: "avatar", ID: lost.ID, Path: "avatars/lost.jpg"},
+	}, report.Missing)
+}
+
+func TestMediaMaintenance_ScheduledCollection(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	maintenance := newMaintenanceService(t, f, &memoryAvatarRepository{})
+
+	// Every instance schedules the same run
+	require.NoError(t, maintenance.ScheduleGC(ctx))
+	require.NoError(t, maintenance.ScheduleGC(ctx))
+
+	next := time.Now().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	job, err := f.jobs.FindActiveByUniqueKey(ctx, scheduledGCKey(next))
+	require.NoError(t, err)
+	require.WithinDuration(t, next, job.RunAt, time.Second)
+
+	// Running it queues the following day
+	storeAged(t, f.basePath, "posts/stray.jpg", 48*time.Hour)
+	f.jobs.makeDue(job.ID)
+	ran, err := f.queue.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	_, err = f.jobs.FindActiveByUniqueKey(ctx, scheduledGCKey(next.Add(24*time.Hour)))
+	require.NoError(t, err)
+
+	// Orphans are only reported unless STORAGE_GC_DELETE is set
+	require.Equal(t, []string{"posts/stray.jpg"}, storedFiles(t, f.basePath))
+}
+
+func scheduledGCKey(at time.Time) string {
+	return service.JobStorageGC + ":" + strconv.FormatInt(at.Unix(), 10)
+}
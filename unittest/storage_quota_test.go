@@ -0,0 +1,383 @@
+package unittest
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/config"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	imgpkg "github.com/afdhali/GolangBlogpostServer/pkg/image"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
+	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUserRepository keeps users by id; the rest of the interface is unused here
+type memoryUserRepository struct {
+	repository.UserRepository
+	mu    sync.Mutex
+	users map[uuid.UUID]*entity.User
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memoryUserRepository) add(role entity.UserRole) *entity.User {
+	user := slotUser()
+	user.Role = role
+	r.Update(context.Background(), user)
+	return user
+}
+
+// memoryStorageQuotaRepository totals the media and users of the other
+// in-memory repositories
+type memoryStorageQuotaRepository struct {
+	mu     sync.Mutex
+	users  *memoryUserRepository
+	media  *memoryMediaRepository
+	quotas map[uuid.UUID]*entity.StorageQuota
+	// onLock runs when an upload takes the user's lock, standing in for
+	// an upload that committed while this one was being stored
+	onLock func(userID uuid.UUID)
+}
+
+func (r *memoryStorageQuotaRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*entity.StorageQuota, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	quota, ok := r.quotas[userID]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	copied := *quota
+	return &copied, nil
+}
+
+func (r *memoryStorageQuotaRepository) Upsert(ctx context.Context, quota *entity.StorageQuota) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *quota
+	r.quotas[quota.UserID] = &copied
+	return nil
+}
+
+func (r *memoryStorageQuotaRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.quotas, userID)
+	return nil
+}
+
+func (r *memoryStorageQuotaRepository) LockUser(ctx context.Context, userID uuid.UUID) error {
+	if r.onLock != nil {
+		r.onLock(userID)
+	}
+	return nil
+}
+
+// UsageByUserID treats users it doesn't know as regular users, so upload
+// tests don't need to create them
+func (r *memoryStorageQuotaRepository) UsageByUserID(ctx context.Context, userID uuid.UUID) (*repository.StorageUsage, error) {
+	user, err := r.users.FindByID(ctx, userID)
+	if err != nil {
+		user = &entity.User{Role: entity.RoleUser}
+		user.ID = userID
+	}
+	return r.usage(user), nil
+}
+
+func (r *memoryStorageQuotaRepository) FindUsage(ctx context.Context, page, limit int) ([]repository.StorageUsage, int64, error) {
+	r.users.mu.Lock()
+	users := make([]*entity.User, 0, len(r.users.users))
+	for _, user := range r.users.users {
+		users = append(users, user)
+	}
+	r.users.mu.Unlock()
+
+	var usages []repository.StorageUsage
+	for _, user := range users {
+		usages = append(usages, *r.usage(user))
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].MediaBytes+usages[i].AvatarBytes > usages[j].MediaBytes+usages[j].AvatarBytes
+	})
+	return usages, int64(len(usages)), nil
+}
+
+func (r *memoryStorageQuotaRepository) usage(user *entity.User) *repository.StorageUsage {
+	usage := &repository.StorageUsage{
+		UserID:      user.ID,
+		Username:    user.Username,
+		Role:        user.Role,
+		AvatarBytes: user.AvatarSize,
+	}
+
+	r.media.mu.Lock()
+	for _, media := range r.media.media {
+		if media.UserID == user.ID {
+			usage.MediaBytes += media.Size
+			usage.MediaFiles++
+		}
+	}
+	r.media.mu.Unlock()
+
+	r.mu.Lock()
+	if quota, ok := r.quotas[user.ID]; ok {
+		usage.MaxBytes, usage.MaxFiles = quota.MaxBytes, quota.MaxFiles
+	}
+	r.mu.Unlock()
+	return usage
+}
+
+func (r *memoryStorageQuotaRepository) override(userID uuid.UUID, maxBytes, maxFiles *int64) {
+	r.Upsert(context.Background(), &entity.StorageQuota{UserID: userID, MaxBytes: maxBytes, MaxFiles: maxFiles})
+}
+
+func newMemoryStorageQuotaRepository(media *memoryMediaRepository) *memoryStorageQuotaRepository {
+	return &memoryStorageQuotaRepository{
+		users:  &memoryUserRepository{users: map[uuid.UUID]*entity.User{}},
+		media:  media,
+		quotas: map[uuid.UUID]*entity.StorageQuota{},
+	}
+}
+
+func newQuotaService(quotas *memoryStorageQuotaRepository, cfg config.QuotaConfig) service.StorageQuotaService {
+	return service.NewStorageQuotaService(quotas, quotas.users, validator.NewValidator(), &config.Config{Quota: cfg})
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// countingPostRepository and countingFollowRepository answer the counts
+// added to user responses
+type countingPostRepository struct{ repository.PostRepository }
+
+func (countingPostRepository) CountByAuthorID(ctx context.Context, authorID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+type countingFollowRepository struct{ repository.FollowRepository }
+
+func (countingFollowRepository) CountFollowers(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (countingFollowRepository) CountFollowing(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func TestStorageQuota_Usage(t *testing.T) {
+	ctx := context.Background()
+	media := &memoryMediaRepository{media: map[uuid.UUID]*entity.Media{}}
+	quotas := newMemoryStorageQuotaRepository(media)
+	quotaService := newQuotaService(quotas, config.QuotaConfig{UserMaxMB: 1, UserMaxFiles: 3})
+
+	user := quotas.users.add(entity.RoleUser)
+	user.AvatarSize = 1000
+	quotas.users.Update(ctx, user)
+	for _, size := range []int64{2000, 3000} {
+		media.Create(ctx, &entity.Media{UserID: user.ID, Size: size})
+	}
+
+	usage, err := quotaService.GetUsage(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(6000), usage.UsedBytes)
+	require.Equal(t, int64(3), usage.UsedFiles) // the avatar counts as a file
+	require.Equal(t, int64(5000), usage.MediaBytes)
+	require.Equal(t, int64(1<<20), usage.MaxBytes)
+	require.Equal(t, int64(1<<20-6000), *usage.RemainingBytes)
+	require.Equal(t, int64(0), *usage.RemainingFiles)
+	require.False(t, usage.Overridden)
+
+	err = quotaService.Check(ctx, user.ID, 100, 1)
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+	require.Contains(t, err.Error(), "3 of 3 files used")
+	require.NoError(t, quotaService.Check(ctx, user.ID, 100, 0))
+
+	// Overrides replace the role's limits; 0 is unlimited
+	quotas.override(user.ID, int64Ptr(7000), int64Ptr(0))
+	usage, err = quotaService.GetUsage(ctx, user.ID)
+	require.NoError(t, err)
+	require.True(t, usage.Overridden)
+	require.Equal(t, int64(1000), *usage.RemainingBytes)
+	require.Nil(t, usage.RemainingFiles)
+	require.NoError(t, quotaService.Check(ctx, user.ID, 1000, 1))
+	require.ErrorIs(t, quotaService.Check(ctx, user.ID, 1001, 1), service.ErrQuotaExceeded)
+
+	// Admins are unlimited unless configured
+	admin := quotas.users.add(entity.RoleAdmin)
+	usage, err = quotaService.GetUsage(ctx, admin.ID)
+	require.NoError(t, err)
+	require.Zero(t, usage.MaxBytes)
+	require.Nil(t, usage.RemainingBytes)
+	require.NoError(t, quotaService.Check(ctx, admin.ID, 1<<40, 1))
+
+	report, total, err := quotaService.GetUsageReport(ctx, 1, 20)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), total)
+	require.Equal(t, user.ID, report[0].UserID)
+}
+
+func TestStorageQuota_SetAndReset(t *testing.T) {
+	ctx := context.Background()
+	quotas := newMemoryStorageQuotaRepository(&memoryMediaRepository{media: map[uuid.UUID]*entity.Media{}})
+	quotaService := newQuotaService(quotas, config.QuotaConfig{UserMaxMB: 10, UserMaxFiles: 100})
+
+	user := quotas.users.add(entity.RoleUser)
+	admin := quotas.users.add(entity.RoleAdmin)
+	superAdmin := quotas.users.add(entity.RoleSuperAdmin)
+
+	_, err := quotaService.SetQuota(ctx, user.ID, &dto.SetStorageQuotaRequest{}, admin)
+	require.EqualError(t, err, "max_bytes or max_files is required")
+	_, err = quotaService.SetQuota(ctx, user.ID, &dto.SetStorageQuotaRequest{MaxFiles: int64Ptr(-1)}, admin)
+	require.Error(t, err)
+
+	usage, err := quotaService.SetQuota(ctx, user.ID, &dto.SetStorageQuotaRequest{MaxFiles: int64Ptr(500), Note: "photographer"}, admin)
+	require.NoError(t, err)
+	require.True(t, usage.Overridden)
+	require.Equal(t, int64(500), usage.MaxFiles)
+	require.Equal(t, int64(10<<20), usage.MaxBytes) // not overridden
+	quota, err := quotas.FindByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, admin.ID, *quota.UpdatedBy)
+
+	// Admins' quotas are for super admins to change
+	_, err = quotaService.SetQuota(ctx, admin.ID, &dto.SetStorageQuotaRequest{MaxBytes: int64Ptr(0)}, admin)
+	require.EqualError(t, err, "you don't have permission to change this user's quota")
+	_, err = quotaService.SetQuota(ctx, admin.ID, &dto.SetStorageQuotaRequest{MaxBytes: int64Ptr(1 << 30)}, superAdmin)
+	require.NoError(t, err)
+
+	_, err = quotaService.SetQuota(ctx, uuid.New(), &dto.SetStorageQuotaRequest{MaxBytes: int64Ptr(1)}, superAdmin)
+	require.EqualError(t, err, "user not found")
+
+	usage, err = quotaService.ResetQuota(ctx, user.ID, admin)
+	require.NoError(t, err)
+	require.False(t, usage.Overridden)
+	require.Equal(t, int64(100), usage.MaxFiles)
+}
+
+func TestMediaService_EnforcesStorageQuota(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	user := f.quotas.users.add(entity.RoleUser)
+
+	f.quotas.override(user.ID, nil, int64Ptr(1))
+	file, header := upload(jpegBytes(t, 32, 32), "first.jpg", "image/jpeg")
+	_, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.NoError(t, err)
+
+	file, header = upload(jpegBytes(t, 32, 32), "second.jpg", "image/jpeg")
+	_, err = f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+
+	// Rejected before anything is stored
+	f.quotas.override(user.ID, int64Ptr(100), nil)
+	files := storedFiles(t, f.basePath)
+	file, header = upload(jpegBytes(t, 64, 64), "third.jpg", "image/jpeg")
+	_, err = f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+	require.Equal(t, files, storedFiles(t, f.basePath))
+
+	// Direct uploads are checked against the declared size up front
+	_, err = f.service.CreateUploadSlot(ctx, user, &dto.CreateUploadSlotRequest{
+		Filename:    "big.jpg",
+		ContentType: "image/jpeg",
+		Size:        5000,
+	})
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+}
+
+func TestMediaService_RechecksQuotaUnderLock(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	user := f.quotas.users.add(entity.RoleUser)
+	f.quotas.override(user.ID, nil, int64Ptr(1))
+
+	// A parallel upload takes the last file slot after the first check
+	f.quotas.onLock = func(userID uuid.UUID) {
+		f.quotas.onLock = nil
+		f.media.Create(ctx, &entity.Media{UserID: userID, Size: 1})
+	}
+	files := storedFiles(t, f.basePath)
+	file, header := upload(jpegBytes(t, 32, 32), "late.jpg", "image/jpeg")
+	_, err := f.service.Upload(ctx, user, file, header, &dto.UploadMediaRequest{})
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+	require.Equal(t, files, storedFiles(t, f.basePath))
+}
+
+func TestStorageQuotaRepository_LockUser(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewStorageQuotaRepository(gormDB)
+	userID := uuid.New()
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT "id" FROM "users" WHERE id = $1 AND "users"."deleted_at" IS NULL LIMIT $2 FOR UPDATE`)).
+		WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+
+	require.NoError(t, repo.LockUser(context.Background(), userID))
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestUserService_AvatarStorageQuota(t *testing.T) {
+	ctx := context.Background()
+	quotas := newMemoryStorageQuotaRepository(&memoryMediaRepository{media: map[uuid.UUID]*entity.Media{}})
+	quotaService := newQuotaService(quotas, config.QuotaConfig{})
+	userService := service.NewUserService(
+		memoryTxManager{}, quotas.users, nil, countingPostRepository{}, countingFollowRepository{}, quotaService,
+		nil, validator.NewValidator(),
+		storage.NewLocalStorage(t.TempDir(), "http://localhost:5000/uploads"),
+		imgpkg.DefaultImageValidator(), imgpkg.DefaultImageProcessor(),
+		pagination.NewSigner("cursor-secret"),
+	)
+	user := quotas.users.add(entity.RoleUser)
+	photo := jpegBytes(t, 64, 64)
+
+	file, header := upload(photo, "me.jpg", "image/jpeg")
+	_, err := userService.UploadAvatar(ctx, user.ID, file, header)
+	require.NoError(t, err)
+	stored, _ := quotas.users.FindByID(ctx, user.ID)
+	require.Positive(t, stored.AvatarSize)
+
+	// Replacing the avatar only needs room for the difference
+	quotas.override(user.ID, int64Ptr(stored.AvatarSize), int64Ptr(1))
+	file, header = upload(photo, "me.jpg", "image/jpeg")
+	_, err = userService.UploadAvatar(ctx, user.ID, file, header)
+	require.NoError(t, err)
+
+	quotas.override(user.ID, int64Ptr(stored.AvatarSize-1), nil)
+	file, header = upload(photo, "me.jpg", "image/jpeg")
+	_, err = userService.UploadAvatar(ctx, user.ID, file, header)
+	require.ErrorIs(t, err, service.ErrQuotaExceeded)
+
+	_, err = userService.DeleteAvatar(ctx, user.ID)
+	require.NoError(t, err)
+	stored, _ = quotas.users.FindByID(ctx, user.ID)
+	require.Zero(t, stored.AvatarSize)
+}
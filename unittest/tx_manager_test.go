@@ -0,0 +1,111 @@
+package unittest
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// memoryTxManager runs units of work directly; the memory repositories
+// have nothing to roll back, and AfterCommit hooks run right away
+type memoryTxManager struct{}
+
+func (memoryTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func newTxFixture(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	dbMock, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { dbMock.Close() })
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: dbMock,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+	return gormDB, sqlMock
+}
+
+func TestTxManager_Commit(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	txManager := repository.NewTxManager(gormDB)
+	users := repository.NewUserRepository(gormDB)
+	tokens := repository.NewRefreshTokenRepository(gormDB)
+	userID := uuid.New()
+
+	// Both statements run in one transaction instead of one each
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1 WHERE "users"."id" = $2 AND "users"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "refresh_tokens" SET "is_revoked"=$1`)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectCommit()
+
+	var committed, rolledBack bool
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		repository.AfterCommit(ctx, func(context.Context) { committed = true })
+		repository.AfterRollback(ctx, func(context.Context) { rolledBack = true })
+
+		if err := users.Delete(ctx, userID); err != nil {
+			return err
+		}
+		// A nested unit of work joins the outer transaction
+		return txManager.WithinTx(ctx, func(ctx context.Context) error {
+			require.False(t, committed)
+			return tokens.RevokeAllByUserID(ctx, userID)
+		})
+	})
+	require.NoError(t, err)
+	require.True(t, committed)
+	require.False(t, rolledBack)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestTxManager_Rollback(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	txManager := repository.NewTxManager(gormDB)
+	users := repository.NewUserRepository(gormDB)
+	tokens := repository.NewRefreshTokenRepository(gormDB)
+	userID := uuid.New()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "deleted_at"=$1`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "refresh_tokens" SET "is_revoked"=$1`)).
+		WillReturnError(errors.New("connection reset"))
+	sqlMock.ExpectRollback()
+
+	var committed, rolledBack bool
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		repository.AfterCommit(ctx, func(context.Context) { committed = true })
+		repository.AfterRollback(ctx, func(context.Context) { rolledBack = true })
+
+		if err := users.Delete(ctx, userID); err != nil {
+			return err
+		}
+		return tokens.RevokeAllByUserID(ctx, userID)
+	})
+	require.EqualError(t, err, "connection reset")
+	require.False(t, committed)
+	require.True(t, rolledBack)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestTxManager_AfterCommitOutsideTx(t *testing.T) {
+	ran := false
+	repository.AfterCommit(context.Background(), func(context.Context) { ran = true })
+	require.True(t, ran)
+
+	repository.AfterRollback(context.Background(), func(context.Context) {
+		t.Fatal("rollback hook outside a transaction")
+	})
+}
@@ -0,0 +1,353 @@
+package unittest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/config"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	imgpkg "github.com/afdhali/GolangBlogpostServer/pkg/image"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/afdhali/GolangBlogpostServer/pkg/mediafile"
+	"github.com/afdhali/GolangBlogpostServer/pkg/pagination"
+	"github.com/afdhali/GolangBlogpostServer/pkg/storage"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUploadSlotRepository is an in-memory stand-in for the upload_slots table
+type memoryUploadSlotRepository struct {
+	mu    sync.Mutex
+	slots map[uuid.UUID]*entity.UploadSlot
+}
+
+func (r *memoryUploadSlotRepository) Create(ctx context.Context, slot *entity.UploadSlot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *slot
+	r.slots[slot.ID] = &copied
+	return nil
+}
+
+func (r *memoryUploadSlotRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.UploadSlot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	slot, ok := r.slots[id]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	copied := *slot
+	return &copied, nil
+}
+
+func (r *memoryUploadSlotRepository) FindExpired(ctx context.Context, now time.Time, limit int) ([]*entity.UploadSlot, error) {
+	return nil, nil
+}
+
+func (r *memoryUploadSlotRepository) MarkCompleted(ctx context.Context, id, mediaID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	slot := r.slots[id]
+	if slot == nil || slot.Status != entity.UploadSlotPending {
+		return false, nil
+	}
+	slot.Status = entity.UploadSlotCompleted
+	slot.MediaID = &mediaID
+	return true, nil
+}
+
+func (r *memoryUploadSlotRepository) MarkExpired(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	slot := r.slots[id]
+	if slot == nil || slot.Status != entity.UploadSlotPending {
+		return false, nil
+	}
+	slot.Status = entity.UploadSlotExpired
+	return true, nil
+}
+
+func (r *memoryUploadSlotRepository) expire(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.slots[id].ExpiresAt = time.Now().Add(-time.Minute)
+}
+
+// memoryMediaRepository keeps created media; the rest of the interface is unused here
+type memoryMediaRepository struct {
+	repository.MediaRepository
+	mu    sync.Mutex
+	media map[uuid.UUID]*entity.Media
+}
+
+func (r *memoryMediaRepository) Create(ctx context.Context, media *entity.Media) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	media.ID = uuid.New()
+	copied := *media
+	r.media[media.ID] = &copied
+	return nil
+}
+
+func (r *memoryMediaRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	media, ok := r.media[id]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	copied := *media
+	return &copied, nil
+}
+
+func (r *memoryMediaRepository) UpdateVariants(ctx context.Context, id uuid.UUID, variants entity.MediaVariants) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.media[id].Variants = variants
+	return nil
+}
+
+type uploadSlotFixture struct {
+	service  service.MediaService
+	slots    *memoryUploadSlotRepository
+	media    *memoryMediaRepository
+	blobs    *memoryMediaBlobRepository
+	folders  *memoryMediaFolderRepository
+	posts    *memoryPostRepository
+	quotas   *memoryStorageQuotaRepository
+	jobs     *memoryJobRepository
+	queue    *service.JobQueue
+	basePath string
+}
+
+func newUploadSlotFixture(t *testing.T) *uploadSlotFixture {
+	return newMediaFixture(t, mediafile.NopThumbnailer{})
+}
+
+func newMediaFixture(t *testing.T, thumbnailer mediafile.Thumbnailer) *uploadSlotFixture {
+	log, err := logger.NewLogger(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { log.Close() })
+
+	f := &uploadSlotFixture{
+		slots:    &memoryUploadSlotRepository{slots: map[uuid.UUID]*entity.UploadSlot{}},
+		media:    &memoryMediaRepository{media: map[uuid.UUID]*entity.Media{}},
+		blobs:    &memoryMediaBlobRepository{blobs: map[string]*entity.MediaBlob{}},
+		folders:  &memoryMediaFolderRepository{folders: map[uuid.UUID]*entity.MediaFolder{}},
+		jobs:     newMemoryJobRepository(),
+		basePath: t.TempDir(),
+	}
+	f.queue = newTestJobQueue(t, f.jobs)
+	f.quotas = newMemoryStorageQuotaRepository(f.media)
+	f.folders.media = f.media
+	f.posts = &memoryPostRepository{posts: map[uuid.UUID]*entity.Post{}, media: f.media}
+
+	variants, err := imgpkg.ParseVariants("thumb:150x150:crop")
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		Image:   config.ImageConfig{ResizeMaxDimension: 2000},
+		Upload:  config.UploadConfig{SlotTTLMin: 15, SigningSecret: "upload-secret"},
+		Storage: config.StorageConfig{SignedURLTTLMin: 60},
+	}
+	f.service = service.NewMediaService(
+		memoryTxManager{}, f.media, f.slots, f.blobs, f.posts, f.folders, newQuotaService(f.quotas, config.QuotaConfig{}),
+		storage.NewLocalStorage(f.basePath, "/uploads"),
+		imgpkg.DefaultImageValidator(),
+		mediafile.NewValidator().
+			Allow(mediafile.KindAudio, 1, []string{"audio/wav"}).
+			Allow(mediafile.KindDocument, 1, []string{"application/pdf"}),
+		imgpkg.NewMediaProcessor(imgpkg.DefaultImageProcessor(), variants),
+		thumbnailer,
+		imgpkg.NewResizeSigner("resize-secret"),
+		pagination.NewSigner("cursor-secret"),
+		validator.NewValidator(), f.queue, log, cfg,
+	)
+	return f
+}
+
+func slotUser() *entity.User {
+	user := &entity.User{}
+	user.ID = uuid.New()
+	return user
+}
+
+// signedUpload splits the local upload URL into its slot id, expiry and signature
+func signedUpload(t *testing.T, target *dto.UploadTargetResponse) (uuid.UUID, int64, string) {
+	u, err := url.Parse(target.URL)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(u.Path, "/upload-slots/"))
+
+	id, err := uuid.Parse(strings.TrimPrefix(u.Path, "/upload-slots/"))
+	require.NoError(t, err)
+	expires, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+	return id, expires, u.Query().Get("sig")
+}
+
+func TestUploadSlot_LocalUploadAndComplete(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	user := slotUser()
+	data := jpegBytes(t, 64, 48)
+
+	slot, err := f.service.CreateUploadSlot(ctx, user, &dto.CreateUploadSlotRequest{
+		Filename:    "photo.jpg",
+		ContentType: "image/jpeg",
+		Size:        int64(len(data)),
+		AltText:     "a photo",
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.MethodPut, slot.Upload.Method)
+	require.Equal(t, "image/jpeg", slot.Upload.Headers["Content-Type"])
+
+	id, expires, sig := signedUpload(t, slot.Upload)
+	require.Equal(t, slot.ID, id)
+
+	// Tampered signature or expiry
+	err = f.service.ReceiveSlotUpload(ctx, id, expires+60, sig, bytes.NewReader(data))
+	require.EqualError(t, err, "invalid signature")
+
+	// Completing before the file arrives
+	_, err = f.service.CompleteUploadSlot(ctx, id, user)
+	require.EqualError(t, err, "file has not been uploaded")
+
+	require.NoError(t, f.service.ReceiveSlotUpload(ctx, id, expires, sig, bytes.NewReader(data)))
+
+	// Only the owner can complete
+	_, err = f.service.CompleteUploadSlot(ctx, id, slotUser())
+	require.EqualError(t, err, "you don't have permission to complete this upload")
+
+	media, err := f.service.CompleteUploadSlot(ctx, id, user)
+	require.NoError(t, err)
+	require.Equal(t, "photo.jpg", media.OriginalName)
+	require.Equal(t, "a photo", media.AltText)
+	require.Equal(t, "image/jpeg", media.MimeType)
+
+	stored, _ := f.slots.FindByID(ctx, id)
+	require.Equal(t, entity.UploadSlotCompleted, stored.Status)
+	require.Equal(t, media.ID, *stored.MediaID)
+
+	// The raw upload is removed once processed
+	_, err = os.Stat(filepath.Join(f.basePath, stored.Path))
+	require.True(t, os.IsNotExist(err))
+
+	// Completing again returns the same media
+	again, err := f.service.CompleteUploadSlot(ctx, id, user)
+	require.NoError(t, err)
+	require.Equal(t, media.ID, again.ID)
+}
+
+func TestUploadSlot_RejectsOversizedAndMismatchedUploads(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	user := slotUser()
+	data := jpegBytes(t, 64, 48)
+
+	// Declared metadata is checked up front
+	_, err := f.service.CreateUploadSlot(ctx, user, &dto.CreateUploadSlotRequest{
+		Filename: "photo.png", ContentType: "image/jpeg", Size: int64(len(data)),
+	})
+	require.ErrorIs(t, err, imgpkg.ErrTypeMismatch)
+
+	_, err = f.service.CreateUploadSlot(ctx, user, &dto.CreateUploadSlotRequest{
+		Filename: "photo.jpg", ContentType: "image/jpeg", Size: 50 * 1024 * 1024,
+	})
+	require.ErrorIs(t, err, imgpkg.ErrImageTooLarge)
+
+	// More bytes than declared
+	slot, err := f.service.CreateUploadSlot(ctx, user, &dto.CreateUploadSlotRequest{
+		Filename: "photo.jpg", ContentType: "image/jpeg", Size: int64(len(data)) - 1,
+	})
+	require.NoError(t, err)
+	id, expires, sig := signedUpload(t, slot.Upload)
+	err = f.service.ReceiveSlotUpload(ctx, id, expires, sig, bytes.NewReader(data))
+	require.EqualError(t, err, "upload exceeds declared size")
+
+	// Content that is not what was declared fails the regular validation
+	text := []byte("definitely not a jpeg")
+	slot, err = f.service.CreateUploadSlot(ctx, user, &dto.CreateUploadSlotRequest{
+		Filename: "photo.jpg", ContentType: "image/jpeg", Size: int64(len(text)),
+	})
+	require.NoError(t, err)
+	id, expires, sig = signedUpload(t, slot.Upload)
+	require.NoError(t, f.service.ReceiveSlotUpload(ctx, id, expires, sig, bytes.NewReader(text)))
+	_, err = f.service.CompleteUploadSlot(ctx, id, user)
+	requireFieldError(t, err, "unsupported_type", imgpkg.ErrInvalidImageType)
+}
+
+func TestUploadSlot_ExpiryRemovesOrphanedUpload(t *testing.T) {
+	f := newUploadSlotFixture(t)
+	ctx := context.Background()
+	user := slotUser()
+	data := jpegBytes(t, 64, 48)
+
+	slot, err := f.service.CreateUploadSlot(ctx, user, &dto.CreateUploadSlotRequest{
+		Filename: "photo.jpg", ContentType: "image/jpeg", Size: int64(len(data)),
+	})
+	require.NoError(t, err)
+	id, expires, sig := signedUpload(t, slot.Upload)
+	require.NoError(t, f.service.ReceiveSlotUpload(ctx, id, expires, sig, bytes.NewReader(data)))
+
+	stored, _ := f.slots.FindByID(ctx, id)
+	rawPath := filepath.Join(f.basePath, stored.Path)
+	require.FileExists(t, rawPath)
+
+	// The cleanup job is scheduled past the expiry
+	job, err := f.jobs.FindActiveByUniqueKey(ctx, service.JobExpireUploadSlot+":"+id.String())
+	require.NoError(t, err)
+	require.True(t, job.RunAt.After(slot.ExpiresAt))
+
+	f.slots.expire(id)
+	_, err = f.service.CompleteUploadSlot(ctx, id, user)
+	require.EqualError(t, err, "upload slot expired")
+
+	f.jobs.makeDue(job.ID)
+	ran, err := f.queue.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	stored, _ = f.slots.FindByID(ctx, id)
+	require.Equal(t, entity.UploadSlotExpired, stored.Status)
+	_, err = os.Stat(rawPath)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestS3Storage_PresignPut(t *testing.T) {
+	fake, srv := newFakeS3(t, "media")
+	s := newS3Storage(t, srv, nil)
+
+	target, err := s.(storage.DirectUploader).PresignPut("incoming/photo.jpg", "image/jpeg", 15*time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, http.MethodPut, target.Method)
+
+	req, err := http.NewRequest(target.Method, target.URL, strings.NewReader("raw bytes"))
+	require.NoError(t, err)
+	for name, value := range target.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	obj, ok := fake.object("incoming/photo.jpg")
+	require.True(t, ok)
+	require.Equal(t, "raw bytes", string(obj.data))
+	require.Equal(t, "image/jpeg", obj.contentType)
+}
@@ -0,0 +1,162 @@
+package unittest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image/jpeg"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	imgpkg "github.com/afdhali/GolangBlogpostServer/pkg/image"
+	"github.com/afdhali/GolangBlogpostServer/pkg/scanner"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/stretchr/testify/require"
+)
+
+func jpegBytes(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, solidImage(width, height), nil))
+	return buf.Bytes()
+}
+
+func requireFieldError(t *testing.T, err error, code string, sentinel error) {
+	var fieldErr *validator.FieldError
+	require.True(t, errors.As(err, &fieldErr), "expected a field error, got %v", err)
+	require.Equal(t, "file", fieldErr.Field)
+	require.Equal(t, code, fieldErr.Code)
+	require.ErrorIs(t, err, sentinel)
+}
+
+func TestImageValidator_AcceptsMatchingUpload(t *testing.T) {
+	v := imgpkg.DefaultImageValidator()
+
+	file, header := upload(jpegBytes(t, 64, 48), "photo.JPG", "image/jpg")
+	require.NoError(t, v.Validate(context.Background(), file, header))
+
+	// A generic declared type falls back to the sniffed one
+	file, header = upload(transparentPNG(t, 16, 16), "logo.png", "application/octet-stream")
+	require.NoError(t, v.Validate(context.Background(), file, header))
+
+	// The file is left at the start for the processor
+	pos, err := file.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.Zero(t, pos)
+}
+
+func TestImageValidator_RejectsSpoofedUploads(t *testing.T) {
+	v := imgpkg.DefaultImageValidator()
+	ctx := context.Background()
+
+	// Not an image at all, whatever the client claims
+	file, header := upload([]byte("<?php system($_GET['c']); ?>"), "shell.jpg", "image/jpeg")
+	requireFieldError(t, v.Validate(ctx, file, header), "unsupported_type", imgpkg.ErrInvalidImageType)
+
+	// PNG renamed to .jpg
+	file, header = upload(transparentPNG(t, 16, 16), "photo.jpg", "image/png")
+	requireFieldError(t, v.Validate(ctx, file, header), "type_mismatch", imgpkg.ErrTypeMismatch)
+
+	// PNG sent with a JPEG content type
+	file, header = upload(transparentPNG(t, 16, 16), "logo.png", "image/jpeg")
+	requireFieldError(t, v.Validate(ctx, file, header), "type_mismatch", imgpkg.ErrTypeMismatch)
+
+	// Valid magic bytes but a truncated body
+	file, header = upload(transparentPNG(t, 16, 16)[:20], "logo.png", "image/png")
+	requireFieldError(t, v.Validate(ctx, file, header), "corrupt_image", imgpkg.ErrCorruptImage)
+}
+
+func TestImageValidator_RejectsTooManyPixels(t *testing.T) {
+	v := imgpkg.DefaultImageValidator().WithMaxPixels(100 * 100)
+
+	file, header := upload(jpegBytes(t, 101, 100), "big.jpg", "image/jpeg")
+	requireFieldError(t, v.Validate(context.Background(), file, header), "dimensions_too_large", imgpkg.ErrTooManyPixels)
+
+	file, header = upload(jpegBytes(t, 100, 100), "ok.jpg", "image/jpeg")
+	require.NoError(t, v.Validate(context.Background(), file, header))
+}
+
+func TestImageValidator_RejectsOversizedFile(t *testing.T) {
+	v := imgpkg.NewValidator(0, []string{"image/jpeg"})
+
+	file, header := upload(jpegBytes(t, 8, 8), "photo.jpg", "image/jpeg")
+	requireFieldError(t, v.Validate(context.Background(), file, header), "file_too_large", imgpkg.ErrImageTooLarge)
+}
+
+func TestImageValidator_Scanner(t *testing.T) {
+	v := imgpkg.DefaultImageValidator().WithScanner(scanner.NewSignatureScanner(nil))
+
+	// EICAR hidden after a valid image
+	infected := append(jpegBytes(t, 8, 8), scanner.EICAR...)
+	file, header := upload(infected, "photo.jpg", "image/jpeg")
+	requireFieldError(t, v.Validate(context.Background(), file, header), "infected", imgpkg.ErrInfected)
+
+	file, header = upload(jpegBytes(t, 8, 8), "photo.jpg", "image/jpeg")
+	require.NoError(t, v.Validate(context.Background(), file, header))
+}
+
+// fakeClamd answers INSTREAM requests like clamd, flagging the EICAR string
+func fakeClamd(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+
+				var data []byte
+				for {
+					var size uint32
+					if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					chunk := make([]byte, size)
+					if _, err := io.ReadFull(r, chunk); err != nil {
+						return
+					}
+					data = append(data, chunk...)
+				}
+
+				if bytes.Contains(data, []byte(scanner.EICAR)) {
+					conn.Write([]byte("stream: Win.Test.EICAR_HDB-1 FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestClamdScanner(t *testing.T) {
+	s := scanner.NewClamdScanner(fakeClamd(t), 5*time.Second)
+	ctx := context.Background()
+
+	require.NoError(t, s.Scan(ctx, bytes.NewReader(bytes.Repeat([]byte("a"), 200*1024))))
+
+	err := s.Scan(ctx, bytes.NewReader([]byte("prefix "+scanner.EICAR)))
+	require.ErrorIs(t, err, scanner.ErrInfected)
+	require.Contains(t, err.Error(), "Win.Test.EICAR_HDB-1")
+
+	// Nothing listening
+	down := scanner.NewClamdScanner("127.0.0.1:1", time.Second)
+	require.ErrorIs(t, down.Scan(ctx, bytes.NewReader(nil)), scanner.ErrUnavailable)
+}
@@ -0,0 +1,149 @@
+package unittest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/handler"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// versionedPostUpdate matches the tail of postRepository.Update's statement
+var versionedPostUpdate = regexp.QuoteMeta(`"version"=$15,"featured_media_id"=$16 WHERE version = $17 AND "posts"."deleted_at" IS NULL AND "id" = $18`)
+
+func TestPostRepository_UpdateVersioned(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewPostRepository(gormDB)
+
+	post := &entity.Post{Title: "Title", Slug: "title", Content: "Content", Version: 3}
+	post.ID = uuid.New()
+	// A loaded association is not saved, so it can't reset category_id
+	post.Category = &entity.Category{Name: "Old"}
+	post.Category.ID = uuid.New()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(versionedPostUpdate).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, post.Title, post.Slug, post.Content, "", "", sqlmock.AnyArg(),
+			"", int64(0), uuid.Nil, uuid.Nil, nil, int64(4), nil, int64(3), post.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	require.NoError(t, repo.Update(context.Background(), post))
+	require.Equal(t, int64(4), post.Version)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestPostRepository_UpdateVersionConflict(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewPostRepository(gormDB)
+
+	post := &entity.Post{Title: "Title", Slug: "title", Content: "Content", Version: 3}
+	post.ID = uuid.New()
+
+	// Another editor saved version 4 first
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(versionedPostUpdate).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+
+	err := repo.Update(context.Background(), post)
+	require.ErrorIs(t, err, repository.ErrVersionConflict)
+	require.Equal(t, int64(3), post.Version)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_DeleteVersionConflict(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	repo := repository.NewCommentRepository(gormDB)
+	id := uuid.New()
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "deleted_at"=$1 WHERE version = $2 AND "comments"."id" = $3 AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), int64(2), id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectCommit()
+
+	err := repo.Delete(context.Background(), id, 2)
+	require.ErrorIs(t, err, repository.ErrVersionConflict)
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+// versionedCategoryService holds one category at version 7
+type versionedCategoryService struct {
+	service.CategoryService
+	current *dto.CategoryResponse
+}
+
+func (s *versionedCategoryService) Update(ctx context.Context, id uuid.UUID, req *dto.UpdateCategoryRequest, user *entity.User) (*dto.CategoryResponse, error) {
+	if req.Version != nil && *req.Version != s.current.Version {
+		return nil, &service.ConflictError{Version: s.current.Version, Current: s.current}
+	}
+	updated := *s.current
+	updated.Name = req.Name
+	updated.Version++
+	return &updated, nil
+}
+
+func TestCategoryHandler_IfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	current := &dto.CategoryResponse{ID: uuid.New(), Name: "Go", Version: 7}
+	h := handler.NewCategoryHandler(&versionedCategoryService{current: current})
+
+	admin := &entity.User{Role: entity.RoleAdmin}
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user", admin) })
+	router.PUT("/categories/:id", h.Update)
+
+	put := func(ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/categories/"+current.ID.String(), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The version is required
+	rec := put("", `{"name":"Golang"}`)
+	require.Equal(t, http.StatusPreconditionRequired, rec.Code)
+
+	rec = put(`"7"`, `{"name":"Golang"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `"8"`, rec.Header().Get("ETag"))
+
+	// The body's version works without If-Match
+	rec = put("", `{"name":"Golang","version":7}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// A stale version gets the category as it is now
+	rec = put(`"6"`, `{"name":"Golang"}`)
+	require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	require.Equal(t, `"7"`, rec.Header().Get("ETag"))
+
+	var body struct {
+		Data struct {
+			Current dto.CategoryResponse `json:"current"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "Go", body.Data.Current.Name)
+	require.Equal(t, int64(7), body.Data.Current.Version)
+
+	// So does an ETag this API never sent
+	rec = put(`W/"7"`, `{"name":"Golang"}`)
+	require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+}
@@ -0,0 +1,116 @@
+package unittest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/afdhali/GolangBlogpostServer/internal/dto"
+	"github.com/afdhali/GolangBlogpostServer/internal/handler"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/viewcounter"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewCounter_DedupAndFlush(t *testing.T) {
+	var flushed map[uuid.UUID]int64
+	counter := viewcounter.NewCounter(func(ctx context.Context, views map[viewcounter.ViewEvent]int64) error {
+		flushed = viewcounter.CountsByPost(views)
+		return nil
+	}, time.Hour, time.Hour, nil)
+
+	postID := uuid.New()
+
+	event := viewcounter.ViewEvent{PostID: postID}
+	require.True(t, counter.Record(event, "user:a"))
+	require.False(t, counter.Record(event, "user:a")) // same visitor within window
+	require.True(t, counter.Record(viewcounter.ViewEvent{PostID: postID, ReferrerHost: "news.ycombinator.com"}, "user:b"))
+
+	require.NoError(t, counter.Flush(context.Background()))
+	require.Equal(t, int64(2), flushed[postID])
+	require.Equal(t, int64(0), counter.Pending(postID))
+}
+
+func TestViewCounter_FailedFlushIsRetried(t *testing.T) {
+	fail := true
+	var flushed map[uuid.UUID]int64
+	counter := viewcounter.NewCounter(func(ctx context.Context, views map[viewcounter.ViewEvent]int64) error {
+		if fail {
+			return errors.New("db down")
+		}
+		flushed = viewcounter.CountsByPost(views)
+		return nil
+	}, time.Hour, time.Hour, nil)
+	counter.Start()
+
+	postID := uuid.New()
+	counter.Record(viewcounter.ViewEvent{PostID: postID}, "user:a")
+
+	require.Error(t, counter.Flush(context.Background()))
+	require.Equal(t, int64(1), counter.Pending(postID))
+
+	// Stop performs the final flush on shutdown
+	fail = false
+	require.NoError(t, counter.Stop(context.Background()))
+	require.Equal(t, int64(1), flushed[postID])
+}
+
+func TestViewCounter_IsBot(t *testing.T) {
+	require.True(t, viewcounter.IsBot(""))
+	require.True(t, viewcounter.IsBot("Mozilla/5.0 (compatible; Googlebot/2.1)"))
+	require.True(t, viewcounter.IsBot("curl/8.4.0"))
+	require.False(t, viewcounter.IsBot("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"))
+}
+
+func TestViewCounter_ReferrerAndCountry(t *testing.T) {
+	require.Equal(t, "google.com", viewcounter.ReferrerHost("https://www.Google.com/search?q=go", "blog.example.com"))
+	require.Equal(t, "", viewcounter.ReferrerHost("https://blog.example.com/posts/1", "blog.example.com:443"))
+	require.Equal(t, "", viewcounter.ReferrerHost("not a url", "blog.example.com"))
+	require.Equal(t, "ID", viewcounter.CountryCode(" id "))
+	require.Equal(t, "", viewcounter.CountryCode("XX"))
+}
+
+func TestViewCounter_VisitorKey(t *testing.T) {
+	const ua = "Mozilla/5.0"
+	require.Equal(t, "user:42", viewcounter.VisitorKey("42", "10.0.0.1", ua))
+
+	key := viewcounter.VisitorKey("", "10.0.0.1", ua)
+	require.NotEqual(t, key, viewcounter.VisitorKey("", "10.0.0.2", ua))
+	require.NotEqual(t, key, viewcounter.VisitorKey("", "10.0.0.1", "curl"))
+	require.Equal(t, key, viewcounter.VisitorKey("", "10.0.0.1", ua))
+}
+
+// recordingViews records views in a counter, keyed as the handler keys them
+type recordingViews struct {
+	service.PostService
+	counter *viewcounter.Counter
+}
+
+func (s recordingViews) IncrementViews(ctx context.Context, id uuid.UUID, req *dto.PostViewRequest) (bool, error) {
+	return s.counter.Record(viewcounter.ViewEvent{PostID: id}, req.VisitorKey), nil
+}
+
+func TestPostHandler_ViewsIgnoreClientSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	counter := viewcounter.NewCounter(func(ctx context.Context, views map[viewcounter.ViewEvent]int64) error {
+		return nil
+	}, time.Hour, time.Hour, nil)
+	router := gin.New()
+	router.POST("/posts/:id/view", handler.NewPostHandler(recordingViews{counter: counter}).IncrementViews)
+
+	// Same IP and user agent, a fresh session on every request
+	postID := uuid.New()
+	for _, session := range []string{"s1", "s2", "s3"} {
+		rec := serve(router, http.MethodPost, "/posts/"+postID.String()+"/view", map[string]string{
+			"User-Agent":   "Mozilla/5.0",
+			"X-Session-ID": session,
+			"Cookie":       "session_id=" + session,
+		})
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	require.Equal(t, int64(1), counter.Pending(postID))
+}
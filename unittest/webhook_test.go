@@ -0,0 +1,294 @@
+package unittest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/afdhali/GolangBlogpostServer/config"
+	"github.com/afdhali/GolangBlogpostServer/internal/entity"
+	"github.com/afdhali/GolangBlogpostServer/internal/repository"
+	"github.com/afdhali/GolangBlogpostServer/internal/service"
+	"github.com/afdhali/GolangBlogpostServer/pkg/logger"
+	"github.com/afdhali/GolangBlogpostServer/pkg/validator"
+	"github.com/afdhali/GolangBlogpostServer/pkg/webhook"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSender_SignsRequest(t *testing.T) {
+	secret := "whsec_test_secret_value"
+	body := []byte(`{"event":"post.published"}`)
+
+	var verifyErr error
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		received, _ := io.ReadAll(r.Body)
+		verifyErr = webhook.Verify(secret, r.Header.Get(webhook.HeaderTimestamp), r.Header.Get(webhook.HeaderSignature), received, 5*time.Minute)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	result := webhook.NewSender(5*time.Second).Send(context.Background(), webhook.Request{
+		URL:        server.URL,
+		Secret:     secret,
+		Event:      "post.published",
+		DeliveryID: "delivery-1",
+		Body:       body,
+	})
+
+	require.NoError(t, result.Err)
+	require.True(t, result.OK())
+	require.NoError(t, verifyErr)
+	require.Equal(t, "post.published", headers.Get(webhook.HeaderEvent))
+	require.Equal(t, "delivery-1", headers.Get(webhook.HeaderDelivery))
+
+	// A different secret must not verify
+	ts := headers.Get(webhook.HeaderTimestamp)
+	require.ErrorIs(t, webhook.Verify("wrong", ts, headers.Get(webhook.HeaderSignature), body, 5*time.Minute), webhook.ErrInvalidSignature)
+}
+
+func TestWebhookSender_Non2xxIsNotOK(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("boom"))
+	}))
+	defer server.Close()
+
+	result := webhook.NewSender(5*time.Second).Send(context.Background(), webhook.Request{
+		URL:    server.URL,
+		Secret: "secret",
+		Body:   []byte(`{}`),
+	})
+
+	require.NoError(t, result.Err)
+	require.False(t, result.OK())
+	require.Equal(t, http.StatusInternalServerError, result.StatusCode)
+	require.Equal(t, "boom", result.Body)
+}
+
+func TestWebhookVerify_RejectsStaleTimestamp(t *testing.T) {
+	body := []byte(`{}`)
+	old := time.Now().Add(-time.Hour).Unix()
+	signature := webhook.Sign("secret", old, body)
+
+	err := webhook.Verify("secret", strconv.FormatInt(old, 10), signature, body, 5*time.Minute)
+	require.ErrorIs(t, err, webhook.ErrExpiredTimestamp)
+}
+
+// memoryWebhookRepository keeps webhooks and their deliveries in memory
+type memoryWebhookRepository struct {
+	mu         sync.Mutex
+	webhooks   map[uuid.UUID]*entity.Webhook
+	deliveries map[uuid.UUID]*entity.WebhookDelivery
+	attempts   []*entity.WebhookDeliveryAttempt
+}
+
+func newMemoryWebhookRepository() *memoryWebhookRepository {
+	return &memoryWebhookRepository{
+		webhooks:   make(map[uuid.UUID]*entity.Webhook),
+		deliveries: make(map[uuid.UUID]*entity.WebhookDelivery),
+	}
+}
+
+func (r *memoryWebhookRepository) Create(ctx context.Context, webhook *entity.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook.ID = uuid.New()
+	r.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (r *memoryWebhookRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	return webhook, nil
+}
+
+func (r *memoryWebhookRepository) FindAll(ctx context.Context) ([]*entity.Webhook, error) {
+	return r.FindActive(ctx)
+}
+
+func (r *memoryWebhookRepository) FindActive(ctx context.Context) ([]*entity.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var webhooks []*entity.Webhook
+	for _, w := range r.webhooks {
+		if w.IsActive {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *memoryWebhookRepository) Update(ctx context.Context, webhook *entity.Webhook) error {
+	return nil
+}
+
+func (r *memoryWebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *memoryWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*entity.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range deliveries {
+		d.ID = uuid.New()
+		copied := *d
+		r.deliveries[d.ID] = &copied
+	}
+	return nil
+}
+
+func (r *memoryWebhookRepository) FindDeliveryToSend(ctx context.Context, id uuid.UUID) (*entity.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deliveries[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *d
+	copied.Webhook = r.webhooks[d.WebhookID]
+	return &copied, nil
+}
+
+func (r *memoryWebhookRepository) RecordAttempt(ctx context.Context, delivery *entity.WebhookDelivery, attempt *entity.WebhookDeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *delivery
+	copied.Webhook = nil
+	r.deliveries[delivery.ID] = &copied
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func (r *memoryWebhookRepository) FindDeliveries(ctx context.Context, webhookID uuid.UUID, status string, page, limit int) ([]*entity.WebhookDelivery, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *memoryWebhookRepository) FindDeliveryByID(ctx context.Context, id uuid.UUID) (*entity.WebhookDelivery, error) {
+	return r.FindDeliveryToSend(ctx, id)
+}
+
+func (r *memoryWebhookRepository) only(t *testing.T) *entity.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	require.Len(t, r.deliveries, 1)
+	for _, d := range r.deliveries {
+		copied := *d
+		return &copied
+	}
+	return nil
+}
+
+func TestWebhookService_DeliversAsJobWithRetries(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	log, err := logger.NewLogger(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { log.Close() })
+
+	jobs := newMemoryJobRepository()
+	queue := newTestJobQueue(t, jobs)
+	webhooks := newMemoryWebhookRepository()
+	cfg := &config.Config{Webhook: config.WebhookConfig{
+		TimeoutSec:     5,
+		MaxAttempts:    3,
+		BackoffBaseSec: 30,
+		BackoffMaxSec:  600,
+	}}
+	webhookService := service.NewWebhookService(memoryTxManager{}, webhooks, queue, webhook.NewSender(5*time.Second), validator.NewValidator(), log, cfg)
+	ctx := context.Background()
+
+	require.NoError(t, webhooks.Create(ctx, &entity.Webhook{URL: server.URL, Secret: "secret", IsActive: true}))
+	webhookService.Enqueue(ctx, entity.WebhookEventPostPublished, map[string]string{"id": "1"})
+
+	// The first attempt fails and is retried on the webhook backoff, not the queue's
+	before := time.Now()
+	ran, err := queue.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	delivery := webhooks.only(t)
+	require.Equal(t, entity.WebhookDeliveryPending, delivery.Status)
+	require.Equal(t, 1, delivery.Attempts)
+	require.Equal(t, http.StatusBadGateway, delivery.ResponseCode)
+
+	var job *entity.Job
+	for _, j := range jobs.jobs {
+		job = j
+	}
+	require.Equal(t, service.JobWebhookDelivery, job.Type)
+	require.Equal(t, entity.JobStatusPending, job.Status)
+	require.Equal(t, 3, job.MaxAttempts)
+	require.WithinDuration(t, before.Add(30*time.Second), job.RunAt, 2*time.Second)
+	require.WithinDuration(t, job.RunAt, delivery.NextAttemptAt, 2*time.Second)
+
+	jobs.makeDue(job.ID)
+	ran, err = queue.RunNext(ctx)
+	require.NoError(t, err)
+	require.True(t, ran)
+
+	delivery = webhooks.only(t)
+	require.Equal(t, entity.WebhookDeliverySucceeded, delivery.Status)
+	require.Equal(t, 2, delivery.Attempts)
+	require.NotNil(t, delivery.DeliveredAt)
+	require.Len(t, webhooks.attempts, 2)
+
+	stored, _ := jobs.FindByID(ctx, job.ID)
+	require.Equal(t, entity.JobStatusSucceeded, stored.Status)
+}
+
+func TestWebhookService_EnqueueStoresDeliveriesWithTheirJobs(t *testing.T) {
+	gormDB, sqlMock := newTxFixture(t)
+	log, err := logger.NewLogger(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { log.Close() })
+
+	cfg := &config.Config{
+		Jobs:    config.JobsConfig{Workers: 1, PollIntervalSec: 1, TimeoutSec: 5, MaxAttempts: 3},
+		Webhook: config.WebhookConfig{TimeoutSec: 5, MaxAttempts: 3, BackoffBaseSec: 30, BackoffMaxSec: 600},
+	}
+	queue := service.NewJobQueue(repository.NewJobRepository(gormDB), log, cfg)
+	webhookService := service.NewWebhookService(repository.NewTxManager(gormDB), repository.NewWebhookRepository(gormDB),
+		queue, webhook.NewSender(5*time.Second), validator.NewValidator(), log, cfg)
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhooks" WHERE is_active = $1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "is_active"}).AddRow(uuid.New(), "https://example.com/hook", true))
+
+	// A delivery whose job can't be queued isn't kept either
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "webhook_deliveries"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	sqlMock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "jobs"`)).
+		WillReturnError(errors.New("connection reset"))
+	sqlMock.ExpectRollback()
+
+	webhookService.Enqueue(context.Background(), entity.WebhookEventPostPublished, map[string]string{"id": "1"})
+	require.NoError(t, sqlMock.ExpectationsWereMet())
+}